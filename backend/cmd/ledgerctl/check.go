@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	auditDto "simple-ledger/internal/audit/dto"
+	integrityDto "simple-ledger/internal/integrity/dto"
+)
+
+// checkReport: 整合性チェックの結果
+type checkReport struct {
+	Ledger     *integrityDto.Report               `json:"ledger"`
+	AuditChain *auditDto.VerifyAuditChainResponse `json:"auditChain"`
+}
+
+// runCheck: すべての取引の整合性（GET /api/integrity/check と同じ検査）と監査ログのハッシュチェーンを検証
+// -quarantine を指定した場合は問題のある取引を隔離する
+// 問題が見つかった場合は終了コード 1 で終了する
+func runCheck(a *app, args []string) error {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	quarantine := flags.Bool("quarantine", false, "quarantine transactions with problems and release repaired ones")
+	_ = flags.Parse(args)
+
+	var report checkReport
+	var err error
+	report.Ledger, err = newIntegrityService(a.db).Check(*quarantine, a.meta)
+	if err != nil {
+		return err
+	}
+	report.AuditChain, err = newAuditService(a.db).Verify()
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("checked %d transactions: %d problems\n", report.Ledger.CheckedTransactions, len(report.Ledger.Issues))
+		for _, issue := range report.Ledger.Issues {
+			fmt.Printf("  [%s] user %d transaction %d: %s\n", issue.Kind, issue.UserID, issue.TransactionID, issue.Detail)
+		}
+		if *quarantine {
+			fmt.Printf("quarantined: %v, released: %v\n", report.Ledger.Quarantined, report.Ledger.Released)
+		}
+		if report.AuditChain.Valid {
+			fmt.Printf("audit chain: valid (%d records)\n", report.AuditChain.CheckedCount)
+		} else {
+			fmt.Printf("audit chain: broken at sequence %d: %s\n", *report.AuditChain.BrokenAtSequence, report.AuditChain.Reason)
+		}
+	}
+
+	if !report.Ledger.Valid || !report.AuditChain.Valid {
+		return errors.New("integrity check failed")
+	}
+	return nil
+}
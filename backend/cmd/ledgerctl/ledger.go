@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	chartOfAccountsRepository "simple-ledger/internal/chart_of_accounts/repository"
+	journalEntryDto "simple-ledger/internal/journal_entry/dto"
+	"simple-ledger/internal/models"
+	"simple-ledger/internal/transaction/dto"
+
+	"gorm.io/gorm"
+)
+
+// ledgerFormatVersion: 帳簿ファイルの形式のバージョン
+const ledgerFormatVersion = 1
+
+// ledgerFile: エクスポート・インポートする帳簿（勘定科目は ID ではなくコードで参照する）
+type ledgerFile struct {
+	Version      int                 `json:"version"`
+	ExportedAt   time.Time           `json:"exportedAt"`
+	Transactions []ledgerTransaction `json:"transactions"`
+}
+
+type ledgerTransaction struct {
+	Date        string        `json:"date"`
+	Description string        `json:"description"`
+	Entries     []ledgerEntry `json:"entries"`
+}
+
+type ledgerEntry struct {
+	AccountCode string           `json:"accountCode"`
+	Type        models.EntryType `json:"type"`
+	Amount      int              `json:"amount"`
+	Description string           `json:"description,omitempty"`
+}
+
+// runExport: ユーザーの取引を帳簿ファイルに出力（取引日の昇順）
+func runExport(a *app, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	email := flags.String("user", "", "email address of the ledger owner")
+	output := flags.String("o", "", "output file (standard output if omitted)")
+	_ = flags.Parse(args)
+
+	user, err := lookupUser(a.db, *email)
+	if err != nil {
+		return err
+	}
+	result, err := newTransactionService(a.db).GetByUserID(user.ID)
+	if err != nil {
+		return err
+	}
+
+	ledger := ledgerFile{Version: ledgerFormatVersion, ExportedAt: time.Now().UTC(), Transactions: []ledgerTransaction{}}
+	for i := len(result.Transactions) - 1; i >= 0; i-- {
+		transaction := result.Transactions[i]
+		exported := ledgerTransaction{Date: transaction.Date, Description: transaction.Description}
+		for _, entry := range transaction.JournalEntries {
+			if entry.ChartOfAccounts == nil {
+				return fmt.Errorf("transaction %d references missing account %d", transaction.ID, entry.ChartOfAccountsID)
+			}
+			exported.Entries = append(exported.Entries, ledgerEntry{
+				AccountCode: entry.ChartOfAccounts.Code,
+				Type:        entry.Type,
+				Amount:      entry.Amount,
+				Description: entry.Description,
+			})
+		}
+		ledger.Transactions = append(ledger.Transactions, exported)
+	}
+
+	var w io.Writer = os.Stdout
+	var file *os.File
+	if *output != "" {
+		file, err = os.Create(*output)
+		if err != nil {
+			return err
+		}
+		w = file
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(ledger); err != nil {
+		if file != nil {
+			_ = file.Close()
+		}
+		return err
+	}
+	if file != nil {
+		// 閉じる際の書き込みの失敗で帳簿ファイルが途中までになった場合もエラーにする
+		if err := file.Close(); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "exported %d transactions to %s\n", len(ledger.Transactions), *output)
+	}
+	return nil
+}
+
+// runImport: 帳簿ファイルの取引をユーザーの取引として登録
+// API と同じ検証（貸借一致・締め済み期間など）を行い、1件でも失敗した場合はすべて取り消す
+func runImport(a *app, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	email := flags.String("user", "", "email address of the ledger owner")
+	input := flags.String("f", "", "ledger file to import")
+	_ = flags.Parse(args)
+
+	if *input == "" {
+		return errors.New("-f is required")
+	}
+	user, err := lookupUser(a.db, *email)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(*input)
+	if err != nil {
+		return err
+	}
+	var ledger ledgerFile
+	if err := json.Unmarshal(data, &ledger); err != nil {
+		return fmt.Errorf("invalid ledger file: %w", err)
+	}
+	if ledger.Version != ledgerFormatVersion {
+		return fmt.Errorf("unsupported ledger file version: %d", ledger.Version)
+	}
+
+	accountIDs, err := accountIDsByCode(a.db)
+	if err != nil {
+		return err
+	}
+	requests := make([]dto.CreateTransactionRequest, 0, len(ledger.Transactions))
+	for i, transaction := range ledger.Transactions {
+		req := dto.CreateTransactionRequest{Date: transaction.Date, Description: transaction.Description}
+		for _, entry := range transaction.Entries {
+			accountID, ok := accountIDs[entry.AccountCode]
+			if !ok {
+				return fmt.Errorf("transaction #%d (%s): unknown account code %q", i+1, transaction.Date, entry.AccountCode)
+			}
+			req.JournalEntries = append(req.JournalEntries, journalEntryDto.CreateJournalEntryRequest{
+				ChartOfAccountsID: accountID,
+				Type:              entry.Type,
+				Amount:            entry.Amount,
+				Description:       entry.Description,
+			})
+		}
+		requests = append(requests, req)
+	}
+
+	err = a.db.Transaction(func(tx *gorm.DB) error {
+		svc := newTransactionService(tx)
+		for i := range requests {
+			if _, err := svc.Create(user.ID, &requests[i], a.meta); err != nil {
+				return fmt.Errorf("transaction #%d (%s): %w", i+1, requests[i].Date, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("import aborted, no transactions were imported: %w", err)
+	}
+	fmt.Printf("imported %d transactions for %s\n", len(requests), user.Email)
+	return nil
+}
+
+// accountIDsByCode: 勘定科目コードから ID を引く対応表
+func accountIDsByCode(db *gorm.DB) (map[string]uint, error) {
+	accounts, err := chartOfAccountsRepository.NewChartOfAccountsRepository(db).GetByTypes([]models.AccountType{
+		models.AssetAccount, models.LiabilityAccount, models.EquityAccount, models.RevenueAccount, models.ExpenseAccount,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]uint, len(accounts))
+	for _, account := range accounts {
+		ids[account.Code] = account.ID
+	}
+	return ids, nil
+}
@@ -0,0 +1,91 @@
+// ledgerctl は HTTP を経由せずに運用作業を行う管理コマンド
+//
+//	ledgerctl user create -email <email> -name <name> [-role admin|user] [-password-stdin]
+//	ledgerctl user disable|enable -email <email>
+//	ledgerctl user reset-password -email <email> [-password-stdin]
+//	ledgerctl export -user <email> [-o <file>]
+//	ledgerctl import -user <email> -f <file>
+//	ledgerctl check [-json]
+//	ledgerctl period close -user <email> -from <YYYY-MM-DD> -to <YYYY-MM-DD>
+//	ledgerctl seed [-force] [all|users|accounts|transactions]
+//
+// 接続先などの設定はサーバーと同じ環境変数で指定する。操作は監査ログに記録される（操作者は空、User-Agent は ledgerctl）
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"simple-ledger/internal/common/config"
+	migration "simple-ledger/internal/common/db"
+	"simple-ledger/internal/common/requestmeta"
+	"simple-ledger/internal/common/security"
+
+	"gorm.io/gorm"
+)
+
+// commandName: 監査ログの User-Agent に記録する名前
+const commandName = "ledgerctl"
+
+// app: サブコマンドが共有する接続と操作の情報
+type app struct {
+	db   *gorm.DB
+	meta requestmeta.Meta
+}
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	commands := map[string]func(*app, []string) error{
+		"user":   runUser,
+		"export": runExport,
+		"import": runImport,
+		"check":  runCheck,
+		"period": runPeriod,
+		"seed":   runSeed,
+	}
+	run, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	config.LoadEnv()
+	db, err := config.SetupDatabase()
+	if err != nil {
+		log.Fatalf("Failed to setup database: %v", err)
+	}
+
+	// 古いスキーマに対して操作しないよう、マイグレーションが適用済みであることを確認する
+	pending, err := migration.Pending(db)
+	if err != nil {
+		log.Fatalf("Failed to check migrations: %v", err)
+	}
+	if len(pending) > 0 {
+		log.Fatalf("Database schema is out of date (%d pending migrations). Run `go run ./cmd/migrate up` first.", len(pending))
+	}
+
+	security.InitPasswordHashing(security.Argon2ParamsFromEnv())
+
+	if err := run(&app{db: db, meta: requestmeta.ForCommand(commandName)}, os.Args[2:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func usage() {
+	fmt.Fprint(os.Stderr, `usage:
+  ledgerctl user create -email <email> -name <name> [-role admin|user] [-password-stdin]
+  ledgerctl user disable|enable -email <email>
+  ledgerctl user reset-password -email <email> [-password-stdin]
+  ledgerctl export -user <email> [-o <file>]
+  ledgerctl import -user <email> -f <file>
+  ledgerctl check [-json]
+  ledgerctl period close -user <email> -from <YYYY-MM-DD> -to <YYYY-MM-DD>
+  ledgerctl seed [-force] [all|users|accounts|transactions]
+`)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+)
+
+// runPeriod: 会計期間の締め
+func runPeriod(a *app, args []string) error {
+	if len(args) == 0 || args[0] != "close" {
+		return errors.New("usage: ledgerctl period close -user <email> -from <YYYY-MM-DD> -to <YYYY-MM-DD>")
+	}
+
+	flags := flag.NewFlagSet("period close", flag.ExitOnError)
+	email := flags.String("user", "", "email address of the ledger owner")
+	from := flags.String("from", "", "first day of the period (YYYY-MM-DD)")
+	to := flags.String("to", "", "last day of the period (YYYY-MM-DD)")
+	_ = flags.Parse(args[1:])
+
+	user, err := lookupUser(a.db, *email)
+	if err != nil {
+		return err
+	}
+	period, err := newFiscalPeriodService(a.db).Close(user.ID, *from, *to, a.meta)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("closed fiscal period %d (%s to %s) for %s\n", period.ID, period.StartDate.Format("2006-01-02"), period.EndDate.Format("2006-01-02"), user.Email)
+	return nil
+}
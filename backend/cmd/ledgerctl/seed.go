@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"simple-ledger/internal/common/config"
+	"simple-ledger/internal/common/db/seeder"
+)
+
+// runSeed: シーダーを再実行
+// シードのユーザーは既知のパスワードでログインできるため、本番環境では -force が必要
+// 取引のシードは実行するたびに追加される
+func runSeed(a *app, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	force := flags.Bool("force", false, "allow seeding when APP_ENV=production")
+	_ = flags.Parse(args)
+
+	if config.GetEnv("APP_ENV", "development") == "production" && !*force {
+		return errors.New("refusing to seed a production database without -force")
+	}
+
+	target := "all"
+	if flags.NArg() > 0 {
+		target = flags.Arg(0)
+	}
+	switch target {
+	case "all":
+		seeder.SeedAll(a.db)
+	case "users":
+		seeder.SeedUsers(a.db)
+	case "accounts":
+		seeder.SeedChartOfAccounts(a.db)
+	case "transactions":
+		seeder.SeedTransactions(a.db)
+	default:
+		return fmt.Errorf("unknown seed target: %s (all, users, accounts or transactions)", target)
+	}
+	fmt.Printf("seeded %s\n", target)
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	attachmentRepository "simple-ledger/internal/attachment/repository"
+	auditRepository "simple-ledger/internal/audit/repository"
+	auditService "simple-ledger/internal/audit/service"
+	authRepository "simple-ledger/internal/auth/repository"
+	chartOfAccountsRepository "simple-ledger/internal/chart_of_accounts/repository"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/security"
+	fiscalPeriodRepository "simple-ledger/internal/fiscal_period/repository"
+	fiscalPeriodService "simple-ledger/internal/fiscal_period/service"
+	integrityRepository "simple-ledger/internal/integrity/repository"
+	integrityService "simple-ledger/internal/integrity/service"
+	journalEntryRepository "simple-ledger/internal/journal_entry/repository"
+	journalEntryService "simple-ledger/internal/journal_entry/service"
+	"simple-ledger/internal/models"
+	postingService "simple-ledger/internal/posting/service"
+	transactionRepository "simple-ledger/internal/transaction/repository"
+	transactionService "simple-ledger/internal/transaction/service"
+	userRepository "simple-ledger/internal/user/repository"
+	userService "simple-ledger/internal/user/service"
+
+	"gorm.io/gorm"
+)
+
+// 各サービスは API のルーター（SetupXxxRoutes）と同じ構成で生成する
+// db に DB トランザクションを渡すと、サービス内の処理もそのトランザクション上で行われる
+
+func newAuditService(db *gorm.DB) *auditService.AuditService {
+	return auditService.NewAuditService(auditRepository.NewAuditLogRepository(db))
+}
+
+func newUserService(db *gorm.DB) *userService.UserService {
+	return userService.NewUserService(userRepository.NewUserRepository(db), authRepository.NewSessionRepository(db), newAuditService(db), uow.New(db), security.PasswordPolicyFromEnv())
+}
+
+func newPostingValidator(db *gorm.DB) postingService.PostingValidator {
+	return postingService.NewPostingValidator(
+		chartOfAccountsRepository.NewChartOfAccountsRepository(db),
+		fiscalPeriodRepository.NewFiscalPeriodRepository(db),
+		postingService.ConfigFromEnv(),
+	)
+}
+
+func newTransactionService(db *gorm.DB) transactionService.TransactionService {
+	journalEntryRepo := journalEntryRepository.NewJournalEntryRepository(db)
+	validator := newPostingValidator(db)
+	auditSvc := newAuditService(db)
+	journalEntrySvc := journalEntryService.NewJournalEntryService(journalEntryRepo, validator, auditSvc, uow.New(db))
+	return transactionService.NewTransactionService(transactionRepository.NewTransactionRepository(db), journalEntryRepo, attachmentRepository.NewAttachmentRepository(db), journalEntrySvc, validator, auditSvc, uow.New(db), transactionService.ConfigFromEnv())
+}
+
+func newFiscalPeriodService(db *gorm.DB) *fiscalPeriodService.FiscalPeriodService {
+	return fiscalPeriodService.NewFiscalPeriodService(fiscalPeriodRepository.NewFiscalPeriodRepository(db), newAuditService(db), uow.New(db))
+}
+
+func newIntegrityService(db *gorm.DB) *integrityService.IntegrityService {
+	return integrityService.NewIntegrityService(integrityRepository.NewIntegrityRepository(db), newAuditService(db), uow.New(db))
+}
+
+// lookupUser: メールアドレスでユーザーを取得
+func lookupUser(db *gorm.DB, email string) (*models.User, error) {
+	if email == "" {
+		return nil, errors.New("-user (or -email) is required")
+	}
+	user, err := userRepository.NewUserRepository(db).GetUserByEmail(email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("user not found: %s", email)
+	}
+	return user, err
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"simple-ledger/internal/user/dto"
+)
+
+// runUser: ユーザーの作成・無効化・有効化・パスワードの再設定
+// パスワードはコマンドライン引数に残らないよう標準入力（-password-stdin）から読み込み、省略した場合は生成して表示する
+func runUser(a *app, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: ledgerctl user create|disable|enable|reset-password ...")
+	}
+
+	flags := flag.NewFlagSet("user "+args[0], flag.ExitOnError)
+	email := flags.String("email", "", "email address of the user")
+	switch args[0] {
+	case "create":
+		name := flags.String("name", "", "display name")
+		role := flags.String("role", "admin", "role (admin or user)")
+		passwordStdin := flags.Bool("password-stdin", false, "read the password from standard input")
+		_ = flags.Parse(args[1:])
+		if *email == "" || *name == "" {
+			return errors.New("-email and -name are required")
+		}
+		if *role != "admin" && *role != "user" {
+			return fmt.Errorf("unsupported role: %s (admin or user)", *role)
+		}
+		password, generated, err := readPassword(*passwordStdin)
+		if err != nil {
+			return err
+		}
+
+		user, err := newUserService(a.db).CreateUser(&dto.CreateUserRequest{Email: *email, Name: *name, Password: password, Role: *role}, a.meta)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created user %d (%s, role: %s)\n", user.ID, user.Email, user.Role)
+		printGeneratedPassword(generated, password)
+		return nil
+
+	case "disable", "enable":
+		_ = flags.Parse(args[1:])
+		active := args[0] == "enable"
+		return updateUser(a, *email, func(req *dto.UpdateUserRequest) {
+			req.IsActive = &active
+		})
+
+	case "reset-password":
+		passwordStdin := flags.Bool("password-stdin", false, "read the password from standard input")
+		_ = flags.Parse(args[1:])
+		password, generated, err := readPassword(*passwordStdin)
+		if err != nil {
+			return err
+		}
+		if err := updateUser(a, *email, func(req *dto.UpdateUserRequest) {
+			req.Password = &password
+		}); err != nil {
+			return err
+		}
+		printGeneratedPassword(generated, password)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown user command: %s", args[0])
+	}
+}
+
+// updateUser: 現在の値を引き継いで UserService.UpdateUser で更新する
+// ロール・パスワード・有効/無効の変更は API と同様に発行済みのアクセストークンを無効にする
+func updateUser(a *app, email string, modify func(req *dto.UpdateUserRequest)) error {
+	user, err := lookupUser(a.db, email)
+	if err != nil {
+		return err
+	}
+
+	req := &dto.UpdateUserRequest{Name: user.Name, Email: user.Email, Role: user.Role}
+	modify(req)
+	updated, err := newUserService(a.db).UpdateUser(user.ID, req, a.meta)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("updated user %d (%s, active: %t)\n", updated.ID, updated.Email, updated.IsActive)
+	return nil
+}
+
+// readPassword: 標準入力の1行目をパスワードとして読み込む（fromStdin が false の場合は生成する）
+func readPassword(fromStdin bool) (password string, generated bool, err error) {
+	if !fromStdin {
+		b := make([]byte, 18)
+		if _, err := rand.Read(b); err != nil {
+			return "", false, err
+		}
+		return base64.RawURLEncoding.EncodeToString(b), true, nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", false, fmt.Errorf("failed to read password from standard input: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), false, nil
+}
+
+// printGeneratedPassword: 生成したパスワードを表示（この1回のみ表示される）
+func printGeneratedPassword(generated bool, password string) {
+	if generated {
+		fmt.Printf("generated password: %s\n", password)
+	}
+}
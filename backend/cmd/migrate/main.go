@@ -0,0 +1,119 @@
+// migrate はデータベースのスキーマのマイグレーションを実行するコマンド
+//
+//	go run ./cmd/migrate up [-to <version>]   未適用のマイグレーションを適用（-to を省略した場合は最新まで）
+//	go run ./cmd/migrate down [-steps <n>]    適用済みのマイグレーションを新しいものから n 件取り消す（既定は 1 件）
+//	go run ./cmd/migrate status               マイグレーションの適用状況を表示
+//
+// 接続先はサーバーと同じ環境変数（DB_DRIVER など）で指定する
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"simple-ledger/internal/common/config"
+	migration "simple-ledger/internal/common/db"
+
+	"gorm.io/gorm"
+)
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	config.LoadEnv()
+	db, err := config.SetupDatabase()
+	if err != nil {
+		log.Fatalf("Failed to setup database: %v", err)
+	}
+
+	command, args := os.Args[1], os.Args[2:]
+	switch command {
+	case "up":
+		err = runUp(db, args)
+	case "down":
+		err = runDown(db, args)
+	case "status":
+		err = runStatus(db)
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate up [-to <version>] | down [-steps <n>] | status")
+}
+
+// runUp: 未適用のマイグレーションを適用
+func runUp(db *gorm.DB, args []string) error {
+	flags := flag.NewFlagSet("up", flag.ExitOnError)
+	to := flags.Uint("to", 0, "apply migrations up to this version (0 for the latest)")
+	_ = flags.Parse(args)
+
+	applied, err := migration.Up(db, *to)
+	for _, m := range applied {
+		log.Printf("applied %04d_%s", m.Version, m.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		log.Print("no pending migrations")
+	}
+	return nil
+}
+
+// runDown: 適用済みのマイグレーションを取り消す
+func runDown(db *gorm.DB, args []string) error {
+	flags := flag.NewFlagSet("down", flag.ExitOnError)
+	steps := flags.Int("steps", 1, "number of migrations to revert")
+	_ = flags.Parse(args)
+
+	if *steps < 1 {
+		return fmt.Errorf("-steps must be at least 1")
+	}
+
+	reverted, err := migration.Down(db, *steps)
+	for _, m := range reverted {
+		log.Printf("reverted %04d_%s", m.Version, m.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(reverted) == 0 {
+		log.Print("no applied migrations")
+	}
+	return nil
+}
+
+// runStatus: マイグレーションの適用状況を表示
+func runStatus(db *gorm.DB) error {
+	states, err := migration.Status(db)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, s := range states {
+		appliedAt := "pending"
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.UTC().Format("2006-01-02 15:04:05 UTC")
+		}
+		if s.Unknown {
+			appliedAt += " (unknown to this build)"
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+	}
+	return w.Flush()
+}
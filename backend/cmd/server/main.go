@@ -1,17 +1,36 @@
 package main
 
 import (
+	"context"
 	"log"
+	attachmentRouter "simple-ledger/internal/attachment/router"
+	auditRepository "simple-ledger/internal/audit/repository"
+	auditRouter "simple-ledger/internal/audit/router"
+	auditService "simple-ledger/internal/audit/service"
 	authRouter "simple-ledger/internal/auth/router"
 	chartOfAccountsRouter "simple-ledger/internal/chart_of_accounts/router"
 	"simple-ledger/internal/common/config"
+	"simple-ledger/internal/common/cookie"
+	"simple-ledger/internal/common/csrf"
 	migration "simple-ledger/internal/common/db"
 	"simple-ledger/internal/common/db/seeder"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/idempotency"
+	"simple-ledger/internal/common/mail"
+	"simple-ledger/internal/common/oidc"
+	"simple-ledger/internal/common/ratelimit"
+	"simple-ledger/internal/common/requestmeta"
 	"simple-ledger/internal/common/security"
+	"simple-ledger/internal/common/storage"
+	integrityRepository "simple-ledger/internal/integrity/repository"
+	integrityRouter "simple-ledger/internal/integrity/router"
+	integrityService "simple-ledger/internal/integrity/service"
 	journalEntryRouter "simple-ledger/internal/journal_entry/router"
 	transactionRouter "simple-ledger/internal/transaction/router"
+	userRepository "simple-ledger/internal/user/repository"
 	userRouter "simple-ledger/internal/user/router"
 	"strings"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -26,6 +45,13 @@ func main() {
 	log.Print("Loading environment variables...")
 	config.LoadEnv()
 
+	/*
+	 * 信頼するプロキシの設定（未設定の場合は X-Forwarded-For を使用しない）
+	 */
+	if err := router.SetTrustedProxies(requestmeta.TrustedProxiesFromEnv()); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
 	/*
 	 * CORS 設定
 	 */
@@ -34,13 +60,18 @@ func main() {
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     allowOriginsList,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "If-Match", idempotency.HeaderName, requestmeta.HeaderRequestID, csrf.HeaderName},
+		ExposeHeaders:    []string{"Content-Length", "Retry-After", "ETag", idempotency.HeaderReplayed, requestmeta.HeaderRequestID, csrf.HeaderName},
 		AllowCredentials: true,
 		MaxAge:           12 * 3600,
 	}))
 	log.Printf("CORS configured for origins: %v", allowOriginsList)
 
+	/*
+	 * リクエストID 採番（監査ログで使用）
+	 */
+	router.Use(requestmeta.Middleware())
+
 	/*
 	 * DB接続
 	 */
@@ -54,11 +85,31 @@ func main() {
 	/*
 	 * マイグレーション実行
 	 */
-	log.Print("Running database migrations...")
-	if err := migration.Migrate(db); err != nil {
-		log.Fatalf("failed to migrate: %v", err)
+	// DB_MIGRATE_ON_START=false の場合は起動時に適用せず、migrate コマンドで適用済みであることを確認する
+	if config.GetEnvAsBool("DB_MIGRATE_ON_START", true) {
+		log.Print("Running database migrations...")
+		applied, err := migration.Up(db, 0)
+		if err != nil {
+			log.Fatalf("failed to migrate: %v", err)
+		}
+		log.Printf("Database migrations completed (%d applied, latest version: %d).", len(applied), migration.Latest())
+	} else {
+		pending, err := migration.Pending(db)
+		if err != nil {
+			log.Fatalf("failed to check migrations: %v", err)
+		}
+		if len(pending) > 0 {
+			log.Fatalf("Database schema is out of date (%d pending migrations, latest version: %d). Run `go run ./cmd/migrate up` first.", len(pending), migration.Latest())
+		}
+		log.Print("Database schema is up to date.")
 	}
-	log.Print("Database migrations completed.")
+
+	/*
+	 * パスワードのハッシュ化（argon2id）設定
+	 */
+	passwordHashParams := security.Argon2ParamsFromEnv()
+	security.InitPasswordHashing(passwordHashParams)
+	log.Printf("Password hashing configured (argon2id: m=%d KiB, t=%d, p=%d)", passwordHashParams.Memory, passwordHashParams.Iterations, passwordHashParams.Parallelism)
 
 	/*
 	 * シードデータ投入
@@ -78,18 +129,109 @@ func main() {
 	tokenExpirationHours := config.GetEnvAsFloat64("TOKEN_EXPIRATION_HOURS", 1)
 	refreshTokenExpirationHours := config.GetEnvAsFloat64("REFRESH_TOKEN_EXPIRATION_HOURS", 1)
 	security.InitJWT(jwtSecret, tokenExpirationHours, refreshTokenExpirationHours)
+
+	// 署名鍵（Ed25519 または RSA）を指定した場合は HS256 の代わりに使用する
+	// ローテーション前の鍵は JWT_VERIFICATION_KEY_FILES に残しておくと、発行済みのトークンを引き続き検証できる
+	jwtKeys, err := security.LoadKeySet(config.GetEnv("JWT_SIGNING_KEY_FILE", ""), strings.Split(config.GetEnv("JWT_VERIFICATION_KEY_FILES", ""), ","))
+	if err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+	if jwtKeys == nil && env == "production" {
+		log.Fatal("JWT_SIGNING_KEY_FILE is required in production")
+	}
+	security.InitJWTKeys(jwtKeys)
+	if jwtKeys != nil {
+		log.Printf("JWT signing key loaded (kid: %s)", jwtKeys.SigningKeyID())
+	}
 	log.Printf("JWT initialized (token: %.2f hours, refresh: %.2f hours)", tokenExpirationHours, refreshTokenExpirationHours)
 
+	// ロール・パスワード・有効/無効の変更をアクセストークンの有効期限前でも反映する
+	tokenVersionCacheSeconds := config.GetEnvAsInt("TOKEN_VERSION_CACHE_SECONDS", 10)
+	security.InitTokenVersions(userRepository.NewUserRepository(db).GetTokenVersion, time.Duration(tokenVersionCacheSeconds)*time.Second)
+	log.Printf("Token version check enabled (cache: %d seconds)", tokenVersionCacheSeconds)
+
+	/*
+	 * クッキーの属性と CSRF 対策（クッキーで認証する更新系リクエストに X-CSRF-Token ヘッダーを必須とする）
+	 */
+	log.Print("Setting up cookies and CSRF protection...")
+	cookieConfig, err := cookie.ConfigFromEnv()
+	if err == nil {
+		err = cookieConfig.Validate(env == "production")
+	}
+	if err != nil {
+		log.Fatalf("Invalid cookie configuration: %v", err)
+	}
+	cookie.Init(cookieConfig)
+	router.Use(csrf.Middleware())
+	log.Printf("Cookies configured (secure: %t, host prefix: %t)", cookieConfig.Secure, cookieConfig.HostPrefix)
+
+	/*
+	 * 添付ファイル用ストレージ初期化
+	 */
+	log.Print("Setting up attachment storage...")
+	attachmentStorage, err := storage.NewFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to setup attachment storage: %v", err)
+	}
+
+	/*
+	 * ログイン試行制限用ストア初期化
+	 */
+	log.Print("Setting up rate limit store...")
+	rateLimitStore, err := ratelimit.NewStoreFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to setup rate limit store: %v", err)
+	}
+
+	/*
+	 * メール送信設定（パスワード再設定・メールアドレス確認）
+	 */
+	log.Print("Setting up mailer...")
+	mailer, err := mail.NewFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to setup mailer: %v", err)
+	}
+
+	/*
+	 * シングルサインオン（OpenID Connect）設定
+	 */
+	log.Print("Setting up single sign-on...")
+	oidcProvider, err := oidc.NewProviderFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to setup single sign-on: %v", err)
+	}
+	if oidcProvider != nil {
+		log.Printf("Single sign-on enabled (issuer: %s)", oidcProvider.Issuer())
+	}
+
+	/*
+	 * 整合性チェックの定期実行（INTEGRITY_CHECK_INTERVAL_MINUTES が 0 の場合は行わない）
+	 */
+	integrityConfig := integrityService.ConfigFromEnv()
+	if integrityConfig.Interval > 0 {
+		integritySvc := integrityService.NewIntegrityService(
+			integrityRepository.NewIntegrityRepository(db),
+			auditService.NewAuditService(auditRepository.NewAuditLogRepository(db)),
+			uow.New(db),
+		)
+		go integritySvc.RunPeriodically(context.Background(), integrityConfig)
+		log.Printf("Periodic integrity check enabled (interval: %s, quarantine: %t)", integrityConfig.Interval, integrityConfig.Quarantine)
+	}
+
 	/*
 	 * ルート定義
 	 */
 	log.Print("Setting up routes...")
+	authRouter.SetupWellKnownRoutes(router)
 	apiGroup := router.Group("/api")
 	userRouter.SetupUserRoutes(apiGroup, db)
-	authRouter.SetupAuthRoutes(apiGroup, db)
+	authRouter.SetupAuthRoutes(apiGroup, db, rateLimitStore, mailer, oidcProvider)
 	chartOfAccountsRouter.SetupChartOfAccountsRoutes(apiGroup, db)
 	transactionRouter.SetupTransactionRoutes(apiGroup, db)
 	journalEntryRouter.SetupJournalEntryRoutes(apiGroup, db)
+	auditRouter.SetupAuditRoutes(apiGroup, db)
+	integrityRouter.SetupIntegrityRoutes(apiGroup, db)
+	attachmentRouter.SetupAttachmentRoutes(apiGroup, db, attachmentStorage)
 	log.Print("Routes setup completed.")
 
 	// サーバー起動
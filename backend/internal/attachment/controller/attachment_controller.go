@@ -0,0 +1,220 @@
+package controller
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"simple-ledger/internal/attachment/dto"
+	"simple-ledger/internal/attachment/service"
+	"simple-ledger/internal/common/requestmeta"
+	postingService "simple-ledger/internal/posting/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AttachmentController: 添付ファイルコントローラー
+type AttachmentController struct {
+	service *service.AttachmentService
+}
+
+// NewAttachmentController: 添付ファイルコントローラーの生成
+func NewAttachmentController(service *service.AttachmentService) *AttachmentController {
+	return &AttachmentController{service: service}
+}
+
+// UploadAttachment: 取引にファイルを添付
+// POST /api/attachments/transactions/:transactionId（multipart/form-data: file, counterparty, amount）
+func (ctrl *AttachmentController) UploadAttachment(c *gin.Context) {
+	transactionID, err := strconv.ParseUint(c.Param("transactionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	// multipart 全体のサイズを制限（フォームの他フィールド分の余裕を持たせる）
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, ctrl.service.MaxFileBytes()+1<<20)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondError(c, service.ErrFileTooLarge)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	if fileHeader.Size > ctrl.service.MaxFileBytes() {
+		respondError(c, service.ErrFileTooLarge)
+		return
+	}
+
+	var req dto.UploadAttachmentRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+		return
+	}
+	defer func() { _ = file.Close() }()
+
+	attachment, err := ctrl.service.Upload(
+		c.Request.Context(), userID.(uint), uint(transactionID), fileHeader.Filename, file, &req, requestmeta.FromContext(c),
+	)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// GetAttachmentsByTransactionID: 取引の添付ファイル一覧を取得
+// GET /api/attachments/transactions/:transactionId
+func (ctrl *AttachmentController) GetAttachmentsByTransactionID(c *gin.Context) {
+	transactionID, err := strconv.ParseUint(c.Param("transactionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	attachments, err := ctrl.service.GetByTransactionID(userID.(uint), uint(transactionID))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// SearchAttachments: 取引年月日・取引金額・取引先で添付ファイルを検索
+// GET /api/attachments/search?dateFrom=2024-01-01&dateTo=2024-12-31&amountMin=1000&amountMax=50000&counterparty=商店&page=1&pageSize=20
+func (ctrl *AttachmentController) SearchAttachments(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	var req dto.SearchAttachmentsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters: " + err.Error()})
+		return
+	}
+
+	result, err := ctrl.service.Search(userID.(uint), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetAttachmentByID: 添付ファイルの情報を取得
+// GET /api/attachments/:id
+func (ctrl *AttachmentController) GetAttachmentByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	attachment, err := ctrl.service.GetByID(userID.(uint), uint(id))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attachment)
+}
+
+// DownloadAttachment: 添付ファイルをダウンロード
+// GET /api/attachments/:id/download
+func (ctrl *AttachmentController) DownloadAttachment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	attachment, data, err := ctrl.service.Download(c.Request.Context(), userID.(uint), uint(id))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	c.Header("X-Content-SHA256", attachment.SHA256)
+	c.Data(http.StatusOK, attachment.ContentType, data)
+}
+
+// DeleteAttachment: 添付ファイルを削除
+// DELETE /api/attachments/:id
+func (ctrl *AttachmentController) DeleteAttachment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	if err := ctrl.service.Delete(c.Request.Context(), userID.(uint), uint(id), requestmeta.FromContext(c)); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Attachment deleted successfully"})
+}
+
+// respondError: サービス層のエラーを HTTP ステータスに変換して返す
+func respondError(c *gin.Context, err error) {
+	var validationErr *postingService.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "details": validationErr.Errors})
+	case errors.Is(err, service.ErrTransactionNotFound), errors.Is(err, service.ErrAttachmentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrFileTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrUnsupportedFileType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,113 @@
+package dto
+
+import "simple-ledger/internal/models"
+
+// UploadAttachmentRequest: 添付ファイルのアップロードリクエスト（multipart/form-data）
+// ファイル本体は "file" フィールドで受け取る
+type UploadAttachmentRequest struct {
+	// Counterparty: 取引先（検索項目）
+	Counterparty string `form:"counterparty" binding:"max=255"`
+
+	// Amount: 取引金額（省略時は取引の借方合計）
+	Amount *int `form:"amount" binding:"omitempty,gt=0"`
+}
+
+// SearchAttachmentsRequest: 添付ファイルの検索リクエスト
+type SearchAttachmentsRequest struct {
+	// DateFrom: 取引年月日の開始（YYYY-MM-DD、この日を含む）
+	DateFrom string `form:"dateFrom"`
+
+	// DateTo: 取引年月日の終了（YYYY-MM-DD、この日を含む）
+	DateTo string `form:"dateTo"`
+
+	// AmountMin: 取引金額の下限
+	AmountMin *int `form:"amountMin" binding:"omitempty,min=0"`
+
+	// AmountMax: 取引金額の上限
+	AmountMax *int `form:"amountMax" binding:"omitempty,min=0"`
+
+	// Counterparty: 取引先（部分一致）
+	Counterparty string `form:"counterparty"`
+
+	// Page: ページ番号
+	Page int `form:"page" binding:"required,min=1"`
+
+	// PageSize: 1ページあたりの件数
+	PageSize int `form:"pageSize" binding:"required,min=1,max=100"`
+}
+
+// AttachmentResponse: 添付ファイルレスポンス
+type AttachmentResponse struct {
+	// ID: 添付ファイルID
+	ID uint `json:"id"`
+
+	// TransactionID: 添付先の取引ID
+	TransactionID uint `json:"transactionId"`
+
+	// TransactionDate: 取引年月日
+	TransactionDate string `json:"transactionDate"`
+
+	// Amount: 取引金額
+	Amount int `json:"amount"`
+
+	// Counterparty: 取引先
+	Counterparty string `json:"counterparty"`
+
+	// FileName: ファイル名
+	FileName string `json:"fileName"`
+
+	// ContentType: ファイルの種類
+	ContentType string `json:"contentType"`
+
+	// Size: ファイルサイズ（バイト）
+	Size int64 `json:"size"`
+
+	// SHA256: ファイル内容のハッシュ
+	SHA256 string `json:"sha256"`
+
+	// CreatedAt: アップロード日時
+	CreatedAt string `json:"createdAt"`
+}
+
+// SearchAttachmentsResponse: 添付ファイル検索レスポンス
+type SearchAttachmentsResponse struct {
+	// Attachments: 添付ファイル一覧
+	Attachments []AttachmentResponse `json:"attachments"`
+
+	// Total: 該当件数
+	Total int `json:"total"`
+
+	// Page: ページ番号
+	Page int `json:"page"`
+
+	// PageSize: 1ページあたりの件数
+	PageSize int `json:"pageSize"`
+
+	// HasNextPage: 次のページがあるかどうか
+	HasNextPage bool `json:"hasNextPage"`
+}
+
+// ToAttachmentResponse: Attachment から AttachmentResponse に変換
+func ToAttachmentResponse(attachment *models.Attachment) *AttachmentResponse {
+	return &AttachmentResponse{
+		ID:              attachment.ID,
+		TransactionID:   attachment.TransactionID,
+		TransactionDate: attachment.TransactionDate.Format("2006-01-02"),
+		Amount:          attachment.Amount,
+		Counterparty:    attachment.Counterparty,
+		FileName:        attachment.FileName,
+		ContentType:     attachment.ContentType,
+		Size:            attachment.Size,
+		SHA256:          attachment.SHA256,
+		CreatedAt:       attachment.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// ToAttachmentResponseSlice: Attachment スライスを AttachmentResponse スライスに変換
+func ToAttachmentResponseSlice(attachments []models.Attachment) []AttachmentResponse {
+	responses := make([]AttachmentResponse, len(attachments))
+	for i := range attachments {
+		responses[i] = *ToAttachmentResponse(&attachments[i])
+	}
+	return responses
+}
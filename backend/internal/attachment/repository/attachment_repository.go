@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"simple-ledger/internal/common/db/dialect"
+	"simple-ledger/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SearchCondition: 添付ファイルの検索条件（ゼロ値の項目は条件に含めない）
+type SearchCondition struct {
+	DateFrom     *time.Time
+	DateTo       *time.Time
+	AmountMin    *int
+	AmountMax    *int
+	Counterparty string
+}
+
+// AttachmentRepository: 添付ファイルリポジトリ
+type AttachmentRepository struct {
+	db *gorm.DB
+}
+
+// NewAttachmentRepository: 添付ファイルリポジトリの生成
+func NewAttachmentRepository(db *gorm.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+// WithTx: 指定したDBトランザクション上で動作するリポジトリを返す
+func (r *AttachmentRepository) WithTx(tx *gorm.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: tx}
+}
+
+// Create: 添付ファイルを登録
+func (r *AttachmentRepository) Create(attachment *models.Attachment) error {
+	attachment.TransactionDate = dialect.Date(attachment.TransactionDate)
+	return r.db.Create(attachment).Error
+}
+
+// GetByID: IDで添付ファイルを取得
+func (r *AttachmentRepository) GetByID(id uint) (*models.Attachment, error) {
+	var attachment models.Attachment
+	if err := r.db.First(&attachment, id).Error; err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// GetByTransactionID: 取引の添付ファイル一覧を取得
+func (r *AttachmentRepository) GetByTransactionID(transactionID uint) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	if err := r.db.
+		Where("transaction_id = ?", transactionID).
+		Order("created_at ASC").
+		Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// CountByTransactionID: 取引の添付ファイルの件数を取得
+func (r *AttachmentRepository) CountByTransactionID(transactionID uint) (int64, error) {
+	var count int64
+	if err := r.db.Model(&models.Attachment{}).Where("transaction_id = ?", transactionID).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// SyncTransaction: 取引の変更に合わせて添付ファイルの検索項目を更新
+// 取引年月日は常に、取引金額はアップロード時に指定しなかった添付ファイルのみ debitTotal に更新する
+func (r *AttachmentRepository) SyncTransaction(transactionID uint, date time.Time, debitTotal int) error {
+	if err := r.db.Model(&models.Attachment{}).
+		Where("transaction_id = ?", transactionID).
+		UpdateColumn("transaction_date", dialect.Date(date)).Error; err != nil {
+		return err
+	}
+	return r.db.Model(&models.Attachment{}).
+		Where("transaction_id = ? AND amount_specified = ?", transactionID, false).
+		UpdateColumn("amount", debitTotal).Error
+}
+
+// Search: 取引年月日・取引金額・取引先で添付ファイルを検索（ページネーション対応）
+func (r *AttachmentRepository) Search(userID uint, cond SearchCondition, page, pageSize int) ([]models.Attachment, int64, error) {
+	query := r.db.Model(&models.Attachment{}).Where("user_id = ?", userID)
+	if cond.DateFrom != nil {
+		query = query.Where("transaction_date >= ?", dialect.Date(*cond.DateFrom))
+	}
+	if cond.DateTo != nil {
+		query = query.Where("transaction_date <= ?", dialect.Date(*cond.DateTo))
+	}
+	if cond.AmountMin != nil {
+		query = query.Where("amount >= ?", *cond.AmountMin)
+	}
+	if cond.AmountMax != nil {
+		query = query.Where("amount <= ?", *cond.AmountMax)
+	}
+	if cond.Counterparty != "" {
+		query = query.Where(dialect.Contains(r.db, "counterparty", cond.Counterparty))
+	}
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var attachments []models.Attachment
+	offset := (page - 1) * pageSize
+	if err := query.
+		Order("transaction_date DESC").
+		Order("id DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&attachments).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return attachments, totalCount, nil
+}
+
+// Delete: 添付ファイルを削除
+func (r *AttachmentRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Attachment{}, id).Error
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"simple-ledger/internal/common/db/dbtest"
+	"simple-ledger/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupAttachmentTestDB(t *testing.T) (*gorm.DB, models.Transaction) {
+	db := dbtest.Open(t, &models.User{}, &models.Transaction{}, &models.Attachment{})
+
+	user := models.User{Email: "test@example.com", Name: "Test User", Password: "hashed_password", Role: "user", IsActive: true}
+	require.NoError(t, db.Create(&user).Error)
+	transaction := models.Transaction{UserID: user.ID, Date: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), Description: "テスト取引"}
+	require.NoError(t, db.Create(&transaction).Error)
+
+	return db, transaction
+}
+
+func createTestAttachment(t *testing.T, repo *AttachmentRepository, transaction models.Transaction, date time.Time, counterparty string) {
+	attachment := models.Attachment{
+		UserID:          transaction.UserID,
+		TransactionID:   transaction.ID,
+		TransactionDate: date,
+		Amount:          1000,
+		Counterparty:    counterparty,
+		FileName:        "receipt.pdf",
+		ContentType:     "application/pdf",
+		Size:            100,
+		StorageKey:      fmt.Sprintf("key-%s-%s", date.Format("20060102"), counterparty),
+		SHA256:          "0000",
+	}
+	require.NoError(t, repo.Create(&attachment))
+}
+
+func TestSearch_DateRangeIsInclusive(t *testing.T) {
+	db, transaction := setupAttachmentTestDB(t)
+	repo := NewAttachmentRepository(db)
+
+	createTestAttachment(t, repo, transaction, time.Date(2024, 11, 30, 0, 0, 0, 0, time.UTC), "A")
+	createTestAttachment(t, repo, transaction, time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), "B")
+	createTestAttachment(t, repo, transaction, time.Date(2024, 12, 31, 15, 0, 0, 0, time.UTC), "C")
+	createTestAttachment(t, repo, transaction, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), "D")
+
+	from := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	results, total, err := repo.Search(transaction.UserID, SearchCondition{DateFrom: &from, DateTo: &to}, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, "C", results[0].Counterparty)
+	assert.Equal(t, "B", results[1].Counterparty)
+}
+
+func TestSearch_CounterpartyMatchesSameOnAllDatabases(t *testing.T) {
+	db, transaction := setupAttachmentTestDB(t)
+	repo := NewAttachmentRepository(db)
+
+	date := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	for _, counterparty := range []string{"ACME Corp", "acme_japan", "acmeXjapan", "株式会社はなまる", "株式会社ぱなまる"} {
+		createTestAttachment(t, repo, transaction, date, counterparty)
+	}
+
+	tests := []struct {
+		counterparty string
+		expected     int64
+	}{
+		{"acme", 3},
+		{"ACME_", 1},
+		{"はなまる", 1}, // 濁点・半濁点の違いは区別する
+	}
+	for _, tt := range tests {
+		_, total, err := repo.Search(transaction.UserID, SearchCondition{Counterparty: tt.counterparty}, 1, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.expected, total, tt.counterparty)
+	}
+}
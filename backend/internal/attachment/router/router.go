@@ -0,0 +1,63 @@
+package router
+
+import (
+	"simple-ledger/internal/attachment/controller"
+	"simple-ledger/internal/attachment/repository"
+	"simple-ledger/internal/attachment/service"
+	auditRepository "simple-ledger/internal/audit/repository"
+	auditService "simple-ledger/internal/audit/service"
+	"simple-ledger/internal/auth/middleware"
+	chartOfAccountsRepository "simple-ledger/internal/chart_of_accounts/repository"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/storage"
+	fiscalPeriodRepository "simple-ledger/internal/fiscal_period/repository"
+	"simple-ledger/internal/models"
+	postingService "simple-ledger/internal/posting/service"
+	transactionRepository "simple-ledger/internal/transaction/repository"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SetupAttachmentRoutes: 添付ファイルのルートを設定
+func SetupAttachmentRoutes(apiGroup *gin.RouterGroup, db *gorm.DB, store storage.Storage) {
+	repo := repository.NewAttachmentRepository(db)
+	validator := postingService.NewPostingValidator(
+		chartOfAccountsRepository.NewChartOfAccountsRepository(db),
+		fiscalPeriodRepository.NewFiscalPeriodRepository(db),
+		postingService.ConfigFromEnv(),
+	)
+	auditSvc := auditService.NewAuditService(auditRepository.NewAuditLogRepository(db))
+	svc := service.NewAttachmentService(
+		repo,
+		transactionRepository.NewTransactionRepository(db),
+		validator,
+		auditSvc,
+		store,
+		uow.New(db),
+		service.ConfigFromEnv(),
+	)
+	ctrl := controller.NewAttachmentController(svc)
+
+	attachmentGroup := apiGroup.Group("/attachments")
+	attachmentGroup.Use(middleware.AuthMiddleware(models.TokenScopeTransactionsWrite))
+	{
+		// POST: 取引にファイルを添付
+		attachmentGroup.POST("/transactions/:transactionId", ctrl.UploadAttachment)
+
+		// GET: 取引の添付ファイル一覧を取得
+		attachmentGroup.GET("/transactions/:transactionId", ctrl.GetAttachmentsByTransactionID)
+
+		// GET: 取引年月日・取引金額・取引先で検索
+		attachmentGroup.GET("/search", ctrl.SearchAttachments)
+
+		// GET: 添付ファイルの情報を取得
+		attachmentGroup.GET("/:id", ctrl.GetAttachmentByID)
+
+		// GET: 添付ファイルをダウンロード
+		attachmentGroup.GET("/:id/download", ctrl.DownloadAttachment)
+
+		// DELETE: 添付ファイルを削除（締め済み期間は不可）
+		attachmentGroup.DELETE("/:id", ctrl.DeleteAttachment)
+	}
+}
@@ -0,0 +1,421 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"simple-ledger/internal/attachment/dto"
+	"simple-ledger/internal/attachment/repository"
+	auditService "simple-ledger/internal/audit/service"
+	"simple-ledger/internal/common/config"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/requestmeta"
+	"simple-ledger/internal/common/storage"
+	"simple-ledger/internal/models"
+	postingService "simple-ledger/internal/posting/service"
+	transactionRepository "simple-ledger/internal/transaction/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrTransactionNotFound: 添付先の取引が存在しない、または他ユーザーの取引
+	ErrTransactionNotFound = errors.New("transaction not found")
+
+	// ErrAttachmentNotFound: 添付ファイルが存在しない、または他ユーザーの添付ファイル
+	ErrAttachmentNotFound = errors.New("attachment not found")
+
+	// ErrFileTooLarge: ファイルサイズが上限を超えている
+	ErrFileTooLarge = errors.New("file is too large")
+
+	// ErrUnsupportedFileType: 受け付けないファイル形式
+	ErrUnsupportedFileType = errors.New("unsupported file type (allowed: PDF, JPEG, PNG, GIF, WebP)")
+
+	// ErrIntegrityCheckFailed: 保存されたファイルの内容がハッシュと一致しない
+	ErrIntegrityCheckFailed = errors.New("attachment content does not match its hash")
+)
+
+// allowedContentTypes: 受け付けるファイル形式と保存時の拡張子
+// 形式はクライアントの申告ではなくファイル内容から判定する
+var allowedContentTypes = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+}
+
+// Config: 添付ファイルの設定
+type Config struct {
+	// MaxFileBytes: 1ファイルあたりの最大サイズ（バイト）
+	MaxFileBytes int64
+}
+
+// DefaultConfig: 添付ファイルのデフォルト設定
+func DefaultConfig() Config {
+	return Config{MaxFileBytes: 10 << 20}
+}
+
+// ConfigFromEnv: 環境変数から添付ファイルの設定を読み込む
+func ConfigFromEnv() Config {
+	defaults := DefaultConfig()
+	return Config{
+		MaxFileBytes: int64(config.GetEnvAsInt("ATTACHMENT_MAX_BYTES", int(defaults.MaxFileBytes))),
+	}
+}
+
+// AttachmentService: 添付ファイルサービス
+type AttachmentService struct {
+	repo            *repository.AttachmentRepository
+	transactionRepo *transactionRepository.TransactionRepository
+	validator       postingService.PostingValidator
+	audit           *auditService.AuditService
+	storage         storage.Storage
+	uow             uow.UnitOfWork
+	config          Config
+}
+
+// NewAttachmentService: 添付ファイルサービスの生成
+func NewAttachmentService(
+	repo *repository.AttachmentRepository,
+	transactionRepo *transactionRepository.TransactionRepository,
+	validator postingService.PostingValidator,
+	audit *auditService.AuditService,
+	store storage.Storage,
+	unitOfWork uow.UnitOfWork,
+	cfg Config,
+) *AttachmentService {
+	return &AttachmentService{
+		repo:            repo,
+		transactionRepo: transactionRepo,
+		validator:       validator,
+		audit:           audit,
+		storage:         store,
+		uow:             unitOfWork,
+		config:          cfg,
+	}
+}
+
+// MaxFileBytes: 1ファイルあたりの最大サイズ
+func (s *AttachmentService) MaxFileBytes() int64 {
+	return s.config.MaxFileBytes
+}
+
+// Upload: 取引にファイルを添付
+// 取引年月日は取引から、取引金額は指定がなければ取引の借方合計から設定する（取引の変更時にも合わせて更新される）
+func (s *AttachmentService) Upload(
+	ctx context.Context,
+	userID uint,
+	transactionID uint,
+	fileName string,
+	file io.Reader,
+	req *dto.UploadAttachmentRequest,
+	meta requestmeta.Meta,
+) (*dto.AttachmentResponse, error) {
+	if _, err := s.getOwnedTransaction(userID, transactionID); err != nil {
+		return nil, err
+	}
+
+	// 上限 + 1 バイトまで読み込み、超過を検出する
+	data, err := io.ReadAll(io.LimitReader(file, s.config.MaxFileBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.config.MaxFileBytes {
+		return nil, ErrFileTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	extension, ok := allowedContentTypes[contentType]
+	if len(data) == 0 || !ok {
+		return nil, ErrUnsupportedFileType
+	}
+
+	sum := sha256.Sum256(data)
+
+	key, err := newStorageKey(userID, extension)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := &models.Attachment{
+		UserID:          userID,
+		TransactionID:   transactionID,
+		AmountSpecified: req.Amount != nil,
+		Counterparty:    strings.TrimSpace(req.Counterparty),
+		FileName:        sanitizeFileName(fileName),
+		ContentType:     contentType,
+		Size:            int64(len(data)),
+		StorageKey:      key,
+		SHA256:          hex.EncodeToString(sum[:]),
+	}
+
+	if err := s.storage.Put(ctx, key, bytes.NewReader(data), attachment.Size, contentType); err != nil {
+		return nil, err
+	}
+
+	err = s.uow.Do(func(tx *gorm.DB) error {
+		// 取引をロックし、登録までの間に取引が削除・変更されないようにする
+		transaction, err := s.lockOwnedTransaction(tx, userID, transactionID)
+		if err != nil {
+			return err
+		}
+		attachment.TransactionDate = transaction.Date
+		attachment.Amount = debitTotal(transaction)
+		if req.Amount != nil {
+			attachment.Amount = *req.Amount
+		}
+
+		if err := s.repo.WithTx(tx).Create(attachment); err != nil {
+			return err
+		}
+
+		return s.audit.WithTx(tx).Record(meta, auditService.Entry{
+			Action:     models.AuditActionCreate,
+			EntityType: models.AuditEntityAttachment,
+			EntityID:   attachment.ID,
+			After:      dto.ToAttachmentResponse(attachment),
+		})
+	})
+	if err != nil {
+		// 登録に失敗した場合は保存済みのファイルを削除（失敗しても登録エラーを優先して返す）
+		if deleteErr := s.storage.Delete(ctx, key); deleteErr != nil {
+			log.Printf("failed to remove orphaned attachment %s: %v", key, deleteErr)
+		}
+		return nil, err
+	}
+
+	return dto.ToAttachmentResponse(attachment), nil
+}
+
+// GetByTransactionID: 取引の添付ファイル一覧を取得
+func (s *AttachmentService) GetByTransactionID(userID uint, transactionID uint) ([]dto.AttachmentResponse, error) {
+	if _, err := s.getOwnedTransaction(userID, transactionID); err != nil {
+		return nil, err
+	}
+
+	attachments, err := s.repo.GetByTransactionID(transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.ToAttachmentResponseSlice(attachments), nil
+}
+
+// GetByID: IDで添付ファイルの情報を取得
+func (s *AttachmentService) GetByID(userID uint, id uint) (*dto.AttachmentResponse, error) {
+	attachment, err := s.getOwnedAttachment(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return dto.ToAttachmentResponse(attachment), nil
+}
+
+// Download: 添付ファイルの内容を取得
+// 保存時のハッシュと照合し、一致しない場合は ErrIntegrityCheckFailed を返す
+func (s *AttachmentService) Download(ctx context.Context, userID uint, id uint) (*models.Attachment, []byte, error) {
+	attachment, err := s.getOwnedAttachment(userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body, err := s.storage.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, ErrIntegrityCheckFailed
+		}
+		return nil, nil, err
+	}
+	defer func() { _ = body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(body, attachment.Size+1))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != attachment.SHA256 {
+		return nil, nil, ErrIntegrityCheckFailed
+	}
+
+	return attachment, data, nil
+}
+
+// Search: 取引年月日・取引金額・取引先で添付ファイルを検索
+func (s *AttachmentService) Search(userID uint, req *dto.SearchAttachmentsRequest) (*dto.SearchAttachmentsResponse, error) {
+	cond := repository.SearchCondition{
+		AmountMin:    req.AmountMin,
+		AmountMax:    req.AmountMax,
+		Counterparty: strings.TrimSpace(req.Counterparty),
+	}
+
+	if req.DateFrom != "" {
+		dateFrom, err := time.Parse("2006-01-02", req.DateFrom)
+		if err != nil {
+			return nil, errors.New("invalid dateFrom format, use YYYY-MM-DD")
+		}
+		cond.DateFrom = &dateFrom
+	}
+	if req.DateTo != "" {
+		dateTo, err := time.Parse("2006-01-02", req.DateTo)
+		if err != nil {
+			return nil, errors.New("invalid dateTo format, use YYYY-MM-DD")
+		}
+		cond.DateTo = &dateTo
+	}
+
+	attachments, totalCount, err := s.repo.Search(userID, cond, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.SearchAttachmentsResponse{
+		Attachments: dto.ToAttachmentResponseSlice(attachments),
+		Total:       int(totalCount),
+		Page:        req.Page,
+		PageSize:    req.PageSize,
+		HasNextPage: int64(req.Page*req.PageSize) < totalCount,
+	}, nil
+}
+
+// Delete: 添付ファイルを削除
+// 添付先の取引の取引年月日が締め済み期間に含まれる場合は削除できない
+func (s *AttachmentService) Delete(ctx context.Context, userID uint, id uint, meta requestmeta.Meta) error {
+	attachment, err := s.getOwnedAttachment(userID, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.uow.Do(func(tx *gorm.DB) error {
+		// 取引の現在の取引年月日で確認する（取引が削除済みの場合は添付ファイルに記録した取引年月日を使う）
+		date := attachment.TransactionDate
+		transaction, err := s.transactionRepo.WithTx(tx).LockByID(attachment.TransactionID)
+		switch {
+		case err == nil:
+			date = transaction.Date
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+		if err := s.validator.WithTx(tx).ValidatePeriodOpen(userID, date); err != nil {
+			return err
+		}
+
+		if err := s.repo.WithTx(tx).Delete(id); err != nil {
+			return err
+		}
+
+		return s.audit.WithTx(tx).Record(meta, auditService.Entry{
+			Action:     models.AuditActionDelete,
+			EntityType: models.AuditEntityAttachment,
+			EntityID:   id,
+			Before:     dto.ToAttachmentResponse(attachment),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// レコードの削除を確定してからファイルを削除（失敗した場合もレコードの削除は取り消さない）
+	if err := s.storage.Delete(ctx, attachment.StorageKey); err != nil {
+		log.Printf("failed to remove attachment file %s: %v", attachment.StorageKey, err)
+	}
+	return nil
+}
+
+// getOwnedTransaction: ユーザーが所有する取引を取得
+func (s *AttachmentService) getOwnedTransaction(userID uint, transactionID uint) (*models.Transaction, error) {
+	transaction, err := s.transactionRepo.GetByID(transactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	if transaction.UserID != userID {
+		return nil, ErrTransactionNotFound
+	}
+
+	return transaction, nil
+}
+
+// lockOwnedTransaction: DBトランザクション内でユーザーが所有する取引を行ロック付きで取得
+func (s *AttachmentService) lockOwnedTransaction(tx *gorm.DB, userID uint, transactionID uint) (*models.Transaction, error) {
+	transaction, err := s.transactionRepo.WithTx(tx).LockByID(transactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	if transaction.UserID != userID {
+		return nil, ErrTransactionNotFound
+	}
+
+	return transaction, nil
+}
+
+// getOwnedAttachment: ユーザーが所有する添付ファイルを取得
+func (s *AttachmentService) getOwnedAttachment(userID uint, id uint) (*models.Attachment, error) {
+	attachment, err := s.repo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+
+	if attachment.UserID != userID {
+		return nil, ErrAttachmentNotFound
+	}
+
+	return attachment, nil
+}
+
+// debitTotal: 取引の借方合計（取引金額）
+func debitTotal(transaction *models.Transaction) int {
+	total := 0
+	for _, entry := range transaction.JournalEntries {
+		if entry.Type == models.DebitEntry {
+			total += entry.Amount
+		}
+	}
+	return total
+}
+
+// newStorageKey: 推測されにくいストレージキーを生成
+func newStorageKey(userID uint, extension string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("attachments/%d/%s%s", userID, hex.EncodeToString(b), extension), nil
+}
+
+// sanitizeFileName: 表示用のファイル名からパスを除き、長さを制限
+func sanitizeFileName(fileName string) string {
+	name := strings.TrimSpace(filepath.Base(strings.ReplaceAll(fileName, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	for len(name) > 255 || !utf8.ValidString(name) {
+		name = strings.ToValidUTF8(name, "")
+		if len(name) > 255 {
+			name = name[:255]
+		}
+	}
+	return name
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"simple-ledger/internal/attachment/dto"
+	"simple-ledger/internal/attachment/repository"
+	auditRepository "simple-ledger/internal/audit/repository"
+	auditService "simple-ledger/internal/audit/service"
+	chartOfAccountsRepository "simple-ledger/internal/chart_of_accounts/repository"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/requestmeta"
+	"simple-ledger/internal/common/storage"
+	fiscalPeriodRepository "simple-ledger/internal/fiscal_period/repository"
+	"simple-ledger/internal/models"
+	postingService "simple-ledger/internal/posting/service"
+	transactionRepository "simple-ledger/internal/transaction/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// samplePDF: PDF として判定される最小限の内容
+var samplePDF = []byte("%PDF-1.4\n1 0 obj\n<<>>\nendobj\n%%EOF\n")
+
+// setupAttachmentServiceTest: ユーザー1が所有する取引（2024-12-01、1000円）とローカルストレージを用意
+func setupAttachmentServiceTest(t *testing.T) (*AttachmentService, *gorm.DB, storage.Storage, models.Transaction) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{},
+		&models.FiscalPeriod{}, &models.Attachment{}, &models.AuditLog{}, &models.AuditChainHead{}); err != nil {
+		panic(err)
+	}
+
+	cash := models.ChartOfAccounts{Code: "1000", Name: "現金", Type: models.AssetAccount, NormalBalance: models.DebitBalance, IsActive: true}
+	db.Create(&cash)
+	expense := models.ChartOfAccounts{Code: "5000", Name: "消耗品費", Type: models.ExpenseAccount, NormalBalance: models.DebitBalance, IsActive: true}
+	db.Create(&expense)
+
+	transaction := models.Transaction{
+		UserID:      1,
+		Date:        time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+		Description: "文房具購入",
+		JournalEntries: []models.JournalEntry{
+			{ChartOfAccountsID: expense.ID, Type: models.DebitEntry, Amount: 1000},
+			{ChartOfAccountsID: cash.ID, Type: models.CreditEntry, Amount: 1000},
+		},
+	}
+	db.Create(&transaction)
+
+	store, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	validator := postingService.NewPostingValidator(
+		chartOfAccountsRepository.NewChartOfAccountsRepository(db),
+		fiscalPeriodRepository.NewFiscalPeriodRepository(db),
+		postingService.DefaultConfig(),
+	)
+	svc := NewAttachmentService(
+		repository.NewAttachmentRepository(db),
+		transactionRepository.NewTransactionRepository(db),
+		validator,
+		auditService.NewAuditService(auditRepository.NewAuditLogRepository(db)),
+		store,
+		uow.New(db),
+		Config{MaxFileBytes: 1024},
+	)
+
+	return svc, db, store, transaction
+}
+
+func TestUpload_StoresFileWithSearchKeysAndHash(t *testing.T) {
+	svc, db, _, transaction := setupAttachmentServiceTest(t)
+	ctx := context.Background()
+
+	attachment, err := svc.Upload(ctx, 1, transaction.ID, "../../領収書.pdf", bytes.NewReader(samplePDF),
+		&dto.UploadAttachmentRequest{Counterparty: " 文具店 "}, requestmeta.Meta{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "領収書.pdf", attachment.FileName)
+	assert.Equal(t, "application/pdf", attachment.ContentType)
+	assert.Equal(t, "2024-12-01", attachment.TransactionDate)
+	assert.Equal(t, 1000, attachment.Amount)
+	assert.Equal(t, "文具店", attachment.Counterparty)
+	assert.Len(t, attachment.SHA256, 64)
+
+	stored, data, err := svc.Download(ctx, 1, attachment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, samplePDF, data)
+	assert.True(t, strings.HasPrefix(stored.StorageKey, "attachments/1/"))
+
+	var auditCount int64
+	db.Model(&models.AuditLog{}).Where("entity_type = ?", models.AuditEntityAttachment).Count(&auditCount)
+	assert.Equal(t, int64(1), auditCount)
+}
+
+func TestUpload_RejectsInvalidFiles(t *testing.T) {
+	svc, db, _, transaction := setupAttachmentServiceTest(t)
+	ctx := context.Background()
+
+	_, err := svc.Upload(ctx, 1, transaction.ID, "memo.txt", strings.NewReader("plain text"), &dto.UploadAttachmentRequest{}, requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrUnsupportedFileType)
+
+	large := append(append([]byte{}, samplePDF...), bytes.Repeat([]byte("0"), 1024)...)
+	_, err = svc.Upload(ctx, 1, transaction.ID, "large.pdf", bytes.NewReader(large), &dto.UploadAttachmentRequest{}, requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrFileTooLarge)
+
+	_, err = svc.Upload(ctx, 2, transaction.ID, "receipt.pdf", bytes.NewReader(samplePDF), &dto.UploadAttachmentRequest{}, requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrTransactionNotFound)
+
+	var count int64
+	db.Model(&models.Attachment{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestSearch_ByDateAmountAndCounterparty(t *testing.T) {
+	svc, _, _, transaction := setupAttachmentServiceTest(t)
+	ctx := context.Background()
+
+	amount := 5000
+	_, err := svc.Upload(ctx, 1, transaction.ID, "a.pdf", bytes.NewReader(samplePDF), &dto.UploadAttachmentRequest{Counterparty: "文具店"}, requestmeta.Meta{})
+	require.NoError(t, err)
+	_, err = svc.Upload(ctx, 1, transaction.ID, "b.pdf", bytes.NewReader(samplePDF), &dto.UploadAttachmentRequest{Counterparty: "家電量販店", Amount: &amount}, requestmeta.Meta{})
+	require.NoError(t, err)
+
+	min, max := 3000, 6000
+	tests := []struct {
+		name string
+		req  dto.SearchAttachmentsRequest
+		want int
+	}{
+		{"期間内", dto.SearchAttachmentsRequest{DateFrom: "2024-12-01", DateTo: "2024-12-31"}, 2},
+		{"期間外", dto.SearchAttachmentsRequest{DateFrom: "2025-01-01"}, 0},
+		{"金額範囲", dto.SearchAttachmentsRequest{AmountMin: &min, AmountMax: &max}, 1},
+		{"取引先", dto.SearchAttachmentsRequest{Counterparty: "文具"}, 1},
+		{"組み合わせ", dto.SearchAttachmentsRequest{DateTo: "2024-12-01", AmountMin: &min, Counterparty: "文具"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.Page, tt.req.PageSize = 1, 10
+			result, err := svc.Search(1, &tt.req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result.Total)
+		})
+	}
+
+	// 他ユーザーの添付ファイルは検索されない
+	result, err := svc.Search(2, &dto.SearchAttachmentsRequest{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Total)
+}
+
+func TestDownload_DetectsTamperedFile(t *testing.T) {
+	svc, db, store, transaction := setupAttachmentServiceTest(t)
+	ctx := context.Background()
+
+	attachment, err := svc.Upload(ctx, 1, transaction.ID, "receipt.pdf", bytes.NewReader(samplePDF), &dto.UploadAttachmentRequest{}, requestmeta.Meta{})
+	require.NoError(t, err)
+
+	var stored models.Attachment
+	db.First(&stored, attachment.ID)
+	tampered := bytes.Replace(samplePDF, []byte("1.4"), []byte("1.5"), 1)
+	require.NoError(t, store.Put(ctx, stored.StorageKey, bytes.NewReader(tampered), int64(len(tampered)), "application/pdf"))
+
+	_, _, err = svc.Download(ctx, 1, attachment.ID)
+	assert.ErrorIs(t, err, ErrIntegrityCheckFailed)
+}
+
+func TestDelete_BlockedInClosedPeriod(t *testing.T) {
+	svc, db, store, transaction := setupAttachmentServiceTest(t)
+	ctx := context.Background()
+
+	attachment, err := svc.Upload(ctx, 1, transaction.ID, "receipt.pdf", bytes.NewReader(samplePDF), &dto.UploadAttachmentRequest{}, requestmeta.Meta{})
+	require.NoError(t, err)
+
+	var stored models.Attachment
+	db.First(&stored, attachment.ID)
+	storageKey := stored.StorageKey
+
+	closedAt := time.Now()
+	period := models.FiscalPeriod{
+		UserID:    1,
+		StartDate: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+		ClosedAt:  &closedAt,
+	}
+	db.Create(&period)
+
+	err = svc.Delete(ctx, 1, attachment.ID, requestmeta.Meta{})
+	var validationErr *postingService.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+
+	// 期間を再開すると削除できる
+	db.Model(&period).Update("closed_at", nil)
+	require.NoError(t, svc.Delete(ctx, 1, attachment.ID, requestmeta.Meta{}))
+
+	_, err = svc.GetByID(1, attachment.ID)
+	assert.ErrorIs(t, err, ErrAttachmentNotFound)
+
+	_, err = store.Get(ctx, storageKey)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestDelete_ChecksTransactionCurrentDate(t *testing.T) {
+	svc, db, _, transaction := setupAttachmentServiceTest(t)
+	ctx := context.Background()
+
+	attachment, err := svc.Upload(ctx, 1, transaction.ID, "receipt.pdf", bytes.NewReader(samplePDF), &dto.UploadAttachmentRequest{}, requestmeta.Meta{})
+	require.NoError(t, err)
+
+	// 取引を 2025-05 に移動して 2025-05 を締める（添付ファイルに記録した取引年月日の期間は締めていない）
+	db.Model(&transaction).Update("date", time.Date(2025, 5, 10, 0, 0, 0, 0, time.UTC))
+	closedAt := time.Now()
+	db.Create(&models.FiscalPeriod{
+		UserID:    1,
+		StartDate: time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, 5, 31, 0, 0, 0, 0, time.UTC),
+		ClosedAt:  &closedAt,
+	})
+
+	err = svc.Delete(ctx, 1, attachment.ID, requestmeta.Meta{})
+	var validationErr *postingService.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+
+	_, err = svc.GetByID(1, attachment.ID)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"net/http"
+
+	"simple-ledger/internal/audit/dto"
+	"simple-ledger/internal/audit/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditController: 監査ログコントローラー
+type AuditController struct {
+	service *service.AuditService
+}
+
+// NewAuditController: 監査ログコントローラーの生成
+func NewAuditController(service *service.AuditService) *AuditController {
+	return &AuditController{service: service}
+}
+
+// GetAuditLogs: 監査ログの一覧を取得
+// GET /api/audit-logs?entityType=transaction&entityId=1&page=1&pageSize=50
+func (ctrl *AuditController) GetAuditLogs(c *gin.Context) {
+	var req dto.GetAuditLogsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters: " + err.Error()})
+		return
+	}
+
+	result, err := ctrl.service.List(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit logs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// VerifyAuditChain: 監査ログのハッシュチェーンを検証
+// GET /api/audit-logs/verify
+func (ctrl *AuditController) VerifyAuditChain(c *gin.Context) {
+	result, err := ctrl.service.Verify()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify audit logs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,84 @@
+package dto
+
+import (
+	"simple-ledger/internal/models"
+	"time"
+)
+
+// GetAuditLogsRequest: 監査ログ一覧の取得条件
+type GetAuditLogsRequest struct {
+	// EntityType: 対象エンティティの種類（省略時は全件）
+	EntityType string `form:"entityType" binding:"omitempty,oneof=transaction journal_entry chart_of_accounts user attachment mfa_policy personal_access_token user_identity"`
+
+	// EntityID: 対象エンティティのID（省略時は絞り込まない）
+	EntityID uint `form:"entityId"`
+
+	// Page: ページ番号
+	Page int `form:"page" binding:"required,min=1"`
+
+	// PageSize: 1ページあたりの件数
+	PageSize int `form:"pageSize" binding:"required,min=1,max=100"`
+}
+
+// AuditLogResponse: 監査ログレスポンス
+type AuditLogResponse struct {
+	ID         uint                   `json:"id"`
+	Sequence   uint64                 `json:"sequence"`
+	ActorID    *uint                  `json:"actorId"`
+	Action     models.AuditAction     `json:"action"`
+	EntityType models.AuditEntityType `json:"entityType"`
+	EntityID   uint                   `json:"entityId"`
+	RequestID  string                 `json:"requestId"`
+	IPAddress  string                 `json:"ipAddress"`
+	Before     string                 `json:"before,omitempty"`
+	After      string                 `json:"after,omitempty"`
+	PrevHash   string                 `json:"prevHash"`
+	Hash       string                 `json:"hash"`
+	CreatedAt  time.Time              `json:"createdAt"`
+}
+
+// GetAuditLogsResponse: 監査ログ一覧レスポンス
+type GetAuditLogsResponse struct {
+	AuditLogs   []AuditLogResponse `json:"auditLogs"`
+	Total       int                `json:"total"`
+	Page        int                `json:"page"`
+	PageSize    int                `json:"pageSize"`
+	HasNextPage bool               `json:"hasNextPage"`
+}
+
+// VerifyAuditChainResponse: ハッシュチェーン検証結果
+type VerifyAuditChainResponse struct {
+	// Valid: チェーンが改ざんされていないかどうか
+	Valid bool `json:"valid"`
+
+	// CheckedCount: 検証したレコード数
+	CheckedCount int `json:"checkedCount"`
+
+	// LastSequence: 検証できた最後の連番
+	LastSequence uint64 `json:"lastSequence"`
+
+	// BrokenAtSequence: 不整合を検出した連番（正常な場合は省略）
+	BrokenAtSequence *uint64 `json:"brokenAtSequence,omitempty"`
+
+	// Reason: 不整合の内容（正常な場合は省略）
+	Reason string `json:"reason,omitempty"`
+}
+
+// ToAuditLogResponse: モデルをレスポンスに変換
+func ToAuditLogResponse(log *models.AuditLog) AuditLogResponse {
+	return AuditLogResponse{
+		ID:         log.ID,
+		Sequence:   log.Sequence,
+		ActorID:    log.ActorID,
+		Action:     log.Action,
+		EntityType: log.EntityType,
+		EntityID:   log.EntityID,
+		RequestID:  log.RequestID,
+		IPAddress:  log.IPAddress,
+		Before:     log.Before,
+		After:      log.After,
+		PrevHash:   log.PrevHash,
+		Hash:       log.Hash,
+		CreatedAt:  log.CreatedAt,
+	}
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"errors"
+	"simple-ledger/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// chainHeadID: チェーン末尾を保持する行のID
+const chainHeadID = 1
+
+// AuditLogRepository: 監査ログリポジトリ
+// 監査ログは追記のみで、更新・削除のメソッドは提供しない
+type AuditLogRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditLogRepository: 監査ログリポジトリの生成
+func NewAuditLogRepository(db *gorm.DB) *AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+// WithTx: 指定したDBトランザクション上で動作するリポジトリを返す
+func (r *AuditLogRepository) WithTx(tx *gorm.DB) *AuditLogRepository {
+	return &AuditLogRepository{db: tx}
+}
+
+// LockHead: チェーン末尾の行を排他ロックして取得（存在しない場合は作成）
+// DBトランザクション内で呼び出すこと。コミットまで他の追記はここで待機する
+func (r *AuditLogRepository) LockHead() (*models.AuditChainHead, error) {
+	var head models.AuditChainHead
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&head, chainHeadID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		head = models.AuditChainHead{ID: chainHeadID}
+		if err := r.db.Create(&head).Error; err != nil {
+			return nil, err
+		}
+		return &head, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &head, nil
+}
+
+// GetHead: チェーン末尾を取得（未作成の場合は空の末尾を返す）
+func (r *AuditLogRepository) GetHead() (*models.AuditChainHead, error) {
+	var head models.AuditChainHead
+	err := r.db.First(&head, chainHeadID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.AuditChainHead{ID: chainHeadID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &head, nil
+}
+
+// Append: 監査ログを追加し、チェーン末尾を更新
+func (r *AuditLogRepository) Append(log *models.AuditLog) error {
+	if err := r.db.Create(log).Error; err != nil {
+		return err
+	}
+
+	return r.db.Model(&models.AuditChainHead{}).
+		Where("id = ?", chainHeadID).
+		Updates(map[string]interface{}{"sequence": log.Sequence, "hash": log.Hash}).Error
+}
+
+// ListAfterSequence: 指定した連番より後の監査ログを連番順に取得
+func (r *AuditLogRepository) ListAfterSequence(sequence uint64, limit int) ([]models.AuditLog, error) {
+	var logs []models.AuditLog
+	if err := r.db.
+		Where("sequence > ?", sequence).
+		Order("sequence ASC").
+		Limit(limit).
+		Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// List: 条件に一致する監査ログを新しい順に取得（ページネーション対応）
+// entityType が空の場合は全件、entityID が 0 の場合はエンティティIDで絞り込まない
+func (r *AuditLogRepository) List(entityType models.AuditEntityType, entityID uint, page, pageSize int) ([]models.AuditLog, int64, error) {
+	query := r.db.Model(&models.AuditLog{})
+	if entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	if entityID != 0 {
+		query = query.Where("entity_id = ?", entityID)
+	}
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var logs []models.AuditLog
+	offset := (page - 1) * pageSize
+	if err := query.
+		Order("sequence DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return logs, totalCount, nil
+}
@@ -0,0 +1,25 @@
+package router
+
+import (
+	"simple-ledger/internal/audit/controller"
+	"simple-ledger/internal/audit/repository"
+	"simple-ledger/internal/audit/service"
+	"simple-ledger/internal/auth/middleware"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func SetupAuditRoutes(apiGroup *gin.RouterGroup, db *gorm.DB) {
+	repo := repository.NewAuditLogRepository(db)
+	svc := service.NewAuditService(repo)
+	ctrl := controller.NewAuditController(svc)
+
+	// 監査ログの閲覧・検証は管理者のみ
+	auditRoutes := apiGroup.Group("/audit-logs")
+	auditRoutes.Use(middleware.AuthMiddleware(), middleware.RequireRole("admin"))
+	{
+		auditRoutes.GET("", ctrl.GetAuditLogs)            // GET /api/audit-logs
+		auditRoutes.GET("/verify", ctrl.VerifyAuditChain) // GET /api/audit-logs/verify
+	}
+}
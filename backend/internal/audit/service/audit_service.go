@@ -0,0 +1,208 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"simple-ledger/internal/audit/dto"
+	"simple-ledger/internal/audit/repository"
+	"simple-ledger/internal/common/requestmeta"
+	"simple-ledger/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// verifyBatchSize: 検証時に一度に読み込むレコード数
+const verifyBatchSize = 500
+
+// Entry: 監査ログに記録する変更内容
+type Entry struct {
+	Action     models.AuditAction
+	EntityType models.AuditEntityType
+	EntityID   uint
+
+	// Before: 変更前の状態（JSONに変換して保存、作成の場合は nil）
+	Before any
+
+	// After: 変更後の状態（JSONに変換して保存、削除の場合は nil）
+	After any
+}
+
+// AuditService: 監査ログサービス
+type AuditService struct {
+	repo *repository.AuditLogRepository
+}
+
+// NewAuditService: 監査ログサービスの生成
+func NewAuditService(repo *repository.AuditLogRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// WithTx: 指定したDBトランザクション上で動作するサービスを返す
+func (s *AuditService) WithTx(tx *gorm.DB) *AuditService {
+	return &AuditService{repo: s.repo.WithTx(tx)}
+}
+
+// Record: 変更内容を監査ログに追記
+// 変更と同じDBトランザクション内（WithTx）で呼び出し、変更と監査ログが必ず一緒にコミットされるようにする
+func (s *AuditService) Record(meta requestmeta.Meta, entry Entry) error {
+	before, err := marshalSnapshot(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := marshalSnapshot(entry.After)
+	if err != nil {
+		return err
+	}
+
+	head, err := s.repo.LockHead()
+	if err != nil {
+		return err
+	}
+
+	log := &models.AuditLog{
+		Sequence:   head.Sequence + 1,
+		ActorID:    meta.ActorID,
+		Action:     entry.Action,
+		EntityType: entry.EntityType,
+		EntityID:   entry.EntityID,
+		RequestID:  meta.RequestID,
+		IPAddress:  meta.IPAddress,
+		Before:     before,
+		After:      after,
+		PrevHash:   head.Hash,
+		// DBの日時精度（ミリ秒）に揃え、読み戻した値でも同じハッシュになるようにする
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+	log.Hash = computeHash(log)
+
+	return s.repo.Append(log)
+}
+
+// List: 監査ログを新しい順に取得
+func (s *AuditService) List(req *dto.GetAuditLogsRequest) (*dto.GetAuditLogsResponse, error) {
+	logs, totalCount, err := s.repo.List(models.AuditEntityType(req.EntityType), req.EntityID, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]dto.AuditLogResponse, 0, len(logs))
+	for i := range logs {
+		responses = append(responses, dto.ToAuditLogResponse(&logs[i]))
+	}
+
+	return &dto.GetAuditLogsResponse{
+		AuditLogs:   responses,
+		Total:       int(totalCount),
+		Page:        req.Page,
+		PageSize:    req.PageSize,
+		HasNextPage: int64(req.Page*req.PageSize) < totalCount,
+	}, nil
+}
+
+// Verify: ハッシュチェーン全体を検証
+// 連番の欠落（削除）、前レコードのハッシュとの不一致（挿入・並べ替え）、
+// 内容とハッシュの不一致（改ざん）、末尾とチェーン末尾行の不一致（末尾の削除）を検出する
+func (s *AuditService) Verify() (*dto.VerifyAuditChainResponse, error) {
+	head, err := s.repo.GetHead()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dto.VerifyAuditChainResponse{Valid: true}
+	prevHash := ""
+
+	for {
+		logs, err := s.repo.ListAfterSequence(result.LastSequence, verifyBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(logs) == 0 {
+			break
+		}
+
+		for i := range logs {
+			log := &logs[i]
+			expected := result.LastSequence + 1
+
+			switch {
+			case log.Sequence != expected:
+				return broken(result, expected, fmt.Sprintf("records %d to %d are missing", expected, log.Sequence-1)), nil
+			case log.PrevHash != prevHash:
+				return broken(result, log.Sequence, "previous hash does not match the preceding record"), nil
+			case computeHash(log) != log.Hash:
+				return broken(result, log.Sequence, "record content does not match its hash"), nil
+			}
+
+			prevHash = log.Hash
+			result.LastSequence = log.Sequence
+			result.CheckedCount++
+		}
+	}
+
+	if head.Sequence != result.LastSequence || head.Hash != prevHash {
+		return broken(result, result.LastSequence+1, fmt.Sprintf("chain head expects %d records but found %d", head.Sequence, result.LastSequence)), nil
+	}
+
+	return result, nil
+}
+
+// broken: 検証結果を不整合として返す
+func broken(result *dto.VerifyAuditChainResponse, sequence uint64, reason string) *dto.VerifyAuditChainResponse {
+	result.Valid = false
+	result.BrokenAtSequence = &sequence
+	result.Reason = reason
+	return result
+}
+
+// hashPayload: ハッシュ計算の対象（フィールド順が固定されるよう構造体で定義）
+type hashPayload struct {
+	Sequence   uint64                 `json:"sequence"`
+	PrevHash   string                 `json:"prevHash"`
+	ActorID    *uint                  `json:"actorId"`
+	Action     models.AuditAction     `json:"action"`
+	EntityType models.AuditEntityType `json:"entityType"`
+	EntityID   uint                   `json:"entityId"`
+	RequestID  string                 `json:"requestId"`
+	IPAddress  string                 `json:"ipAddress"`
+	Before     string                 `json:"before"`
+	After      string                 `json:"after"`
+	CreatedAt  string                 `json:"createdAt"`
+}
+
+// computeHash: 監査ログのハッシュ（SHA-256）を計算
+func computeHash(log *models.AuditLog) string {
+	payload, _ := json.Marshal(hashPayload{
+		Sequence:   log.Sequence,
+		PrevHash:   log.PrevHash,
+		ActorID:    log.ActorID,
+		Action:     log.Action,
+		EntityType: log.EntityType,
+		EntityID:   log.EntityID,
+		RequestID:  log.RequestID,
+		IPAddress:  log.IPAddress,
+		Before:     log.Before,
+		After:      log.After,
+		CreatedAt:  log.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// marshalSnapshot: 変更前後の状態をJSON文字列に変換（nil の場合は空文字）
+func marshalSnapshot(snapshot any) (string, error) {
+	if snapshot == nil {
+		return "", nil
+	}
+	b, err := json.Marshal(snapshot)
+	if err != nil {
+		return "", err
+	}
+	if string(b) == "null" {
+		return "", nil
+	}
+	return string(b), nil
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"testing"
+
+	"simple-ledger/internal/audit/repository"
+	"simple-ledger/internal/common/requestmeta"
+	"simple-ledger/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupAuditServiceTest: 3件の監査ログを記録した状態を作成
+func setupAuditServiceTest(t *testing.T) (*AuditService, *gorm.DB) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err := db.AutoMigrate(&models.AuditLog{}, &models.AuditChainHead{}); err != nil {
+		panic(err)
+	}
+
+	svc := NewAuditService(repository.NewAuditLogRepository(db))
+	actorID := uint(1)
+	meta := requestmeta.Meta{ActorID: &actorID, RequestID: "req-1", IPAddress: "192.0.2.1"}
+
+	entries := []Entry{
+		{Action: models.AuditActionCreate, EntityType: models.AuditEntityTransaction, EntityID: 10, After: map[string]int{"amount": 1000}},
+		{Action: models.AuditActionUpdate, EntityType: models.AuditEntityTransaction, EntityID: 10, Before: map[string]int{"amount": 1000}, After: map[string]int{"amount": 2000}},
+		{Action: models.AuditActionDelete, EntityType: models.AuditEntityTransaction, EntityID: 10, Before: map[string]int{"amount": 2000}},
+	}
+	for _, entry := range entries {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return svc.WithTx(tx).Record(meta, entry)
+		})
+		require.NoError(t, err)
+	}
+
+	return svc, db
+}
+
+func TestRecord_ChainsHashes(t *testing.T) {
+	_, db := setupAuditServiceTest(t)
+
+	var logs []models.AuditLog
+	db.Order("sequence ASC").Find(&logs)
+	require.Len(t, logs, 3)
+
+	assert.Equal(t, uint64(1), logs[0].Sequence)
+	assert.Empty(t, logs[0].PrevHash)
+	assert.Equal(t, logs[0].Hash, logs[1].PrevHash)
+	assert.Equal(t, logs[1].Hash, logs[2].PrevHash)
+	assert.Equal(t, `{"amount":1000}`, logs[1].Before)
+	assert.Equal(t, `{"amount":2000}`, logs[1].After)
+	assert.Empty(t, logs[2].After)
+	assert.Equal(t, "req-1", logs[0].RequestID)
+	assert.Equal(t, uint(1), *logs[0].ActorID)
+
+	var head models.AuditChainHead
+	db.First(&head, 1)
+	assert.Equal(t, uint64(3), head.Sequence)
+	assert.Equal(t, logs[2].Hash, head.Hash)
+}
+
+func TestRecord_RollsBackWithTransaction(t *testing.T) {
+	svc, db := setupAuditServiceTest(t)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := svc.WithTx(tx).Record(requestmeta.Meta{}, Entry{Action: models.AuditActionCreate, EntityType: models.AuditEntityUser, EntityID: 1}); err != nil {
+			return err
+		}
+		return assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+
+	var count int64
+	db.Model(&models.AuditLog{}).Count(&count)
+	assert.Equal(t, int64(3), count)
+
+	result, err := svc.Verify()
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+}
+
+func TestVerify_ValidChain(t *testing.T) {
+	svc, _ := setupAuditServiceTest(t)
+
+	result, err := svc.Verify()
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, 3, result.CheckedCount)
+	assert.Equal(t, uint64(3), result.LastSequence)
+	assert.Nil(t, result.BrokenAtSequence)
+}
+
+func TestVerify_DetectsAlteredRecord(t *testing.T) {
+	svc, db := setupAuditServiceTest(t)
+
+	db.Model(&models.AuditLog{}).Where("sequence = ?", 2).Update("after", `{"amount":9999}`)
+
+	result, err := svc.Verify()
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, uint64(2), *result.BrokenAtSequence)
+	assert.Equal(t, 1, result.CheckedCount)
+}
+
+func TestVerify_DetectsDeletedRecord(t *testing.T) {
+	svc, db := setupAuditServiceTest(t)
+
+	db.Where("sequence = ?", 2).Delete(&models.AuditLog{})
+
+	result, err := svc.Verify()
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, uint64(2), *result.BrokenAtSequence)
+}
+
+func TestVerify_DetectsRenumberedRecords(t *testing.T) {
+	svc, db := setupAuditServiceTest(t)
+
+	// 削除後に連番を詰めても、前レコードのハッシュが一致しないため検出できる
+	db.Where("sequence = ?", 2).Delete(&models.AuditLog{})
+	db.Model(&models.AuditLog{}).Where("sequence = ?", 3).Update("sequence", 2)
+	db.Model(&models.AuditChainHead{}).Where("id = ?", 1).Update("sequence", 2)
+
+	result, err := svc.Verify()
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, uint64(2), *result.BrokenAtSequence)
+}
+
+func TestVerify_DetectsTruncatedTail(t *testing.T) {
+	svc, db := setupAuditServiceTest(t)
+
+	db.Where("sequence = ?", 3).Delete(&models.AuditLog{})
+
+	result, err := svc.Verify()
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, uint64(3), *result.BrokenAtSequence)
+	assert.Equal(t, 2, result.CheckedCount)
+}
@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"simple-ledger/internal/auth/dto"
+	"simple-ledger/internal/auth/service"
+	"simple-ledger/internal/common/cookie"
+	"simple-ledger/internal/common/requestmeta"
+	"simple-ledger/internal/common/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccountController struct {
+	service *service.AccountService
+}
+
+func NewAccountController(service *service.AccountService) *AccountController {
+	return &AccountController{service: service}
+}
+
+// ForgotPassword はパスワード再設定メールの送信依頼エンドポイント
+// POST /api/auth/password/forgot
+// 登録の有無を推測されないよう、メールアドレスにかかわらず同じ応答を返す
+func (c *AccountController) ForgotPassword(ctx *gin.Context) {
+	var req dto.ForgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.service.RequestPasswordReset(ctx.Request.Context(), req.Email); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request password reset"})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "登録されているメールアドレスの場合、パスワード再設定用のリンクを送信しました"})
+}
+
+// ResetPassword はパスワード再設定エンドポイント
+// POST /api/auth/password/reset
+func (c *AccountController) ResetPassword(ctx *gin.Context) {
+	var req dto.ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.service.ResetPassword(req.Token, req.NewPassword, requestmeta.FromContext(ctx)); err != nil {
+		if errors.Is(err, service.ErrInvalidAccountToken) || errors.Is(err, security.ErrWeakPassword) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	// すべてのセッションを失効させたため、このブラウザのクッキーも削除する
+	clearAuthCookies(ctx)
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// ChangePassword はログイン中のユーザーのパスワード変更エンドポイント
+// POST /api/auth/password/change
+func (c *AccountController) ChangePassword(ctx *gin.Context) {
+	var req dto.ChangePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	// 変更を行った端末のセッションは維持する
+	refreshToken, _ := cookie.RefreshToken.Get(ctx)
+
+	err := c.service.ChangePassword(userID.(uint), req.CurrentPassword, req.NewPassword, refreshToken, requestmeta.FromContext(ctx))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCurrentPassword) || errors.Is(err, service.ErrSamePassword) || errors.Is(err, security.ErrWeakPassword) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// SendEmailVerification はメールアドレス確認メールの送信エンドポイント
+// POST /api/auth/email/verification
+func (c *AccountController) SendEmailVerification(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	if err := c.service.SendEmailVerification(ctx.Request.Context(), userID.(uint)); err != nil {
+		var tooSoon *service.ResendTooSoonError
+		if errors.As(err, &tooSoon) {
+			ctx.Header("Retry-After", strconv.Itoa(tooSoon.RetryAfterSeconds()))
+			ctx.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error(), "retryAfter": tooSoon.RetryAfterSeconds()})
+			return
+		}
+		if errors.Is(err, service.ErrEmailAlreadyVerified) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "確認用のリンクを送信しました"})
+}
+
+// VerifyEmail はメールアドレス確認エンドポイント
+// POST /api/auth/email/verify
+func (c *AccountController) VerifyEmail(ctx *gin.Context) {
+	var req dto.VerifyEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.service.VerifyEmail(req.Token, requestmeta.FromContext(ctx)); err != nil {
+		if errors.Is(err, service.ErrInvalidAccountToken) || errors.Is(err, security.ErrWeakPassword) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -1,10 +1,15 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"simple-ledger/internal/auth/dto"
 	"simple-ledger/internal/auth/service"
+	"simple-ledger/internal/common/cookie"
+	"simple-ledger/internal/common/csrf"
+	"simple-ledger/internal/common/requestmeta"
 	"simple-ledger/internal/common/security"
 
 	"github.com/gin-gonic/gin"
@@ -27,32 +32,27 @@ func (c *AuthController) Login(ctx *gin.Context) {
 		return
 	}
 
-	accessToken, refreshToken, err := c.service.Login(req.Email, req.Password)
+	result, err := c.service.Login(ctx.Request.Context(), req.Email, req.Password, requestmeta.FromContext(ctx))
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		if respondLoginError(ctx, err) {
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		return
+	}
+
+	// 二段階認証が必要な場合はトークンを発行せず、2段階目に使用するチャレンジトークンを返す
+	if result.MFARequired() {
+		ctx.JSON(http.StatusOK, dto.LoginResponse{
+			ExpiresIn:          security.GetMFAChallengeExpirationSeconds(),
+			MFARequired:        true,
+			ChallengeToken:     result.ChallengeToken,
+			EnrollmentRequired: result.EnrollmentRequired,
+		})
 		return
 	}
 
-	// HttpOnly Cookie にトークンを設定（XSS攻撃対策）
-	ctx.SetCookie(
-		"accessToken",
-		accessToken,
-		int(security.GetTokenExpirationSeconds()),
-		"/",
-		ctx.Request.Host,
-		false, // Secure: 開発環境では false（本番環境では true にすること）
-		true,  // HttpOnly: JavaScript からアクセス不可
-	)
-
-	ctx.SetCookie(
-		"refreshToken",
-		refreshToken,
-		int(security.GetRefreshTokenExpirationSeconds()),
-		"/",
-		ctx.Request.Host,
-		false, // Secure: 開発環境では false（本番環境では true にすること）
-		true,  // HttpOnly: JavaScript からアクセス不可
-	)
+	setLoginCookies(ctx, result.AccessToken, result.RefreshToken)
 
 	// クライアントに成功を通知（トークン値は含めない）
 	response := dto.LoginResponse{
@@ -62,11 +62,73 @@ func (c *AuthController) Login(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, response)
 }
 
+// LoginMFA はログインの2段階目（二段階認証のコード入力）エンドポイント
+// POST /api/auth/login/mfa
+func (c *AuthController) LoginMFA(ctx *gin.Context) {
+	var req dto.MFALoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := c.service.CompleteMFALogin(ctx.Request.Context(), req.ChallengeToken, req.Code, req.RecoveryCode, requestmeta.FromContext(ctx))
+	if err != nil {
+		if respondLoginError(ctx, err) {
+			return
+		}
+		if errors.Is(err, service.ErrMFANotEnrolled) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		return
+	}
+
+	setLoginCookies(ctx, result.AccessToken, result.RefreshToken)
+
+	ctx.JSON(http.StatusOK, dto.LoginResponse{
+		ExpiresIn:     security.GetTokenExpirationSeconds(),
+		RecoveryCodes: result.RecoveryCodes,
+	})
+}
+
+// BeginMFAEnrollment はログイン途中の二段階認証の登録開始エンドポイント（ロールで必須の場合）
+// POST /api/auth/login/mfa/enroll
+func (c *AuthController) BeginMFAEnrollment(ctx *gin.Context) {
+	var req dto.MFAChallengeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	enrollment, err := c.service.BeginMFAEnrollment(req.ChallengeToken)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidMFAChallenge) {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrMFAAlreadyEnabled) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start enrollment"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, enrollment)
+}
+
+// GetCSRFToken は CSRF トークンを返すエンドポイント（更新系リクエストの X-CSRF-Token ヘッダーに設定する）
+// GET /api/auth/csrf
+func (c *AuthController) GetCSRFToken(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, dto.CSRFTokenResponse{CSRFToken: csrf.Token(ctx)})
+}
+
 // RefreshToken はトークン更新エンドポイント
 // POST /api/auth/refresh
 func (c *AuthController) RefreshToken(ctx *gin.Context) {
 	// クッキーから refreshToken を取得
-	refreshToken, err := ctx.Cookie("refreshToken")
+	refreshToken, err := cookie.RefreshToken.Get(ctx)
 	if err != nil {
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "リフレッシュトークンが見つかりません"})
 		return
@@ -74,31 +136,16 @@ func (c *AuthController) RefreshToken(ctx *gin.Context) {
 
 	accessToken, newRefreshToken, err := c.service.RefreshAccessToken(refreshToken)
 	if err != nil {
+		// 再利用を検知した場合はセッションが失効しているため、クッキーも削除する
+		if errors.Is(err, service.ErrRefreshTokenReused) {
+			clearAuthCookies(ctx)
+		}
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
-	// HttpOnly Cookie にアクセストークンを設定
-	ctx.SetCookie(
-		"accessToken",
-		accessToken,
-		int(security.GetTokenExpirationSeconds()),
-		"/",
-		ctx.Request.Host,
-		false, // Secure: 開発環境では false（本番環境では true にすること）
-		true,  // HttpOnly: JavaScript からアクセス不可
-	)
-
-	// HttpOnly Cookie に新しいリフレッシュトークンを設定（スライディングウィンドウ）
-	ctx.SetCookie(
-		"refreshToken",
-		newRefreshToken,
-		int(security.GetRefreshTokenExpirationSeconds()),
-		"/",
-		ctx.Request.Host,
-		false, // Secure: 開発環境では false（本番環境では true にすること）
-		true,  // HttpOnly: JavaScript からアクセス不可
-	)
+	// 新しいリフレッシュトークンも設定する（スライディングウィンドウ）
+	setAuthCookies(ctx, accessToken, newRefreshToken)
 
 	response := gin.H{
 		"expiresIn": security.GetTokenExpirationSeconds(),
@@ -109,27 +156,96 @@ func (c *AuthController) RefreshToken(ctx *gin.Context) {
 
 // Logout はログアウトエンドポイント
 // POST /api/auth/logout
+// リフレッシュトークンが属するセッションを失効させ、クッキーを削除する
 func (c *AuthController) Logout(ctx *gin.Context) {
-	// クッキーを削除（MaxAge を負の値に設定）
-	ctx.SetCookie(
-		"accessToken",
-		"",
-		-1,
-		"/",
-		ctx.Request.Host,
-		false,
-		true,
-	)
-
-	ctx.SetCookie(
-		"refreshToken",
-		"",
-		-1,
-		"/",
-		ctx.Request.Host,
-		false,
-		true,
-	)
+	if refreshToken, err := cookie.RefreshToken.Get(ctx); err == nil {
+		if err := c.service.Logout(refreshToken); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+			return
+		}
+	}
+
+	clearAuthCookies(ctx)
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "ログアウトしました"})
 }
+
+// LogoutAll はすべての端末からログアウトするエンドポイント
+// POST /api/auth/logout-all
+func (c *AuthController) LogoutAll(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	revoked, err := c.service.LogoutAll(userID.(uint))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	clearAuthCookies(ctx)
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "すべての端末からログアウトしました", "revokedSessions": revoked})
+}
+
+// UnlockUser はログイン失敗によるアカウントロックを解除するエンドポイント（管理者用）
+// POST /api/users/:id/unlock
+func (c *AuthController) UnlockUser(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := c.service.UnlockUser(ctx.Request.Context(), uint(id), requestmeta.FromContext(ctx)); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock user"})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// respondLoginError: 認証の失敗をレスポンスに変換（該当しないエラーの場合は false）
+func respondLoginError(ctx *gin.Context, err error) bool {
+	var tooMany *service.TooManyAttemptsError
+	switch {
+	case errors.As(err, &tooMany):
+		ctx.Header("Retry-After", strconv.Itoa(tooMany.RetryAfterSeconds()))
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error(), "retryAfter": tooMany.RetryAfterSeconds()})
+	case errors.Is(err, service.ErrInvalidCredentials),
+		errors.Is(err, service.ErrUserInactive),
+		errors.Is(err, service.ErrInvalidMFACode),
+		errors.Is(err, service.ErrInvalidMFAChallenge):
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrPasswordLoginDisabled):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
+
+// setAuthCookies: HttpOnly Cookie にトークンを設定（XSS攻撃対策）
+func setAuthCookies(ctx *gin.Context, accessToken string, refreshToken string) {
+	cookie.AccessToken.Set(ctx, accessToken, security.GetTokenExpirationSeconds())
+	cookie.RefreshToken.Set(ctx, refreshToken, security.GetRefreshTokenExpirationSeconds())
+}
+
+// setLoginCookies: ログイン時のクッキーを設定
+// ログイン前に発行した CSRF トークンは使えないよう作り直す（トークンの更新時は実行中のリクエストのため維持する）
+func setLoginCookies(ctx *gin.Context, accessToken string, refreshToken string) {
+	setAuthCookies(ctx, accessToken, refreshToken)
+	csrf.Rotate(ctx)
+}
+
+// clearAuthCookies: トークンのクッキーを削除（MaxAge を負の値に設定）
+func clearAuthCookies(ctx *gin.Context) {
+	cookie.AccessToken.Clear(ctx)
+	cookie.RefreshToken.Clear(ctx)
+}
@@ -2,19 +2,28 @@ package controller
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	auditRepository "simple-ledger/internal/audit/repository"
+	auditService "simple-ledger/internal/audit/service"
 	"simple-ledger/internal/auth/dto"
+	"simple-ledger/internal/auth/repository"
 	"simple-ledger/internal/auth/service"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/ratelimit"
+	"simple-ledger/internal/common/requestmeta"
 	"simple-ledger/internal/common/security"
 	"simple-ledger/internal/models"
-	"simple-ledger/internal/user/repository"
+	userRepository "simple-ledger/internal/user/repository"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
@@ -22,14 +31,35 @@ import (
 func setupTestAuthController() (*AuthController, *gorm.DB) {
 	gin.SetMode(gin.TestMode)
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	_ = db.AutoMigrate(&models.User{})
-	userRepo := repository.NewUserRepository(db)
-	authService := service.NewAuthService(userRepo)
+	_ = db.AutoMigrate(
+		&models.User{}, &models.AuthSession{}, &models.RefreshToken{}, &models.LoginHistory{}, &models.AuditLog{}, &models.AuditChainHead{},
+		&models.UserTOTP{}, &models.MFARecoveryCode{}, &models.RoleMFAPolicy{},
+	)
+	userRepo := userRepository.NewUserRepository(db)
+	audit := auditService.NewAuditService(auditRepository.NewAuditLogRepository(db))
+	unitOfWork := uow.New(db)
+	authService := service.NewAuthService(
+		userRepo,
+		repository.NewSessionRepository(db),
+		repository.NewLoginHistoryRepository(db),
+		service.NewLoginGuard(ratelimit.NewMemoryStore(), service.DefaultLoginLimitConfig()),
+		service.NewMFAService(repository.NewMFARepository(db), userRepo, audit, unitOfWork, service.MFAConfig{Issuer: "Simple Ledger"}),
+		audit,
+		unitOfWork,
+	)
 	ctrl := NewAuthController(authService)
 	security.InitJWT("test-secret", 1.0, 1.0)
 	return ctrl, db
 }
 
+// loginTokens: Login の結果をアクセストークン・リフレッシュトークンに展開
+func loginTokens(result *service.LoginResult, err error) (string, string, error) {
+	if err != nil {
+		return "", "", err
+	}
+	return result.AccessToken, result.RefreshToken, nil
+}
+
 func TestLogin_Success(t *testing.T) {
 	ctrl, db := setupTestAuthController()
 
@@ -154,9 +184,7 @@ func TestRefreshToken_Success(t *testing.T) {
 	db.Create(user)
 
 	// ログインしてリフレッシュトークンを取得
-	userRepo := repository.NewUserRepository(db)
-	authService := service.NewAuthService(userRepo)
-	_, refreshToken, _ := authService.Login("test@example.com", "password123")
+	_, refreshToken, _ := loginTokens(ctrl.service.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{}))
 
 	httpReq := httptest.NewRequest("POST", "/api/auth/refresh", nil)
 	httpReq.AddCookie(&http.Cookie{
@@ -208,3 +236,179 @@ func TestRefreshToken_InvalidToken(t *testing.T) {
 
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 }
+
+func TestLogout_RevokesRefreshToken(t *testing.T) {
+	ctrl, db := setupTestAuthController()
+
+	hashedPassword, _ := security.HashPassword("password123")
+	db.Create(&models.User{
+		Email:    "test@example.com",
+		Name:     "Test User",
+		Password: hashedPassword,
+		Role:     "user",
+		IsActive: true,
+	})
+
+	_, refreshToken, _ := loginTokens(ctrl.service.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{}))
+
+	// ログアウト
+	httpReq := httptest.NewRequest("POST", "/api/auth/logout", nil)
+	httpReq.AddCookie(&http.Cookie{Name: "refreshToken", Value: refreshToken})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httpReq
+
+	ctrl.Logout(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	for _, cookie := range w.Result().Cookies() {
+		assert.Empty(t, cookie.Value)
+	}
+
+	// ログアウト後のリフレッシュトークンは使えない
+	httpReq = httptest.NewRequest("POST", "/api/auth/refresh", nil)
+	httpReq.AddCookie(&http.Cookie{Name: "refreshToken", Value: refreshToken})
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httpReq
+
+	ctrl.RefreshToken(c)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestLogoutAll_Success(t *testing.T) {
+	ctrl, db := setupTestAuthController()
+
+	hashedPassword, _ := security.HashPassword("password123")
+	user := &models.User{
+		Email:    "test@example.com",
+		Name:     "Test User",
+		Password: hashedPassword,
+		Role:     "user",
+		IsActive: true,
+	}
+	db.Create(user)
+
+	_, _, _ = loginTokens(ctrl.service.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{}))
+	_, _, _ = loginTokens(ctrl.service.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{}))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/api/auth/logout-all", nil)
+	c.Set("userID", user.ID)
+
+	ctrl.LogoutAll(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, float64(2), response["revokedSessions"])
+}
+
+func TestLogin_TooManyAttempts(t *testing.T) {
+	ctrl, db := setupTestAuthController()
+
+	hashedPassword, _ := security.HashPassword("password123")
+	db.Create(&models.User{
+		Email:    "test@example.com",
+		Name:     "Test User",
+		Password: hashedPassword,
+		Role:     "user",
+		IsActive: true,
+	})
+
+	// 許容回数を超えて失敗させる
+	for i := 0; i < 4; i++ {
+		_, _, _ = loginTokens(ctrl.service.Login(context.Background(), "test@example.com", "wrongpassword", requestmeta.Meta{}))
+	}
+
+	body, _ := json.Marshal(dto.LoginRequest{Email: "test@example.com", Password: "password123"})
+	httpReq := httptest.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(body))
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httpReq
+
+	ctrl.Login(c)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+}
+
+func TestLogin_SpoofedForwardedForDoesNotBypassIPLimit(t *testing.T) {
+	ctrl, _ := setupTestAuthController()
+	t.Setenv("TRUSTED_PROXIES", "")
+
+	router := gin.New()
+	require.NoError(t, router.SetTrustedProxies(requestmeta.TrustedProxiesFromEnv()))
+	router.POST("/api/auth/login", requestmeta.Middleware(), ctrl.Login)
+
+	login := func(i int) *httptest.ResponseRecorder {
+		// アカウント単位の制限にかからないよう毎回別のメールアドレスを使い、X-Forwarded-For も毎回変える
+		body, _ := json.Marshal(dto.LoginRequest{Email: fmt.Sprintf("user%d@example.com", i), Password: "wrongpassword"})
+		httpReq := httptest.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(body))
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i))
+		httpReq.RemoteAddr = "192.0.2.10:12345"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httpReq)
+		return w
+	}
+
+	freeFailures := int(service.DefaultLoginLimitConfig().IPPolicy.FreeFailures)
+	for i := 0; i <= freeFailures; i++ {
+		assert.Equal(t, http.StatusUnauthorized, login(i).Code)
+	}
+
+	// 偽装したヘッダーは無視され、接続元のアドレスで制限される
+	w := login(freeFailures + 1)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+}
+
+func TestLogin_MFARequiredReturnsChallengeWithoutCookies(t *testing.T) {
+	ctrl, db := setupTestAuthController()
+
+	hashedPassword, _ := security.HashPassword("password123")
+	db.Create(&models.User{
+		Email:    "test@example.com",
+		Name:     "Test User",
+		Password: hashedPassword,
+		Role:     "user",
+		IsActive: true,
+	})
+	db.Create(&models.RoleMFAPolicy{Role: "user", Required: true})
+
+	body, _ := json.Marshal(dto.LoginRequest{Email: "test@example.com", Password: "password123"})
+	httpReq := httptest.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(body))
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httpReq
+
+	ctrl.Login(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response dto.LoginResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.True(t, response.MFARequired)
+	assert.True(t, response.EnrollmentRequired)
+	assert.NotEmpty(t, response.ChallengeToken)
+	assert.Equal(t, security.GetMFAChallengeExpirationSeconds(), response.ExpiresIn)
+
+	// 2段階目が完了するまでトークンのクッキーは設定しない
+	assert.Empty(t, w.Result().Cookies())
+}
+
+func TestSafeRedirectPath(t *testing.T) {
+	assert.Equal(t, "/transactions?page=2", safeRedirectPath("/transactions?page=2"))
+	assert.Equal(t, "/", safeRedirectPath(""))
+	assert.Equal(t, "/", safeRedirectPath("https://evil.example.com"))
+	assert.Equal(t, "/", safeRedirectPath("//evil.example.com"))
+	assert.Equal(t, "/", safeRedirectPath("/\\evil.example.com"))
+}
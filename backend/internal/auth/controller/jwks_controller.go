@@ -0,0 +1,25 @@
+package controller
+
+import (
+	"net/http"
+
+	"simple-ledger/internal/common/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jwksMaxAge: 検証する側が JWKS をキャッシュしてよい秒数
+const jwksMaxAge = "300"
+
+type JWKSController struct{}
+
+func NewJWKSController() *JWKSController {
+	return &JWKSController{}
+}
+
+// GetJWKS はアクセストークンなどの検証に使える公開鍵の一覧を返すエンドポイント
+// GET /.well-known/jwks.json
+func (c *JWKSController) GetJWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age="+jwksMaxAge)
+	ctx.JSON(http.StatusOK, security.PublicJWKS())
+}
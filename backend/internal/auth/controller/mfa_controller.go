@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"simple-ledger/internal/auth/dto"
+	"simple-ledger/internal/auth/service"
+	"simple-ledger/internal/common/requestmeta"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAController struct {
+	service *service.MFAService
+}
+
+func NewMFAController(service *service.MFAService) *MFAController {
+	return &MFAController{service: service}
+}
+
+// GetStatus は二段階認証の状態取得エンドポイント
+// GET /api/auth/mfa
+func (c *MFAController) GetStatus(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	status, err := c.service.Status(userID.(uint), ctx.GetString("role"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch two-factor authentication status"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, status)
+}
+
+// Enroll は二段階認証の登録開始エンドポイント（シークレットと otpauth URI を返す）
+// POST /api/auth/mfa/enroll
+func (c *MFAController) Enroll(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	enrollment, err := c.service.BeginEnrollment(userID.(uint), ctx.GetString("email"))
+	if err != nil {
+		if errors.Is(err, service.ErrMFAAlreadyEnabled) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start enrollment"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, enrollment)
+}
+
+// Enable は認証アプリのコードで登録を確認して二段階認証を有効化するエンドポイント
+// POST /api/auth/mfa/enable
+func (c *MFAController) Enable(ctx *gin.Context) {
+	var req dto.MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	codes, err := c.service.ConfirmEnrollment(userID.(uint), req.Code, requestmeta.FromContext(ctx))
+	if err != nil {
+		respondMFAError(ctx, err, "Failed to enable two-factor authentication")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// Disable は二段階認証の無効化エンドポイント
+// POST /api/auth/mfa/disable
+func (c *MFAController) Disable(ctx *gin.Context) {
+	var req dto.DisableMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	err := c.service.Disable(userID.(uint), ctx.GetString("role"), req.Code, req.RecoveryCode, requestmeta.FromContext(ctx))
+	if err != nil {
+		respondMFAError(ctx, err, "Failed to disable two-factor authentication")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes はリカバリーコードの再発行エンドポイント
+// POST /api/auth/mfa/recovery-codes
+func (c *MFAController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	var req dto.MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	codes, err := c.service.RegenerateRecoveryCodes(userID.(uint), req.Code)
+	if err != nil {
+		respondMFAError(ctx, err, "Failed to regenerate recovery codes")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// ResetUserMFA はユーザーの二段階認証を解除するエンドポイント（管理者用）
+// DELETE /api/users/:id/mfa
+func (c *MFAController) ResetUserMFA(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := c.service.Reset(uint(id), requestmeta.FromContext(ctx)); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset two-factor authentication"})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// GetPolicies はロールごとの二段階認証の必須設定の一覧エンドポイント（管理者用）
+// GET /api/mfa/policies
+func (c *MFAController) GetPolicies(ctx *gin.Context) {
+	policies, err := c.service.ListPolicies()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch policies"})
+		return
+	}
+
+	response := make([]dto.MFAPolicyResponse, 0, len(policies))
+	for i := range policies {
+		response = append(response, dto.ToMFAPolicyResponse(&policies[i]))
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// UpdatePolicy はロールの二段階認証の必須設定の変更エンドポイント（管理者用）
+// PUT /api/mfa/policies/:role
+func (c *MFAController) UpdatePolicy(ctx *gin.Context) {
+	var req dto.UpdateMFAPolicyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := c.service.UpdatePolicy(ctx.Param("role"), *req.Required, requestmeta.FromContext(ctx))
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownRole) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update policy"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.ToMFAPolicyResponse(policy))
+}
+
+// respondMFAError: 二段階認証の操作のエラーをレスポンスに変換
+func respondMFAError(ctx *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidMFACode),
+		errors.Is(err, service.ErrMFANotEnrolled),
+		errors.Is(err, service.ErrMFANotEnabled):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrMFAAlreadyEnabled):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrMFARequiredByPolicy):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"simple-ledger/internal/auth/dto"
+	"simple-ledger/internal/auth/service"
+	"simple-ledger/internal/common/cookie"
+	"simple-ledger/internal/common/requestmeta"
+	"simple-ledger/internal/common/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCController struct {
+	service    *service.OIDCService
+	appBaseURL string // ログイン後のリダイレクト先（フロントエンド）
+}
+
+func NewOIDCController(service *service.OIDCService, appBaseURL string) *OIDCController {
+	return &OIDCController{service: service, appBaseURL: strings.TrimRight(appBaseURL, "/")}
+}
+
+// GetConfig はシングルサインオンが利用できるかを返すエンドポイント
+// GET /api/auth/oidc
+func (c *OIDCController) GetConfig(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, dto.OIDCConfigResponse{Enabled: c.service.Enabled()})
+}
+
+// Login はシングルサインオンの開始エンドポイント（IdP にリダイレクトする）
+// GET /api/auth/oidc/login?redirect=/transactions
+func (c *OIDCController) Login(ctx *gin.Context) {
+	authURL, stateToken, err := c.service.BeginLogin(ctx.Request.Context(), safeRedirectPath(ctx.Query("redirect")))
+	if err != nil {
+		if errors.Is(err, service.ErrOIDCNotConfigured) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("failed to start single sign-on: %v", err)
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": "Failed to contact the identity provider"})
+		return
+	}
+
+	// シングルサインオンの状態トークンはコールバックでのみ送信される
+	cookie.OIDCState.Set(ctx, stateToken, security.GetOIDCStateExpirationSeconds())
+
+	ctx.Redirect(http.StatusFound, authURL)
+}
+
+// Callback は IdP からのリダイレクトを受け取るエンドポイント
+// GET /api/auth/oidc/callback?code=...&state=...
+// 結果はフロントエンドへのリダイレクトで返す（失敗時は /login?error=...）
+func (c *OIDCController) Callback(ctx *gin.Context) {
+	stateToken, _ := cookie.OIDCState.Get(ctx)
+	cookie.OIDCState.Clear(ctx)
+
+	// 利用者が IdP で同意しなかった場合など
+	if ctx.Query("error") != "" {
+		c.redirectLoginError(ctx, "sso_denied")
+		return
+	}
+
+	result, redirectPath, err := c.service.CompleteLogin(ctx.Request.Context(), stateToken, ctx.Query("state"), ctx.Query("code"), requestmeta.FromContext(ctx))
+	if err != nil {
+		c.redirectLoginError(ctx, oidcErrorCode(err))
+		return
+	}
+
+	// 二段階認証が必要な場合は、チャレンジトークンをフラグメントで渡す（サーバーやログに送信されない）
+	if result.MFARequired() {
+		fragment := url.Values{
+			"challengeToken":     {result.ChallengeToken},
+			"enrollmentRequired": {strconv.FormatBool(result.EnrollmentRequired)},
+			"redirect":           {redirectPath},
+		}
+		ctx.Redirect(http.StatusFound, c.appBaseURL+"/login/mfa#"+fragment.Encode())
+		return
+	}
+
+	setLoginCookies(ctx, result.AccessToken, result.RefreshToken)
+	ctx.Redirect(http.StatusFound, c.appBaseURL+redirectPath)
+}
+
+// UpdatePasswordLogin はユーザーのパスワードによるログインを許可・禁止するエンドポイント（管理者用）
+// PUT /api/users/:id/password-login
+func (c *OIDCController) UpdatePasswordLogin(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req dto.UpdatePasswordLoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.service.SetPasswordLogin(uint(id), *req.Enabled, requestmeta.FromContext(ctx)); err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrNoLinkedIdentity):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password login"})
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// redirectLoginError: フロントエンドのログイン画面にエラーコードを付けてリダイレクト
+func (c *OIDCController) redirectLoginError(ctx *gin.Context, code string) {
+	ctx.Redirect(http.StatusFound, c.appBaseURL+"/login?"+url.Values{"error": {code}}.Encode())
+}
+
+// oidcErrorCode: シングルサインオンの失敗をフロントエンドに渡すエラーコードに変換
+func oidcErrorCode(err error) string {
+	switch {
+	case errors.Is(err, service.ErrInvalidOIDCState):
+		return "sso_expired"
+	case errors.Is(err, service.ErrOIDCEmailNotVerified):
+		return "sso_email_not_verified"
+	case errors.Is(err, service.ErrOIDCNoRole):
+		return "sso_no_role"
+	case errors.Is(err, service.ErrUserInactive):
+		return "sso_inactive"
+	default:
+		log.Printf("single sign-on failed: %v", err)
+		return "sso_failed"
+	}
+}
+
+// safeRedirectPath: ログイン後のリダイレクト先をフロントエンド内のパスに制限する（オープンリダイレクト対策）
+func safeRedirectPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.ContainsAny(path, "\\\r\n") {
+		return "/"
+	}
+	return path
+}
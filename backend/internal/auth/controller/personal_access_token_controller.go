@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"simple-ledger/internal/auth/dto"
+	"simple-ledger/internal/auth/service"
+	"simple-ledger/internal/common/requestmeta"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PersonalAccessTokenController struct {
+	service *service.PersonalAccessTokenService
+}
+
+func NewPersonalAccessTokenController(service *service.PersonalAccessTokenService) *PersonalAccessTokenController {
+	return &PersonalAccessTokenController{service: service}
+}
+
+// GetTokens は個人用アクセストークン一覧エンドポイント
+// GET /api/auth/tokens
+func (c *PersonalAccessTokenController) GetTokens(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	tokens, err := c.service.List(userID.(uint))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch personal access tokens"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+// CreateToken は個人用アクセストークンの発行エンドポイント
+// POST /api/auth/tokens
+func (c *PersonalAccessTokenController) CreateToken(ctx *gin.Context) {
+	var req dto.CreatePersonalAccessTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	token, err := c.service.Create(userID.(uint), &req, requestmeta.FromContext(ctx))
+	if err != nil {
+		if errors.Is(err, service.ErrAdminScopeNotAllowed) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create personal access token"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, token)
+}
+
+// RevokeToken は個人用アクセストークンの失効エンドポイント
+// DELETE /api/auth/tokens/:id
+func (c *PersonalAccessTokenController) RevokeToken(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	if err := c.service.Revoke(userID.(uint), uint(id), requestmeta.FromContext(ctx)); err != nil {
+		if errors.Is(err, service.ErrPersonalAccessTokenNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke personal access token"})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"simple-ledger/internal/auth/dto"
+	"simple-ledger/internal/auth/service"
+	"simple-ledger/internal/common/cookie"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionController struct {
+	service *service.SessionService
+}
+
+func NewSessionController(service *service.SessionService) *SessionController {
+	return &SessionController{service: service}
+}
+
+// GetSessions はログイン中のセッション一覧エンドポイント
+// GET /api/auth/sessions
+func (c *SessionController) GetSessions(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	// リフレッシュトークンから現在のセッションを判定する（無い場合は判定しない）
+	refreshToken, _ := cookie.RefreshToken.Get(ctx)
+
+	sessions, err := c.service.ListSessions(userID.(uint), refreshToken)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession はセッションを個別に失効させるエンドポイント
+// DELETE /api/auth/sessions/:id
+func (c *SessionController) RevokeSession(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	if err := c.service.RevokeSession(userID.(uint), uint(id)); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// GetLoginHistory は自分のログイン履歴エンドポイント
+// GET /api/auth/login-history?page=1&pageSize=20
+func (c *SessionController) GetLoginHistory(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	var req dto.GetLoginHistoryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters: " + err.Error()})
+		return
+	}
+
+	result, err := c.service.ListLoginHistory(userID.(uint), &req)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch login history"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// SearchLoginHistory は全ユーザーのログイン履歴検索エンドポイント（管理者用）
+// GET /api/login-history?userId=1&email=user@example.com&success=false&dateFrom=2024-01-01&dateTo=2024-12-31&page=1&pageSize=50
+func (c *SessionController) SearchLoginHistory(ctx *gin.Context) {
+	var req dto.SearchLoginHistoryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters: " + err.Error()})
+		return
+	}
+
+	result, err := c.service.SearchLoginHistory(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
@@ -1,5 +1,11 @@
 package dto
 
+import (
+	"time"
+
+	"simple-ledger/internal/models"
+)
+
 // LoginRequest はログインリクエスト
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
@@ -8,6 +14,248 @@ type LoginRequest struct {
 
 // LoginResponse はログインレスポンス
 // トークンはHttpOnly Cookieに設定されるため、ここには含めない
+// 二段階認証が必要な場合は Cookie を設定せず、2段階目に使用するチャレンジトークンを返す
 type LoginResponse struct {
-	ExpiresIn int `json:"expiresIn"` // 秒単位
+	ExpiresIn int `json:"expiresIn"` // 秒単位（二段階認証が必要な場合はチャレンジトークンの有効期限）
+
+	MFARequired        bool   `json:"mfaRequired,omitempty"`
+	ChallengeToken     string `json:"challengeToken,omitempty"`
+	EnrollmentRequired bool   `json:"enrollmentRequired,omitempty"` // ロールで必須のため、先に登録が必要
+
+	// RecoveryCodes: ログイン時に二段階認証の登録を完了した場合のリカバリーコード（この応答でのみ返す）
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
+
+// CSRFTokenResponse: 更新系リクエストの X-CSRF-Token ヘッダーに設定するトークン
+type CSRFTokenResponse struct {
+	CSRFToken string `json:"csrfToken"`
+}
+
+// MFALoginRequest: ログインの2段階目（code と recoveryCode のどちらかを指定）
+type MFALoginRequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recoveryCode"`
+}
+
+// MFAChallengeRequest: チャレンジトークンによる二段階認証の登録開始
+type MFAChallengeRequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+}
+
+// MFACodeRequest: 認証アプリのコード
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// DisableMFARequest: 二段階認証の無効化（code と recoveryCode のどちらかを指定）
+type DisableMFARequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+// UpdateMFAPolicyRequest: ロールの二段階認証の必須設定の変更
+type UpdateMFAPolicyRequest struct {
+	Required *bool `json:"required" binding:"required"`
+}
+
+// MFAStatusResponse: 二段階認証の状態
+type MFAStatusResponse struct {
+	Enabled                bool       `json:"enabled"`
+	EnrollmentPending      bool       `json:"enrollmentPending"` // 登録を開始したが有効化していない
+	Required               bool       `json:"required"`          // ロールで必須かどうか
+	EnabledAt              *time.Time `json:"enabledAt"`
+	RemainingRecoveryCodes int        `json:"remainingRecoveryCodes"`
+}
+
+// MFAEnrollmentResponse: 二段階認証の登録情報（認証アプリに登録する）
+type MFAEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauthUri"`
+}
+
+// RecoveryCodesResponse: リカバリーコード（この応答でのみ返す）
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// MFAPolicyResponse: ロールの二段階認証の必須設定
+type MFAPolicyResponse struct {
+	Role      string    `json:"role"`
+	Required  bool      `json:"required"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ForgotPasswordRequest: パスワード再設定メールの送信依頼
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest: メールで受け取ったトークンによるパスワードの再設定
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required"`
+}
+
+// ChangePasswordRequest: ログイン中のユーザーによるパスワードの変更
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required"`
+}
+
+// VerifyEmailRequest: メールで受け取ったトークンによるメールアドレスの確認
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// CreatePersonalAccessTokenRequest: 個人用アクセストークンの発行
+type CreatePersonalAccessTokenRequest struct {
+	// Name: 用途を識別するための名前
+	Name string `json:"name" binding:"required,max=100"`
+
+	// Scopes: スコープ（read, transactions:write, admin）
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,oneof=read transactions:write admin"`
+
+	// ExpiresInDays: 有効期間（日数）
+	ExpiresInDays int `json:"expiresInDays" binding:"required,min=1,max=365"`
+}
+
+// PersonalAccessTokenResponse: 個人用アクセストークン（トークン自体は含まない）
+type PersonalAccessTokenResponse struct {
+	ID          uint       `json:"id"`
+	Name        string     `json:"name"`
+	TokenPrefix string     `json:"tokenPrefix"`
+	Scopes      []string   `json:"scopes"`
+	ExpiresAt   time.Time  `json:"expiresAt"`
+	LastUsedAt  *time.Time `json:"lastUsedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// CreatePersonalAccessTokenResponse: 発行した個人用アクセストークン
+// Token は発行時のこのレスポンスでのみ返す
+type CreatePersonalAccessTokenResponse struct {
+	PersonalAccessTokenResponse
+	Token string `json:"token"`
+}
+
+// OIDCConfigResponse: シングルサインオンの設定（フロントエンドでログインボタンを表示するかの判定に使う）
+type OIDCConfigResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+// UpdatePasswordLoginRequest: ユーザーのパスワードによるログインの許可・禁止（管理者用）
+type UpdatePasswordLoginRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// SessionResponse: ログイン中のセッション
+type SessionResponse struct {
+	ID         uint      `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IPAddress  string    `json:"ipAddress"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+	Current    bool      `json:"current"` // リクエスト元のセッションかどうか
+}
+
+// GetLoginHistoryRequest: 自分のログイン履歴の取得条件
+type GetLoginHistoryRequest struct {
+	// Page: ページ番号
+	Page int `form:"page" binding:"required,min=1"`
+
+	// PageSize: 1ページあたりの件数
+	PageSize int `form:"pageSize" binding:"required,min=1,max=100"`
+}
+
+// SearchLoginHistoryRequest: ログイン履歴の検索条件（管理者用）
+type SearchLoginHistoryRequest struct {
+	// UserID: ユーザーID（省略時は絞り込まない）
+	UserID *uint `form:"userId"`
+
+	// Email: ログインに使用されたメールアドレス（完全一致）
+	Email string `form:"email"`
+
+	// Success: 成功・失敗（省略時は両方）
+	Success *bool `form:"success"`
+
+	// DateFrom: 開始日（YYYY-MM-DD、この日を含む）
+	DateFrom string `form:"dateFrom"`
+
+	// DateTo: 終了日（YYYY-MM-DD、この日を含む）
+	DateTo string `form:"dateTo"`
+
+	// Page: ページ番号
+	Page int `form:"page" binding:"required,min=1"`
+
+	// PageSize: 1ページあたりの件数
+	PageSize int `form:"pageSize" binding:"required,min=1,max=100"`
+}
+
+// LoginHistoryResponse: ログイン履歴レスポンス
+type LoginHistoryResponse struct {
+	ID            uint      `json:"id"`
+	UserID        *uint     `json:"userId"`
+	Email         string    `json:"email"`
+	Success       bool      `json:"success"`
+	FailureReason string    `json:"failureReason,omitempty"`
+	IPAddress     string    `json:"ipAddress"`
+	UserAgent     string    `json:"userAgent"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// GetLoginHistoryResponse: ログイン履歴一覧レスポンス
+type GetLoginHistoryResponse struct {
+	LoginHistory []LoginHistoryResponse `json:"loginHistory"`
+	Total        int                    `json:"total"`
+	Page         int                    `json:"page"`
+	PageSize     int                    `json:"pageSize"`
+	HasNextPage  bool                   `json:"hasNextPage"`
+}
+
+// ToSessionResponse: モデルをレスポンスに変換
+func ToSessionResponse(session *models.AuthSession, current bool) SessionResponse {
+	return SessionResponse{
+		ID:         session.ID,
+		UserAgent:  session.UserAgent,
+		IPAddress:  session.IPAddress,
+		CreatedAt:  session.CreatedAt,
+		LastUsedAt: session.LastUsedAt,
+		Current:    current,
+	}
+}
+
+// ToPersonalAccessTokenResponse: モデルをレスポンスに変換
+func ToPersonalAccessTokenResponse(token *models.PersonalAccessToken) PersonalAccessTokenResponse {
+	return PersonalAccessTokenResponse{
+		ID:          token.ID,
+		Name:        token.Name,
+		TokenPrefix: token.TokenPrefix,
+		Scopes:      token.ScopeList(),
+		ExpiresAt:   token.ExpiresAt,
+		LastUsedAt:  token.LastUsedAt,
+		CreatedAt:   token.CreatedAt,
+	}
+}
+
+// ToMFAPolicyResponse: モデルをレスポンスに変換
+func ToMFAPolicyResponse(policy *models.RoleMFAPolicy) MFAPolicyResponse {
+	return MFAPolicyResponse{
+		Role:      policy.Role,
+		Required:  policy.Required,
+		UpdatedAt: policy.UpdatedAt,
+	}
+}
+
+// ToLoginHistoryResponse: モデルをレスポンスに変換
+func ToLoginHistoryResponse(history *models.LoginHistory) LoginHistoryResponse {
+	return LoginHistoryResponse{
+		ID:            history.ID,
+		UserID:        history.UserID,
+		Email:         history.Email,
+		Success:       history.Success,
+		FailureReason: history.FailureReason,
+		IPAddress:     history.IPAddress,
+		UserAgent:     history.UserAgent,
+		CreatedAt:     history.CreatedAt,
+	}
 }
@@ -2,38 +2,189 @@ package middleware
 
 import (
 	"net/http"
+	"slices"
+	"strings"
 
+	"simple-ledger/internal/common/cookie"
 	"simple-ledger/internal/common/security"
+	"simple-ledger/internal/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware は JWT トークンを検証するミドルウェア
-// HttpOnly Cookie からトークンを取得して検証
-func AuthMiddleware() gin.HandlerFunc {
+// 認証方法（コンテキストの "authMethod" に設定）
+const (
+	AuthMethodSession = "session" // JWT アクセストークン（Cookie または Bearer ヘッダー）
+	AuthMethodToken   = "token"   // 個人用アクセストークン
+)
+
+// PersonalAccessTokenVerifier: 個人用アクセストークンを検証し、トークンの所有者とスコープを返す
+type PersonalAccessTokenVerifier interface {
+	VerifyPersonalAccessToken(token string) (*models.User, []string, error)
+}
+
+// tokenVerifier: 個人用アクセストークンの検証（未設定の場合は個人用アクセストークンを受け付けない）
+var tokenVerifier PersonalAccessTokenVerifier
+
+// InitPersonalAccessTokens は個人用アクセストークンの検証方法を設定
+func InitPersonalAccessTokens(verifier PersonalAccessTokenVerifier) {
+	tokenVerifier = verifier
+}
+
+// AuthMiddleware は JWT トークンまたは個人用アクセストークンを検証するミドルウェア
+// Authorization: Bearer ヘッダーがあればそれを、無ければ HttpOnly Cookie からトークンを取得して検証
+// writeScopes は個人用アクセストークンで更新系メソッド（POST/PUT/PATCH/DELETE）を許可するスコープ
+// 参照系メソッドは read スコープ、admin スコープはすべてのメソッドで許可する
+func AuthMiddleware(writeScopes ...string) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		// クッキーからアクセストークンを取得
-		token, err := ctx.Cookie("accessToken")
-		if err != nil {
-			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "missing access token cookie"})
-			ctx.Abort()
+		token, ok := bearerToken(ctx)
+		if !ok {
+			return
+		}
+		if token == "" {
+			// クッキーからアクセストークンを取得
+			value, err := cookie.AccessToken.Get(ctx)
+			if err != nil {
+				ctx.JSON(http.StatusUnauthorized, gin.H{"error": "missing access token cookie"})
+				ctx.Abort()
+				return
+			}
+			token = value
+		}
+
+		if security.IsPersonalAccessToken(token) {
+			authenticateToken(ctx, token, writeScopes)
 			return
 		}
 
 		// トークンを検証
-		claims, err := security.VerifyToken(token)
+		claims, err := security.VerifyAccessToken(token)
 		if err != nil {
 			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			ctx.Abort()
 			return
 		}
 
+		// 発行後にロール・パスワード・有効/無効が変更された（またはユーザーが削除された）トークンは拒否する
+		if err := security.CheckTokenVersion(claims); err != nil {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			ctx.Abort()
+			return
+		}
+
 		// コンテキストにクレーム情報を保存
 		ctx.Set("userID", claims.UserID)
 		ctx.Set("email", claims.Email)
 		ctx.Set("role", claims.Role)
 		ctx.Set("isActive", claims.IsActive)
+		ctx.Set("authMethod", AuthMethodSession)
+
+		ctx.Next()
+	}
+}
+
+// RequireRole は指定したロールのいずれかを持つユーザーのみ通過させるミドルウェア
+// AuthMiddleware の後に使用する
+// 個人用アクセストークンの場合は admin スコープも必要とする（管理者用エンドポイントの保護に使うため）
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.GetString("authMethod") == AuthMethodToken && !slices.Contains(ctx.GetStringSlice("tokenScopes"), models.TokenScopeAdmin) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "insufficient token scope"})
+			ctx.Abort()
+			return
+		}
+
+		role := ctx.GetString("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				ctx.Next()
+				return
+			}
+		}
+
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		ctx.Abort()
+	}
+}
 
+// RequireSession はログインによるセッション（JWT）でのみ通過させるミドルウェア
+// パスワードや二段階認証、トークン自体の管理など、個人用アクセストークンで行わせない操作に使用する
+// AuthMiddleware の後に使用する
+func RequireSession() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.GetString("authMethod") == AuthMethodToken {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "personal access tokens cannot be used for this endpoint"})
+			ctx.Abort()
+			return
+		}
 		ctx.Next()
 	}
 }
+
+// bearerToken: Authorization ヘッダーから Bearer トークンを取得
+// ヘッダーが無い場合は空文字を返し、形式が不正な場合はレスポンスを返して false を返す
+func bearerToken(ctx *gin.Context) (string, bool) {
+	header := ctx.GetHeader("Authorization")
+	if header == "" {
+		return "", true
+	}
+
+	scheme, token, found := strings.Cut(header, " ")
+	token = strings.TrimSpace(token)
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header"})
+		ctx.Abort()
+		return "", false
+	}
+	return token, true
+}
+
+// authenticateToken: 個人用アクセストークンを検証し、スコープがリクエストを許可する場合のみ通過させる
+func authenticateToken(ctx *gin.Context, token string, writeScopes []string) {
+	if tokenVerifier == nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		ctx.Abort()
+		return
+	}
+
+	user, scopes, err := tokenVerifier.VerifyPersonalAccessToken(token)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		ctx.Abort()
+		return
+	}
+
+	if !scopeAllows(scopes, ctx.Request.Method, writeScopes) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "insufficient token scope"})
+		ctx.Abort()
+		return
+	}
+
+	ctx.Set("userID", user.ID)
+	ctx.Set("email", user.Email)
+	ctx.Set("role", user.Role)
+	ctx.Set("isActive", user.IsActive)
+	ctx.Set("authMethod", AuthMethodToken)
+	ctx.Set("tokenScopes", scopes)
+
+	ctx.Next()
+}
+
+// scopeAllows: スコープがリクエストのメソッドを許可するかどうか
+func scopeAllows(scopes []string, method string, writeScopes []string) bool {
+	if slices.Contains(scopes, models.TokenScopeAdmin) {
+		return true
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return slices.Contains(scopes, models.TokenScopeRead)
+	default:
+		for _, scope := range writeScopes {
+			if slices.Contains(scopes, scope) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -1,11 +1,14 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"simple-ledger/internal/common/security"
+	"simple-ledger/internal/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -16,7 +19,7 @@ func TestAuthMiddleware_ValidToken(t *testing.T) {
 	security.InitJWT("test-secret", 1.0, 1.0)
 
 	// テストトークンを生成
-	token, _ := security.GenerateToken(1, "test@example.com", "user", true)
+	token, _ := security.GenerateToken(1, "test@example.com", "user", true, 0)
 
 	// テストリクエスト
 	httpReq := httptest.NewRequest("GET", "/protected", nil)
@@ -114,6 +117,30 @@ func TestAuthMiddleware_InvalidToken(t *testing.T) {
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 }
 
+func TestAuthMiddleware_RejectsRefreshToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	security.InitJWT("test-secret", 1.0, 1.0)
+
+	// リフレッシュトークンをアクセストークンとして送信
+	refreshToken, _ := security.GenerateRefreshToken(1, "session-1", "token-1")
+	httpReq := httptest.NewRequest("GET", "/protected", nil)
+	httpReq.AddCookie(&http.Cookie{
+		Name:  "accessToken",
+		Value: refreshToken,
+	})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httpReq
+
+	// ミドルウェアを適用
+	AuthMiddleware()(c)
+
+	// リクエストが中止されているか確認
+	assert.True(t, c.IsAborted())
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
 func TestAuthMiddleware_ExpiredToken(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	security.InitJWT("test-secret", 1.0, 1.0)
@@ -139,3 +166,132 @@ func TestAuthMiddleware_ExpiredToken(t *testing.T) {
 	assert.True(t, c.IsAborted())
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 }
+
+func TestRequireRole_AllowsMatchingRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/admin", nil)
+	c.Set("role", "admin")
+
+	RequireRole("admin")(c)
+
+	assert.False(t, c.IsAborted())
+}
+
+func TestRequireRole_RejectsOtherRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/admin", nil)
+	c.Set("role", "user")
+
+	RequireRole("admin")(c)
+
+	assert.True(t, c.IsAborted())
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// fakeTokenVerifier: 固定の個人用アクセストークンのみ受け付ける検証
+type fakeTokenVerifier struct {
+	token  string
+	scopes []string
+}
+
+func (v *fakeTokenVerifier) VerifyPersonalAccessToken(token string) (*models.User, []string, error) {
+	if token != v.token {
+		return nil, nil, errors.New("invalid token")
+	}
+	return &models.User{ID: 2, Email: "script@example.com", Role: "admin", IsActive: true}, v.scopes, nil
+}
+
+// runWithBearer: Authorization ヘッダー付きのリクエストにミドルウェアを適用
+func runWithBearer(method string, authorization string, handlers ...gin.HandlerFunc) (*gin.Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/protected", nil)
+	c.Request.Header.Set("Authorization", authorization)
+	for _, handler := range handlers {
+		if handler(c); c.IsAborted() {
+			break
+		}
+	}
+	return c, w
+}
+
+func TestAuthMiddleware_BearerJWT(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	security.InitJWT("test-secret", 1.0, 1.0)
+
+	token, _ := security.GenerateToken(1, "test@example.com", "user", true, 0)
+	c, _ := runWithBearer("GET", "Bearer "+token, AuthMiddleware())
+
+	assert.False(t, c.IsAborted())
+	assert.Equal(t, uint(1), c.GetUint("userID"))
+	assert.Equal(t, AuthMethodSession, c.GetString("authMethod"))
+
+	_, w := runWithBearer("GET", "Basic "+token, AuthMiddleware())
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthMiddleware_RejectsStaleTokenVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	security.InitJWT("test-secret", 1.0, 1.0)
+
+	// ロール変更などでユーザーのトークンバージョンが 1 に上がった状態
+	security.InitTokenVersions(func(userID uint) (uint, error) { return 1, nil }, time.Minute)
+	t.Cleanup(func() { security.InitTokenVersions(nil, 0) })
+
+	stale, _ := security.GenerateToken(1, "test@example.com", "admin", true, 0)
+	_, w := runWithBearer("GET", "Bearer "+stale, AuthMiddleware())
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	current, _ := security.GenerateToken(1, "test@example.com", "user", true, 1)
+	c, _ := runWithBearer("GET", "Bearer "+current, AuthMiddleware())
+	assert.False(t, c.IsAborted())
+	assert.Equal(t, "user", c.GetString("role"))
+}
+
+func TestAuthMiddleware_PersonalAccessTokenScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	token := security.PersonalAccessTokenPrefix + "test"
+	InitPersonalAccessTokens(&fakeTokenVerifier{token: token, scopes: []string{models.TokenScopeRead}})
+	t.Cleanup(func() { InitPersonalAccessTokens(nil) })
+
+	// read スコープは参照のみ
+	c, _ := runWithBearer("GET", "Bearer "+token, AuthMiddleware(models.TokenScopeTransactionsWrite))
+	assert.False(t, c.IsAborted())
+	assert.Equal(t, uint(2), c.GetUint("userID"))
+	assert.Equal(t, AuthMethodToken, c.GetString("authMethod"))
+
+	_, w := runWithBearer("POST", "Bearer "+token, AuthMiddleware(models.TokenScopeTransactionsWrite))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 管理者ユーザーでも admin スコープが無ければ管理者用エンドポイントは使えない
+	_, w = runWithBearer("GET", "Bearer "+token, AuthMiddleware(), RequireRole("admin"))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// セッション専用のエンドポイントは使えない
+	_, w = runWithBearer("GET", "Bearer "+token, AuthMiddleware(), RequireSession())
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 不明なトークン
+	_, w = runWithBearer("GET", "Bearer "+token+"x", AuthMiddleware())
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthMiddleware_PersonalAccessTokenWriteScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	token := security.PersonalAccessTokenPrefix + "test"
+	InitPersonalAccessTokens(&fakeTokenVerifier{token: token, scopes: []string{models.TokenScopeTransactionsWrite}})
+	t.Cleanup(func() { InitPersonalAccessTokens(nil) })
+
+	c, _ := runWithBearer("POST", "Bearer "+token, AuthMiddleware(models.TokenScopeTransactionsWrite))
+	assert.False(t, c.IsAborted())
+
+	// 取引以外の更新系エンドポイントでは使えない
+	_, w := runWithBearer("POST", "Bearer "+token, AuthMiddleware())
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"time"
+
+	"simple-ledger/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AccountTokenRepository: アカウント操作用トークン（パスワード再設定・メールアドレスの確認）のリポジトリ
+type AccountTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountTokenRepository(db *gorm.DB) *AccountTokenRepository {
+	return &AccountTokenRepository{db: db}
+}
+
+// WithTx は指定したDBトランザクション上で動作するリポジトリを返す
+func (r *AccountTokenRepository) WithTx(tx *gorm.DB) *AccountTokenRepository {
+	return &AccountTokenRepository{db: tx}
+}
+
+// Create はトークンを記録
+func (r *AccountTokenRepository) Create(token *models.AccountToken) error {
+	return r.db.Create(token).Error
+}
+
+// GetByTokenID は jti でトークンを取得
+func (r *AccountTokenRepository) GetByTokenID(tokenID string) (*models.AccountToken, error) {
+	var token models.AccountToken
+	if err := r.db.Where("token_id = ?", tokenID).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetLatest はユーザーに最後に発行した用途のトークンを取得（再送間隔の判定に使う）
+func (r *AccountTokenRepository) GetLatest(userID uint, purpose string) (*models.AccountToken, error) {
+	var token models.AccountToken
+	if err := r.db.Where("user_id = ? AND purpose = ?", userID, purpose).
+		Order("created_at DESC, id DESC").
+		First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed は未使用のトークンを使用済みにする（使用済みの場合は更新せず false を返す）
+func (r *AccountTokenRepository) MarkUsed(id uint, at time.Time) (bool, error) {
+	result := r.db.Model(&models.AccountToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+	return result.RowsAffected == 1, result.Error
+}
+
+// InvalidateAll はユーザーの未使用の用途のトークンをすべて使用済みにする
+func (r *AccountTokenRepository) InvalidateAll(userID uint, purpose string, at time.Time) error {
+	return r.db.Model(&models.AccountToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", at).Error
+}
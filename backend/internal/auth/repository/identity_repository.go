@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"time"
+
+	"simple-ledger/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// IdentityRepository: シングルサインオンのアカウントの紐づけのリポジトリ
+type IdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewIdentityRepository(db *gorm.DB) *IdentityRepository {
+	return &IdentityRepository{db: db}
+}
+
+// WithTx は指定したDBトランザクション上で動作するリポジトリを返す
+func (r *IdentityRepository) WithTx(tx *gorm.DB) *IdentityRepository {
+	return &IdentityRepository{db: tx}
+}
+
+// Create は紐づけを記録
+func (r *IdentityRepository) Create(identity *models.UserIdentity) error {
+	return r.db.Create(identity).Error
+}
+
+// GetBySubject は IdP の発行者と sub で紐づけを取得
+func (r *IdentityRepository) GetBySubject(issuer string, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	if err := r.db.Where("issuer = ? AND subject = ?", issuer, subject).First(&identity).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// CountByUser はユーザーに紐づくアカウントの数を返す
+func (r *IdentityRepository) CountByUser(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.UserIdentity{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// RecordLogin は最後のログイン日時と IdP から受け取ったメールアドレスを更新
+func (r *IdentityRepository) RecordLogin(id uint, email string, at time.Time) error {
+	return r.db.Model(&models.UserIdentity{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"email": email, "last_login_at": at}).Error
+}
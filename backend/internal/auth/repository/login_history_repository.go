@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"time"
+
+	"simple-ledger/internal/common/db/dialect"
+	"simple-ledger/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// LoginHistoryFilter: ログイン履歴の検索条件（nil・空の項目は絞り込まない）
+type LoginHistoryFilter struct {
+	UserID   *uint
+	Email    string
+	Success  *bool
+	DateFrom *time.Time
+	DateTo   *time.Time // この日時を含まない
+}
+
+// LoginHistoryRepository: ログイン履歴のリポジトリ
+type LoginHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginHistoryRepository(db *gorm.DB) *LoginHistoryRepository {
+	return &LoginHistoryRepository{db: db}
+}
+
+// WithTx は指定したDBトランザクション上で動作するリポジトリを返す
+func (r *LoginHistoryRepository) WithTx(tx *gorm.DB) *LoginHistoryRepository {
+	return &LoginHistoryRepository{db: tx}
+}
+
+// Create はログイン履歴を記録
+func (r *LoginHistoryRepository) Create(history *models.LoginHistory) error {
+	return r.db.Create(history).Error
+}
+
+// List は条件に一致するログイン履歴を新しい順に取得（ページネーション対応）
+func (r *LoginHistoryRepository) List(filter LoginHistoryFilter, page, pageSize int) ([]models.LoginHistory, int64, error) {
+	query := r.db.Model(&models.LoginHistory{})
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Email != "" {
+		query = query.Where("email = ?", filter.Email)
+	}
+	if filter.Success != nil {
+		query = query.Where("success = ?", *filter.Success)
+	}
+	if filter.DateFrom != nil {
+		query = query.Where("created_at >= ?", dialect.Time(*filter.DateFrom))
+	}
+	if filter.DateTo != nil {
+		query = query.Where("created_at < ?", dialect.Time(*filter.DateTo))
+	}
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var histories []models.LoginHistory
+	offset := (page - 1) * pageSize
+	if err := query.
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&histories).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return histories, totalCount, nil
+}
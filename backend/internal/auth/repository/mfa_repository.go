@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"time"
+
+	"simple-ledger/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// MFARepository: 二段階認証（TOTP・リカバリーコード・ロールごとの必須設定）のリポジトリ
+type MFARepository struct {
+	db *gorm.DB
+}
+
+func NewMFARepository(db *gorm.DB) *MFARepository {
+	return &MFARepository{db: db}
+}
+
+// WithTx は指定したDBトランザクション上で動作するリポジトリを返す
+func (r *MFARepository) WithTx(tx *gorm.DB) *MFARepository {
+	return &MFARepository{db: tx}
+}
+
+// GetTOTP はユーザーの TOTP 設定を取得
+func (r *MFARepository) GetTOTP(userID uint) (*models.UserTOTP, error) {
+	var totp models.UserTOTP
+	if err := r.db.Where("user_id = ?", userID).First(&totp).Error; err != nil {
+		return nil, err
+	}
+	return &totp, nil
+}
+
+// SaveTOTP は TOTP 設定を保存（ID が 0 の場合は作成）
+func (r *MFARepository) SaveTOTP(totp *models.UserTOTP) error {
+	return r.db.Save(totp).Error
+}
+
+// EnableTOTP は TOTP を有効化し、有効化に使用したタイムステップを記録
+func (r *MFARepository) EnableTOTP(id uint, at time.Time, step int64) error {
+	return r.db.Model(&models.UserTOTP{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"enabled_at": at, "last_used_step": step}).Error
+}
+
+// MarkStepUsed は使用したタイムステップを記録する
+// 記録済みのステップ以前の場合は更新せず false を返す（同じコードの同時利用を防ぐ）
+func (r *MFARepository) MarkStepUsed(id uint, step int64) (bool, error) {
+	result := r.db.Model(&models.UserTOTP{}).
+		Where("id = ? AND last_used_step < ?", id, step).
+		Update("last_used_step", step)
+	return result.RowsAffected == 1, result.Error
+}
+
+// DeleteTOTP はユーザーの TOTP 設定を削除
+func (r *MFARepository) DeleteTOTP(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.UserTOTP{}).Error
+}
+
+// ReplaceRecoveryCodes はユーザーのリカバリーコードを作り直す（既存のコードは使えなくなる）
+func (r *MFARepository) ReplaceRecoveryCodes(userID uint, codeHashes []string) error {
+	if err := r.DeleteRecoveryCodes(userID); err != nil {
+		return err
+	}
+
+	codes := make([]models.MFARecoveryCode, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, models.MFARecoveryCode{UserID: userID, CodeHash: hash})
+	}
+	return r.db.Create(&codes).Error
+}
+
+// UseRecoveryCode は未使用のリカバリーコードを使用済みにする（該当するコードがない場合は false）
+func (r *MFARepository) UseRecoveryCode(userID uint, codeHash string, at time.Time) (bool, error) {
+	result := r.db.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", at)
+	return result.RowsAffected == 1, result.Error
+}
+
+// CountUnusedRecoveryCodes は未使用のリカバリーコードの件数を返す
+func (r *MFARepository) CountUnusedRecoveryCodes(userID uint) (int64, error) {
+	var count int64
+	if err := r.db.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// DeleteRecoveryCodes はユーザーのリカバリーコードをすべて削除
+func (r *MFARepository) DeleteRecoveryCodes(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error
+}
+
+// GetRolePolicy はロールの二段階認証の必須設定を取得（未設定の場合は必須でない設定を返す）
+func (r *MFARepository) GetRolePolicy(role string) (*models.RoleMFAPolicy, error) {
+	var policy models.RoleMFAPolicy
+	if err := r.db.Where("role = ?", role).First(&policy).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return &models.RoleMFAPolicy{Role: role}, nil
+		}
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// ListRolePolicies は設定済みのロールの二段階認証の必須設定を取得
+func (r *MFARepository) ListRolePolicies() ([]models.RoleMFAPolicy, error) {
+	var policies []models.RoleMFAPolicy
+	if err := r.db.Order("role ASC").Find(&policies).Error; err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// SaveRolePolicy はロールの二段階認証の必須設定を保存
+func (r *MFARepository) SaveRolePolicy(policy *models.RoleMFAPolicy) error {
+	return r.db.Save(policy).Error
+}
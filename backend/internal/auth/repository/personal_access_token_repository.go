@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"time"
+
+	"simple-ledger/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// PersonalAccessTokenRepository: 個人用アクセストークンのリポジトリ
+type PersonalAccessTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewPersonalAccessTokenRepository(db *gorm.DB) *PersonalAccessTokenRepository {
+	return &PersonalAccessTokenRepository{db: db}
+}
+
+// WithTx は指定したDBトランザクション上で動作するリポジトリを返す
+func (r *PersonalAccessTokenRepository) WithTx(tx *gorm.DB) *PersonalAccessTokenRepository {
+	return &PersonalAccessTokenRepository{db: tx}
+}
+
+// Create はトークンを記録
+func (r *PersonalAccessTokenRepository) Create(token *models.PersonalAccessToken) error {
+	return r.db.Create(token).Error
+}
+
+// GetByID は ID でトークンを取得
+func (r *PersonalAccessTokenRepository) GetByID(id uint) (*models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	if err := r.db.First(&token, id).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetByHash はトークンのハッシュでトークンを取得
+func (r *PersonalAccessTokenRepository) GetByHash(hash string) (*models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	if err := r.db.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ListByUser はユーザーの失効していないトークンを新しい順に取得（期限切れのトークンも含む）
+func (r *PersonalAccessTokenRepository) ListByUser(userID uint) ([]models.PersonalAccessToken, error) {
+	var tokens []models.PersonalAccessToken
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("created_at DESC, id DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+// Revoke は有効なトークンを失効させる（失効済みの場合は更新せず false を返す）
+func (r *PersonalAccessTokenRepository) Revoke(id uint, at time.Time) (bool, error) {
+	result := r.db.Model(&models.PersonalAccessToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at)
+	return result.RowsAffected == 1, result.Error
+}
+
+// Touch は最終利用日時を更新
+func (r *PersonalAccessTokenRepository) Touch(id uint, at time.Time) error {
+	return r.db.Model(&models.PersonalAccessToken{}).
+		Where("id = ?", id).
+		Update("last_used_at", at).Error
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"time"
+
+	"simple-ledger/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SessionRepository: ログインセッションとリフレッシュトークンのリポジトリ
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// WithTx は指定したDBトランザクション上で動作するリポジトリを返す
+func (r *SessionRepository) WithTx(tx *gorm.DB) *SessionRepository {
+	return &SessionRepository{db: tx}
+}
+
+// CreateSession はセッションを作成
+func (r *SessionRepository) CreateSession(session *models.AuthSession) error {
+	return r.db.Create(session).Error
+}
+
+// GetSessionByFamilyID はトークンファミリーIDでセッションを取得
+func (r *SessionRepository) GetSessionByFamilyID(familyID string) (*models.AuthSession, error) {
+	var session models.AuthSession
+	if err := r.db.Where("family_id = ?", familyID).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetSessionByID はIDでセッションを取得
+func (r *SessionRepository) GetSessionByID(id uint) (*models.AuthSession, error) {
+	var session models.AuthSession
+	if err := r.db.First(&session, id).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListActiveSessions はユーザーの有効なセッションを最終利用日時の新しい順に取得
+// since より後にリフレッシュされていないセッションはリフレッシュトークンが期限切れのため除外する
+func (r *SessionRepository) ListActiveSessions(userID uint, since time.Time) ([]models.AuthSession, error) {
+	var sessions []models.AuthSession
+	if err := r.db.
+		Where("user_id = ? AND revoked_at IS NULL AND last_used_at > ?", userID, since).
+		Order("last_used_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// TouchSession はセッションの最終利用日時を更新
+func (r *SessionRepository) TouchSession(id uint, at time.Time) error {
+	return r.db.Model(&models.AuthSession{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
+
+// SetTokenVersion はセッションのトークンバージョンを更新（ロール・パスワード等の変更後も維持するセッション用）
+func (r *SessionRepository) SetTokenVersion(id uint, tokenVersion uint) error {
+	return r.db.Model(&models.AuthSession{}).Where("id = ?", id).Update("token_version", tokenVersion).Error
+}
+
+// RevokeSession はセッションを失効させる（失効済みの場合は何もしない）
+func (r *SessionRepository) RevokeSession(id uint, reason string, at time.Time) error {
+	return r.db.Model(&models.AuthSession{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"revoked_at": at, "revoked_reason": reason}).Error
+}
+
+// RevokeAllSessions はユーザーの有効なセッションをすべて失効させ、失効させた件数を返す
+func (r *SessionRepository) RevokeAllSessions(userID uint, reason string, at time.Time) (int64, error) {
+	result := r.db.Model(&models.AuthSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{"revoked_at": at, "revoked_reason": reason})
+	return result.RowsAffected, result.Error
+}
+
+// RevokeOtherSessions はユーザーの指定したセッション以外のセッションを失効させ、失効させた件数を返す
+func (r *SessionRepository) RevokeOtherSessions(userID uint, keepSessionID uint, reason string, at time.Time) (int64, error) {
+	result := r.db.Model(&models.AuthSession{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, keepSessionID).
+		Updates(map[string]interface{}{"revoked_at": at, "revoked_reason": reason})
+	return result.RowsAffected, result.Error
+}
+
+// CreateRefreshToken はリフレッシュトークンを記録
+func (r *SessionRepository) CreateRefreshToken(token *models.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+// GetRefreshTokenByTokenID は jti でリフレッシュトークンを取得
+func (r *SessionRepository) GetRefreshTokenByTokenID(tokenID string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	if err := r.db.Where("token_id = ?", tokenID).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkRotated はリフレッシュトークンを交換済みにする
+// 未交換の場合のみ更新するため、同じトークンを同時に使った場合も true を得るのは片方だけになる
+func (r *SessionRepository) MarkRotated(id uint, at time.Time) (bool, error) {
+	result := r.db.Model(&models.RefreshToken{}).
+		Where("id = ? AND rotated_at IS NULL", id).
+		Update("rotated_at", at)
+	return result.RowsAffected == 1, result.Error
+}
@@ -1,25 +1,119 @@
 package router
 
 import (
+	auditRepository "simple-ledger/internal/audit/repository"
+	auditService "simple-ledger/internal/audit/service"
 	"simple-ledger/internal/auth/controller"
+	"simple-ledger/internal/auth/middleware"
+	"simple-ledger/internal/auth/repository"
 	"simple-ledger/internal/auth/service"
-	"simple-ledger/internal/user/repository"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/mail"
+	"simple-ledger/internal/common/oidc"
+	"simple-ledger/internal/common/ratelimit"
+	userRepository "simple-ledger/internal/user/repository"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func SetupAuthRoutes(r *gin.RouterGroup, db *gorm.DB) {
+// limiterStore はログイン試行回数の保存先（複数インスタンスでは Redis 互換ストアを共有する）
+// mailer はパスワード再設定・メールアドレス確認のメールの送信先
+// oidcProvider はシングルサインオンの IdP（nil の場合はシングルサインオンを無効にする）
+func SetupAuthRoutes(r *gin.RouterGroup, db *gorm.DB, limiterStore ratelimit.Store, mailer mail.Mailer, oidcProvider *oidc.Provider) {
 	// リポジトリ、サービス、コントローラーの初期化
-	userRepo := repository.NewUserRepository(db)
-	authService := service.NewAuthService(userRepo)
+	userRepo := userRepository.NewUserRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	loginHistoryRepo := repository.NewLoginHistoryRepository(db)
+	guard := service.NewLoginGuard(limiterStore, service.LoginLimitConfigFromEnv())
+	auditSvc := auditService.NewAuditService(auditRepository.NewAuditLogRepository(db))
+	unitOfWork := uow.New(db)
+	mfaService := service.NewMFAService(repository.NewMFARepository(db), userRepo, auditSvc, unitOfWork, service.MFAConfigFromEnv())
+	authService := service.NewAuthService(userRepo, sessionRepo, loginHistoryRepo, guard, mfaService, auditSvc, unitOfWork)
 	authCtrl := controller.NewAuthController(authService)
+	mfaCtrl := controller.NewMFAController(mfaService)
+	accountConfig := service.AccountConfigFromEnv()
+	accountService := service.NewAccountService(userRepo, repository.NewAccountTokenRepository(db), sessionRepo, mailer, auditSvc, unitOfWork, accountConfig)
+	accountCtrl := controller.NewAccountController(accountService)
+	oidcService := service.NewOIDCService(oidcProvider, authService, userRepo, repository.NewIdentityRepository(db), auditSvc, unitOfWork, service.OIDCConfigFromEnv())
+	oidcCtrl := controller.NewOIDCController(oidcService, accountConfig.AppBaseURL)
+	sessionCtrl := controller.NewSessionController(service.NewSessionService(sessionRepo, loginHistoryRepo))
+	tokenService := service.NewPersonalAccessTokenService(repository.NewPersonalAccessTokenRepository(db), userRepo, auditSvc, unitOfWork)
+	tokenCtrl := controller.NewPersonalAccessTokenController(tokenService)
+
+	// 個人用アクセストークンによる Bearer 認証を有効にする（他のルートの AuthMiddleware でも使用する）
+	middleware.InitPersonalAccessTokens(tokenService)
 
 	// 認証関連のルート定義
 	authGroup := r.Group("/auth")
 	{
-		authGroup.POST("/login", authCtrl.Login)          // POST /api/auth/login
-		authGroup.POST("/refresh", authCtrl.RefreshToken) // POST /api/auth/refresh
-		authGroup.POST("/logout", authCtrl.Logout)        // POST /api/auth/logout
+		authGroup.GET("/csrf", authCtrl.GetCSRFToken)                    // GET /api/auth/csrf
+		authGroup.POST("/login", authCtrl.Login)                         // POST /api/auth/login
+		authGroup.POST("/login/mfa", authCtrl.LoginMFA)                  // POST /api/auth/login/mfa
+		authGroup.POST("/login/mfa/enroll", authCtrl.BeginMFAEnrollment) // POST /api/auth/login/mfa/enroll
+		authGroup.POST("/refresh", authCtrl.RefreshToken)                // POST /api/auth/refresh
+		authGroup.POST("/logout", authCtrl.Logout)                       // POST /api/auth/logout
+
+		authGroup.POST("/password/forgot", accountCtrl.ForgotPassword) // POST /api/auth/password/forgot
+		authGroup.POST("/password/reset", accountCtrl.ResetPassword)   // POST /api/auth/password/reset
+		authGroup.POST("/email/verify", accountCtrl.VerifyEmail)       // POST /api/auth/email/verify
+
+		authGroup.GET("/oidc", oidcCtrl.GetConfig)         // GET /api/auth/oidc
+		authGroup.GET("/oidc/login", oidcCtrl.Login)       // GET /api/auth/oidc/login
+		authGroup.GET("/oidc/callback", oidcCtrl.Callback) // GET /api/auth/oidc/callback
 	}
+
+	// ログイン中のユーザー自身のセッション管理（個人用アクセストークンでは操作できない）
+	authProtected := authGroup.Group("")
+	authProtected.Use(middleware.AuthMiddleware(), middleware.RequireSession())
+	{
+		authProtected.POST("/logout-all", authCtrl.LogoutAll)            // POST /api/auth/logout-all
+		authProtected.GET("/sessions", sessionCtrl.GetSessions)          // GET /api/auth/sessions
+		authProtected.DELETE("/sessions/:id", sessionCtrl.RevokeSession) // DELETE /api/auth/sessions/:id
+		authProtected.GET("/login-history", sessionCtrl.GetLoginHistory) // GET /api/auth/login-history
+
+		authProtected.GET("/mfa", mfaCtrl.GetStatus)                               // GET /api/auth/mfa
+		authProtected.POST("/mfa/enroll", mfaCtrl.Enroll)                          // POST /api/auth/mfa/enroll
+		authProtected.POST("/mfa/enable", mfaCtrl.Enable)                          // POST /api/auth/mfa/enable
+		authProtected.POST("/mfa/disable", mfaCtrl.Disable)                        // POST /api/auth/mfa/disable
+		authProtected.POST("/mfa/recovery-codes", mfaCtrl.RegenerateRecoveryCodes) // POST /api/auth/mfa/recovery-codes
+
+		authProtected.POST("/password/change", accountCtrl.ChangePassword)           // POST /api/auth/password/change
+		authProtected.POST("/email/verification", accountCtrl.SendEmailVerification) // POST /api/auth/email/verification
+
+		authProtected.GET("/tokens", tokenCtrl.GetTokens)          // GET /api/auth/tokens
+		authProtected.POST("/tokens", tokenCtrl.CreateToken)       // POST /api/auth/tokens
+		authProtected.DELETE("/tokens/:id", tokenCtrl.RevokeToken) // DELETE /api/auth/tokens/:id
+	}
+
+	// 全ユーザーのログイン履歴は管理者のみ
+	loginHistoryRoutes := r.Group("/login-history")
+	loginHistoryRoutes.Use(middleware.AuthMiddleware(), middleware.RequireRole("admin"))
+	{
+		loginHistoryRoutes.GET("", sessionCtrl.SearchLoginHistory) // GET /api/login-history
+	}
+
+	// アカウントロック・二段階認証の解除とパスワードによるログインの設定は管理者のみ
+	adminUserRoutes := r.Group("/users")
+	adminUserRoutes.Use(middleware.AuthMiddleware(), middleware.RequireRole("admin"))
+	{
+		adminUserRoutes.POST("/:id/unlock", authCtrl.UnlockUser)                 // POST /api/users/:id/unlock
+		adminUserRoutes.DELETE("/:id/mfa", mfaCtrl.ResetUserMFA)                 // DELETE /api/users/:id/mfa
+		adminUserRoutes.PUT("/:id/password-login", oidcCtrl.UpdatePasswordLogin) // PUT /api/users/:id/password-login
+	}
+
+	// ロールごとの二段階認証の必須設定は管理者のみ
+	mfaPolicyRoutes := r.Group("/mfa/policies")
+	mfaPolicyRoutes.Use(middleware.AuthMiddleware(), middleware.RequireRole("admin"))
+	{
+		mfaPolicyRoutes.GET("", mfaCtrl.GetPolicies)        // GET /api/mfa/policies
+		mfaPolicyRoutes.PUT("/:role", mfaCtrl.UpdatePolicy) // PUT /api/mfa/policies/:role
+	}
+}
+
+// SetupWellKnownRoutes は /api の外に公開するルート（/.well-known）を定義する
+func SetupWellKnownRoutes(r gin.IRouter) {
+	jwksCtrl := controller.NewJWKSController()
+
+	r.GET("/.well-known/jwks.json", jwksCtrl.GetJWKS) // GET /.well-known/jwks.json
 }
@@ -0,0 +1,455 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	auditService "simple-ledger/internal/audit/service"
+	"simple-ledger/internal/auth/repository"
+	"simple-ledger/internal/common/config"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/mail"
+	"simple-ledger/internal/common/requestmeta"
+	"simple-ledger/internal/common/security"
+	"simple-ledger/internal/models"
+	userRepository "simple-ledger/internal/user/repository"
+
+	"gorm.io/gorm"
+)
+
+// パスワード変更に伴うセッション失効理由
+const (
+	RevokedReasonPasswordReset  = "password_reset"
+	RevokedReasonPasswordChange = "password_change"
+)
+
+var (
+	ErrInvalidAccountToken    = errors.New("invalid or expired token")
+	ErrInvalidCurrentPassword = errors.New("current password is incorrect")
+	ErrSamePassword           = errors.New("new password must be different from the current password")
+	ErrEmailAlreadyVerified   = errors.New("email address is already verified")
+)
+
+// ResendTooSoonError: 再送間隔内のため送信しなかった
+type ResendTooSoonError struct {
+	// RetryAfter: 再送できるまでの時間
+	RetryAfter time.Duration
+}
+
+func (e *ResendTooSoonError) Error() string {
+	return "email was sent recently, try again later"
+}
+
+// RetryAfterSeconds: Retry-After ヘッダーに設定する秒数（切り上げ）
+func (e *ResendTooSoonError) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+// AccountConfig: パスワード再設定・メールアドレスの確認の設定
+type AccountConfig struct {
+	// AppBaseURL: メールに記載するリンクの基準URL（フロントエンド）
+	AppBaseURL string
+
+	// PasswordResetTTL: パスワード再設定トークンの有効期間
+	PasswordResetTTL time.Duration
+
+	// EmailVerificationTTL: メールアドレス確認トークンの有効期間
+	EmailVerificationTTL time.Duration
+
+	// ResendInterval: 同じユーザーに同じ用途のメールを再送できるまでの間隔
+	ResendInterval time.Duration
+
+	// PasswordPolicy: 再設定・変更後のパスワードの条件
+	PasswordPolicy security.PasswordPolicy
+}
+
+// DefaultAccountConfig: 既定の設定
+func DefaultAccountConfig() AccountConfig {
+	return AccountConfig{
+		AppBaseURL:           "http://localhost:3000",
+		PasswordResetTTL:     30 * time.Minute,
+		EmailVerificationTTL: 24 * time.Hour,
+		ResendInterval:       time.Minute,
+		PasswordPolicy:       security.DefaultPasswordPolicy(),
+	}
+}
+
+// AccountConfigFromEnv: 環境変数から設定を読み込む（未設定の項目は既定値）
+func AccountConfigFromEnv() AccountConfig {
+	cfg := DefaultAccountConfig()
+	cfg.AppBaseURL = strings.TrimRight(config.GetEnv("APP_BASE_URL", cfg.AppBaseURL), "/")
+	cfg.PasswordResetTTL = time.Duration(config.GetEnvAsInt("PASSWORD_RESET_TOKEN_MINUTES", int(cfg.PasswordResetTTL.Minutes()))) * time.Minute
+	cfg.EmailVerificationTTL = time.Duration(config.GetEnvAsInt("EMAIL_VERIFICATION_TOKEN_HOURS", int(cfg.EmailVerificationTTL.Hours()))) * time.Hour
+	cfg.ResendInterval = time.Duration(config.GetEnvAsInt("ACCOUNT_MAIL_RESEND_SECONDS", int(cfg.ResendInterval.Seconds()))) * time.Second
+	cfg.PasswordPolicy = security.PasswordPolicyFromEnv()
+	return cfg
+}
+
+// AccountService: パスワードの再設定・変更とメールアドレスの確認
+type AccountService struct {
+	userRepo    *userRepository.UserRepository
+	tokenRepo   *repository.AccountTokenRepository
+	sessionRepo *repository.SessionRepository
+	mailer      mail.Mailer
+	audit       *auditService.AuditService
+	uow         uow.UnitOfWork
+	config      AccountConfig
+	now         func() time.Time
+}
+
+func NewAccountService(
+	userRepo *userRepository.UserRepository,
+	tokenRepo *repository.AccountTokenRepository,
+	sessionRepo *repository.SessionRepository,
+	mailer mail.Mailer,
+	audit *auditService.AuditService,
+	unitOfWork uow.UnitOfWork,
+	cfg AccountConfig,
+) *AccountService {
+	return &AccountService{
+		userRepo:    userRepo,
+		tokenRepo:   tokenRepo,
+		sessionRepo: sessionRepo,
+		mailer:      mailer,
+		audit:       audit,
+		uow:         unitOfWork,
+		config:      cfg,
+		now:         time.Now,
+	}
+}
+
+// passwordSnapshot: パスワード変更の監査ログに記録する内容（ハッシュは記録しない）
+type passwordSnapshot struct {
+	PasswordChanged bool   `json:"passwordChanged"`
+	Method          string `json:"method"` // reset（メールによる再設定）または change（利用者による変更）
+}
+
+// emailVerificationSnapshot: メールアドレスの確認の監査ログに記録する状態
+type emailVerificationSnapshot struct {
+	Email           string     `json:"email"`
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt"`
+}
+
+// RequestPasswordReset はパスワード再設定用のリンクをメールで送信する
+// 登録の有無を推測されないよう、存在しない・無効なユーザーや再送間隔内の場合も成功として扱う
+func (s *AccountService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetUserByEmail(strings.TrimSpace(email))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !user.IsActive {
+		return nil
+	}
+
+	wait, err := s.resendWait(user.ID, models.AccountTokenPasswordReset)
+	if err != nil {
+		return err
+	}
+	if wait > 0 {
+		return nil
+	}
+
+	token, err := s.issueToken(user, models.AccountTokenPasswordReset, s.config.PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	link := s.link("/reset-password", token)
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: "パスワードの再設定",
+		Body: fmt.Sprintf(
+			"%s 様\n\nパスワードの再設定が依頼されました。\n以下のリンクから %d 分以内に新しいパスワードを設定してください。\n\n%s\n\nこのメールに心当たりがない場合は破棄してください。パスワードは変更されません。\n",
+			user.Name, int(s.config.PasswordResetTTL.Minutes()), link,
+		),
+	}
+	// 送信に失敗した場合も応答は変えない（登録の有無が分かってしまうため）
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		log.Printf("failed to send password reset email to user %d: %v", user.ID, err)
+	}
+	return nil
+}
+
+// ResetPassword はパスワード再設定トークンを検証して新しいパスワードを設定する
+// 本人のメールアドレスに届いたリンクのため、メールアドレスも確認済みとし、すべてのセッションを失効させる
+func (s *AccountService) ResetPassword(token string, newPassword string, meta requestmeta.Meta) error {
+	user, stored, err := s.verifyToken(token, models.AccountTokenPasswordReset)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive {
+		return ErrInvalidAccountToken
+	}
+	if err := s.config.PasswordPolicy.Validate(newPassword, user.Email, user.Name); err != nil {
+		return err
+	}
+
+	hashedPassword, err := security.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	err = s.uow.Do(func(tx *gorm.DB) error {
+		tokenRepo := s.tokenRepo.WithTx(tx)
+		used, err := tokenRepo.MarkUsed(stored.ID, now)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidAccountToken
+		}
+		if err := tokenRepo.InvalidateAll(user.ID, models.AccountTokenPasswordReset, now); err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"password": hashedPassword}
+		if user.EmailVerifiedAt == nil {
+			updates["email_verified_at"] = now
+		}
+		userRepository.IncrementTokenVersion(updates)
+		if _, err := s.userRepo.WithTx(tx).UpdateUser(user.ID, updates); err != nil {
+			return err
+		}
+
+		if _, err := s.sessionRepo.WithTx(tx).RevokeAllSessions(user.ID, RevokedReasonPasswordReset, now); err != nil {
+			return err
+		}
+
+		// 未認証の操作のため ActorID は nil（操作元はIPアドレスで記録される）
+		return s.audit.WithTx(tx).Record(meta, auditService.Entry{
+			Action:     models.AuditActionUpdate,
+			EntityType: models.AuditEntityUser,
+			EntityID:   user.ID,
+			After:      passwordSnapshot{PasswordChanged: true, Method: "reset"},
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	security.InvalidateTokenVersion(user.ID)
+	return nil
+}
+
+// ChangePassword はログイン中のユーザーが現在のパスワードを確認して新しいパスワードに変更する
+// currentRefreshToken に対応するセッション（変更を行った端末）以外のセッションは失効させる
+func (s *AccountService) ChangePassword(userID uint, currentPassword string, newPassword string, currentRefreshToken string, meta requestmeta.Meta) error {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if !security.VerifyPassword(user.Password, &currentPassword) {
+		return ErrInvalidCurrentPassword
+	}
+	if currentPassword == newPassword {
+		return ErrSamePassword
+	}
+	if err := s.config.PasswordPolicy.Validate(newPassword, user.Email, user.Name); err != nil {
+		return err
+	}
+
+	hashedPassword, err := security.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	// 変更を行った端末のセッション（リフレッシュトークンが無い・無効な場合はすべて失効させる）
+	var keepSessionID uint
+	if claims, err := security.VerifyRefreshToken(currentRefreshToken); err == nil && claims.UserID == userID {
+		if session, err := s.sessionRepo.GetSessionByFamilyID(claims.SessionID); err == nil && session.RevokedAt == nil {
+			keepSessionID = session.ID
+		}
+	}
+
+	// 変更を行った端末のアクセストークンも失効する（リフレッシュトークンで再発行される）
+	updates := map[string]interface{}{"password": hashedPassword}
+	userRepository.IncrementTokenVersion(updates)
+
+	now := s.now()
+	err = s.uow.Do(func(tx *gorm.DB) error {
+		updated, err := s.userRepo.WithTx(tx).UpdateUser(user.ID, updates)
+		if err != nil {
+			return err
+		}
+
+		// 変更前に送信した再設定リンクで元に戻されないよう無効化する
+		if err := s.tokenRepo.WithTx(tx).InvalidateAll(user.ID, models.AccountTokenPasswordReset, now); err != nil {
+			return err
+		}
+
+		sessionRepo := s.sessionRepo.WithTx(tx)
+		if _, err := sessionRepo.RevokeOtherSessions(user.ID, keepSessionID, RevokedReasonPasswordChange, now); err != nil {
+			return err
+		}
+		// 変更を行った端末のセッションは新しいトークンバージョンでリフレッシュを続けられるようにする
+		if keepSessionID != 0 {
+			if err := sessionRepo.SetTokenVersion(keepSessionID, updated.TokenVersion); err != nil {
+				return err
+			}
+		}
+
+		return s.audit.WithTx(tx).Record(meta, auditService.Entry{
+			Action:     models.AuditActionUpdate,
+			EntityType: models.AuditEntityUser,
+			EntityID:   user.ID,
+			After:      passwordSnapshot{PasswordChanged: true, Method: "change"},
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	security.InvalidateTokenVersion(user.ID)
+	return nil
+}
+
+// SendEmailVerification はメールアドレス確認用のリンクを送信する
+// 再送間隔内の場合は *ResendTooSoonError を返す
+func (s *AccountService) SendEmailVerification(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	wait, err := s.resendWait(user.ID, models.AccountTokenEmailVerification)
+	if err != nil {
+		return err
+	}
+	if wait > 0 {
+		return &ResendTooSoonError{RetryAfter: wait}
+	}
+
+	token, err := s.issueToken(user, models.AccountTokenEmailVerification, s.config.EmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	link := s.link("/verify-email", token)
+	return s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "メールアドレスの確認",
+		Body: fmt.Sprintf(
+			"%s 様\n\n以下のリンクを開いて、メールアドレスの確認を完了してください（有効期限: %d 時間）。\n\n%s\n\nこのメールに心当たりがない場合は破棄してください。\n",
+			user.Name, int(s.config.EmailVerificationTTL.Hours()), link,
+		),
+	})
+}
+
+// VerifyEmail はメールアドレス確認トークンを検証してメールアドレスを確認済みにする
+// トークン発行後にメールアドレスが変更された場合は無効とする
+func (s *AccountService) VerifyEmail(token string, meta requestmeta.Meta) error {
+	user, stored, err := s.verifyToken(token, models.AccountTokenEmailVerification)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(user.Email, stored.Email) {
+		return ErrInvalidAccountToken
+	}
+
+	now := s.now()
+	return s.uow.Do(func(tx *gorm.DB) error {
+		tokenRepo := s.tokenRepo.WithTx(tx)
+		used, err := tokenRepo.MarkUsed(stored.ID, now)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidAccountToken
+		}
+		if err := tokenRepo.InvalidateAll(user.ID, models.AccountTokenEmailVerification, now); err != nil {
+			return err
+		}
+
+		if _, err := s.userRepo.WithTx(tx).UpdateUser(user.ID, map[string]interface{}{"email_verified_at": now}); err != nil {
+			return err
+		}
+
+		return s.audit.WithTx(tx).Record(meta, auditService.Entry{
+			Action:     models.AuditActionUpdate,
+			EntityType: models.AuditEntityUser,
+			EntityID:   user.ID,
+			Before:     emailVerificationSnapshot{Email: user.Email, EmailVerifiedAt: user.EmailVerifiedAt},
+			After:      emailVerificationSnapshot{Email: user.Email, EmailVerifiedAt: &now},
+		})
+	})
+}
+
+// issueToken: トークンを記録して署名済みトークンを返す
+func (s *AccountService) issueToken(user *models.User, purpose string, ttl time.Duration) (string, error) {
+	tokenID, err := security.NewTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.tokenRepo.Create(&models.AccountToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenID:   tokenID,
+		Email:     user.Email,
+		ExpiresAt: s.now().Add(ttl),
+	}); err != nil {
+		return "", err
+	}
+
+	return security.GenerateAccountToken(purpose, user.ID, user.Email, tokenID, ttl)
+}
+
+// verifyToken: 署名と記録を確認し、未使用・有効期限内のトークンとそのユーザーを返す
+func (s *AccountService) verifyToken(token string, purpose string) (*models.User, *models.AccountToken, error) {
+	claims, err := security.VerifyAccountToken(token, purpose)
+	if err != nil {
+		return nil, nil, ErrInvalidAccountToken
+	}
+
+	stored, err := s.tokenRepo.GetByTokenID(claims.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidAccountToken
+		}
+		return nil, nil, err
+	}
+	if stored.Purpose != purpose || stored.UserID != claims.UserID || stored.UsedAt != nil || !s.now().Before(stored.ExpiresAt) {
+		return nil, nil, ErrInvalidAccountToken
+	}
+
+	user, err := s.userRepo.GetUserByID(stored.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidAccountToken
+		}
+		return nil, nil, err
+	}
+
+	return user, stored, nil
+}
+
+// resendWait: 同じ用途のメールを再送できるまでの残り時間（送信可能な場合は 0）
+func (s *AccountService) resendWait(userID uint, purpose string) (time.Duration, error) {
+	latest, err := s.tokenRepo.GetLatest(userID, purpose)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return max(latest.CreatedAt.Add(s.config.ResendInterval).Sub(s.now()), 0), nil
+}
+
+// link: トークンを含むフロントエンドのURL
+func (s *AccountService) link(path string, token string) string {
+	return s.config.AppBaseURL + path + "?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	auditRepository "simple-ledger/internal/audit/repository"
+	auditService "simple-ledger/internal/audit/service"
+	"simple-ledger/internal/auth/repository"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/mail"
+	"simple-ledger/internal/common/requestmeta"
+	userRepository "simple-ledger/internal/user/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTestAccountService: AuthService と同じDBを使う AccountService を作成
+func setupTestAccountService() (*AuthService, *AccountService, *mail.MemoryOutbox) {
+	db := setupTestAuthDB()
+	authService := newTestAuthService(db, DefaultLoginLimitConfig())
+	outbox := mail.NewMemoryOutbox()
+	accountService := NewAccountService(
+		userRepository.NewUserRepository(db),
+		repository.NewAccountTokenRepository(db),
+		authService.sessionRepo,
+		outbox,
+		auditService.NewAuditService(auditRepository.NewAuditLogRepository(db)),
+		uow.New(db),
+		DefaultAccountConfig(),
+	)
+	return authService, accountService, outbox
+}
+
+// tokenFromMail: メール本文のリンクからトークンを取り出す
+func tokenFromMail(t *testing.T, msg mail.Message) string {
+	_, rest, found := strings.Cut(msg.Body, "?token=")
+	require.True(t, found, "mail body should contain a token link")
+	token, err := url.QueryUnescape(strings.Fields(rest)[0])
+	require.NoError(t, err)
+	return token
+}
+
+func TestResetPassword(t *testing.T) {
+	authService, accountService, outbox := setupTestAccountService()
+	user := createTestLoginUser(t, authService)
+	ctx := context.Background()
+	_, refreshToken, _ := loginTokens(authService.Login(ctx, "test@example.com", "password123", requestmeta.Meta{}))
+
+	// 登録されていないメールアドレスには送信しない
+	require.NoError(t, accountService.RequestPasswordReset(ctx, "unknown@example.com"))
+	assert.Empty(t, outbox.Messages())
+
+	require.NoError(t, accountService.RequestPasswordReset(ctx, "test@example.com"))
+	require.Len(t, outbox.Messages(), 1)
+	msg := outbox.Messages()[0]
+	assert.Equal(t, "test@example.com", msg.To)
+	assert.Contains(t, msg.Body, "http://localhost:3000/reset-password?token=")
+
+	token := tokenFromMail(t, msg)
+	require.NoError(t, accountService.ResetPassword(token, "newpassword456", requestmeta.Meta{}))
+
+	// 一度だけ使用できる
+	assert.ErrorIs(t, accountService.ResetPassword(token, "otherpassword789", requestmeta.Meta{}), ErrInvalidAccountToken)
+
+	// 既存のセッションは失効し、新しいパスワードでログインできる
+	_, _, err := authService.RefreshAccessToken(refreshToken)
+	assert.Error(t, err)
+	_, _, err = loginTokens(authService.Login(ctx, "test@example.com", "password123", requestmeta.Meta{}))
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, _, err = loginTokens(authService.Login(ctx, "test@example.com", "newpassword456", requestmeta.Meta{}))
+	assert.NoError(t, err)
+
+	// メールを受け取れたためメールアドレスも確認済みになる
+	updated, _ := authService.userRepo.GetUserByID(user.ID)
+	assert.NotNil(t, updated.EmailVerifiedAt)
+}
+
+func TestResetPassword_InvalidTokens(t *testing.T) {
+	authService, accountService, outbox := setupTestAccountService()
+	createTestLoginUser(t, authService)
+	ctx := context.Background()
+
+	require.NoError(t, accountService.RequestPasswordReset(ctx, "test@example.com"))
+	token := tokenFromMail(t, outbox.Messages()[0])
+
+	// 再送間隔内は新しいメールを送信しない
+	require.NoError(t, accountService.RequestPasswordReset(ctx, "test@example.com"))
+	assert.Len(t, outbox.Messages(), 1)
+
+	// 他の用途のトークンや改ざんされたトークンは使えない
+	assert.ErrorIs(t, accountService.VerifyEmail(token, requestmeta.Meta{}), ErrInvalidAccountToken)
+	assert.ErrorIs(t, accountService.ResetPassword(token+"x", "newpassword456", requestmeta.Meta{}), ErrInvalidAccountToken)
+
+	// 有効期限切れ
+	accountService.now = func() time.Time { return time.Now().Add(DefaultAccountConfig().PasswordResetTTL + time.Minute) }
+	assert.ErrorIs(t, accountService.ResetPassword(token, "newpassword456", requestmeta.Meta{}), ErrInvalidAccountToken)
+}
+
+func TestChangePassword_KeepsCurrentSession(t *testing.T) {
+	authService, accountService, _ := setupTestAccountService()
+	user := createTestLoginUser(t, authService)
+	ctx := context.Background()
+
+	_, currentToken, _ := loginTokens(authService.Login(ctx, "test@example.com", "password123", requestmeta.Meta{}))
+	_, otherToken, _ := loginTokens(authService.Login(ctx, "test@example.com", "password123", requestmeta.Meta{}))
+
+	err := accountService.ChangePassword(user.ID, "wrongpassword", "newpassword456", currentToken, requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrInvalidCurrentPassword)
+	err = accountService.ChangePassword(user.ID, "password123", "password123", currentToken, requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrSamePassword)
+
+	require.NoError(t, accountService.ChangePassword(user.ID, "password123", "newpassword456", currentToken, requestmeta.Meta{ActorID: &user.ID}))
+
+	_, _, err = authService.RefreshAccessToken(currentToken)
+	assert.NoError(t, err)
+	_, _, err = authService.RefreshAccessToken(otherToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestVerifyEmail(t *testing.T) {
+	authService, accountService, outbox := setupTestAccountService()
+	user := createTestLoginUser(t, authService)
+	ctx := context.Background()
+
+	require.NoError(t, accountService.SendEmailVerification(ctx, user.ID))
+	token := tokenFromMail(t, outbox.Messages()[0])
+
+	var tooSoon *ResendTooSoonError
+	assert.ErrorAs(t, accountService.SendEmailVerification(ctx, user.ID), &tooSoon)
+
+	require.NoError(t, accountService.VerifyEmail(token, requestmeta.Meta{}))
+	updated, _ := authService.userRepo.GetUserByID(user.ID)
+	assert.NotNil(t, updated.EmailVerifiedAt)
+
+	assert.ErrorIs(t, accountService.VerifyEmail(token, requestmeta.Meta{}), ErrInvalidAccountToken)
+	assert.ErrorIs(t, accountService.SendEmailVerification(ctx, user.ID), ErrEmailAlreadyVerified)
+}
+
+func TestVerifyEmail_RejectsTokenForPreviousAddress(t *testing.T) {
+	authService, accountService, outbox := setupTestAccountService()
+	user := createTestLoginUser(t, authService)
+
+	require.NoError(t, accountService.SendEmailVerification(context.Background(), user.ID))
+	token := tokenFromMail(t, outbox.Messages()[0])
+
+	_, err := authService.userRepo.UpdateUser(user.ID, map[string]interface{}{"email": "changed@example.com"})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, accountService.VerifyEmail(token, requestmeta.Meta{}), ErrInvalidAccountToken)
+	updated, _ := authService.userRepo.GetUserByID(user.ID)
+	assert.Nil(t, updated.EmailVerifiedAt)
+}
@@ -1,65 +1,323 @@
 package service
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	auditService "simple-ledger/internal/audit/service"
+	"simple-ledger/internal/auth/dto"
+	"simple-ledger/internal/auth/repository"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/requestmeta"
 	"simple-ledger/internal/common/security"
-	"simple-ledger/internal/user/repository"
+	"simple-ledger/internal/models"
+	userRepository "simple-ledger/internal/user/repository"
 
 	"gorm.io/gorm"
 )
 
+// セッション失効理由
+const (
+	RevokedReasonLogout        = "logout"
+	RevokedReasonLogoutAll     = "logout_all"
+	RevokedReasonReuseDetected = "reuse_detected"
+)
+
+var (
+	ErrInvalidCredentials  = errors.New("invalid email or password")
+	ErrUserInactive        = errors.New("user account is inactive")
+	ErrUserLocked          = errors.New("user account is locked")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrUserNotFound        = errors.New("user not found")
+
+	ErrPasswordLoginDisabled = errors.New("password login is disabled for this account, use single sign-on")
+)
+
 type AuthService struct {
-	userRepo *repository.UserRepository
+	userRepo         *userRepository.UserRepository
+	sessionRepo      *repository.SessionRepository
+	loginHistoryRepo *repository.LoginHistoryRepository
+	guard            *LoginGuard
+	mfa              *MFAService
+	audit            *auditService.AuditService
+	uow              uow.UnitOfWork
 }
 
-func NewAuthService(userRepo *repository.UserRepository) *AuthService {
-	return &AuthService{userRepo: userRepo}
+func NewAuthService(
+	userRepo *userRepository.UserRepository,
+	sessionRepo *repository.SessionRepository,
+	loginHistoryRepo *repository.LoginHistoryRepository,
+	guard *LoginGuard,
+	mfa *MFAService,
+	audit *auditService.AuditService,
+	unitOfWork uow.UnitOfWork,
+) *AuthService {
+	return &AuthService{
+		userRepo:         userRepo,
+		sessionRepo:      sessionRepo,
+		loginHistoryRepo: loginHistoryRepo,
+		guard:            guard,
+		mfa:              mfa,
+		audit:            audit,
+		uow:              unitOfWork,
+	}
+}
+
+// LoginResult: ログイン結果
+// 二段階認証が必要な場合はトークンを発行せず、ChallengeToken のみを設定する
+type LoginResult struct {
+	AccessToken  string
+	RefreshToken string
+
+	ChallengeToken     string
+	EnrollmentRequired bool // ロールで必須のため、2段階目の前に登録が必要
+
+	// RecoveryCodes: 2段階目で二段階認証の登録を完了した場合のリカバリーコード
+	RecoveryCodes []string
+}
+
+// MFARequired は2段階目が必要かどうかを返す
+func (r *LoginResult) MFARequired() bool {
+	return r.ChallengeToken != ""
 }
 
 // Login はログイン処理を実行
-// メールアドレスとパスワードでユーザーを認証し、JWT トークンを返す
-func (s *AuthService) Login(email string, password string) (accessToken string, refreshToken string, err error) {
+// メールアドレスとパスワードでユーザーを認証し、新しいセッションを作成して JWT トークンを返す
+// 二段階認証が有効な（またはロールで必須の）ユーザーにはトークンの代わりにチャレンジトークンを返す
+// 成功・失敗にかかわらずログイン履歴を記録する
+// 失敗が続く場合は IP アドレス・アカウントごとに待機時間を設け、上限に達したアカウントは一時的にロックする
+func (s *AuthService) Login(ctx context.Context, email string, password string, meta requestmeta.Meta) (*LoginResult, error) {
+	// 待機中の試行は認証処理を行わずに拒否する（大量の試行で履歴が埋まらないよう記録もしない）
+	if err := s.guard.Check(ctx, meta.IPAddress, email); err != nil {
+		return nil, err
+	}
+
 	// メールアドレスでユーザーを検索
 	user, err := s.userRepo.GetUserByEmail(email)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return "", "", fmt.Errorf("invalid email or password")
+			return nil, s.loginFailed(ctx, nil, email, models.LoginFailureInvalidCredentials, meta, ErrInvalidCredentials)
 		}
-		return "", "", err
+		return nil, err
 	}
 
-	// ユーザーが有効か確認
-	if !user.IsActive {
-		return "", "", fmt.Errorf("user account is inactive")
+	if err := s.checkLoginAllowed(ctx, user, email, meta); err != nil {
+		return nil, err
 	}
 
 	// パスワード検証
 	if !security.VerifyPassword(user.Password, &password) {
-		return "", "", fmt.Errorf("invalid email or password")
+		return nil, s.loginFailed(ctx, user, email, models.LoginFailureInvalidCredentials, meta, ErrInvalidCredentials)
 	}
+	s.rehashPassword(user, password)
 
-	// アクセストークンを生成
-	accessToken, err = security.GenerateToken(user.ID, user.Email, user.Role, user.IsActive)
+	// シングルサインオンのみ許可されたユーザー（パスワードが正しい場合のみ知らせる）
+	if user.PasswordLoginDisabled {
+		if err := s.recordLoginFailure(&user.ID, email, models.LoginFailurePasswordLoginDisabled, meta); err != nil {
+			return nil, err
+		}
+		return nil, ErrPasswordLoginDisabled
+	}
+
+	// 二段階認証が必要な場合、失敗回数は2段階目の成功時に消去する
+	purpose, err := s.mfa.ChallengePurpose(user)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
+	if purpose != "" {
+		return mfaChallenge(user.ID, purpose)
+	}
+
+	return s.startSession(ctx, user, email, meta)
+}
 
-	// リフレッシュトークンを生成
-	refreshToken, err = security.GenerateRefreshToken(user.ID, user.Email, user.Role, user.IsActive)
+// rehashPassword: 以前の方式（bcrypt）や古いパラメーターのハッシュを、平文のパスワードがわかるログイン時に作り直す
+// パスワード自体は変わらないため、トークンバージョンは変更しない（失敗してもログインは続ける）
+func (s *AuthService) rehashPassword(user *models.User, password string) {
+	if !security.PasswordNeedsRehash(user.Password) {
+		return
+	}
+
+	hashedPassword, err := security.HashPassword(password)
+	if err == nil {
+		_, err = s.userRepo.UpdateUser(user.ID, map[string]interface{}{"password": hashedPassword})
+	}
 	if err != nil {
-		return "", "", err
+		log.Printf("failed to rehash password for user %d: %v", user.ID, err)
+	}
+}
+
+// CompleteMFALogin はログインの2段階目として TOTP コードまたはリカバリーコードを検証し、トークンを発行する
+// 登録が必要なチャレンジの場合は BeginMFAEnrollment で登録したシークレットのコードで有効化し、リカバリーコードも返す
+// コードの誤りはパスワードの誤りと同様に失敗回数に数える
+func (s *AuthService) CompleteMFALogin(ctx context.Context, challengeToken string, code string, recoveryCode string, meta requestmeta.Meta) (*LoginResult, error) {
+	userID, purpose, err := verifyMFAChallenge(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidMFAChallenge
+		}
+		return nil, err
+	}
+
+	if err := s.guard.Check(ctx, meta.IPAddress, user.Email); err != nil {
+		return nil, err
+	}
+	// チャレンジ発行後にロック・無効化された場合
+	if err := s.checkLoginAllowed(ctx, user, user.Email, meta); err != nil {
+		return nil, err
+	}
+
+	var recoveryCodes []string
+	switch purpose {
+	case MFAChallengeEnroll:
+		recoveryCodes, err = s.mfa.ConfirmEnrollment(user.ID, code, meta)
+	default:
+		err = s.mfa.VerifySecondFactor(user.ID, code, recoveryCode)
+	}
+	if errors.Is(err, ErrInvalidMFACode) {
+		return nil, s.loginFailed(ctx, user, user.Email, models.LoginFailureInvalidMFACode, meta, ErrInvalidMFACode)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return accessToken, refreshToken, nil
+	result, err := s.startSession(ctx, user, user.Email, meta)
+	if err != nil {
+		return nil, err
+	}
+	result.RecoveryCodes = recoveryCodes
+	return result, nil
+}
+
+// BeginMFAEnrollment はロールで二段階認証が必須のユーザーが、ログイン途中に登録を開始する
+func (s *AuthService) BeginMFAEnrollment(challengeToken string) (*dto.MFAEnrollmentResponse, error) {
+	userID, purpose, err := verifyMFAChallenge(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if purpose != MFAChallengeEnroll {
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidMFAChallenge
+		}
+		return nil, err
+	}
+
+	return s.mfa.BeginEnrollment(user.ID, user.Email)
+}
+
+// checkLoginAllowed: ロック中・無効化されたユーザーのログインを拒否する
+func (s *AuthService) checkLoginAllowed(ctx context.Context, user *models.User, email string, meta requestmeta.Meta) error {
+	// ロック中のアカウントはパスワードを検証しない
+	now := time.Now()
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		if err := s.recordLoginFailure(&user.ID, email, models.LoginFailureAccountLocked, meta); err != nil {
+			return err
+		}
+		return &TooManyAttemptsError{RetryAfter: user.LockedUntil.Sub(now)}
+	}
+
+	// ユーザーが有効か確認
+	if !user.IsActive {
+		return s.loginFailed(ctx, user, email, models.LoginFailureUserInactive, meta, ErrUserInactive)
+	}
+	return nil
+}
+
+// startSession: 認証に成功したユーザーの新しいセッションを作成してトークンを発行
+func (s *AuthService) startSession(ctx context.Context, user *models.User, email string, meta requestmeta.Meta) (*LoginResult, error) {
+	if err := s.guard.ResetAccount(ctx, email); err != nil {
+		return nil, err
+	}
+
+	familyID, err := security.NewTokenID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var refreshToken string
+
+	// セッション・最初のリフレッシュトークン・最終ログイン日時・ログイン履歴を記録
+	err = s.uow.Do(func(tx *gorm.DB) error {
+		sessionRepo := s.sessionRepo.WithTx(tx)
+
+		session := &models.AuthSession{
+			UserID:       user.ID,
+			FamilyID:     familyID,
+			UserAgent:    truncate(meta.UserAgent, 512),
+			IPAddress:    meta.IPAddress,
+			TokenVersion: user.TokenVersion,
+			LastUsedAt:   now,
+		}
+		if err := sessionRepo.CreateSession(session); err != nil {
+			return err
+		}
+
+		refreshToken, err = issueRefreshToken(sessionRepo, user.ID, session, now)
+		if err != nil {
+			return err
+		}
+
+		if _, err := s.userRepo.WithTx(tx).UpdateUser(user.ID, map[string]interface{}{"last_login_at": now, "locked_until": nil}); err != nil {
+			return err
+		}
+
+		return s.loginHistoryRepo.WithTx(tx).Create(&models.LoginHistory{
+			UserID:    &user.ID,
+			Email:     truncate(email, 255),
+			Success:   true,
+			SessionID: &session.ID,
+			IPAddress: meta.IPAddress,
+			UserAgent: truncate(meta.UserAgent, 512),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// アクセストークンを生成
+	accessToken, err := security.GenerateToken(user.ID, user.Email, user.Role, user.IsActive, user.TokenVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{AccessToken: accessToken, RefreshToken: refreshToken}, nil
 }
 
 // RefreshAccessToken はアクセストークンとリフレッシュトークンを更新
-// スライディングウィンドウ方式で、アクティブユーザーのセッションを自動延長する
+// 使用したリフレッシュトークンは交換済みとなり、再度提示された場合はセッション全体を失効させる
 func (s *AuthService) RefreshAccessToken(refreshToken string) (accessToken string, newRefreshToken string, err error) {
-	// リフレッシュトークンを検証
-	claims, err := security.VerifyToken(refreshToken)
+	// リフレッシュトークンを検証（アクセストークンは typ クレームで拒否される）
+	claims, err := security.VerifyRefreshToken(refreshToken)
+	if err != nil {
+		return "", "", ErrInvalidRefreshToken
+	}
+
+	session, stored, err := s.findRefreshToken(claims)
 	if err != nil {
-		return "", "", fmt.Errorf("invalid refresh token")
+		return "", "", err
+	}
+
+	if session.RevokedAt != nil {
+		return "", "", ErrInvalidRefreshToken
+	}
+
+	// 交換済みのトークンが再提示された場合は漏洩とみなし、ファミリー全体を失効させる
+	if stored.RotatedAt != nil {
+		return "", "", s.revokeOnReuse(session)
 	}
 
 	// ユーザーを取得（最新情報を確認）
@@ -70,20 +328,256 @@ func (s *AuthService) RefreshAccessToken(refreshToken string) (accessToken strin
 
 	// ユーザーが有効か確認
 	if !user.IsActive {
-		return "", "", fmt.Errorf("user account is inactive")
+		return "", "", ErrUserInactive
 	}
 
-	// 新しいアクセストークンを生成
-	accessToken, err = security.GenerateToken(user.ID, user.Email, user.Role, user.IsActive)
+	// ロック中のアカウントにはアクセストークンを再発行しない
+	now := time.Now()
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		return "", "", ErrUserLocked
+	}
+
+	// ログイン後にロール・パスワード・有効/無効が変更されたセッションは使えない
+	if session.TokenVersion != user.TokenVersion {
+		return "", "", ErrInvalidRefreshToken
+	}
+
+	// 古いトークンを交換済みにし、同じファミリーで新しいリフレッシュトークンを発行
+	err = s.uow.Do(func(tx *gorm.DB) error {
+		sessionRepo := s.sessionRepo.WithTx(tx)
+
+		rotated, err := sessionRepo.MarkRotated(stored.ID, now)
+		if err != nil {
+			return err
+		}
+		if !rotated {
+			return ErrRefreshTokenReused
+		}
+
+		if err := sessionRepo.TouchSession(session.ID, now); err != nil {
+			return err
+		}
+
+		newRefreshToken, err = issueRefreshToken(sessionRepo, user.ID, session, now)
+		return err
+	})
+	if errors.Is(err, ErrRefreshTokenReused) {
+		// 同じトークンが同時に使われた（チェック後に他のリクエストが交換した）場合
+		return "", "", s.revokeOnReuse(session)
+	}
 	if err != nil {
 		return "", "", err
 	}
 
-	// 新しいリフレッシュトークンを生成（スライディングウィンドウ）
-	newRefreshToken, err = security.GenerateRefreshToken(user.ID, user.Email, user.Role, user.IsActive)
+	// 新しいアクセストークンを生成
+	accessToken, err = security.GenerateToken(user.ID, user.Email, user.Role, user.IsActive, user.TokenVersion)
 	if err != nil {
 		return "", "", err
 	}
 
 	return accessToken, newRefreshToken, nil
 }
+
+// Logout はリフレッシュトークンが属するセッションを失効させる
+// トークンが無効・期限切れの場合は失効対象がないため何もしない
+func (s *AuthService) Logout(refreshToken string) error {
+	claims, err := security.VerifyRefreshToken(refreshToken)
+	if err != nil {
+		return nil
+	}
+
+	session, err := s.sessionRepo.GetSessionByFamilyID(claims.SessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if session.UserID != claims.UserID {
+		return nil
+	}
+
+	return s.sessionRepo.RevokeSession(session.ID, RevokedReasonLogout, time.Now())
+}
+
+// LogoutAll はユーザーのすべてのセッションを失効させ、失効させた件数を返す
+// 発行済みのアクセストークンは有効期限まで利用できる
+func (s *AuthService) LogoutAll(userID uint) (int64, error) {
+	return s.sessionRepo.RevokeAllSessions(userID, RevokedReasonLogoutAll, time.Now())
+}
+
+// UnlockUser はログイン失敗によるアカウントロックを解除する（管理者用）
+func (s *AuthService) UnlockUser(ctx context.Context, userID uint, meta requestmeta.Meta) error {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	if user.LockedUntil != nil {
+		err = s.uow.Do(func(tx *gorm.DB) error {
+			if _, err := s.userRepo.WithTx(tx).UpdateUser(user.ID, map[string]interface{}{"locked_until": nil}); err != nil {
+				return err
+			}
+
+			return s.audit.WithTx(tx).Record(meta, auditService.Entry{
+				Action:     models.AuditActionUnlock,
+				EntityType: models.AuditEntityUser,
+				EntityID:   user.ID,
+				Before:     lockSnapshot{LockedUntil: user.LockedUntil},
+				After:      lockSnapshot{},
+			})
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// ロック前の失敗回数が残っているとすぐに再ロックされるため消去する
+	return s.guard.ResetAccount(ctx, user.Email)
+}
+
+// lockSnapshot: アカウントロックの監査ログに記録する状態
+type lockSnapshot struct {
+	LockedUntil    *time.Time `json:"lockedUntil"`
+	FailedAttempts int64      `json:"failedAttempts,omitempty"`
+}
+
+// loginFailed: ログイン失敗を記録し、失敗回数が上限に達したアカウントをロックして、呼び出し元に返すエラーを返す
+// user は存在しないメールアドレスの場合 nil（待機時間は同様に設けるが、ロック対象のアカウントはない）
+func (s *AuthService) loginFailed(ctx context.Context, user *models.User, email string, reason string, meta requestmeta.Meta, loginErr error) error {
+	var userID *uint
+	if user != nil {
+		userID = &user.ID
+	}
+	if err := s.recordLoginFailure(userID, email, reason, meta); err != nil {
+		return err
+	}
+
+	failures, err := s.guard.Fail(ctx, meta.IPAddress, email)
+	if err != nil {
+		return err
+	}
+	if user == nil || !s.guard.ShouldLock(failures) {
+		return loginErr
+	}
+
+	if err := s.lockUser(user, email, failures, meta); err != nil {
+		return err
+	}
+	// ロック期間中は locked_until で拒否するため、失敗回数は消去して解除後に数え直す
+	if err := s.guard.ResetAccount(ctx, email); err != nil {
+		return err
+	}
+	return loginErr
+}
+
+// lockUser: アカウントをロックし、ログイン履歴と監査ログに記録
+func (s *AuthService) lockUser(user *models.User, email string, failures int64, meta requestmeta.Meta) error {
+	lockedUntil := time.Now().Add(s.guard.LockoutDuration())
+
+	return s.uow.Do(func(tx *gorm.DB) error {
+		if _, err := s.userRepo.WithTx(tx).UpdateUser(user.ID, map[string]interface{}{"locked_until": lockedUntil}); err != nil {
+			return err
+		}
+
+		if err := s.loginHistoryRepo.WithTx(tx).Create(&models.LoginHistory{
+			UserID:        &user.ID,
+			Email:         truncate(email, 255),
+			Success:       false,
+			FailureReason: models.LoginFailureLockedOut,
+			IPAddress:     meta.IPAddress,
+			UserAgent:     truncate(meta.UserAgent, 512),
+		}); err != nil {
+			return err
+		}
+
+		// 未認証の操作のため ActorID は nil（操作元はIPアドレスで記録される）
+		return s.audit.WithTx(tx).Record(meta, auditService.Entry{
+			Action:     models.AuditActionLock,
+			EntityType: models.AuditEntityUser,
+			EntityID:   user.ID,
+			Before:     lockSnapshot{LockedUntil: user.LockedUntil},
+			After:      lockSnapshot{LockedUntil: &lockedUntil, FailedAttempts: failures},
+		})
+	})
+}
+
+// recordLoginFailure: ログイン失敗を履歴に記録
+func (s *AuthService) recordLoginFailure(userID *uint, email string, reason string, meta requestmeta.Meta) error {
+	return s.loginHistoryRepo.Create(&models.LoginHistory{
+		UserID:        userID,
+		Email:         truncate(email, 255),
+		Success:       false,
+		FailureReason: reason,
+		IPAddress:     meta.IPAddress,
+		UserAgent:     truncate(meta.UserAgent, 512),
+	})
+}
+
+// findRefreshToken: クレームに対応するセッションとリフレッシュトークンを取得
+func (s *AuthService) findRefreshToken(claims *security.CustomClaims) (*models.AuthSession, *models.RefreshToken, error) {
+	session, err := s.sessionRepo.GetSessionByFamilyID(claims.SessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidRefreshToken
+		}
+		return nil, nil, err
+	}
+	if session.UserID != claims.UserID {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	stored, err := s.sessionRepo.GetRefreshTokenByTokenID(claims.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidRefreshToken
+		}
+		return nil, nil, err
+	}
+	if stored.SessionID != session.ID {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	return session, stored, nil
+}
+
+// revokeOnReuse: リフレッシュトークンの再利用を検知したセッションを失効させる
+func (s *AuthService) revokeOnReuse(session *models.AuthSession) error {
+	if err := s.sessionRepo.RevokeSession(session.ID, RevokedReasonReuseDetected, time.Now()); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// issueRefreshToken: セッションに新しいリフレッシュトークンを記録して署名済みトークンを返す
+func issueRefreshToken(sessionRepo *repository.SessionRepository, userID uint, session *models.AuthSession, now time.Time) (string, error) {
+	tokenID, err := security.NewTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	expiresIn := time.Duration(security.GetRefreshTokenExpirationSeconds()) * time.Second
+	if err := sessionRepo.CreateRefreshToken(&models.RefreshToken{
+		SessionID: session.ID,
+		TokenID:   tokenID,
+		ExpiresAt: now.Add(expiresIn),
+	}); err != nil {
+		return "", err
+	}
+
+	return security.GenerateRefreshToken(userID, session.FamilyID, tokenID)
+}
+
+// truncate: 文字列を最大バイト数で切り詰める（UTF-8 の途中では切らない）
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && (s[max]&0xC0) == 0x80 {
+		max--
+	}
+	return s[:max]
+}
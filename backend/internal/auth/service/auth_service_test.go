@@ -1,23 +1,69 @@
 package service
 
 import (
+	"context"
+	"strings"
 	"testing"
-
+	"time"
+
+	auditDto "simple-ledger/internal/audit/dto"
+	auditRepository "simple-ledger/internal/audit/repository"
+	auditService "simple-ledger/internal/audit/service"
+	"simple-ledger/internal/auth/repository"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/ratelimit"
+	"simple-ledger/internal/common/requestmeta"
 	"simple-ledger/internal/common/security"
 	"simple-ledger/internal/models"
-	"simple-ledger/internal/user/repository"
+	userRepository "simple-ledger/internal/user/repository"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
 func setupTestAuthService() *AuthService {
+	return setupTestAuthServiceWithLimits(DefaultLoginLimitConfig())
+}
+
+// setupTestAuthServiceWithLimits: ログイン試行制限の設定を指定して AuthService を作成
+func setupTestAuthServiceWithLimits(cfg LoginLimitConfig) *AuthService {
+	return newTestAuthService(setupTestAuthDB(), cfg)
+}
+
+// setupTestAuthDB: 認証関連のテーブルを作成したテスト用DB
+func setupTestAuthDB() *gorm.DB {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	_ = db.AutoMigrate(&models.User{})
-	userRepo := repository.NewUserRepository(db)
+	_ = db.AutoMigrate(
+		&models.User{}, &models.AuthSession{}, &models.RefreshToken{}, &models.LoginHistory{}, &models.AuditLog{}, &models.AuditChainHead{},
+		&models.UserTOTP{}, &models.MFARecoveryCode{}, &models.RoleMFAPolicy{}, &models.AccountToken{}, &models.PersonalAccessToken{}, &models.UserIdentity{},
+	)
 	security.InitJWT("test-secret", 1.0, 1.0)
-	return NewAuthService(userRepo)
+	return db
+}
+
+func newTestAuthService(db *gorm.DB, cfg LoginLimitConfig) *AuthService {
+	userRepo := userRepository.NewUserRepository(db)
+	audit := auditService.NewAuditService(auditRepository.NewAuditLogRepository(db))
+	unitOfWork := uow.New(db)
+	return NewAuthService(
+		userRepo,
+		repository.NewSessionRepository(db),
+		repository.NewLoginHistoryRepository(db),
+		NewLoginGuard(ratelimit.NewMemoryStore(), cfg),
+		NewMFAService(repository.NewMFARepository(db), userRepo, audit, unitOfWork, MFAConfig{Issuer: "Simple Ledger"}),
+		audit,
+		unitOfWork,
+	)
+}
+
+// loginTokens: Login の結果をアクセストークン・リフレッシュトークンに展開
+func loginTokens(result *LoginResult, err error) (string, string, error) {
+	if err != nil {
+		return "", "", err
+	}
+	return result.AccessToken, result.RefreshToken, nil
 }
 
 func TestLogin_Success(t *testing.T) {
@@ -35,17 +81,44 @@ func TestLogin_Success(t *testing.T) {
 	_ = service.userRepo.CreateUser(user)
 
 	// ログイン処理
-	accessToken, refreshToken, err := service.Login("test@example.com", "password123")
+	accessToken, refreshToken, err := loginTokens(service.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{}))
 
 	assert.NoError(t, err)
 	assert.NotEmpty(t, accessToken)
 	assert.NotEmpty(t, refreshToken)
 }
 
+func TestLogin_RehashesLegacyPassword(t *testing.T) {
+	service := setupTestAuthService()
+
+	// 以前の bcrypt でハッシュ化したユーザー
+	legacyHash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	user := &models.User{
+		Email:    "test@example.com",
+		Name:     "Test User",
+		Password: string(legacyHash),
+		Role:     "user",
+		IsActive: true,
+	}
+	_ = service.userRepo.CreateUser(user)
+
+	_, err := service.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{})
+	assert.NoError(t, err)
+
+	// ログインに成功すると argon2id で作り直される（トークンバージョンは変わらない）
+	updated, _ := service.userRepo.GetUserByID(user.ID)
+	assert.True(t, strings.HasPrefix(updated.Password, "$argon2id$"))
+	assert.False(t, security.PasswordNeedsRehash(updated.Password))
+	assert.Equal(t, uint(0), updated.TokenVersion)
+
+	_, err = service.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{})
+	assert.NoError(t, err)
+}
+
 func TestLogin_InvalidEmail(t *testing.T) {
 	service := setupTestAuthService()
 
-	accessToken, refreshToken, err := service.Login("nonexistent@example.com", "password123")
+	accessToken, refreshToken, err := loginTokens(service.Login(context.Background(), "nonexistent@example.com", "password123", requestmeta.Meta{}))
 
 	assert.Error(t, err)
 	assert.Empty(t, accessToken)
@@ -67,7 +140,7 @@ func TestLogin_InvalidPassword(t *testing.T) {
 	_ = service.userRepo.CreateUser(user)
 
 	// 不正なパスワードでログイン
-	accessToken, refreshToken, err := service.Login("test@example.com", "wrongpassword")
+	accessToken, refreshToken, err := loginTokens(service.Login(context.Background(), "test@example.com", "wrongpassword", requestmeta.Meta{}))
 
 	assert.Error(t, err)
 	assert.Empty(t, accessToken)
@@ -95,7 +168,7 @@ func TestLogin_InactiveUser(t *testing.T) {
 	_, _ = service.userRepo.UpdateUser(user.ID, map[string]interface{}{"is_active": false})
 
 	// ログイン処理
-	accessToken, refreshToken, err := service.Login("inactive@example.com", "password123")
+	accessToken, refreshToken, err := loginTokens(service.Login(context.Background(), "inactive@example.com", "password123", requestmeta.Meta{}))
 
 	assert.Error(t, err, "Should return error for inactive user")
 	assert.Empty(t, accessToken)
@@ -120,7 +193,7 @@ func TestRefreshAccessToken_Success(t *testing.T) {
 	}
 
 	// ログイン
-	_, refreshToken, _ := service.Login("test@example.com", "password123")
+	_, refreshToken, _ := loginTokens(service.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{}))
 
 	// トークン更新
 	newAccessToken, newRefreshToken, err := service.RefreshAccessToken(refreshToken)
@@ -157,7 +230,7 @@ func TestRefreshAccessToken_InactiveUser(t *testing.T) {
 	_ = service.userRepo.CreateUser(user)
 
 	// ログイン
-	_, refreshToken, _ := service.Login("test@example.com", "password123")
+	_, refreshToken, _ := loginTokens(service.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{}))
 
 	// ユーザーを無効化
 	user.IsActive = false
@@ -170,3 +243,309 @@ func TestRefreshAccessToken_InactiveUser(t *testing.T) {
 	assert.Empty(t, newAccessToken)
 	assert.Empty(t, newRefreshToken)
 }
+
+// createTestLoginUser: ログイン可能なテストユーザーを作成
+func createTestLoginUser(t *testing.T, service *AuthService) *models.User {
+	hashedPassword, _ := security.HashPassword("password123")
+	user := &models.User{
+		Email:    "test@example.com",
+		Name:     "Test User",
+		Password: hashedPassword,
+		Role:     "user",
+		IsActive: true,
+	}
+	if err := service.userRepo.CreateUser(user); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	return user
+}
+
+func TestLogin_CreatesSession(t *testing.T) {
+	service := setupTestAuthService()
+	createTestLoginUser(t, service)
+
+	_, refreshToken, err := loginTokens(service.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{UserAgent: "test-agent", IPAddress: "192.0.2.1"}))
+	assert.NoError(t, err)
+
+	claims, err := security.VerifyRefreshToken(refreshToken)
+	assert.NoError(t, err)
+
+	session, err := service.sessionRepo.GetSessionByFamilyID(claims.SessionID)
+	assert.NoError(t, err)
+	assert.Equal(t, "test-agent", session.UserAgent)
+	assert.Equal(t, "192.0.2.1", session.IPAddress)
+	assert.Nil(t, session.RevokedAt)
+}
+
+func TestRefreshAccessToken_RejectsAccessToken(t *testing.T) {
+	service := setupTestAuthService()
+	createTestLoginUser(t, service)
+
+	accessToken, _, _ := loginTokens(service.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{}))
+
+	_, _, err := service.RefreshAccessToken(accessToken)
+
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestRefreshAccessToken_RejectsLockedUser(t *testing.T) {
+	service := setupTestAuthService()
+	user := createTestLoginUser(t, service)
+
+	_, refreshToken, _ := loginTokens(service.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{}))
+
+	_, err := service.userRepo.UpdateUser(user.ID, map[string]interface{}{"locked_until": time.Now().Add(time.Hour)})
+	assert.NoError(t, err)
+
+	_, _, err = service.RefreshAccessToken(refreshToken)
+
+	assert.ErrorIs(t, err, ErrUserLocked)
+}
+
+func TestRefreshAccessToken_RejectsTokenVersionMismatch(t *testing.T) {
+	service := setupTestAuthService()
+	user := createTestLoginUser(t, service)
+
+	_, refreshToken, _ := loginTokens(service.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{}))
+
+	// ロール・パスワード・有効/無効の変更でトークンバージョンが上がった場合
+	updates := map[string]interface{}{"role": "admin"}
+	userRepository.IncrementTokenVersion(updates)
+	_, err := service.userRepo.UpdateUser(user.ID, updates)
+	assert.NoError(t, err)
+
+	_, _, err = service.RefreshAccessToken(refreshToken)
+
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestRefreshAccessToken_RotatesToken(t *testing.T) {
+	service := setupTestAuthService()
+	createTestLoginUser(t, service)
+
+	_, refreshToken, _ := loginTokens(service.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{}))
+
+	_, rotatedToken, err := service.RefreshAccessToken(refreshToken)
+	assert.NoError(t, err)
+	assert.NotEqual(t, refreshToken, rotatedToken)
+
+	// 同じファミリーで新しい jti が発行される
+	oldClaims, _ := security.VerifyRefreshToken(refreshToken)
+	newClaims, _ := security.VerifyRefreshToken(rotatedToken)
+	assert.Equal(t, oldClaims.SessionID, newClaims.SessionID)
+	assert.NotEqual(t, oldClaims.ID, newClaims.ID)
+
+	// 新しいトークンで再度更新できる
+	_, _, err = service.RefreshAccessToken(rotatedToken)
+	assert.NoError(t, err)
+}
+
+func TestRefreshAccessToken_ReuseRevokesFamily(t *testing.T) {
+	service := setupTestAuthService()
+	createTestLoginUser(t, service)
+
+	_, refreshToken, _ := loginTokens(service.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{}))
+	_, rotatedToken, err := service.RefreshAccessToken(refreshToken)
+	assert.NoError(t, err)
+
+	// 交換済みのトークンを再提示すると再利用として検知される
+	_, _, err = service.RefreshAccessToken(refreshToken)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	// 正規の利用者が持つ最新のトークンも失効する
+	_, _, err = service.RefreshAccessToken(rotatedToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	claims, _ := security.VerifyRefreshToken(refreshToken)
+	session, _ := service.sessionRepo.GetSessionByFamilyID(claims.SessionID)
+	assert.NotNil(t, session.RevokedAt)
+	assert.Equal(t, RevokedReasonReuseDetected, session.RevokedReason)
+}
+
+func TestLogout_RevokesSession(t *testing.T) {
+	service := setupTestAuthService()
+	createTestLoginUser(t, service)
+
+	_, refreshToken, _ := loginTokens(service.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{}))
+	_, otherRefreshToken, _ := loginTokens(service.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{}))
+
+	assert.NoError(t, service.Logout(refreshToken))
+
+	_, _, err := service.RefreshAccessToken(refreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	// 他のセッションには影響しない
+	_, _, err = service.RefreshAccessToken(otherRefreshToken)
+	assert.NoError(t, err)
+
+	// 不正なトークンでのログアウトはエラーにしない
+	assert.NoError(t, service.Logout("invalid-token"))
+}
+
+func TestLogoutAll_RevokesAllSessions(t *testing.T) {
+	service := setupTestAuthService()
+	user := createTestLoginUser(t, service)
+
+	_, firstToken, _ := loginTokens(service.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{}))
+	_, secondToken, _ := loginTokens(service.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{}))
+
+	revoked, err := service.LogoutAll(user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), revoked)
+
+	_, _, err = service.RefreshAccessToken(firstToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	_, _, err = service.RefreshAccessToken(secondToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestLogin_RecordsHistory(t *testing.T) {
+	service := setupTestAuthService()
+	user := createTestLoginUser(t, service)
+	meta := requestmeta.Meta{UserAgent: "test-agent", IPAddress: "192.0.2.1"}
+
+	_, _, _ = loginTokens(service.Login(context.Background(), "unknown@example.com", "password123", meta))
+	_, _, _ = loginTokens(service.Login(context.Background(), "test@example.com", "wrongpassword", meta))
+	_, _, err := loginTokens(service.Login(context.Background(), "test@example.com", "password123", meta))
+	assert.NoError(t, err)
+
+	histories, total, err := service.loginHistoryRepo.List(repository.LoginHistoryFilter{}, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+
+	// 新しい順: 成功 → パスワード誤り → 存在しないユーザー
+	assert.True(t, histories[0].Success)
+	assert.Equal(t, user.ID, *histories[0].UserID)
+	assert.NotNil(t, histories[0].SessionID)
+	assert.Equal(t, "192.0.2.1", histories[0].IPAddress)
+	assert.Equal(t, "test-agent", histories[0].UserAgent)
+
+	assert.False(t, histories[1].Success)
+	assert.Equal(t, models.LoginFailureInvalidCredentials, histories[1].FailureReason)
+	assert.Equal(t, user.ID, *histories[1].UserID)
+
+	assert.False(t, histories[2].Success)
+	assert.Nil(t, histories[2].UserID)
+	assert.Equal(t, "unknown@example.com", histories[2].Email)
+
+	// 最終ログイン日時が更新される
+	updated, _ := service.userRepo.GetUserByID(user.ID)
+	assert.NotNil(t, updated.LastLoginAt)
+}
+
+// testLoginLimitConfig: 待機なしで2回、3回目から待機、4回失敗でロックする設定
+func testLoginLimitConfig() LoginLimitConfig {
+	cfg := DefaultLoginLimitConfig()
+	cfg.AccountPolicy.FreeFailures = 2
+	cfg.LockoutThreshold = 4
+	return cfg
+}
+
+func TestLogin_BackoffAfterRepeatedFailures(t *testing.T) {
+	service := setupTestAuthServiceWithLimits(testLoginLimitConfig())
+	createTestLoginUser(t, service)
+	ctx := context.Background()
+	meta := requestmeta.Meta{IPAddress: "192.0.2.1"}
+
+	for i := 0; i < 3; i++ {
+		_, _, err := loginTokens(service.Login(ctx, "test@example.com", "wrongpassword", meta))
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	// 3回目の失敗後は待機時間が設けられ、正しいパスワードでも拒否される
+	_, _, err := loginTokens(service.Login(ctx, "test@example.com", "password123", meta))
+	var tooMany *TooManyAttemptsError
+	assert.ErrorAs(t, err, &tooMany)
+	assert.Equal(t, 1, tooMany.RetryAfterSeconds())
+
+	// 表記揺れでも同じアカウントとして扱う
+	_, _, err = loginTokens(service.Login(ctx, " TEST@example.com", "password123", requestmeta.Meta{IPAddress: "192.0.2.2"}))
+	assert.ErrorAs(t, err, &tooMany)
+}
+
+func TestLogin_LocksAccountAfterThreshold(t *testing.T) {
+	// 待機時間なしでロックのみを確認する
+	cfg := testLoginLimitConfig()
+	cfg.AccountPolicy.BaseDelay = 0
+	service := setupTestAuthServiceWithLimits(cfg)
+	user := createTestLoginUser(t, service)
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		_, _, err := loginTokens(service.Login(ctx, "test@example.com", "wrongpassword", requestmeta.Meta{}))
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	locked, _ := service.userRepo.GetUserByID(user.ID)
+	assert.NotNil(t, locked.LockedUntil)
+
+	// ロック中は正しいパスワードでも拒否される
+	_, _, err := loginTokens(service.Login(ctx, "test@example.com", "password123", requestmeta.Meta{}))
+	var tooMany *TooManyAttemptsError
+	assert.ErrorAs(t, err, &tooMany)
+	assert.InDelta(t, cfg.LockoutDuration.Seconds(), tooMany.RetryAfter.Seconds(), 5)
+
+	// ロックの発生とロック中の試行がログイン履歴に記録される
+	histories, _, _ := service.loginHistoryRepo.List(repository.LoginHistoryFilter{UserID: &user.ID}, 1, 10)
+	assert.Equal(t, models.LoginFailureAccountLocked, histories[0].FailureReason)
+	assert.Equal(t, models.LoginFailureLockedOut, histories[1].FailureReason)
+
+	// ロックは監査ログにも記録される
+	logs, err := service.audit.List(&auditDto.GetAuditLogsRequest{EntityType: string(models.AuditEntityUser), Page: 1, PageSize: 10})
+	assert.NoError(t, err)
+	if assert.Len(t, logs.AuditLogs, 1) {
+		assert.Equal(t, models.AuditActionLock, logs.AuditLogs[0].Action)
+		assert.Equal(t, user.ID, logs.AuditLogs[0].EntityID)
+	}
+}
+
+func TestUnlockUser(t *testing.T) {
+	cfg := testLoginLimitConfig()
+	cfg.AccountPolicy.BaseDelay = 0
+	service := setupTestAuthServiceWithLimits(cfg)
+	user := createTestLoginUser(t, service)
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		_, _, _ = loginTokens(service.Login(ctx, "test@example.com", "wrongpassword", requestmeta.Meta{}))
+	}
+
+	adminID := uint(99)
+	assert.NoError(t, service.UnlockUser(ctx, user.ID, requestmeta.Meta{ActorID: &adminID}))
+
+	unlocked, _ := service.userRepo.GetUserByID(user.ID)
+	assert.Nil(t, unlocked.LockedUntil)
+
+	_, _, err := loginTokens(service.Login(ctx, "test@example.com", "password123", requestmeta.Meta{}))
+	assert.NoError(t, err)
+
+	logs, _ := service.audit.List(&auditDto.GetAuditLogsRequest{EntityType: string(models.AuditEntityUser), Page: 1, PageSize: 10})
+	if assert.Len(t, logs.AuditLogs, 2) {
+		assert.Equal(t, models.AuditActionUnlock, logs.AuditLogs[0].Action)
+		assert.Equal(t, adminID, *logs.AuditLogs[0].ActorID)
+	}
+
+	assert.ErrorIs(t, service.UnlockUser(ctx, 9999, requestmeta.Meta{}), ErrUserNotFound)
+}
+
+func TestLogin_IPBackoffAcrossAccounts(t *testing.T) {
+	cfg := DefaultLoginLimitConfig()
+	cfg.IPPolicy.FreeFailures = 2
+	service := setupTestAuthServiceWithLimits(cfg)
+	createTestLoginUser(t, service)
+	ctx := context.Background()
+	meta := requestmeta.Meta{IPAddress: "192.0.2.1"}
+
+	// 異なるアカウントへの試行でも同じIPアドレスからの失敗として数える
+	_, _, _ = loginTokens(service.Login(ctx, "a@example.com", "password123", meta))
+	_, _, _ = loginTokens(service.Login(ctx, "b@example.com", "password123", meta))
+	_, _, _ = loginTokens(service.Login(ctx, "c@example.com", "password123", meta))
+
+	_, _, err := loginTokens(service.Login(ctx, "test@example.com", "password123", meta))
+	var tooMany *TooManyAttemptsError
+	assert.ErrorAs(t, err, &tooMany)
+
+	// 別のIPアドレスからは試行できる
+	_, _, err = loginTokens(service.Login(ctx, "test@example.com", "password123", requestmeta.Meta{IPAddress: "192.0.2.2"}))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"simple-ledger/internal/common/config"
+	"simple-ledger/internal/common/ratelimit"
+)
+
+// LoginLimitConfig: ログイン試行制限の設定
+type LoginLimitConfig struct {
+	// IPPolicy: 送信元IPアドレスごとの失敗回数と待機時間
+	IPPolicy ratelimit.Policy
+
+	// AccountPolicy: アカウント（メールアドレス）ごとの失敗回数と待機時間
+	AccountPolicy ratelimit.Policy
+
+	// LockoutThreshold: アカウントをロックする失敗回数（AccountPolicy.Window 内）
+	LockoutThreshold int64
+
+	// LockoutDuration: ロックの期間
+	LockoutDuration time.Duration
+}
+
+// DefaultLoginLimitConfig: ログイン試行制限のデフォルト設定
+func DefaultLoginLimitConfig() LoginLimitConfig {
+	return LoginLimitConfig{
+		IPPolicy: ratelimit.Policy{
+			Window:       15 * time.Minute,
+			FreeFailures: 10,
+			BaseDelay:    time.Second,
+			MaxDelay:     15 * time.Minute,
+		},
+		AccountPolicy: ratelimit.Policy{
+			Window:       15 * time.Minute,
+			FreeFailures: 3,
+			BaseDelay:    time.Second,
+			MaxDelay:     5 * time.Minute,
+		},
+		LockoutThreshold: 10,
+		LockoutDuration:  15 * time.Minute,
+	}
+}
+
+// LoginLimitConfigFromEnv: 環境変数からログイン試行制限の設定を読み込む
+func LoginLimitConfigFromEnv() LoginLimitConfig {
+	cfg := DefaultLoginLimitConfig()
+
+	window := time.Duration(config.GetEnvAsInt("LOGIN_FAILURE_WINDOW_MINUTES", int(cfg.AccountPolicy.Window/time.Minute))) * time.Minute
+	baseDelay := time.Duration(config.GetEnvAsInt("LOGIN_BACKOFF_BASE_SECONDS", int(cfg.AccountPolicy.BaseDelay/time.Second))) * time.Second
+
+	cfg.IPPolicy.Window = window
+	cfg.IPPolicy.BaseDelay = baseDelay
+	cfg.IPPolicy.FreeFailures = int64(config.GetEnvAsInt("LOGIN_IP_FREE_FAILURES", int(cfg.IPPolicy.FreeFailures)))
+	cfg.IPPolicy.MaxDelay = time.Duration(config.GetEnvAsInt("LOGIN_IP_MAX_BACKOFF_SECONDS", int(cfg.IPPolicy.MaxDelay/time.Second))) * time.Second
+
+	cfg.AccountPolicy.Window = window
+	cfg.AccountPolicy.BaseDelay = baseDelay
+	cfg.AccountPolicy.FreeFailures = int64(config.GetEnvAsInt("LOGIN_ACCOUNT_FREE_FAILURES", int(cfg.AccountPolicy.FreeFailures)))
+	cfg.AccountPolicy.MaxDelay = time.Duration(config.GetEnvAsInt("LOGIN_ACCOUNT_MAX_BACKOFF_SECONDS", int(cfg.AccountPolicy.MaxDelay/time.Second))) * time.Second
+
+	cfg.LockoutThreshold = int64(config.GetEnvAsInt("LOGIN_LOCKOUT_THRESHOLD", int(cfg.LockoutThreshold)))
+	cfg.LockoutDuration = time.Duration(config.GetEnvAsInt("LOGIN_LOCKOUT_MINUTES", int(cfg.LockoutDuration/time.Minute))) * time.Minute
+
+	return cfg
+}
+
+// TooManyAttemptsError: 試行回数の制限またはアカウントロックにより拒否された
+// ロックの有無でレスポンスを変えるとアカウントの存在が推測できるため、どちらも同じエラーとする
+type TooManyAttemptsError struct {
+	// RetryAfter: 次に試行できるまでの時間
+	RetryAfter time.Duration
+}
+
+func (e *TooManyAttemptsError) Error() string {
+	return "too many login attempts, try again later"
+}
+
+// RetryAfterSeconds: Retry-After ヘッダーに設定する秒数（切り上げ）
+func (e *TooManyAttemptsError) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+// LoginGuard: 送信元IPアドレスとアカウントごとのログイン失敗を数え、指数バックオフで試行を制限する
+type LoginGuard struct {
+	ip      *ratelimit.Limiter
+	account *ratelimit.Limiter
+	config  LoginLimitConfig
+}
+
+// NewLoginGuard: LoginGuard の生成
+// 複数インスタンスで動作させる場合は Redis 互換の Store を共有すること
+func NewLoginGuard(store ratelimit.Store, cfg LoginLimitConfig) *LoginGuard {
+	return &LoginGuard{
+		ip:      ratelimit.NewLimiter(store, "login:ip", cfg.IPPolicy),
+		account: ratelimit.NewLimiter(store, "login:account", cfg.AccountPolicy),
+		config:  cfg,
+	}
+}
+
+// Check: IPアドレスまたはアカウントが待機中であれば *TooManyAttemptsError を返す
+func (g *LoginGuard) Check(ctx context.Context, ipAddress string, email string) error {
+	ipWait, err := g.ip.Check(ctx, ipAddress)
+	if err != nil {
+		return err
+	}
+	accountWait, err := g.account.Check(ctx, accountKey(email))
+	if err != nil {
+		return err
+	}
+
+	wait := max(ipWait, accountWait)
+	if wait > 0 {
+		return &TooManyAttemptsError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// Fail: ログイン失敗を記録し、アカウントの失敗回数を返す
+func (g *LoginGuard) Fail(ctx context.Context, ipAddress string, email string) (int64, error) {
+	if _, _, err := g.ip.Fail(ctx, ipAddress); err != nil {
+		return 0, err
+	}
+	failures, _, err := g.account.Fail(ctx, accountKey(email))
+	return failures, err
+}
+
+// ShouldLock: アカウントをロックする失敗回数に達したか
+func (g *LoginGuard) ShouldLock(failures int64) bool {
+	return g.config.LockoutThreshold > 0 && failures >= g.config.LockoutThreshold
+}
+
+// LockoutDuration: ロックの期間
+func (g *LoginGuard) LockoutDuration() time.Duration {
+	return g.config.LockoutDuration
+}
+
+// ResetAccount: アカウントの失敗回数と待機状態を消去（ログイン成功・ロック・ロック解除時）
+// 共有IPアドレスの他の利用者への影響を抑えるため、IPアドレスの失敗回数は期間の経過でのみ消える
+func (g *LoginGuard) ResetAccount(ctx context.Context, email string) error {
+	return g.account.Reset(ctx, accountKey(email))
+}
+
+// accountKey: メールアドレスの表記揺れ（大文字・空白）で制限を回避されないよう正規化
+func accountKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
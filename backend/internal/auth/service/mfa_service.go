@@ -0,0 +1,464 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	auditService "simple-ledger/internal/audit/service"
+	"simple-ledger/internal/auth/dto"
+	"simple-ledger/internal/auth/repository"
+	"simple-ledger/internal/common/config"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/requestmeta"
+	"simple-ledger/internal/common/security"
+	"simple-ledger/internal/models"
+	userRepository "simple-ledger/internal/user/repository"
+
+	"gorm.io/gorm"
+)
+
+// チャレンジトークンの用途
+const (
+	MFAChallengeVerify = "verify" // 登録済みのコードを入力する
+	MFAChallengeEnroll = "enroll" // ロールで必須のため、登録してからコードを入力する
+)
+
+// recoveryCodeCount: 一度に発行するリカバリーコードの数
+const recoveryCodeCount = 10
+
+// recoveryCodeAlphabet: 読み間違えやすい文字（0/o, 1/l/i）を除いた文字
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// mfaRoles: 二段階認証の必須設定の対象となるロール
+var mfaRoles = []string{"admin", "user"}
+
+var (
+	ErrMFAAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled      = errors.New("two-factor authentication enrollment has not been started")
+	ErrMFANotEnabled       = errors.New("two-factor authentication is not enabled")
+	ErrInvalidMFACode      = errors.New("invalid verification code")
+	ErrMFARequiredByPolicy = errors.New("two-factor authentication is required for your role")
+	ErrInvalidMFAChallenge = errors.New("invalid or expired challenge")
+	ErrUnknownRole         = errors.New("unknown role")
+)
+
+// MFAConfig: 二段階認証の設定
+type MFAConfig struct {
+	// Issuer: 認証アプリに表示される発行者名
+	Issuer string
+}
+
+// MFAConfigFromEnv: 環境変数から二段階認証の設定を読み込む
+func MFAConfigFromEnv() MFAConfig {
+	return MFAConfig{Issuer: config.GetEnv("MFA_ISSUER", "Simple Ledger")}
+}
+
+// MFAService: TOTP 二段階認証の登録・検証とロールごとの必須設定
+type MFAService struct {
+	repo     *repository.MFARepository
+	userRepo *userRepository.UserRepository
+	audit    *auditService.AuditService
+	uow      uow.UnitOfWork
+	config   MFAConfig
+	now      func() time.Time
+}
+
+func NewMFAService(repo *repository.MFARepository, userRepo *userRepository.UserRepository, audit *auditService.AuditService, unitOfWork uow.UnitOfWork, cfg MFAConfig) *MFAService {
+	return &MFAService{
+		repo:     repo,
+		userRepo: userRepo,
+		audit:    audit,
+		uow:      unitOfWork,
+		config:   cfg,
+		now:      time.Now,
+	}
+}
+
+// mfaSnapshot: 二段階認証の有効・無効の監査ログに記録する状態
+type mfaSnapshot struct {
+	MFAEnabled bool `json:"mfaEnabled"`
+}
+
+// ChallengePurpose はパスワード認証に成功したユーザーに必要な2段階目を返す
+// 二段階認証が不要な場合は空文字列
+func (s *MFAService) ChallengePurpose(user *models.User) (string, error) {
+	totp, err := s.repo.GetTOTP(user.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	if err == nil && totp.EnabledAt != nil {
+		return MFAChallengeVerify, nil
+	}
+
+	policy, err := s.repo.GetRolePolicy(user.Role)
+	if err != nil {
+		return "", err
+	}
+	if policy.Required {
+		return MFAChallengeEnroll, nil
+	}
+	return "", nil
+}
+
+// Status はユーザーの二段階認証の状態を返す
+func (s *MFAService) Status(userID uint, role string) (*dto.MFAStatusResponse, error) {
+	policy, err := s.repo.GetRolePolicy(role)
+	if err != nil {
+		return nil, err
+	}
+	status := &dto.MFAStatusResponse{Required: policy.Required}
+
+	totp, err := s.repo.GetTOTP(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return status, nil
+		}
+		return nil, err
+	}
+
+	status.Enabled = totp.EnabledAt != nil
+	status.EnrollmentPending = totp.EnabledAt == nil
+	status.EnabledAt = totp.EnabledAt
+	if status.Enabled {
+		remaining, err := s.repo.CountUnusedRecoveryCodes(userID)
+		if err != nil {
+			return nil, err
+		}
+		status.RemainingRecoveryCodes = int(remaining)
+	}
+	return status, nil
+}
+
+// BeginEnrollment は新しいシークレットを発行して登録を開始する
+// 有効化前に再度呼び出した場合はシークレットを作り直す
+func (s *MFAService) BeginEnrollment(userID uint, accountName string) (*dto.MFAEnrollmentResponse, error) {
+	totp, err := s.repo.GetTOTP(userID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		totp = &models.UserTOTP{UserID: userID}
+	}
+	if totp.EnabledAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := security.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	totp.Secret = secret
+	totp.LastUsedStep = 0
+	if err := s.repo.SaveTOTP(totp); err != nil {
+		return nil, err
+	}
+
+	return &dto.MFAEnrollmentResponse{
+		Secret:     secret,
+		OTPAuthURI: security.TOTPAuthURI(s.config.Issuer, accountName, secret),
+	}, nil
+}
+
+// ConfirmEnrollment は認証アプリのコードで登録を確認して有効化し、リカバリーコードを返す
+// リカバリーコードはここで一度だけ返し、ハッシュのみを保存する
+func (s *MFAService) ConfirmEnrollment(userID uint, code string, meta requestmeta.Meta) ([]string, error) {
+	totp, err := s.repo.GetTOTP(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMFANotEnrolled
+		}
+		return nil, err
+	}
+	if totp.EnabledAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	step, ok := security.VerifyTOTP(totp.Secret, code, s.now(), 0)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.uow.Do(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if err := repo.EnableTOTP(totp.ID, s.now(), step); err != nil {
+			return err
+		}
+		if err := repo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+			return err
+		}
+		return s.audit.WithTx(tx).Record(meta, auditService.Entry{
+			Action:     models.AuditActionMFAEnable,
+			EntityType: models.AuditEntityUser,
+			EntityID:   userID,
+			Before:     mfaSnapshot{MFAEnabled: false},
+			After:      mfaSnapshot{MFAEnabled: true},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// VerifySecondFactor はログインの2段階目として TOTP コードまたはリカバリーコードを検証する
+// 使用したコードは再利用できない
+func (s *MFAService) VerifySecondFactor(userID uint, code string, recoveryCode string) error {
+	totp, err := s.enabledTOTP(userID)
+	if err != nil {
+		return err
+	}
+
+	if code != "" {
+		return s.verifyTOTP(totp, code)
+	}
+	if recoveryCode != "" {
+		used, err := s.repo.UseRecoveryCode(userID, hashRecoveryCode(recoveryCode), s.now())
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+	return ErrInvalidMFACode
+}
+
+// Disable はユーザー自身が二段階認証を無効化する（ロールで必須の場合は無効化できない）
+func (s *MFAService) Disable(userID uint, role string, code string, recoveryCode string, meta requestmeta.Meta) error {
+	policy, err := s.repo.GetRolePolicy(role)
+	if err != nil {
+		return err
+	}
+	if policy.Required {
+		return ErrMFARequiredByPolicy
+	}
+
+	if err := s.VerifySecondFactor(userID, code, recoveryCode); err != nil {
+		return err
+	}
+
+	return s.removeTOTP(userID, meta)
+}
+
+// RegenerateRecoveryCodes は TOTP コードを確認してリカバリーコードを作り直す（既存のコードは使えなくなる）
+func (s *MFAService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	totp, err := s.enabledTOTP(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verifyTOTP(totp, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	err = s.uow.Do(func(tx *gorm.DB) error {
+		return s.repo.WithTx(tx).ReplaceRecoveryCodes(userID, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Reset は管理者がユーザーの二段階認証を解除する（認証アプリを紛失した場合など）
+// ロールで必須の場合、ユーザーは次回ログイン時に再登録する
+func (s *MFAService) Reset(userID uint, meta requestmeta.Meta) error {
+	if _, err := s.userRepo.GetUserByID(userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	if _, err := s.repo.GetTOTP(userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return s.removeTOTP(userID, meta)
+}
+
+// ListPolicies はロールごとの二段階認証の必須設定を返す
+func (s *MFAService) ListPolicies() ([]models.RoleMFAPolicy, error) {
+	policies := make([]models.RoleMFAPolicy, 0, len(mfaRoles))
+	for _, role := range mfaRoles {
+		policy, err := s.repo.GetRolePolicy(role)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, *policy)
+	}
+	return policies, nil
+}
+
+// UpdatePolicy はロールの二段階認証の必須設定を変更する（管理者用）
+func (s *MFAService) UpdatePolicy(role string, required bool, meta requestmeta.Meta) (*models.RoleMFAPolicy, error) {
+	if !slices.Contains(mfaRoles, role) {
+		return nil, ErrUnknownRole
+	}
+
+	before, err := s.repo.GetRolePolicy(role)
+	if err != nil {
+		return nil, err
+	}
+	after := &models.RoleMFAPolicy{Role: role, Required: required}
+
+	err = s.uow.Do(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).SaveRolePolicy(after); err != nil {
+			return err
+		}
+		// ロールは数値IDを持たないため EntityID は 0 とし、ロール名は記録内容に含める
+		return s.audit.WithTx(tx).Record(meta, auditService.Entry{
+			Action:     models.AuditActionUpdate,
+			EntityType: models.AuditEntityMFAPolicy,
+			Before:     before,
+			After:      after,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// enabledTOTP: 有効化済みの TOTP 設定を取得
+func (s *MFAService) enabledTOTP(userID uint) (*models.UserTOTP, error) {
+	totp, err := s.repo.GetTOTP(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMFANotEnabled
+		}
+		return nil, err
+	}
+	if totp.EnabledAt == nil {
+		return nil, ErrMFANotEnabled
+	}
+	return totp, nil
+}
+
+// verifyTOTP: TOTP コードを検証し、使用したタイムステップを記録
+func (s *MFAService) verifyTOTP(totp *models.UserTOTP, code string) error {
+	step, ok := security.VerifyTOTP(totp.Secret, code, s.now(), totp.LastUsedStep)
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	marked, err := s.repo.MarkStepUsed(totp.ID, step)
+	if err != nil {
+		return err
+	}
+	if !marked {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// removeTOTP: TOTP 設定とリカバリーコードを削除して監査ログに記録
+func (s *MFAService) removeTOTP(userID uint, meta requestmeta.Meta) error {
+	return s.uow.Do(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if err := repo.DeleteTOTP(userID); err != nil {
+			return err
+		}
+		if err := repo.DeleteRecoveryCodes(userID); err != nil {
+			return err
+		}
+		return s.audit.WithTx(tx).Record(meta, auditService.Entry{
+			Action:     models.AuditActionMFADisable,
+			EntityType: models.AuditEntityUser,
+			EntityID:   userID,
+			Before:     mfaSnapshot{MFAEnabled: true},
+			After:      mfaSnapshot{MFAEnabled: false},
+		})
+	})
+}
+
+// generateRecoveryCodes: リカバリーコード（xxxxx-xxxxx 形式）とそのハッシュを生成
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for len(codes) < recoveryCodeCount {
+		raw, err := randomString(recoveryCodeAlphabet, 10)
+		if err != nil {
+			return nil, nil, err
+		}
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode: 入力の揺れ（大文字・ハイフン・空白）を正規化してハッシュ化
+// コードは十分な長さのランダム値のため、パスワードのような低速ハッシュは不要
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomString: alphabet の文字から偏りのないランダムな文字列を生成
+func randomString(alphabet string, length int) (string, error) {
+	// alphabet の長さの倍数に収まらない値は捨てて偏りをなくす
+	limit := byte(256 - 256%len(alphabet))
+	result := make([]byte, 0, length)
+	buf := make([]byte, length*2)
+
+	for len(result) < length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if b >= limit {
+				continue
+			}
+			result = append(result, alphabet[int(b)%len(alphabet)])
+			if len(result) == length {
+				break
+			}
+		}
+	}
+	return string(result), nil
+}
+
+// mfaChallenge: パスワード認証に成功したユーザーにチャレンジトークンを発行
+func mfaChallenge(userID uint, purpose string) (*LoginResult, error) {
+	token, err := security.GenerateMFAChallengeToken(userID, purpose)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{
+		ChallengeToken:     token,
+		EnrollmentRequired: purpose == MFAChallengeEnroll,
+	}, nil
+}
+
+// verifyMFAChallenge: チャレンジトークンを検証してユーザーIDと用途を返す
+func verifyMFAChallenge(challengeToken string) (uint, string, error) {
+	claims, err := security.VerifyMFAChallengeToken(challengeToken)
+	if err != nil {
+		return 0, "", ErrInvalidMFAChallenge
+	}
+	if claims.Purpose != MFAChallengeVerify && claims.Purpose != MFAChallengeEnroll {
+		return 0, "", ErrInvalidMFAChallenge
+	}
+	return claims.UserID, claims.Purpose, nil
+}
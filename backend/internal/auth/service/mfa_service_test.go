@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	auditDto "simple-ledger/internal/audit/dto"
+	"simple-ledger/internal/auth/repository"
+	"simple-ledger/internal/common/requestmeta"
+	"simple-ledger/internal/common/security"
+	"simple-ledger/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// enableTestMFA: テストユーザーの二段階認証を有効化し、シークレットとリカバリーコードを返す
+func enableTestMFA(t *testing.T, service *AuthService, user *models.User, now time.Time) (string, []string) {
+	service.mfa.now = func() time.Time { return now }
+
+	enrollment, err := service.mfa.BeginEnrollment(user.ID, user.Email)
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.OTPAuthURI, "secret="+enrollment.Secret)
+
+	codes, err := service.mfa.ConfirmEnrollment(user.ID, totpCodeAt(t, enrollment.Secret, now), requestmeta.Meta{})
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+
+	return enrollment.Secret, codes
+}
+
+func totpCodeAt(t *testing.T, secret string, at time.Time) string {
+	code, err := security.TOTPCode(secret, security.TOTPStep(at))
+	require.NoError(t, err)
+	return code
+}
+
+func TestLogin_MFAChallengeAndSecondStep(t *testing.T) {
+	service := setupTestAuthService()
+	user := createTestLoginUser(t, service)
+	now := time.Now()
+	secret, _ := enableTestMFA(t, service, user, now)
+	ctx := context.Background()
+
+	// パスワードのみではトークンを発行しない
+	result, err := service.Login(ctx, "test@example.com", "password123", requestmeta.Meta{})
+	require.NoError(t, err)
+	assert.True(t, result.MFARequired())
+	assert.False(t, result.EnrollmentRequired)
+	assert.Empty(t, result.AccessToken)
+	assert.Empty(t, result.RefreshToken)
+
+	// 有効化に使用したコードは再利用できない
+	_, err = service.CompleteMFALogin(ctx, result.ChallengeToken, totpCodeAt(t, secret, now), "", requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+
+	next := now.Add(30 * time.Second)
+	service.mfa.now = func() time.Time { return next }
+	completed, err := service.CompleteMFALogin(ctx, result.ChallengeToken, totpCodeAt(t, secret, next), "", requestmeta.Meta{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, completed.AccessToken)
+	assert.NotEmpty(t, completed.RefreshToken)
+
+	// アクセストークンはチャレンジトークンとして使えない
+	_, err = service.CompleteMFALogin(ctx, completed.AccessToken, totpCodeAt(t, secret, next), "", requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrInvalidMFAChallenge)
+
+	history, _, err := service.loginHistoryRepo.List(repository.LoginHistoryFilter{UserID: &user.ID}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, models.LoginFailureInvalidMFACode, history[1].FailureReason)
+	assert.True(t, history[0].Success)
+}
+
+func TestCompleteMFALogin_RecoveryCodeIsSingleUse(t *testing.T) {
+	service := setupTestAuthService()
+	user := createTestLoginUser(t, service)
+	_, codes := enableTestMFA(t, service, user, time.Now())
+	ctx := context.Background()
+
+	result, err := service.Login(ctx, "test@example.com", "password123", requestmeta.Meta{})
+	require.NoError(t, err)
+
+	// 大文字・ハイフンなしでも受け付ける
+	input := strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))
+	_, err = service.CompleteMFALogin(ctx, result.ChallengeToken, "", input, requestmeta.Meta{})
+	require.NoError(t, err)
+
+	_, err = service.CompleteMFALogin(ctx, result.ChallengeToken, "", codes[0], requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+
+	status, err := service.mfa.Status(user.ID, user.Role)
+	require.NoError(t, err)
+	assert.True(t, status.Enabled)
+	assert.Equal(t, recoveryCodeCount-1, status.RemainingRecoveryCodes)
+}
+
+func TestLogin_RolePolicyRequiresEnrollment(t *testing.T) {
+	service := setupTestAuthService()
+	user := createTestLoginUser(t, service)
+	ctx := context.Background()
+
+	_, err := service.mfa.UpdatePolicy(user.Role, true, requestmeta.Meta{})
+	require.NoError(t, err)
+
+	result, err := service.Login(ctx, "test@example.com", "password123", requestmeta.Meta{})
+	require.NoError(t, err)
+	assert.True(t, result.MFARequired())
+	assert.True(t, result.EnrollmentRequired)
+
+	enrollment, err := service.BeginMFAEnrollment(result.ChallengeToken)
+	require.NoError(t, err)
+
+	now := time.Now()
+	service.mfa.now = func() time.Time { return now }
+	completed, err := service.CompleteMFALogin(ctx, result.ChallengeToken, totpCodeAt(t, enrollment.Secret, now), "", requestmeta.Meta{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, completed.AccessToken)
+	assert.Len(t, completed.RecoveryCodes, recoveryCodeCount)
+
+	// 必須のロールでは自分で無効化できない
+	err = service.mfa.Disable(user.ID, user.Role, "", completed.RecoveryCodes[0], requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrMFARequiredByPolicy)
+}
+
+func TestResetMFA_ByAdmin(t *testing.T) {
+	service := setupTestAuthService()
+	user := createTestLoginUser(t, service)
+	enableTestMFA(t, service, user, time.Now())
+	adminID := uint(99)
+
+	require.NoError(t, service.mfa.Reset(user.ID, requestmeta.Meta{ActorID: &adminID}))
+
+	result, err := service.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{})
+	require.NoError(t, err)
+	assert.False(t, result.MFARequired())
+	assert.NotEmpty(t, result.AccessToken)
+
+	logs, err := service.audit.List(&auditDto.GetAuditLogsRequest{EntityType: string(models.AuditEntityUser), Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, logs.AuditLogs, 2)
+	assert.Equal(t, models.AuditActionMFADisable, logs.AuditLogs[0].Action)
+	assert.Equal(t, adminID, *logs.AuditLogs[0].ActorID)
+	assert.Equal(t, models.AuditActionMFAEnable, logs.AuditLogs[1].Action)
+
+	assert.ErrorIs(t, service.mfa.Reset(12345, requestmeta.Meta{}), ErrUserNotFound)
+}
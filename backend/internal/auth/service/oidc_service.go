@@ -0,0 +1,423 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	auditService "simple-ledger/internal/audit/service"
+	"simple-ledger/internal/auth/repository"
+	"simple-ledger/internal/common/config"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/oidc"
+	"simple-ledger/internal/common/requestmeta"
+	"simple-ledger/internal/common/security"
+	"simple-ledger/internal/models"
+	userRepository "simple-ledger/internal/user/repository"
+
+	"gorm.io/gorm"
+)
+
+// rolePriority: IdP のクレームが複数のロールに対応する場合に優先するロール（先頭ほど優先）
+var rolePriority = []string{"admin", "user"}
+
+var (
+	ErrOIDCNotConfigured    = errors.New("single sign-on is not configured")
+	ErrInvalidOIDCState     = errors.New("invalid or expired single sign-on request")
+	ErrOIDCEmailNotVerified = errors.New("email address is not verified by the identity provider")
+	ErrOIDCNoRole           = errors.New("no role is assigned to this account by the identity provider")
+	ErrNoLinkedIdentity     = errors.New("user has no linked single sign-on account")
+)
+
+// OIDCConfig: シングルサインオンのユーザーの作成・ロールの設定
+type OIDCConfig struct {
+	// RoleClaim: ロールの判定に使う ID トークンのクレーム（文字列または文字列の配列）
+	RoleClaim string
+
+	// RoleMapping: クレームの値からロールへの対応
+	RoleMapping map[string]string
+
+	// DefaultRole: 対応するロールが無い場合に新規ユーザーに割り当てるロール（空の場合は作成しない）
+	DefaultRole string
+}
+
+// DefaultOIDCConfig: 既定の設定
+func DefaultOIDCConfig() OIDCConfig {
+	return OIDCConfig{
+		RoleClaim:   "groups",
+		RoleMapping: map[string]string{},
+		DefaultRole: "user",
+	}
+}
+
+// OIDCConfigFromEnv: 環境変数から設定を読み込む
+// OIDC_ROLE_MAPPING は "クレームの値=ロール" のカンマ区切り（例: ledger-admins=admin,ledger-users=user）
+// 不正な値は権限を広げないよう無視する
+func OIDCConfigFromEnv() OIDCConfig {
+	cfg := DefaultOIDCConfig()
+	cfg.RoleClaim = config.GetEnv("OIDC_ROLE_CLAIM", cfg.RoleClaim)
+	cfg.DefaultRole = config.GetEnv("OIDC_DEFAULT_ROLE", cfg.DefaultRole)
+	if cfg.DefaultRole != "" && !isKnownRole(cfg.DefaultRole) {
+		log.Printf("ignoring unknown OIDC_DEFAULT_ROLE: %q", cfg.DefaultRole)
+		cfg.DefaultRole = ""
+	}
+
+	for _, pair := range strings.Split(config.GetEnv("OIDC_ROLE_MAPPING", ""), ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		value, role, found := strings.Cut(pair, "=")
+		value, role = strings.TrimSpace(value), strings.TrimSpace(role)
+		if !found || value == "" || !isKnownRole(role) {
+			log.Printf("ignoring invalid OIDC_ROLE_MAPPING entry: %q", pair)
+			continue
+		}
+		cfg.RoleMapping[value] = role
+	}
+	return cfg
+}
+
+// identitySnapshot: 監査ログに記録するアカウントの紐づけ
+type identitySnapshot struct {
+	UserID  uint   `json:"userId"`
+	Issuer  string `json:"issuer"`
+	Subject string `json:"subject"`
+	Email   string `json:"email"`
+}
+
+// provisionedUserSnapshot: シングルサインオンで作成・更新したユーザーの監査ログに記録する状態
+type provisionedUserSnapshot struct {
+	Email                 string `json:"email,omitempty"`
+	Name                  string `json:"name,omitempty"`
+	Role                  string `json:"role,omitempty"`
+	PasswordLoginDisabled bool   `json:"passwordLoginDisabled"`
+}
+
+// OIDCService: OpenID Connect によるシングルサインオン
+// IdP のアカウントは発行者と sub で識別し、初回ログイン時に確認済みのメールアドレスで既存ユーザーに紐づけるか、新しいユーザーを作成する
+type OIDCService struct {
+	provider     *oidc.Provider // 未設定の場合は nil
+	auth         *AuthService
+	userRepo     *userRepository.UserRepository
+	identityRepo *repository.IdentityRepository
+	audit        *auditService.AuditService
+	uow          uow.UnitOfWork
+	config       OIDCConfig
+	now          func() time.Time
+}
+
+func NewOIDCService(
+	provider *oidc.Provider,
+	auth *AuthService,
+	userRepo *userRepository.UserRepository,
+	identityRepo *repository.IdentityRepository,
+	audit *auditService.AuditService,
+	unitOfWork uow.UnitOfWork,
+	cfg OIDCConfig,
+) *OIDCService {
+	return &OIDCService{
+		provider:     provider,
+		auth:         auth,
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+		audit:        audit,
+		uow:          unitOfWork,
+		config:       cfg,
+		now:          time.Now,
+	}
+}
+
+// Enabled はシングルサインオンが設定されているかどうかを返す
+func (s *OIDCService) Enabled() bool {
+	return s.provider != nil
+}
+
+// BeginLogin は IdP の認可エンドポイントへのURLと、ブラウザーの Cookie に保存する状態トークンを返す
+// redirectPath はログイン後に表示するフロントエンドのパス
+func (s *OIDCService) BeginLogin(ctx context.Context, redirectPath string) (authURL string, stateToken string, err error) {
+	if s.provider == nil {
+		return "", "", ErrOIDCNotConfigured
+	}
+
+	state, err := oidc.RandomString()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := oidc.RandomString()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := oidc.RandomString()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err = s.provider.AuthCodeURL(ctx, state, nonce, oidc.CodeChallenge(verifier))
+	if err != nil {
+		return "", "", err
+	}
+	stateToken, err = security.GenerateOIDCStateToken(state, nonce, verifier, redirectPath)
+	if err != nil {
+		return "", "", err
+	}
+	return authURL, stateToken, nil
+}
+
+// CompleteLogin は IdP から戻ってきた認可コードを交換してユーザーを特定し、セッションを作成する
+// 二段階認証が有効な（またはロールで必須の）ユーザーにはパスワードでのログインと同様にチャレンジトークンを返す
+// 戻り値の redirectPath はログイン開始時に指定されたフロントエンドのパス
+func (s *OIDCService) CompleteLogin(ctx context.Context, stateToken string, state string, code string, meta requestmeta.Meta) (result *LoginResult, redirectPath string, err error) {
+	if s.provider == nil {
+		return nil, "", ErrOIDCNotConfigured
+	}
+
+	stateClaims, err := security.VerifyOIDCStateToken(stateToken)
+	if err != nil || subtle.ConstantTimeCompare([]byte(stateClaims.State), []byte(state)) != 1 {
+		return nil, "", ErrInvalidOIDCState
+	}
+	redirectPath = stateClaims.RedirectPath
+
+	claims, err := s.provider.Exchange(ctx, code, stateClaims.CodeVerifier, stateClaims.Nonce)
+	if err != nil {
+		return nil, redirectPath, err
+	}
+
+	user, err := s.resolveUser(claims, meta)
+	if err != nil {
+		return nil, redirectPath, err
+	}
+
+	// パスワードの試行によるロックは適用しない（IdP で認証済みのため）
+	if !user.IsActive {
+		if err := s.auth.recordLoginFailure(&user.ID, user.Email, models.LoginFailureUserInactive, meta); err != nil {
+			return nil, redirectPath, err
+		}
+		return nil, redirectPath, ErrUserInactive
+	}
+
+	purpose, err := s.auth.mfa.ChallengePurpose(user)
+	if err != nil {
+		return nil, redirectPath, err
+	}
+	if purpose != "" {
+		result, err = mfaChallenge(user.ID, purpose)
+		return result, redirectPath, err
+	}
+
+	result, err = s.auth.startSession(ctx, user, user.Email, meta)
+	return result, redirectPath, err
+}
+
+// SetPasswordLogin はユーザーのパスワードによるログインを許可・禁止する（管理者用）
+// 禁止できるのはシングルサインオンのアカウントが紐づいているユーザーのみ（ログインできなくなるため）
+func (s *OIDCService) SetPasswordLogin(userID uint, enabled bool, meta requestmeta.Meta) error {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if user.PasswordLoginDisabled == !enabled {
+		return nil
+	}
+
+	if !enabled {
+		count, err := s.identityRepo.CountByUser(user.ID)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrNoLinkedIdentity
+		}
+	}
+
+	return s.uow.Do(func(tx *gorm.DB) error {
+		if _, err := s.userRepo.WithTx(tx).UpdateUser(user.ID, map[string]interface{}{"password_login_disabled": !enabled}); err != nil {
+			return err
+		}
+		return s.audit.WithTx(tx).Record(meta, auditService.Entry{
+			Action:     models.AuditActionUpdate,
+			EntityType: models.AuditEntityUser,
+			EntityID:   user.ID,
+			Before:     provisionedUserSnapshot{PasswordLoginDisabled: user.PasswordLoginDisabled},
+			After:      provisionedUserSnapshot{PasswordLoginDisabled: !enabled},
+		})
+	})
+}
+
+// resolveUser: IdP のアカウントに対応するユーザーを取得する
+// 紐づけが無い場合は、IdP で確認済みのメールアドレスが一致する既存ユーザーに紐づけるか、新しいユーザーを作成する
+// 既存ユーザーのロールは、クレームがロールに対応する場合のみ IdP に合わせて更新する
+func (s *OIDCService) resolveUser(claims *oidc.Claims, meta requestmeta.Meta) (*models.User, error) {
+	issuer := s.provider.Issuer()
+	mappedRole := s.mapRole(claims)
+	now := s.now()
+
+	var user *models.User
+	roleChanged := false
+	err := s.uow.Do(func(tx *gorm.DB) error {
+		userRepo := s.userRepo.WithTx(tx)
+		identityRepo := s.identityRepo.WithTx(tx)
+		audit := s.audit.WithTx(tx)
+
+		identity, err := identityRepo.GetBySubject(issuer, claims.Subject)
+		switch {
+		case err == nil:
+			if user, err = userRepo.GetUserByID(identity.UserID); err != nil {
+				return err
+			}
+			if err := identityRepo.RecordLogin(identity.ID, claims.Email, now); err != nil {
+				return err
+			}
+
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			// メールアドレスによる紐づけ・作成は、IdP がメールアドレスを確認済みの場合のみ行う
+			if claims.Email == "" || !claims.EmailVerified {
+				return ErrOIDCEmailNotVerified
+			}
+
+			user, err = userRepo.GetUserByEmail(claims.Email)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				if user, err = s.provisionUser(userRepo, audit, claims, mappedRole, meta, now); err != nil {
+					return err
+				}
+			} else if err != nil {
+				return err
+			} else if user.EmailVerifiedAt == nil {
+				if user, err = userRepo.UpdateUser(user.ID, map[string]interface{}{"email_verified_at": now}); err != nil {
+					return err
+				}
+			}
+
+			identity = &models.UserIdentity{
+				UserID:      user.ID,
+				Issuer:      issuer,
+				Subject:     claims.Subject,
+				Email:       claims.Email,
+				LastLoginAt: &now,
+			}
+			if err := identityRepo.Create(identity); err != nil {
+				return err
+			}
+			if err := audit.Record(meta, auditService.Entry{
+				Action:     models.AuditActionCreate,
+				EntityType: models.AuditEntityUserIdentity,
+				EntityID:   identity.ID,
+				After:      identitySnapshot{UserID: user.ID, Issuer: issuer, Subject: claims.Subject, Email: claims.Email},
+			}); err != nil {
+				return err
+			}
+
+		default:
+			return err
+		}
+
+		if mappedRole == "" || mappedRole == user.Role {
+			return nil
+		}
+		before := user.Role
+		updates := map[string]interface{}{"role": mappedRole}
+		userRepository.IncrementTokenVersion(updates)
+		if user, err = userRepo.UpdateUser(user.ID, updates); err != nil {
+			return err
+		}
+		roleChanged = true
+		return audit.Record(meta, auditService.Entry{
+			Action:     models.AuditActionUpdate,
+			EntityType: models.AuditEntityUser,
+			EntityID:   user.ID,
+			Before:     provisionedUserSnapshot{Role: before, PasswordLoginDisabled: user.PasswordLoginDisabled},
+			After:      provisionedUserSnapshot{Role: mappedRole, PasswordLoginDisabled: user.PasswordLoginDisabled},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if roleChanged {
+		security.InvalidateTokenVersion(user.ID)
+	}
+	return user, nil
+}
+
+// provisionUser: IdP のアカウントから新しいユーザーを作成（Just-In-Time プロビジョニング）
+// パスワードを持たないため、パスワードによるログインは禁止しておく
+func (s *OIDCService) provisionUser(userRepo *userRepository.UserRepository, audit *auditService.AuditService, claims *oidc.Claims, mappedRole string, meta requestmeta.Meta, now time.Time) (*models.User, error) {
+	role := mappedRole
+	if role == "" {
+		role = s.config.DefaultRole
+	}
+	if role == "" {
+		return nil, ErrOIDCNoRole
+	}
+
+	name := strings.TrimSpace(claims.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	user := &models.User{
+		Email:                 claims.Email,
+		Name:                  truncate(name, 255),
+		Role:                  role,
+		IsActive:              true,
+		EmailVerifiedAt:       &now,
+		PasswordLoginDisabled: true,
+	}
+	if err := userRepo.CreateUser(user); err != nil {
+		return nil, err
+	}
+
+	err := audit.Record(meta, auditService.Entry{
+		Action:     models.AuditActionCreate,
+		EntityType: models.AuditEntityUser,
+		EntityID:   user.ID,
+		After: provisionedUserSnapshot{
+			Email:                 user.Email,
+			Name:                  user.Name,
+			Role:                  user.Role,
+			PasswordLoginDisabled: user.PasswordLoginDisabled,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// mapRole: ID トークンのクレームに対応するロールを返す（対応が無い場合は空文字）
+func (s *OIDCService) mapRole(claims *oidc.Claims) string {
+	var values []string
+	switch v := claims.Raw[s.config.RoleClaim].(type) {
+	case string:
+		values = []string{v}
+	case []any:
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				values = append(values, str)
+			}
+		}
+	}
+
+	matched := map[string]bool{}
+	for _, value := range values {
+		if role, ok := s.config.RoleMapping[value]; ok {
+			matched[role] = true
+		}
+	}
+	for _, role := range rolePriority {
+		if matched[role] {
+			return role
+		}
+	}
+	return ""
+}
+
+// isKnownRole: ユーザーに割り当てられるロールかどうか
+func isKnownRole(role string) bool {
+	return slices.Contains(rolePriority, role)
+}
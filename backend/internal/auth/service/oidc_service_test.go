@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	auditRepository "simple-ledger/internal/audit/repository"
+	auditService "simple-ledger/internal/audit/service"
+	"simple-ledger/internal/auth/repository"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/oidc"
+	"simple-ledger/internal/common/requestmeta"
+	"simple-ledger/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTestOIDCService: モック IdP に接続する OIDCService を作成
+func setupTestOIDCService(t *testing.T) (*AuthService, *OIDCService) {
+	mock, err := oidc.NewMockIssuer("simple-ledger", "secret")
+	require.NoError(t, err)
+	server := httptest.NewServer(mock)
+	t.Cleanup(server.Close)
+	mock.SetIssuerURL(server.URL)
+
+	provider, err := oidc.NewProvider(oidc.Config{
+		IssuerURL:    server.URL,
+		ClientID:     "simple-ledger",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:8080/api/auth/oidc/callback",
+	})
+	require.NoError(t, err)
+
+	db := setupTestAuthDB()
+	authService := newTestAuthService(db, DefaultLoginLimitConfig())
+	cfg := DefaultOIDCConfig()
+	cfg.RoleMapping = map[string]string{"ledger-admins": "admin", "ledger-users": "user"}
+	oidcService := NewOIDCService(
+		provider,
+		authService,
+		authService.userRepo,
+		repository.NewIdentityRepository(db),
+		auditService.NewAuditService(auditRepository.NewAuditLogRepository(db)),
+		uow.New(db),
+		cfg,
+	)
+	return authService, oidcService
+}
+
+// ssoLogin: モック IdP で指定したユーザーとしてログインし、コールバックの処理結果を返す
+func ssoLogin(t *testing.T, s *OIDCService, user url.Values) (*LoginResult, error) {
+	ctx := context.Background()
+	authURL, stateToken, err := s.BeginLogin(ctx, "/transactions")
+	require.NoError(t, err)
+
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	query := u.Query()
+	for k, v := range user {
+		query[k] = v
+	}
+	u.RawQuery = query.Encode()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(u.String())
+	require.NoError(t, err)
+	resp.Body.Close()
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+
+	result, redirectPath, err := s.CompleteLogin(ctx, stateToken, callback.Query().Get("state"), callback.Query().Get("code"), requestmeta.Meta{})
+	if err == nil {
+		assert.Equal(t, "/transactions", redirectPath)
+	}
+	return result, err
+}
+
+func TestOIDCLogin_ProvisionsNewUser(t *testing.T) {
+	authService, oidcService := setupTestOIDCService(t)
+
+	result, err := ssoLogin(t, oidcService, url.Values{"login_hint": {"alice@example.com"}, "name": {"Alice"}, "groups": {"ledger-users,ledger-admins"}})
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.AccessToken)
+	assert.NotEmpty(t, result.RefreshToken)
+
+	user, err := authService.userRepo.GetUserByEmail("alice@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", user.Name)
+	assert.Equal(t, "admin", user.Role)
+	assert.True(t, user.PasswordLoginDisabled)
+	assert.NotNil(t, user.EmailVerifiedAt)
+
+	// 2回目は紐づけ済みのユーザーでログインし、ロールは IdP に合わせて更新する
+	_, err = ssoLogin(t, oidcService, url.Values{"login_hint": {"alice@example.com"}, "groups": {"ledger-users"}})
+	require.NoError(t, err)
+	users, _ := authService.userRepo.GetAllUsers()
+	assert.Len(t, users, 1)
+	updated, _ := authService.userRepo.GetUserByID(user.ID)
+	assert.Equal(t, "user", updated.Role)
+}
+
+func TestOIDCLogin_LinksExistingUserByVerifiedEmail(t *testing.T) {
+	authService, oidcService := setupTestOIDCService(t)
+	user := createTestLoginUser(t, authService)
+
+	// IdP がメールアドレスを確認していない場合は紐づけない
+	_, err := ssoLogin(t, oidcService, url.Values{"login_hint": {"test@example.com"}, "email_verified": {"false"}})
+	assert.ErrorIs(t, err, ErrOIDCEmailNotVerified)
+
+	_, err = ssoLogin(t, oidcService, url.Values{"login_hint": {"test@example.com"}})
+	require.NoError(t, err)
+
+	count, err := oidcService.identityRepo.CountByUser(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// 既存ユーザーは紐づけてもパスワードでログインできる
+	linked, _ := authService.userRepo.GetUserByID(user.ID)
+	assert.Equal(t, "user", linked.Role)
+	assert.False(t, linked.PasswordLoginDisabled)
+	_, _, err = loginTokens(authService.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{}))
+	assert.NoError(t, err)
+}
+
+func TestOIDCLogin_RejectsStateMismatch(t *testing.T) {
+	_, oidcService := setupTestOIDCService(t)
+
+	_, stateToken, err := oidcService.BeginLogin(context.Background(), "/")
+	require.NoError(t, err)
+
+	_, _, err = oidcService.CompleteLogin(context.Background(), stateToken, "other-state", "code", requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrInvalidOIDCState)
+	_, _, err = oidcService.CompleteLogin(context.Background(), "", "", "code", requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrInvalidOIDCState)
+}
+
+func TestOIDCLogin_NoRoleWithoutDefault(t *testing.T) {
+	_, oidcService := setupTestOIDCService(t)
+	oidcService.config.DefaultRole = ""
+
+	_, err := ssoLogin(t, oidcService, url.Values{"login_hint": {"bob@example.com"}})
+	assert.ErrorIs(t, err, ErrOIDCNoRole)
+}
+
+func TestSetPasswordLogin(t *testing.T) {
+	authService, oidcService := setupTestOIDCService(t)
+	user := createTestLoginUser(t, authService)
+
+	// シングルサインオンのアカウントが無いユーザーは禁止できない
+	assert.ErrorIs(t, oidcService.SetPasswordLogin(user.ID, false, requestmeta.Meta{}), ErrNoLinkedIdentity)
+
+	_, err := ssoLogin(t, oidcService, url.Values{"login_hint": {"test@example.com"}})
+	require.NoError(t, err)
+	require.NoError(t, oidcService.SetPasswordLogin(user.ID, false, requestmeta.Meta{}))
+
+	_, _, err = loginTokens(authService.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{}))
+	assert.ErrorIs(t, err, ErrPasswordLoginDisabled)
+
+	history, _, err := authService.loginHistoryRepo.List(repository.LoginHistoryFilter{UserID: &user.ID}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, models.LoginFailurePasswordLoginDisabled, history[0].FailureReason)
+
+	require.NoError(t, oidcService.SetPasswordLogin(user.ID, true, requestmeta.Meta{}))
+	_, _, err = loginTokens(authService.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{}))
+	assert.NoError(t, err)
+}
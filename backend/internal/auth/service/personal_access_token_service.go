@@ -0,0 +1,212 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	auditService "simple-ledger/internal/audit/service"
+	"simple-ledger/internal/auth/dto"
+	"simple-ledger/internal/auth/repository"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/requestmeta"
+	"simple-ledger/internal/common/security"
+	"simple-ledger/internal/models"
+	userRepository "simple-ledger/internal/user/repository"
+
+	"gorm.io/gorm"
+)
+
+// tokenPrefixLength: 一覧での識別用に保持するトークンの先頭の文字数（接頭辞 + 6文字）
+const tokenPrefixLength = len(security.PersonalAccessTokenPrefix) + 6
+
+// lastUsedUpdateInterval: 最終利用日時を更新する最小間隔
+// スクリプトからの連続したリクエストのたびに書き込まないようにする
+const lastUsedUpdateInterval = time.Minute
+
+var (
+	ErrPersonalAccessTokenNotFound = errors.New("personal access token not found")
+	ErrInvalidPersonalAccessToken  = errors.New("invalid or expired personal access token")
+	ErrAdminScopeNotAllowed        = errors.New("admin scope can only be granted by administrators")
+)
+
+// personalAccessTokenSnapshot: 監査ログに記録するトークンの状態（トークン自体やハッシュは含めない）
+type personalAccessTokenSnapshot struct {
+	UserID    uint      `json:"userId"`
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// PersonalAccessTokenService: 個人用アクセストークンの発行・失効と検証
+type PersonalAccessTokenService struct {
+	repo     *repository.PersonalAccessTokenRepository
+	userRepo *userRepository.UserRepository
+	audit    *auditService.AuditService
+	uow      uow.UnitOfWork
+	now      func() time.Time
+}
+
+func NewPersonalAccessTokenService(repo *repository.PersonalAccessTokenRepository, userRepo *userRepository.UserRepository, audit *auditService.AuditService, unitOfWork uow.UnitOfWork) *PersonalAccessTokenService {
+	return &PersonalAccessTokenService{
+		repo:     repo,
+		userRepo: userRepo,
+		audit:    audit,
+		uow:      unitOfWork,
+		now:      time.Now,
+	}
+}
+
+// Create はユーザーの個人用アクセストークンを発行する
+// トークン自体はハッシュのみを保存するため、レスポンスで一度だけ返す
+func (s *PersonalAccessTokenService) Create(userID uint, req *dto.CreatePersonalAccessTokenRequest, meta requestmeta.Meta) (*dto.CreatePersonalAccessTokenResponse, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := normalizeScopes(req.Scopes)
+	if slices.Contains(scopes, models.TokenScopeAdmin) && user.Role != "admin" {
+		return nil, ErrAdminScopeNotAllowed
+	}
+
+	token, err := security.NewPersonalAccessToken()
+	if err != nil {
+		return nil, err
+	}
+
+	record := &models.PersonalAccessToken{
+		UserID:      user.ID,
+		Name:        strings.TrimSpace(req.Name),
+		TokenPrefix: token[:tokenPrefixLength],
+		TokenHash:   security.HashPersonalAccessToken(token),
+		Scopes:      strings.Join(scopes, ","),
+		ExpiresAt:   s.now().AddDate(0, 0, req.ExpiresInDays),
+	}
+
+	err = s.uow.Do(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).Create(record); err != nil {
+			return err
+		}
+		return s.audit.WithTx(tx).Record(meta, auditService.Entry{
+			Action:     models.AuditActionCreate,
+			EntityType: models.AuditEntityPersonalAccessToken,
+			EntityID:   record.ID,
+			After:      toPersonalAccessTokenSnapshot(record),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.CreatePersonalAccessTokenResponse{
+		PersonalAccessTokenResponse: dto.ToPersonalAccessTokenResponse(record),
+		Token:                       token,
+	}, nil
+}
+
+// List はユーザーの失効していないトークンを取得
+func (s *PersonalAccessTokenService) List(userID uint) ([]dto.PersonalAccessTokenResponse, error) {
+	tokens, err := s.repo.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]dto.PersonalAccessTokenResponse, 0, len(tokens))
+	for i := range tokens {
+		responses = append(responses, dto.ToPersonalAccessTokenResponse(&tokens[i]))
+	}
+	return responses, nil
+}
+
+// Revoke はユーザー自身のトークンを失効させる
+// 他ユーザーのトークンや失効済みのトークンは存在しないものとして扱う
+func (s *PersonalAccessTokenService) Revoke(userID uint, id uint, meta requestmeta.Meta) error {
+	token, err := s.repo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPersonalAccessTokenNotFound
+		}
+		return err
+	}
+	if token.UserID != userID || token.RevokedAt != nil {
+		return ErrPersonalAccessTokenNotFound
+	}
+
+	return s.uow.Do(func(tx *gorm.DB) error {
+		revoked, err := s.repo.WithTx(tx).Revoke(token.ID, s.now())
+		if err != nil {
+			return err
+		}
+		if !revoked {
+			return ErrPersonalAccessTokenNotFound
+		}
+		return s.audit.WithTx(tx).Record(meta, auditService.Entry{
+			Action:     models.AuditActionDelete,
+			EntityType: models.AuditEntityPersonalAccessToken,
+			EntityID:   token.ID,
+			Before:     toPersonalAccessTokenSnapshot(token),
+		})
+	})
+}
+
+// VerifyPersonalAccessToken はトークンを検証し、トークンの所有者とスコープを返す
+// 失効済み・期限切れのトークンや、無効化されたユーザーのトークンは受け付けない
+func (s *PersonalAccessTokenService) VerifyPersonalAccessToken(token string) (*models.User, []string, error) {
+	if !security.IsPersonalAccessToken(token) {
+		return nil, nil, ErrInvalidPersonalAccessToken
+	}
+
+	record, err := s.repo.GetByHash(security.HashPersonalAccessToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidPersonalAccessToken
+		}
+		return nil, nil, err
+	}
+
+	now := s.now()
+	if record.RevokedAt != nil || !now.Before(record.ExpiresAt) {
+		return nil, nil, ErrInvalidPersonalAccessToken
+	}
+
+	user, err := s.userRepo.GetUserByID(record.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidPersonalAccessToken
+		}
+		return nil, nil, err
+	}
+	if !user.IsActive {
+		return nil, nil, ErrInvalidPersonalAccessToken
+	}
+
+	if record.LastUsedAt == nil || now.Sub(*record.LastUsedAt) >= lastUsedUpdateInterval {
+		if err := s.repo.Touch(record.ID, now); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return user, record.ScopeList(), nil
+}
+
+// normalizeScopes: 重複を除いたスコープを返す
+func normalizeScopes(scopes []string) []string {
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !slices.Contains(normalized, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized
+}
+
+func toPersonalAccessTokenSnapshot(token *models.PersonalAccessToken) personalAccessTokenSnapshot {
+	return personalAccessTokenSnapshot{
+		UserID:    token.UserID,
+		Name:      token.Name,
+		Scopes:    token.ScopeList(),
+		ExpiresAt: token.ExpiresAt,
+	}
+}
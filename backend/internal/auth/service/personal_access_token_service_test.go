@@ -0,0 +1,112 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	auditRepository "simple-ledger/internal/audit/repository"
+	auditService "simple-ledger/internal/audit/service"
+	"simple-ledger/internal/auth/dto"
+	"simple-ledger/internal/auth/repository"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/requestmeta"
+	"simple-ledger/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTestTokenService: AuthService と同じDBを使う PersonalAccessTokenService を作成
+func setupTestTokenService() (*AuthService, *PersonalAccessTokenService) {
+	db := setupTestAuthDB()
+	authService := newTestAuthService(db, DefaultLoginLimitConfig())
+	tokenService := NewPersonalAccessTokenService(
+		repository.NewPersonalAccessTokenRepository(db),
+		authService.userRepo,
+		auditService.NewAuditService(auditRepository.NewAuditLogRepository(db)),
+		uow.New(db),
+	)
+	return authService, tokenService
+}
+
+func TestPersonalAccessToken_CreateAndVerify(t *testing.T) {
+	authService, tokenService := setupTestTokenService()
+	user := createTestLoginUser(t, authService)
+
+	created, err := tokenService.Create(user.ID, &dto.CreatePersonalAccessTokenRequest{
+		Name:          "cron import",
+		Scopes:        []string{models.TokenScopeRead, models.TokenScopeTransactionsWrite, models.TokenScopeRead},
+		ExpiresInDays: 30,
+	}, requestmeta.Meta{ActorID: &user.ID})
+	require.NoError(t, err)
+	assert.True(t, len(created.Token) > len(created.TokenPrefix))
+	assert.Equal(t, created.Token[:len(created.TokenPrefix)], created.TokenPrefix)
+	assert.Equal(t, []string{models.TokenScopeRead, models.TokenScopeTransactionsWrite}, created.Scopes)
+
+	// トークン自体は保存しない
+	stored, err := tokenService.repo.GetByID(created.ID)
+	require.NoError(t, err)
+	assert.NotEqual(t, created.Token, stored.TokenHash)
+	assert.NotContains(t, stored.TokenHash, created.Token)
+
+	owner, scopes, err := tokenService.VerifyPersonalAccessToken(created.Token)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, owner.ID)
+	assert.Equal(t, []string{models.TokenScopeRead, models.TokenScopeTransactionsWrite}, scopes)
+
+	tokens, err := tokenService.List(user.ID)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.NotNil(t, tokens[0].LastUsedAt)
+
+	_, _, err = tokenService.VerifyPersonalAccessToken(created.Token + "0")
+	assert.ErrorIs(t, err, ErrInvalidPersonalAccessToken)
+}
+
+func TestPersonalAccessToken_AdminScopeRequiresAdmin(t *testing.T) {
+	authService, tokenService := setupTestTokenService()
+	user := createTestLoginUser(t, authService)
+
+	_, err := tokenService.Create(user.ID, &dto.CreatePersonalAccessTokenRequest{
+		Name:          "admin",
+		Scopes:        []string{models.TokenScopeAdmin},
+		ExpiresInDays: 1,
+	}, requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrAdminScopeNotAllowed)
+}
+
+func TestPersonalAccessToken_RejectsRevokedExpiredAndInactive(t *testing.T) {
+	authService, tokenService := setupTestTokenService()
+	user := createTestLoginUser(t, authService)
+	create := func() *dto.CreatePersonalAccessTokenResponse {
+		created, err := tokenService.Create(user.ID, &dto.CreatePersonalAccessTokenRequest{
+			Name:          "script",
+			Scopes:        []string{models.TokenScopeRead},
+			ExpiresInDays: 1,
+		}, requestmeta.Meta{})
+		require.NoError(t, err)
+		return created
+	}
+
+	// 他ユーザーのトークンは失効できない
+	revoked := create()
+	assert.ErrorIs(t, tokenService.Revoke(user.ID+1, revoked.ID, requestmeta.Meta{}), ErrPersonalAccessTokenNotFound)
+	require.NoError(t, tokenService.Revoke(user.ID, revoked.ID, requestmeta.Meta{}))
+	assert.ErrorIs(t, tokenService.Revoke(user.ID, revoked.ID, requestmeta.Meta{}), ErrPersonalAccessTokenNotFound)
+	_, _, err := tokenService.VerifyPersonalAccessToken(revoked.Token)
+	assert.ErrorIs(t, err, ErrInvalidPersonalAccessToken)
+
+	// 有効期限切れ
+	expired := create()
+	tokenService.now = func() time.Time { return time.Now().AddDate(0, 0, 2) }
+	_, _, err = tokenService.VerifyPersonalAccessToken(expired.Token)
+	assert.ErrorIs(t, err, ErrInvalidPersonalAccessToken)
+	tokenService.now = time.Now
+
+	// 無効化されたユーザー
+	active := create()
+	_, err = authService.userRepo.UpdateUser(user.ID, map[string]interface{}{"is_active": false})
+	require.NoError(t, err)
+	_, _, err = tokenService.VerifyPersonalAccessToken(active.Token)
+	assert.ErrorIs(t, err, ErrInvalidPersonalAccessToken)
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"simple-ledger/internal/auth/dto"
+	"simple-ledger/internal/auth/repository"
+	"simple-ledger/internal/common/security"
+
+	"gorm.io/gorm"
+)
+
+// RevokedReasonUserRevoked: 利用者がセッション一覧から個別に失効させた
+const RevokedReasonUserRevoked = "user_revoked"
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionService: ログイン中のセッションとログイン履歴の参照・管理
+type SessionService struct {
+	sessionRepo      *repository.SessionRepository
+	loginHistoryRepo *repository.LoginHistoryRepository
+}
+
+func NewSessionService(sessionRepo *repository.SessionRepository, loginHistoryRepo *repository.LoginHistoryRepository) *SessionService {
+	return &SessionService{sessionRepo: sessionRepo, loginHistoryRepo: loginHistoryRepo}
+}
+
+// ListSessions はユーザーの有効なセッションを取得
+// currentRefreshToken（リクエスト元のリフレッシュトークン）に対応するセッションには Current を付ける
+func (s *SessionService) ListSessions(userID uint, currentRefreshToken string) ([]dto.SessionResponse, error) {
+	expiresIn := time.Duration(security.GetRefreshTokenExpirationSeconds()) * time.Second
+	sessions, err := s.sessionRepo.ListActiveSessions(userID, time.Now().Add(-expiresIn))
+	if err != nil {
+		return nil, err
+	}
+
+	currentFamilyID := ""
+	if claims, err := security.VerifyRefreshToken(currentRefreshToken); err == nil && claims.UserID == userID {
+		currentFamilyID = claims.SessionID
+	}
+
+	responses := make([]dto.SessionResponse, 0, len(sessions))
+	for i := range sessions {
+		responses = append(responses, dto.ToSessionResponse(&sessions[i], sessions[i].FamilyID == currentFamilyID))
+	}
+	return responses, nil
+}
+
+// RevokeSession はユーザー自身のセッションを失効させる
+// 他ユーザーのセッションは存在しないものとして扱う
+func (s *SessionService) RevokeSession(userID uint, sessionID uint) error {
+	session, err := s.sessionRepo.GetSessionByID(sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	if session.UserID != userID {
+		return ErrSessionNotFound
+	}
+
+	return s.sessionRepo.RevokeSession(session.ID, RevokedReasonUserRevoked, time.Now())
+}
+
+// ListLoginHistory はユーザー自身のログイン履歴を新しい順に取得
+func (s *SessionService) ListLoginHistory(userID uint, req *dto.GetLoginHistoryRequest) (*dto.GetLoginHistoryResponse, error) {
+	return s.listLoginHistory(repository.LoginHistoryFilter{UserID: &userID}, req.Page, req.PageSize)
+}
+
+// SearchLoginHistory は全ユーザーのログイン履歴を検索（管理者用）
+func (s *SessionService) SearchLoginHistory(req *dto.SearchLoginHistoryRequest) (*dto.GetLoginHistoryResponse, error) {
+	filter := repository.LoginHistoryFilter{
+		UserID:  req.UserID,
+		Email:   req.Email,
+		Success: req.Success,
+	}
+
+	if req.DateFrom != "" {
+		dateFrom, err := time.Parse("2006-01-02", req.DateFrom)
+		if err != nil {
+			return nil, errors.New("invalid dateFrom format, use YYYY-MM-DD")
+		}
+		filter.DateFrom = &dateFrom
+	}
+	if req.DateTo != "" {
+		dateTo, err := time.Parse("2006-01-02", req.DateTo)
+		if err != nil {
+			return nil, errors.New("invalid dateTo format, use YYYY-MM-DD")
+		}
+		// 終了日を含めるため翌日の0時より前を対象とする
+		dateTo = dateTo.AddDate(0, 0, 1)
+		filter.DateTo = &dateTo
+	}
+
+	return s.listLoginHistory(filter, req.Page, req.PageSize)
+}
+
+// listLoginHistory: 条件に一致するログイン履歴をレスポンスに変換
+func (s *SessionService) listLoginHistory(filter repository.LoginHistoryFilter, page, pageSize int) (*dto.GetLoginHistoryResponse, error) {
+	histories, totalCount, err := s.loginHistoryRepo.List(filter, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]dto.LoginHistoryResponse, 0, len(histories))
+	for i := range histories {
+		responses = append(responses, dto.ToLoginHistoryResponse(&histories[i]))
+	}
+
+	return &dto.GetLoginHistoryResponse{
+		LoginHistory: responses,
+		Total:        int(totalCount),
+		Page:         page,
+		PageSize:     pageSize,
+		HasNextPage:  int64(page*pageSize) < totalCount,
+	}, nil
+}
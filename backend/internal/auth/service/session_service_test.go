@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"simple-ledger/internal/auth/dto"
+	"simple-ledger/internal/common/requestmeta"
+	"simple-ledger/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTestSessionService: AuthService と同じDBを使う SessionService を作成
+func setupTestSessionService() (*AuthService, *SessionService) {
+	authService := setupTestAuthService()
+	return authService, NewSessionService(authService.sessionRepo, authService.loginHistoryRepo)
+}
+
+func TestListSessions_MarksCurrentSession(t *testing.T) {
+	authService, sessionService := setupTestSessionService()
+	user := createTestLoginUser(t, authService)
+
+	_, _, _ = loginTokens(authService.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{UserAgent: "other-device"}))
+	_, currentToken, _ := loginTokens(authService.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{UserAgent: "this-device"}))
+
+	sessions, err := sessionService.ListSessions(user.ID, currentToken)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+
+	for _, session := range sessions {
+		assert.Equal(t, session.UserAgent == "this-device", session.Current)
+	}
+}
+
+func TestRevokeSession_OwnSessionOnly(t *testing.T) {
+	authService, sessionService := setupTestSessionService()
+	user := createTestLoginUser(t, authService)
+
+	_, refreshToken, _ := loginTokens(authService.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{}))
+	sessions, _ := sessionService.ListSessions(user.ID, "")
+	require.Len(t, sessions, 1)
+
+	// 他ユーザーのセッションは存在しないものとして扱う
+	err := sessionService.RevokeSession(user.ID+1, sessions[0].ID)
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+
+	err = sessionService.RevokeSession(user.ID, 9999)
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+
+	require.NoError(t, sessionService.RevokeSession(user.ID, sessions[0].ID))
+
+	// 失効したセッションは一覧に表示されず、リフレッシュもできない
+	sessions, _ = sessionService.ListSessions(user.ID, "")
+	assert.Empty(t, sessions)
+
+	_, _, err = authService.RefreshAccessToken(refreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestLoginHistory_ListAndSearch(t *testing.T) {
+	authService, sessionService := setupTestSessionService()
+	user := createTestLoginUser(t, authService)
+
+	_, _, _ = loginTokens(authService.Login(context.Background(), "test@example.com", "password123", requestmeta.Meta{}))
+	_, _, _ = loginTokens(authService.Login(context.Background(), "test@example.com", "wrongpassword", requestmeta.Meta{}))
+	_, _, _ = loginTokens(authService.Login(context.Background(), "unknown@example.com", "password123", requestmeta.Meta{}))
+
+	// 自分の履歴には存在しないユーザーでの試行は含まれない
+	own, err := sessionService.ListLoginHistory(user.ID, &dto.GetLoginHistoryRequest{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 2, own.Total)
+
+	failed := false
+	all, err := sessionService.SearchLoginHistory(&dto.SearchLoginHistoryRequest{Success: &failed, Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 2, all.Total)
+
+	byEmail, err := sessionService.SearchLoginHistory(&dto.SearchLoginHistoryRequest{Email: "unknown@example.com", Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	require.Equal(t, 1, byEmail.Total)
+	assert.Equal(t, models.LoginFailureInvalidCredentials, byEmail.LoginHistory[0].FailureReason)
+
+	future, err := sessionService.SearchLoginHistory(&dto.SearchLoginHistoryRequest{DateFrom: "2999-01-01", Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 0, future.Total)
+
+	_, err = sessionService.SearchLoginHistory(&dto.SearchLoginHistoryRequest{DateTo: "2024/01/01", Page: 1, PageSize: 10})
+	assert.Error(t, err)
+}
@@ -9,6 +9,12 @@ import (
 type ChartOfAccountsRepository interface {
 	// GetByTypes: 指定された勘定科目区分のデータを取得
 	GetByTypes(types []models.AccountType) ([]models.ChartOfAccounts, error)
+
+	// GetByIDs: 指定されたIDの勘定科目を取得（無効な勘定科目も含む）
+	GetByIDs(ids []uint) ([]models.ChartOfAccounts, error)
+
+	// WithTx: 指定したDBトランザクション上で動作するリポジトリを返す
+	WithTx(tx *gorm.DB) ChartOfAccountsRepository
 }
 
 type chartOfAccountsRepository struct {
@@ -19,6 +25,10 @@ func NewChartOfAccountsRepository(db *gorm.DB) ChartOfAccountsRepository {
 	return &chartOfAccountsRepository{db: db}
 }
 
+func (r *chartOfAccountsRepository) WithTx(tx *gorm.DB) ChartOfAccountsRepository {
+	return &chartOfAccountsRepository{db: tx}
+}
+
 func (r *chartOfAccountsRepository) GetByTypes(types []models.AccountType) ([]models.ChartOfAccounts, error) {
 	var accounts []models.ChartOfAccounts
 
@@ -34,3 +44,18 @@ func (r *chartOfAccountsRepository) GetByTypes(types []models.AccountType) ([]mo
 
 	return accounts, nil
 }
+
+func (r *chartOfAccountsRepository) GetByIDs(ids []uint) ([]models.ChartOfAccounts, error) {
+	var accounts []models.ChartOfAccounts
+	if len(ids) == 0 {
+		return accounts, nil
+	}
+
+	if err := r.db.
+		Where("id IN ?", ids).
+		Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
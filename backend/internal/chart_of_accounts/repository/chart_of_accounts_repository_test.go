@@ -3,21 +3,19 @@ package repository
 import (
 	"testing"
 
+	"simple-ledger/internal/common/db/dbtest"
 	"simple-ledger/internal/models"
 
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
-func setupChartOfAccountsTestDB() *gorm.DB {
-	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	_ = db.AutoMigrate(&models.ChartOfAccounts{})
-	return db
+func setupChartOfAccountsTestDB(t *testing.T) *gorm.DB {
+	return dbtest.Open(t, &models.ChartOfAccounts{})
 }
 
 func TestGetByTypes_SingleType(t *testing.T) {
-	db := setupChartOfAccountsTestDB()
+	db := setupChartOfAccountsTestDB(t)
 	repo := NewChartOfAccountsRepository(db)
 
 	accounts := []models.ChartOfAccounts{
@@ -35,7 +33,7 @@ func TestGetByTypes_SingleType(t *testing.T) {
 }
 
 func TestGetByTypes_MultipleTypes(t *testing.T) {
-	db := setupChartOfAccountsTestDB()
+	db := setupChartOfAccountsTestDB(t)
 	repo := NewChartOfAccountsRepository(db)
 
 	accounts := []models.ChartOfAccounts{
@@ -52,10 +50,25 @@ func TestGetByTypes_MultipleTypes(t *testing.T) {
 }
 
 func TestGetByTypes_EmptyResult(t *testing.T) {
-	db := setupChartOfAccountsTestDB()
+	db := setupChartOfAccountsTestDB(t)
 	repo := NewChartOfAccountsRepository(db)
 
 	result, err := repo.GetByTypes([]models.AccountType{models.LiabilityAccount})
 	assert.NoError(t, err)
 	assert.Len(t, result, 0)
 }
+
+func TestGetByIDs_IncludesInactive(t *testing.T) {
+	db := setupChartOfAccountsTestDB(t)
+	repo := NewChartOfAccountsRepository(db)
+
+	active := models.ChartOfAccounts{Code: "4000", Name: "売上", Type: models.RevenueAccount, NormalBalance: models.CreditBalance, IsActive: true}
+	db.Create(&active)
+	inactive := models.ChartOfAccounts{Code: "5000", Name: "仕入", Type: models.ExpenseAccount, NormalBalance: models.DebitBalance, IsActive: true}
+	db.Create(&inactive)
+	db.Model(&inactive).Update("is_active", false)
+
+	result, err := repo.GetByIDs([]uint{active.ID, inactive.ID, 999})
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+}
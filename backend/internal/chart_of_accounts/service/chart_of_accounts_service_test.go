@@ -4,10 +4,12 @@ import (
 	"errors"
 	"testing"
 
+	"simple-ledger/internal/chart_of_accounts/repository"
 	"simple-ledger/internal/models"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 type MockChartOfAccountsRepository struct {
@@ -22,6 +24,18 @@ func (m *MockChartOfAccountsRepository) GetByTypes(types []models.AccountType) (
 	return args.Get(0).([]models.ChartOfAccounts), args.Error(1)
 }
 
+func (m *MockChartOfAccountsRepository) GetByIDs(ids []uint) ([]models.ChartOfAccounts, error) {
+	args := m.Called(ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ChartOfAccounts), args.Error(1)
+}
+
+func (m *MockChartOfAccountsRepository) WithTx(tx *gorm.DB) repository.ChartOfAccountsRepository {
+	return m
+}
+
 func TestGetByTypes_Success(t *testing.T) {
 	mockRepo := new(MockChartOfAccountsRepository)
 
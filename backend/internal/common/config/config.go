@@ -40,3 +40,13 @@ func GetEnvAsFloat64(key string, defaultValue float64) float64 {
 	}
 	return defaultValue
 }
+
+// GetEnvAsBool は環境変数をboolとして取得（失敗時はデフォルト値）
+func GetEnvAsBool(key string, defaultValue bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		if v, err := strconv.ParseBool(value); err == nil {
+			return v
+		}
+	}
+	return defaultValue
+}
@@ -2,23 +2,137 @@ package config
 
 import (
 	"fmt"
+	"net/url"
+	"strings"
+	"time"
 
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
-func SetupDatabase() (*gorm.DB, error) {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		GetEnv("DB_USER", ""),
-		GetEnv("DB_PASSWORD", ""),
-		GetEnv("DB_HOST", ""),
-		GetEnvAsInt("DB_PORT", 3306),
-		GetEnv("DB_NAME", ""),
-	)
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+// 対応するデータベース（DB_DRIVER の値）
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// SQLiteMemory: SQLite をメモリー上に作成する場合の DB_PATH（プロセスの終了時に破棄される）
+const SQLiteMemory = ":memory:"
+
+// DatabaseConfig はデータベースの接続設定
+type DatabaseConfig struct {
+	// Driver: 使用するデータベース（mysql・postgres・sqlite）
+	Driver string
+
+	// MySQL・PostgreSQL の接続先
+	Host     string
+	Port     int // 0 の場合はデータベースの既定のポート
+	User     string
+	Password string
+	Name     string
+
+	// SSLMode: PostgreSQL の sslmode（disable・require・verify-full など）
+	SSLMode string
+
+	// Path: SQLite のファイルのパス（:memory: の場合はメモリー上に作成）
+	Path string
+}
+
+// DatabaseConfigFromEnv は環境変数から接続設定を読み込む（DB_DRIVER の既定は mysql）
+func DatabaseConfigFromEnv() DatabaseConfig {
+	return DatabaseConfig{
+		Driver:   strings.ToLower(GetEnv("DB_DRIVER", DriverMySQL)),
+		Host:     GetEnv("DB_HOST", ""),
+		Port:     GetEnvAsInt("DB_PORT", 0),
+		User:     GetEnv("DB_USER", ""),
+		Password: GetEnv("DB_PASSWORD", ""),
+		Name:     GetEnv("DB_NAME", ""),
+		SSLMode:  GetEnv("DB_SSLMODE", "disable"),
+		Path:     GetEnv("DB_PATH", "ledger.db"),
+	}
+}
+
+// DSN はドライバーに渡す接続文字列を組み立てる
+// 日時はすべて UTC で読み書きする（データベースによって日時の比較結果が変わらないようにする）
+func (c DatabaseConfig) DSN() (string, error) {
+	switch c.Driver {
+	case DriverMySQL:
+		port := c.Port
+		if port == 0 {
+			port = 3306
+		}
+		return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=UTC",
+			c.User, c.Password, c.Host, port, c.Name), nil
+	case DriverPostgres:
+		port := c.Port
+		if port == 0 {
+			port = 5432
+		}
+		u := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(c.User, c.Password),
+			Host:     fmt.Sprintf("%s:%d", c.Host, port),
+			Path:     "/" + c.Name,
+			RawQuery: url.Values{"sslmode": {c.SSLMode}, "TimeZone": {"UTC"}}.Encode(),
+		}
+		return u.String(), nil
+	case DriverSQLite:
+		if c.Path == "" {
+			return "", fmt.Errorf("DB_PATH is required for sqlite")
+		}
+		// 外部キー制約を有効にし、書き込みが重なった場合はロックの解放を待つ
+		params := "_foreign_keys=on&_busy_timeout=5000"
+		if c.Path == SQLiteMemory {
+			// メモリー上のデータベースは接続ごとに別になるため、プロセス内の接続で共有する
+			return "file::memory:?cache=shared&" + params, nil
+		}
+		return c.Path + "?" + params + "&_journal_mode=WAL", nil
+	default:
+		return "", fmt.Errorf("unsupported DB_DRIVER: %s (mysql, postgres or sqlite)", c.Driver)
+	}
+}
+
+// Dialector は GORM のドライバーを返す
+func (c DatabaseConfig) Dialector() (gorm.Dialector, error) {
+	dsn, err := c.DSN()
+	if err != nil {
+		return nil, err
+	}
+	switch c.Driver {
+	case DriverMySQL:
+		return mysql.Open(dsn), nil
+	case DriverPostgres:
+		return postgres.Open(dsn), nil
+	default:
+		return sqlite.Open(dsn), nil
+	}
+}
+
+// NewGormConfig は GORM の設定を返す
+func NewGormConfig() *gorm.Config {
+	return &gorm.Config{
+		// CreatedAt などの自動設定される日時も UTC で保存する
+		NowFunc: func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// OpenDatabase は設定に従ってデータベースに接続する
+func OpenDatabase(cfg DatabaseConfig) (*gorm.DB, error) {
+	dialector, err := cfg.Dialector()
+	if err != nil {
+		return nil, err
+	}
+	db, err := gorm.Open(dialector, NewGormConfig())
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
 	return db, nil
 }
+
+func SetupDatabase() (*gorm.DB, error) {
+	return OpenDatabase(DatabaseConfigFromEnv())
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabaseConfigDSN(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      DatabaseConfig
+		expected string
+	}{
+		{
+			name:     "mysql",
+			cfg:      DatabaseConfig{Driver: DriverMySQL, Host: "db", User: "ledger", Password: "secret", Name: "ledger"},
+			expected: "ledger:secret@tcp(db:3306)/ledger?charset=utf8mb4&parseTime=True&loc=UTC",
+		},
+		{
+			name:     "postgres",
+			cfg:      DatabaseConfig{Driver: DriverPostgres, Host: "db", Port: 5433, User: "ledger", Password: "p@ss word", Name: "ledger", SSLMode: "require"},
+			expected: "postgres://ledger:p%40ss%20word@db:5433/ledger?TimeZone=UTC&sslmode=require",
+		},
+		{
+			name:     "sqlite file",
+			cfg:      DatabaseConfig{Driver: DriverSQLite, Path: "/data/ledger.db"},
+			expected: "/data/ledger.db?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL",
+		},
+		{
+			name:     "sqlite memory",
+			cfg:      DatabaseConfig{Driver: DriverSQLite, Path: SQLiteMemory},
+			expected: "file::memory:?cache=shared&_foreign_keys=on&_busy_timeout=5000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dsn, err := tt.cfg.DSN()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, dsn)
+		})
+	}
+}
+
+func TestDatabaseConfigDSN_UnsupportedDriver(t *testing.T) {
+	_, err := DatabaseConfig{Driver: "oracle"}.DSN()
+	assert.Error(t, err)
+}
+
+func TestOpenDatabase_SQLiteMemory(t *testing.T) {
+	db, err := OpenDatabase(DatabaseConfig{Driver: DriverSQLite, Path: SQLiteMemory})
+	assert.NoError(t, err)
+	assert.NoError(t, db.Exec("SELECT 1").Error)
+}
@@ -0,0 +1,141 @@
+package cookie
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"simple-ledger/internal/common/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Config はクッキーの属性の設定
+type Config struct {
+	// Secure: HTTPS の場合のみ送信する（本番環境では必須）
+	Secure bool
+
+	// SameSite: サイトをまたぐリクエストでの送信（Lax・Strict・None）
+	SameSite http.SameSite
+
+	// Domain: 送信先のドメイン（空の場合は設定したホストのみ）
+	Domain string
+
+	// HostPrefix: 名前に __Host- 接頭辞を付ける（サブドメインなどから上書きできないようにする）
+	// Path が / 以外のクッキーには __Secure- を付ける。Secure が必要で、Domain は指定できない
+	HostPrefix bool
+}
+
+// DefaultConfig は既定の設定（開発環境向け）
+func DefaultConfig() Config {
+	return Config{
+		Secure:   false,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// ConfigFromEnv は環境変数から設定を読み込む（未設定の項目は既定値）
+func ConfigFromEnv() (Config, error) {
+	cfg := DefaultConfig()
+	cfg.Secure = config.GetEnvAsBool("COOKIE_SECURE", cfg.Secure)
+	cfg.Domain = config.GetEnv("COOKIE_DOMAIN", cfg.Domain)
+	cfg.HostPrefix = config.GetEnvAsBool("COOKIE_HOST_PREFIX", cfg.HostPrefix)
+
+	switch strings.ToLower(config.GetEnv("COOKIE_SAMESITE", "lax")) {
+	case "lax", "":
+		cfg.SameSite = http.SameSiteLaxMode
+	case "strict":
+		cfg.SameSite = http.SameSiteStrictMode
+	case "none":
+		cfg.SameSite = http.SameSiteNoneMode
+	default:
+		return cfg, fmt.Errorf("unsupported COOKIE_SAMESITE: %s (lax, strict or none)", config.GetEnv("COOKIE_SAMESITE", ""))
+	}
+	return cfg, nil
+}
+
+// Validate はブラウザーが受け付けない、または安全でない組み合わせを拒否する
+// production が true の場合は Secure を必須とする
+func (c Config) Validate(production bool) error {
+	if c.SameSite == http.SameSiteNoneMode && !c.Secure {
+		return errors.New("COOKIE_SAMESITE=none requires COOKIE_SECURE=true")
+	}
+	if c.HostPrefix && !c.Secure {
+		return errors.New("COOKIE_HOST_PREFIX requires COOKIE_SECURE=true")
+	}
+	if c.HostPrefix && c.Domain != "" {
+		return errors.New("COOKIE_HOST_PREFIX cannot be used with COOKIE_DOMAIN")
+	}
+	if strings.Contains(c.Domain, ":") {
+		return errors.New("COOKIE_DOMAIN must not contain a port")
+	}
+	if production && !c.Secure {
+		return errors.New("COOKIE_SECURE must be true in production")
+	}
+	return nil
+}
+
+// current は現在の設定
+var current = DefaultConfig()
+
+// Init はクッキーの属性を設定する
+func Init(cfg Config) {
+	current = cfg
+}
+
+// Cookie はアプリケーションが使用するクッキー（すべて HttpOnly）
+// Domain・Secure・SameSite と名前の接頭辞は設定から決まる
+type Cookie struct {
+	name     string
+	path     string
+	sameSite http.SameSite // 0 の場合は設定の値
+}
+
+var (
+	AccessToken  = Cookie{name: "accessToken", path: "/"}
+	RefreshToken = Cookie{name: "refreshToken", path: "/"}
+	CSRFToken    = Cookie{name: "csrfToken", path: "/"}
+
+	// OIDCState: IdP からのリダイレクト（サイトをまたぐトップレベルの GET）でも送信されるよう Lax に固定する
+	OIDCState = Cookie{name: "oidcState", path: "/api/auth/oidc", sameSite: http.SameSiteLaxMode}
+)
+
+// Name は接頭辞を含むクッキーの名前を返す
+func (c Cookie) Name() string {
+	if !current.HostPrefix {
+		return c.name
+	}
+	if c.path == "/" {
+		return "__Host-" + c.name
+	}
+	return "__Secure-" + c.name
+}
+
+// Get はリクエストのクッキーの値を返す
+func (c Cookie) Get(ctx *gin.Context) (string, error) {
+	return ctx.Cookie(c.Name())
+}
+
+// Set はクッキーを設定する（maxAge が 0 の場合はブラウザーを閉じるまで）
+func (c Cookie) Set(ctx *gin.Context, value string, maxAge int) {
+	sameSite := c.sameSite
+	if sameSite == 0 {
+		sameSite = current.SameSite
+	}
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     c.Name(),
+		Value:    value,
+		Path:     c.path,
+		Domain:   current.Domain,
+		MaxAge:   maxAge,
+		Secure:   current.Secure,
+		HttpOnly: true, // JavaScript からアクセス不可
+		SameSite: sameSite,
+	})
+}
+
+// Clear はクッキーを削除する（MaxAge を負の値に設定）
+func (c Cookie) Clear(ctx *gin.Context) {
+	c.Set(ctx, "", -1)
+}
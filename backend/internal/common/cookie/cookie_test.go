@@ -0,0 +1,63 @@
+package cookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        Config
+		production bool
+		wantErr    bool
+	}{
+		{"development default", DefaultConfig(), false, false},
+		{"production requires secure", DefaultConfig(), true, true},
+		{"production secure", Config{Secure: true, SameSite: http.SameSiteLaxMode}, true, false},
+		{"samesite none without secure", Config{SameSite: http.SameSiteNoneMode}, false, true},
+		{"host prefix without secure", Config{SameSite: http.SameSiteLaxMode, HostPrefix: true}, false, true},
+		{"host prefix with domain", Config{Secure: true, SameSite: http.SameSiteLaxMode, HostPrefix: true, Domain: "example.com"}, false, true},
+		{"domain with port", Config{SameSite: http.SameSiteLaxMode, Domain: "localhost:8080"}, false, true},
+		{"host prefix", Config{Secure: true, SameSite: http.SameSiteStrictMode, HostPrefix: true}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate(tt.production)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCookie_Set(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	Init(Config{Secure: true, SameSite: http.SameSiteStrictMode, HostPrefix: true})
+	t.Cleanup(func() { Init(DefaultConfig()) })
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	AccessToken.Set(ctx, "token", 60)
+	OIDCState.Set(ctx, "state", 60)
+
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 2) {
+		assert.Equal(t, "__Host-accessToken", cookies[0].Name)
+		assert.Equal(t, "/", cookies[0].Path)
+		assert.Empty(t, cookies[0].Domain)
+		assert.True(t, cookies[0].Secure)
+		assert.True(t, cookies[0].HttpOnly)
+		assert.Equal(t, http.SameSiteStrictMode, cookies[0].SameSite)
+
+		// Path が / 以外のクッキーは __Secure-、SameSite は Lax に固定
+		assert.Equal(t, "__Secure-oidcState", cookies[1].Name)
+		assert.Equal(t, http.SameSiteLaxMode, cookies[1].SameSite)
+	}
+}
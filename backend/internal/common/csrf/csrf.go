@@ -0,0 +1,75 @@
+package csrf
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+
+	"simple-ledger/internal/common/cookie"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderName: CSRF トークンを受け渡す HTTP ヘッダー
+// フロントエンドは別オリジンのためクッキーを読めないので、レスポンスヘッダーで受け取り、更新系リクエストで送り返す
+const HeaderName = "X-CSRF-Token"
+
+// contextKeyToken: gin.Context に CSRF トークンを保存するキー
+const contextKeyToken = "csrfToken"
+
+// tokenLength: トークンの長さ（Base64 にする前のバイト数）
+const tokenLength = 32
+
+// Middleware は Double Submit Cookie 方式で CSRF を防ぐミドルウェア
+// クッキーのトークンをレスポンスヘッダーで返し、POST・PUT・PATCH・DELETE では同じ値のヘッダーを必須とする
+// Authorization ヘッダーを送るリクエスト（個人用アクセストークンなど）はクッキーで認証しないため対象外
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, err := cookie.CSRFToken.Get(ctx)
+		if err != nil || len(token) != base64.RawURLEncoding.EncodedLen(tokenLength) {
+			token = Rotate(ctx)
+		} else {
+			ctx.Set(contextKeyToken, token)
+			ctx.Header(HeaderName, token)
+		}
+
+		if requiresToken(ctx.Request) && subtle.ConstantTimeCompare([]byte(ctx.GetHeader(HeaderName)), []byte(token)) != 1 {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "invalid CSRF token"})
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// Rotate は新しいトークンを発行してクッキーとレスポンスヘッダーに設定する
+// ログイン時に呼び出し、ログイン前に知られたトークンを使えないようにする
+func Rotate(ctx *gin.Context) string {
+	b := make([]byte, tokenLength)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	cookie.CSRFToken.Set(ctx, token, 0)
+	ctx.Set(contextKeyToken, token)
+	ctx.Header(HeaderName, token)
+	return token
+}
+
+// Token は現在のリクエストの CSRF トークンを返す（Middleware を通過していない場合は空）
+func Token(ctx *gin.Context) string {
+	return ctx.GetString(contextKeyToken)
+}
+
+// requiresToken: CSRF トークンが必要なリクエストか
+func requiresToken(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return r.Header.Get("Authorization") == ""
+	default:
+		return false
+	}
+}
@@ -0,0 +1,104 @@
+package csrf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/resource", func(ctx *gin.Context) { ctx.String(http.StatusOK, Token(ctx)) })
+	r.POST("/resource", func(ctx *gin.Context) { ctx.Status(http.StatusNoContent) })
+	r.POST("/login", func(ctx *gin.Context) { ctx.String(http.StatusOK, Rotate(ctx)) })
+	return r
+}
+
+func TestMiddleware_IssuesToken(t *testing.T) {
+	r := setupRouter()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/resource", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	token := w.Header().Get(HeaderName)
+	assert.NotEmpty(t, token)
+	assert.Equal(t, token, w.Body.String())
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, "csrfToken", cookies[0].Name)
+		assert.Equal(t, token, cookies[0].Value)
+		assert.True(t, cookies[0].HttpOnly)
+	}
+
+	// クッキーがある場合は同じトークンを返し、クッキーは設定し直さない
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+	req.AddCookie(cookies[0])
+	r.ServeHTTP(w, req)
+	assert.Equal(t, token, w.Header().Get(HeaderName))
+	assert.Empty(t, w.Result().Cookies())
+}
+
+func TestMiddleware_RequiresTokenForUnsafeMethods(t *testing.T) {
+	r := setupRouter()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/resource", nil))
+	cookie := w.Result().Cookies()[0]
+
+	tests := []struct {
+		name          string
+		header        string
+		authorization string
+		withCookie    bool
+		want          int
+	}{
+		{"matching header", cookie.Value, "", true, http.StatusNoContent},
+		{"missing header", "", "", true, http.StatusForbidden},
+		{"wrong header", cookie.Value + "x", "", true, http.StatusForbidden},
+		{"missing cookie", cookie.Value, "", false, http.StatusForbidden},
+		{"authorization header", "", "Bearer slpat_test", false, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/resource", nil)
+			if tt.header != "" {
+				req.Header.Set(HeaderName, tt.header)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			if tt.withCookie {
+				req.AddCookie(cookie)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestRotate(t *testing.T) {
+	r := setupRouter()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/resource", nil))
+	cookie := w.Result().Cookies()[0]
+
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.Header.Set(HeaderName, cookie.Value)
+	req.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	rotated := w.Header().Get(HeaderName)
+	assert.NotEqual(t, cookie.Value, rotated)
+	assert.Equal(t, rotated, w.Body.String())
+}
@@ -0,0 +1,275 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 0001_initial_schema: 起動時の AutoMigrate で作成していたスキーマ
+// 以前のバージョンで作成済みのデータベースに適用しても、不足しているテーブル・列・インデックスを追加するだけで既存のデータは変更しない
+// モデルを変更してもこのマイグレーションの結果が変わらないよう、当時のモデルを複製した構造体を使う
+func init() {
+	register(Migration{
+		Version: 1,
+		Name:    "initial_schema",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(initialSchemaTables...); err != nil {
+				return err
+			}
+			// 監査ログチェーンの末尾行を用意（追記時はこの行をロックして直列化する）
+			return tx.FirstOrCreate(&auditChainHead0001{ID: 1}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			// 外部キーで参照されるテーブルが後になるよう、作成と逆の順に削除する
+			for i := len(initialSchemaTables) - 1; i >= 0; i-- {
+				if err := tx.Migrator().DropTable(initialSchemaTables[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
+
+// initialSchemaTables: 作成するテーブル（参照されるテーブルが先）
+var initialSchemaTables = []interface{}{
+	&user0001{},
+	&authSession0001{}, &refreshToken0001{}, &loginHistory0001{},
+	&userTOTP0001{}, &mfaRecoveryCode0001{}, &roleMFAPolicy0001{}, &accountToken0001{}, &personalAccessToken0001{}, &userIdentity0001{},
+	&chartOfAccounts0001{},
+	&transaction0001{},
+	&journalEntry0001{},
+	&fiscalPeriod0001{},
+	&attachment0001{},
+	&auditLog0001{}, &auditChainHead0001{},
+}
+
+type user0001 struct {
+	ID                    uint   `gorm:"primaryKey"`
+	Email                 string `gorm:"type:varchar(255);uniqueIndex"`
+	Name                  string `gorm:"type:varchar(255);not null"`
+	Password              string `gorm:"type:text"`
+	Role                  string `gorm:"type:varchar(50);not null"`
+	IsActive              bool   `gorm:"default:true"`
+	LastLoginAt           *time.Time
+	LockedUntil           *time.Time
+	EmailVerifiedAt       *time.Time
+	PasswordLoginDisabled bool `gorm:"not null;default:false"`
+	TokenVersion          uint `gorm:"not null;default:0"`
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+}
+
+func (user0001) TableName() string { return "users" }
+
+type authSession0001 struct {
+	ID            uint   `gorm:"primaryKey"`
+	UserID        uint   `gorm:"not null;index"`
+	FamilyID      string `gorm:"type:varchar(64);not null;uniqueIndex"`
+	UserAgent     string `gorm:"type:varchar(512)"`
+	IPAddress     string `gorm:"type:varchar(45)"`
+	LastUsedAt    time.Time
+	RevokedAt     *time.Time
+	RevokedReason string `gorm:"type:varchar(50)"`
+	CreatedAt     time.Time
+}
+
+func (authSession0001) TableName() string { return "auth_sessions" }
+
+type refreshToken0001 struct {
+	ID        uint      `gorm:"primaryKey"`
+	SessionID uint      `gorm:"not null;index"`
+	TokenID   string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	RotatedAt *time.Time
+	CreatedAt time.Time
+}
+
+func (refreshToken0001) TableName() string { return "refresh_tokens" }
+
+type loginHistory0001 struct {
+	ID            uint   `gorm:"primaryKey"`
+	UserID        *uint  `gorm:"index"`
+	Email         string `gorm:"type:varchar(255);not null;index"`
+	Success       bool   `gorm:"not null"`
+	FailureReason string `gorm:"type:varchar(50)"`
+	SessionID     *uint
+	IPAddress     string    `gorm:"type:varchar(45)"`
+	UserAgent     string    `gorm:"type:varchar(512)"`
+	CreatedAt     time.Time `gorm:"index"`
+}
+
+func (loginHistory0001) TableName() string { return "login_histories" }
+
+type userTOTP0001 struct {
+	ID           uint   `gorm:"primaryKey"`
+	UserID       uint   `gorm:"not null;uniqueIndex"`
+	Secret       string `gorm:"type:varchar(64);not null"`
+	EnabledAt    *time.Time
+	LastUsedStep int64 `gorm:"not null;default:0"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (userTOTP0001) TableName() string { return "user_totps" }
+
+type mfaRecoveryCode0001 struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;index"`
+	CodeHash  string `gorm:"type:varchar(64);not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (mfaRecoveryCode0001) TableName() string { return "mfa_recovery_codes" }
+
+type roleMFAPolicy0001 struct {
+	Role      string `gorm:"type:varchar(50);primaryKey"`
+	Required  bool   `gorm:"not null;default:false"`
+	UpdatedAt time.Time
+}
+
+func (roleMFAPolicy0001) TableName() string { return "role_mfa_policies" }
+
+type accountToken0001 struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index:idx_account_token_user_purpose"`
+	Purpose   string    `gorm:"type:varchar(50);not null;index:idx_account_token_user_purpose"`
+	TokenID   string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	Email     string    `gorm:"type:varchar(255);not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (accountToken0001) TableName() string { return "account_tokens" }
+
+type personalAccessToken0001 struct {
+	ID          uint      `gorm:"primaryKey"`
+	UserID      uint      `gorm:"not null;index"`
+	Name        string    `gorm:"type:varchar(100);not null"`
+	TokenPrefix string    `gorm:"type:varchar(16);not null"`
+	TokenHash   string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	Scopes      string    `gorm:"type:varchar(255);not null"`
+	ExpiresAt   time.Time `gorm:"not null"`
+	LastUsedAt  *time.Time
+	RevokedAt   *time.Time
+	CreatedAt   time.Time
+}
+
+func (personalAccessToken0001) TableName() string { return "personal_access_tokens" }
+
+type userIdentity0001 struct {
+	ID          uint   `gorm:"primaryKey"`
+	UserID      uint   `gorm:"not null;index"`
+	Issuer      string `gorm:"type:varchar(255);not null;uniqueIndex:idx_user_identity_subject"`
+	Subject     string `gorm:"type:varchar(255);not null;uniqueIndex:idx_user_identity_subject"`
+	Email       string `gorm:"type:varchar(255)"`
+	LastLoginAt *time.Time
+	CreatedAt   time.Time
+}
+
+func (userIdentity0001) TableName() string { return "user_identities" }
+
+type chartOfAccounts0001 struct {
+	ID            uint   `gorm:"primaryKey"`
+	Code          string `gorm:"type:varchar(50);uniqueIndex;not null"`
+	Name          string `gorm:"type:varchar(255);not null"`
+	Type          string `gorm:"type:varchar(50);not null"`
+	NormalBalance string `gorm:"type:varchar(50);not null"`
+	Description   string `gorm:"type:text"`
+	IsActive      bool   `gorm:"default:true"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (chartOfAccounts0001) TableName() string { return "chart_of_accounts" }
+
+type transaction0001 struct {
+	ID                   uint               `gorm:"primaryKey"`
+	UserID               uint               `gorm:"not null;index:idx_user_date_created,sort:asc"`
+	User                 *user0001          `gorm:"foreignKey:UserID"`
+	Date                 time.Time          `gorm:"type:date;not null;index:idx_user_date_created,sort:desc"`
+	Description          string             `gorm:"type:text"`
+	JournalEntries       []journalEntry0001 `gorm:"foreignKey:TransactionID"`
+	CorrectedFromID      *uint              `gorm:"index"`
+	CorrectedTransaction *transaction0001   `gorm:"foreignKey:CorrectedFromID"`
+	IsCorrection         bool               `gorm:"default:false"`
+	CorrectionNote       string             `gorm:"type:text"`
+	CreatedAt            time.Time          `gorm:"index:idx_user_date_created,sort:desc"`
+	UpdatedAt            time.Time
+}
+
+func (transaction0001) TableName() string { return "transactions" }
+
+type journalEntry0001 struct {
+	ID                uint                 `gorm:"primaryKey"`
+	TransactionID     uint                 `gorm:"not null;index:idx_transaction_id"`
+	Transaction       *transaction0001     `gorm:"foreignKey:TransactionID"`
+	ChartOfAccountsID uint                 `gorm:"not null;index"`
+	ChartOfAccounts   *chartOfAccounts0001 `gorm:"foreignKey:ChartOfAccountsID"`
+	Type              string               `gorm:"type:varchar(50);not null"`
+	Amount            int                  `gorm:"not null"`
+	Description       string               `gorm:"type:text"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+func (journalEntry0001) TableName() string { return "journal_entries" }
+
+type fiscalPeriod0001 struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index:idx_fiscal_period_user_start"`
+	StartDate time.Time `gorm:"type:date;not null;index:idx_fiscal_period_user_start"`
+	EndDate   time.Time `gorm:"type:date;not null"`
+	ClosedAt  *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (fiscalPeriod0001) TableName() string { return "fiscal_periods" }
+
+type attachment0001 struct {
+	ID              uint      `gorm:"primaryKey"`
+	UserID          uint      `gorm:"not null;index:idx_attachment_user_date"`
+	TransactionID   uint      `gorm:"not null;index"`
+	TransactionDate time.Time `gorm:"type:date;not null;index:idx_attachment_user_date"`
+	Amount          int       `gorm:"not null;index"`
+	Counterparty    string    `gorm:"type:varchar(255);index"`
+	FileName        string    `gorm:"type:varchar(255);not null"`
+	ContentType     string    `gorm:"type:varchar(100);not null"`
+	Size            int64     `gorm:"not null"`
+	StorageKey      string    `gorm:"type:varchar(255);not null;uniqueIndex"`
+	SHA256          string    `gorm:"column:sha256;type:varchar(64);not null"`
+	CreatedAt       time.Time
+}
+
+func (attachment0001) TableName() string { return "attachments" }
+
+type auditLog0001 struct {
+	ID         uint   `gorm:"primaryKey"`
+	Sequence   uint64 `gorm:"not null;uniqueIndex"`
+	ActorID    *uint  `gorm:"index"`
+	Action     string `gorm:"type:varchar(50);not null"`
+	EntityType string `gorm:"type:varchar(50);not null;index:idx_audit_entity"`
+	EntityID   uint   `gorm:"not null;index:idx_audit_entity"`
+	RequestID  string `gorm:"type:varchar(64)"`
+	IPAddress  string `gorm:"type:varchar(45)"`
+	Before     string `gorm:"type:text"`
+	After      string `gorm:"type:text"`
+	PrevHash   string `gorm:"type:varchar(64)"`
+	Hash       string `gorm:"type:varchar(64);not null"`
+	CreatedAt  time.Time
+}
+
+func (auditLog0001) TableName() string { return "audit_logs" }
+
+type auditChainHead0001 struct {
+	ID        uint   `gorm:"primaryKey"`
+	Sequence  uint64 `gorm:"not null"`
+	Hash      string `gorm:"type:varchar(64)"`
+	UpdatedAt time.Time
+}
+
+func (auditChainHead0001) TableName() string { return "audit_chain_heads" }
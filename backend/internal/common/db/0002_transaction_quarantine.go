@@ -0,0 +1,40 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 0002_transaction_quarantine: 整合性チェックで問題が見つかった取引を隔離するための列を追加
+func init() {
+	register(Migration{
+		Version: 2,
+		Name:    "transaction_quarantine",
+		Up: func(tx *gorm.DB) error {
+			for _, column := range transactionQuarantineColumns {
+				if err := tx.Migrator().AddColumn(&transaction0002{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range transactionQuarantineColumns {
+				if err := tx.Migrator().DropColumn(&transaction0002{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
+
+var transactionQuarantineColumns = []string{"QuarantinedAt", "QuarantineReason"}
+
+type transaction0002 struct {
+	QuarantinedAt    *time.Time
+	QuarantineReason string `gorm:"type:text"`
+}
+
+func (transaction0002) TableName() string { return "transactions" }
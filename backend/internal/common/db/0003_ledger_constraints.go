@@ -0,0 +1,201 @@
+package migration
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// 0003_ledger_constraints: 仕訳エントリーにデータベースの制約を追加
+//   - 取引への外部キー（取引を削除すると仕訳エントリーも削除）
+//   - 勘定科目への外部キー（仕訳エントリーから参照されている勘定科目は削除不可）
+//   - 金額は正の値、種類は debit か credit のみ
+//   - PostgreSQL では、コミット時に取引ごとの貸借一致を検証する遅延制約トリガー
+//
+// MySQL と SQLite は遅延トリガーに対応していないため、貸借一致はアプリケーション（ensureBalanced）と整合性チェックで検証する
+// 既存のデータが制約に違反する場合は、制約を追加せずにエラーを返す
+func init() {
+	register(Migration{
+		Version: 3,
+		Name:    "ledger_constraints",
+		Up: func(tx *gorm.DB) error {
+			if err := checkLedgerConstraintViolations(tx); err != nil {
+				return err
+			}
+			if err := replaceJournalEntryConstraints(tx, &transaction0003{}, &journalEntry0003{}); err != nil {
+				return err
+			}
+			for _, name := range journalEntryChecks {
+				if err := tx.Migrator().CreateConstraint(&journalEntry0003{}, name); err != nil {
+					return err
+				}
+			}
+			if err := restoreJournalEntryIndexes(tx); err != nil {
+				return err
+			}
+			if tx.Dialector.Name() == "postgres" {
+				return tx.Exec(balanceTriggerUpSQL).Error
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			if tx.Dialector.Name() == "postgres" {
+				if err := tx.Exec(balanceTriggerDownSQL).Error; err != nil {
+					return err
+				}
+			}
+			for _, name := range journalEntryChecks {
+				if tx.Migrator().HasConstraint(&journalEntry0003{}, name) {
+					if err := tx.Migrator().DropConstraint(&journalEntry0003{}, name); err != nil {
+						return err
+					}
+				}
+			}
+			if err := replaceJournalEntryConstraints(tx, &transaction0001{}, &journalEntry0001{}); err != nil {
+				return err
+			}
+			return restoreJournalEntryIndexes(tx)
+		},
+	})
+}
+
+// journalEntryChecks: 仕訳エントリーの CHECK 制約
+var journalEntryChecks = []string{"chk_journal_entries_amount", "chk_journal_entries_type"}
+
+type transaction0003 struct {
+	ID             uint               `gorm:"primaryKey"`
+	JournalEntries []journalEntry0003 `gorm:"foreignKey:TransactionID;constraint:OnDelete:CASCADE"`
+}
+
+func (transaction0003) TableName() string { return "transactions" }
+
+type journalEntry0003 struct {
+	ID                uint                 `gorm:"primaryKey"`
+	TransactionID     uint                 `gorm:"not null;index:idx_transaction_id"`
+	ChartOfAccountsID uint                 `gorm:"not null;index"`
+	ChartOfAccounts   *chartOfAccounts0001 `gorm:"foreignKey:ChartOfAccountsID;constraint:OnDelete:RESTRICT"`
+	Type              string               `gorm:"type:varchar(50);not null;check:chk_journal_entries_type,type IN ('debit','credit')"`
+	Amount            int                  `gorm:"not null;check:chk_journal_entries_amount,amount > 0"`
+}
+
+func (journalEntry0003) TableName() string { return "journal_entries" }
+
+// replaceJournalEntryConstraints: 仕訳エントリーの外部キーを作り直す（既存の制約の削除時の動作は変更できないため）
+func replaceJournalEntryConstraints(tx *gorm.DB, transaction interface{}, journalEntry interface{}) error {
+	constraints := []struct {
+		model interface{}
+		name  string
+	}{
+		{transaction, "JournalEntries"},
+		{journalEntry, "ChartOfAccounts"},
+	}
+	for _, constraint := range constraints {
+		if tx.Migrator().HasConstraint(constraint.model, constraint.name) {
+			if err := tx.Migrator().DropConstraint(constraint.model, constraint.name); err != nil {
+				return err
+			}
+		}
+		if err := tx.Migrator().CreateConstraint(constraint.model, constraint.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreJournalEntryIndexes: SQLite では制約の変更時にテーブルを作り直し、インデックスが失われるため作成し直す
+func restoreJournalEntryIndexes(tx *gorm.DB) error {
+	for _, name := range []string{"idx_transaction_id", "ChartOfAccountsID"} {
+		if tx.Migrator().HasIndex(&journalEntry0001{}, name) {
+			continue
+		}
+		if err := tx.Migrator().CreateIndex(&journalEntry0001{}, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkLedgerConstraintViolations: 制約に違反する既存の仕訳エントリーの件数を確認
+func checkLedgerConstraintViolations(tx *gorm.DB) error {
+	checks := []struct {
+		description string
+		query       *gorm.DB
+	}{
+		{
+			"reference a missing transaction",
+			tx.Table("journal_entries AS je").Joins("LEFT JOIN transactions AS t ON t.id = je.transaction_id").Where("t.id IS NULL"),
+		},
+		{
+			"reference a missing account",
+			tx.Table("journal_entries AS je").Joins("LEFT JOIN chart_of_accounts AS c ON c.id = je.chart_of_accounts_id").Where("c.id IS NULL"),
+		},
+		{
+			"have an amount that is not positive",
+			tx.Table("journal_entries").Where("amount <= 0"),
+		},
+		{
+			"have a type other than debit or credit",
+			tx.Table("journal_entries").Where("type NOT IN ?", []string{"debit", "credit"}),
+		},
+	}
+
+	var problems []string
+	for _, check := range checks {
+		var count int64
+		if err := check.query.Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			problems = append(problems, fmt.Sprintf("%d journal entries %s", count, check.description))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("existing data violates the ledger constraints (%s); repair it and run the migration again", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// balanceTriggerUpSQL: 仕訳エントリーの変更があった取引の貸借一致をコミット時に検証する
+// 取引の削除による仕訳エントリーの削除では、残りの行が無くなるため一致とみなされる
+const balanceTriggerUpSQL = `
+CREATE OR REPLACE FUNCTION journal_entries_check_balance() RETURNS trigger AS $$
+DECLARE
+	target_ids bigint[];
+	target_id bigint;
+	debit_total bigint;
+	credit_total bigint;
+BEGIN
+	IF TG_OP = 'INSERT' THEN
+		target_ids := ARRAY[NEW.transaction_id];
+	ELSIF TG_OP = 'DELETE' THEN
+		target_ids := ARRAY[OLD.transaction_id];
+	ELSE
+		target_ids := ARRAY[OLD.transaction_id, NEW.transaction_id];
+	END IF;
+
+	FOREACH target_id IN ARRAY target_ids LOOP
+		SELECT COALESCE(SUM(CASE WHEN type = 'debit' THEN amount ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN type = 'credit' THEN amount ELSE 0 END), 0)
+		  INTO debit_total, credit_total
+		  FROM journal_entries
+		 WHERE transaction_id = target_id;
+		IF debit_total <> credit_total THEN
+			RAISE EXCEPTION 'transaction % is unbalanced (debit %, credit %)', target_id, debit_total, credit_total
+				USING ERRCODE = 'check_violation';
+		END IF;
+	END LOOP;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER trg_journal_entries_balance
+	AFTER INSERT OR UPDATE OR DELETE ON journal_entries
+	DEFERRABLE INITIALLY DEFERRED
+	FOR EACH ROW EXECUTE FUNCTION journal_entries_check_balance();
+`
+
+const balanceTriggerDownSQL = `
+DROP TRIGGER IF EXISTS trg_journal_entries_balance ON journal_entries;
+DROP FUNCTION IF EXISTS journal_entries_check_balance();
+`
@@ -0,0 +1,23 @@
+package migration
+
+import "gorm.io/gorm"
+
+// 0004_transaction_version: 取引の楽観的排他制御に使うバージョンの列を追加（既存の取引は 1）
+func init() {
+	register(Migration{
+		Version: 4,
+		Name:    "transaction_version",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().AddColumn(&transaction0004{}, "Version")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&transaction0004{}, "Version")
+		},
+	})
+}
+
+type transaction0004 struct {
+	Version uint `gorm:"not null;default:1"`
+}
+
+func (transaction0004) TableName() string { return "transactions" }
@@ -0,0 +1,35 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 0005_idempotency_keys: Idempotency-Key ヘッダー付きリクエストの応答を記録するテーブルを追加
+func init() {
+	register(Migration{
+		Version: 5,
+		Name:    "idempotency_keys",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&idempotencyKey0005{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&idempotencyKey0005{})
+		},
+	})
+}
+
+type idempotencyKey0005 struct {
+	ID              uint   `gorm:"primaryKey"`
+	UserID          uint   `gorm:"not null;uniqueIndex:idx_idempotency_user_key"`
+	Key             string `gorm:"column:idempotency_key;type:varchar(255);not null;uniqueIndex:idx_idempotency_user_key"`
+	Fingerprint     string `gorm:"type:varchar(64);not null"`
+	StatusCode      int    `gorm:"not null;default:0"`
+	ResponseHeaders string `gorm:"type:text"`
+	ResponseBody    []byte
+	ExpiresAt       time.Time `gorm:"not null;index"`
+	CreatedAt       time.Time
+}
+
+func (idempotencyKey0005) TableName() string { return "idempotency_keys" }
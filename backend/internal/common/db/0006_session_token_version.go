@@ -0,0 +1,28 @@
+package migration
+
+import "gorm.io/gorm"
+
+// 0006_session_token_version: セッションにログイン時のユーザーのトークンバージョンの列を追加
+// ロール・パスワード・有効/無効の変更前に開始したセッションでのリフレッシュを拒否するために使う
+func init() {
+	register(Migration{
+		Version: 6,
+		Name:    "session_token_version",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&authSession0006{}, "TokenVersion"); err != nil {
+				return err
+			}
+			// 既存のセッションは現在のトークンバージョンで開始したものとみなす（適用時にログアウトさせない）
+			return tx.Exec("UPDATE auth_sessions SET token_version = COALESCE((SELECT users.token_version FROM users WHERE users.id = auth_sessions.user_id), 0)").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&authSession0006{}, "TokenVersion")
+		},
+	})
+}
+
+type authSession0006 struct {
+	TokenVersion uint `gorm:"not null;default:0"`
+}
+
+func (authSession0006) TableName() string { return "auth_sessions" }
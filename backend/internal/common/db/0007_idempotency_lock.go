@@ -0,0 +1,28 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 0007_idempotency_lock: 処理中の Idempotency-Key の記録にロックの期限の列を追加
+// 処理中にプロセスが停止した記録を、有効期限（TTL）を待たずに引き継げるようにする
+func init() {
+	register(Migration{
+		Version: 7,
+		Name:    "idempotency_lock",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().AddColumn(&idempotencyKey0007{}, "LockedUntil")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&idempotencyKey0007{}, "LockedUntil")
+		},
+	})
+}
+
+type idempotencyKey0007 struct {
+	LockedUntil *time.Time
+}
+
+func (idempotencyKey0007) TableName() string { return "idempotency_keys" }
@@ -0,0 +1,31 @@
+package migration
+
+import "gorm.io/gorm"
+
+// 0008_attachment_amount_specified: 添付ファイルに取引金額を指定したかの列を追加
+// 指定しなかった添付ファイルの取引金額は、取引の変更時に借方合計に合わせて更新する
+func init() {
+	register(Migration{
+		Version: 8,
+		Name:    "attachment_amount_specified",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&attachment0008{}, "AmountSpecified"); err != nil {
+				return err
+			}
+			// 既存の添付ファイルは、取引金額が取引の借方合計と異なるものを指定ありとみなす
+			return tx.Exec(`UPDATE attachments SET amount_specified = ?
+				WHERE amount <> COALESCE((SELECT SUM(journal_entries.amount) FROM journal_entries
+					WHERE journal_entries.transaction_id = attachments.transaction_id AND journal_entries.type = ?), 0)`,
+				true, "debit").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&attachment0008{}, "AmountSpecified")
+		},
+	})
+}
+
+type attachment0008 struct {
+	AmountSpecified bool `gorm:"not null;default:false"`
+}
+
+func (attachment0008) TableName() string { return "attachments" }
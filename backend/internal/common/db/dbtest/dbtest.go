@@ -0,0 +1,100 @@
+// Package dbtest はリポジトリのテストで使用するデータベースを用意する
+//
+// 既定ではテストごとにメモリー上の SQLite を作成する。
+// 環境変数 TEST_DB_DRIVER（mysql・postgres）と TEST_DB_DSN を設定すると、そのデータベースでテストを実行する。
+// その場合はテストごとにすべてのテーブルを削除して作り直すため、テスト専用のデータベースを指定し、
+// パッケージを並列に実行しないよう go test -p 1 で実行すること。
+package dbtest
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"simple-ledger/internal/common/config"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// Open はテスト用のデータベースに接続し、models のテーブルを作成する
+// 接続はテストの終了時に閉じる
+func Open(t testing.TB, models ...interface{}) *gorm.DB {
+	t.Helper()
+
+	dialector, reset, err := dialectorFromEnv(t)
+	if err != nil {
+		t.Fatalf("failed to configure test database: %v", err)
+	}
+	db, err := gorm.Open(dialector, config.NewGormConfig())
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	if reset {
+		if err := dropAllTables(db); err != nil {
+			t.Fatalf("failed to reset test database: %v", err)
+		}
+	}
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	return db
+}
+
+// Driver はテストで使用するデータベースの種類を返す
+func Driver() string {
+	driver := strings.ToLower(os.Getenv("TEST_DB_DRIVER"))
+	if driver == "" {
+		return config.DriverSQLite
+	}
+	return driver
+}
+
+// dialectorFromEnv: 環境変数からドライバーを選ぶ（既存のテーブルを削除する必要がある場合は reset が true）
+func dialectorFromEnv(t testing.TB) (gorm.Dialector, bool, error) {
+	dsn := os.Getenv("TEST_DB_DSN")
+	switch Driver() {
+	case config.DriverSQLite:
+		if dsn != "" {
+			return sqlite.Open(dsn), true, nil
+		}
+		// 同じテスト内の接続でのみ共有する名前付きのメモリー上のデータベース
+		name := strings.NewReplacer("/", "_", " ", "_", "#", "_").Replace(t.Name())
+		return sqlite.Open("file:" + name + "?mode=memory&cache=shared&_foreign_keys=on"), false, nil
+	case config.DriverMySQL:
+		if dsn == "" {
+			return nil, false, fmt.Errorf("TEST_DB_DSN is required for mysql")
+		}
+		return mysql.Open(dsn), true, nil
+	case config.DriverPostgres:
+		if dsn == "" {
+			return nil, false, fmt.Errorf("TEST_DB_DSN is required for postgres")
+		}
+		return postgres.Open(dsn), true, nil
+	default:
+		return nil, false, fmt.Errorf("unsupported TEST_DB_DRIVER: %s (mysql, postgres or sqlite)", Driver())
+	}
+}
+
+// dropAllTables: 前のテストが作成したテーブルをすべて削除する（自動採番の値も初期化される）
+func dropAllTables(db *gorm.DB) error {
+	tables, err := db.Migrator().GetTables()
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		if err := db.Migrator().DropTable(table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
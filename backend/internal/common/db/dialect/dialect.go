@@ -0,0 +1,38 @@
+package dialect
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// likeEscape: LIKE のエスケープ文字
+// バックスラッシュは MySQL と PostgreSQL で文字列リテラル内の扱いが異なるため使わない
+const likeEscape = "!"
+
+var likeEscaper = strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_")
+
+// Contains は column が value を含む行に絞り込む条件を返す
+// 英字の大文字・小文字は区別せず、value の % や _ はワイルドカードではなく文字として扱う
+// LIKE の大文字・小文字の区別はデータベース（MySQL は照合順序）によって異なるため、両辺を小文字にそろえて比較する
+func Contains(db *gorm.DB, column, value string) clause.Expression {
+	pattern := "%" + likeEscaper.Replace(strings.ToLower(value)) + "%"
+	if db.Dialector.Name() == "mysql" {
+		// 既定の照合順序ではアクセントや濁点の違い（は・ば・ぱ）を区別しないため、バイナリーで比較する
+		return clause.Expr{SQL: "LOWER(" + column + ") COLLATE utf8mb4_bin LIKE ? ESCAPE '" + likeEscape + "'", Vars: []interface{}{pattern}}
+	}
+	return clause.Expr{SQL: "LOWER(" + column + ") LIKE ? ESCAPE '" + likeEscape + "'", Vars: []interface{}{pattern}}
+}
+
+// Date は日付型（type:date）の列と比較する値を返す（t の日付の UTC の 0 時）
+// 保存する値もこの形にそろえておくことで、日付を文字列で保存する SQLite でも比較結果が変わらない
+func Date(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Time は日時型の列と比較する値を返す（UTC）
+func Time(t time.Time) time.Time {
+	return t.UTC()
+}
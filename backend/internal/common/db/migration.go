@@ -1,23 +1,212 @@
 package migration
 
 import (
-	"simple-ledger/internal/models"
+	"fmt"
+	"sort"
+	"time"
 
 	"gorm.io/gorm"
 )
 
-func Migrate(db *gorm.DB) error {
-	if err := db.AutoMigrate(&models.User{}); err != nil {
-		return err
+// Migration はスキーマのバージョンを1つ進める（戻す）変更
+// Up・Down は DB トランザクション内で実行される（MySQL の DDL は暗黙的にコミットされる点に注意）
+// 適用済みのマイグレーションは変更せず、スキーマの変更は新しいバージョンとして追加すること
+// バージョン管理の導入前に起動時の AutoMigrate で作成したデータベースは 0001 が引き継ぐため、
+// 0002 以降は 0001 を適用した後のスキーマを前提に書く（既存のテーブル・列の有無を確認しない）
+type Migration struct {
+	// Version: バージョン（昇順に適用する）
+	Version uint
+
+	// Name: 変更内容を表す名前（ファイル名と同じ）
+	Name string
+
+	Up   func(tx *gorm.DB) error
+	Down func(tx *gorm.DB) error
+}
+
+// State はマイグレーションの適用状況
+type State struct {
+	Version   uint
+	Name      string
+	AppliedAt *time.Time // nil の場合は未適用
+
+	// Unknown: データベースには適用済みだが、このバージョンのアプリケーションには含まれない
+	Unknown bool
+}
+
+// schemaMigration: 適用済みのマイグレーション（schema_migrations テーブル）
+type schemaMigration struct {
+	Version   uint      `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// migrations: 登録されたマイグレーション（各ファイルの init で登録する）
+var migrations []Migration
+
+// register はマイグレーションを登録する
+func register(m Migration) {
+	for _, existing := range migrations {
+		if existing.Version == m.Version {
+			panic(fmt.Sprintf("migration: duplicate version %d (%s, %s)", m.Version, existing.Name, m.Name))
+		}
+	}
+	migrations = append(migrations, m)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+}
+
+// All は登録されたマイグレーションをバージョンの昇順で返す
+func All() []Migration {
+	return append([]Migration(nil), migrations...)
+}
+
+// Latest は最新のバージョンを返す
+func Latest() uint {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// Up は未適用のマイグレーションを target のバージョンまで順に適用する（target が 0 の場合は最新まで）
+// 適用したマイグレーションを返す
+func Up(db *gorm.DB, target uint) ([]Migration, error) {
+	applied, err := appliedVersions(db, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range migrations {
+		if target != 0 && m.Version > target {
+			break
+		}
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now().UTC()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Down は適用済みのマイグレーションを新しいものから steps 件取り消す
+// 取り消したマイグレーションを返す
+func Down(db *gorm.DB, steps int) ([]Migration, error) {
+	applied, err := appliedVersions(db, false)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]uint, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+	var done []Migration
+	for _, version := range versions {
+		if len(done) >= steps {
+			break
+		}
+		m, ok := find(version)
+		if !ok {
+			return done, fmt.Errorf("migration %d (%s) is not known to this build and cannot be reverted", version, applied[version].Name)
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&schemaMigration{}, version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("reverting migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
 	}
-	if err := db.AutoMigrate(&models.ChartOfAccounts{}); err != nil {
-		return err
+	return done, nil
+}
+
+// Status はすべてのマイグレーションの適用状況をバージョンの昇順で返す
+func Status(db *gorm.DB) ([]State, error) {
+	applied, err := appliedVersions(db, false)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]State, 0, len(migrations))
+	for _, m := range migrations {
+		status := State{Version: m.Version, Name: m.Name}
+		if row, ok := applied[m.Version]; ok {
+			status.AppliedAt = &row.AppliedAt
+			delete(applied, m.Version)
+		}
+		statuses = append(statuses, status)
 	}
-	if err := db.AutoMigrate(&models.Transaction{}); err != nil {
-		return err
+	for _, row := range applied {
+		statuses = append(statuses, State{Version: row.Version, Name: row.Name, AppliedAt: &row.AppliedAt, Unknown: true})
 	}
-	if err := db.AutoMigrate(&models.JournalEntry{}); err != nil {
-		return err
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Pending は未適用のマイグレーションを返す
+func Pending(db *gorm.DB) ([]Migration, error) {
+	applied, err := appliedVersions(db, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; !ok {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// appliedVersions: 適用済みのマイグレーションを取得
+// schema_migrations テーブルが無い場合、create が true なら作成し、false なら適用済みなしとする（参照のみでスキーマを変更しない）
+func appliedVersions(db *gorm.DB, create bool) (map[uint]schemaMigration, error) {
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		if !create {
+			return map[uint]schemaMigration{}, nil
+		}
+		if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+			return nil, fmt.Errorf("failed to prepare schema_migrations: %w", err)
+		}
+	}
+
+	var rows []schemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[uint]schemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// find: バージョンのマイグレーションを探す
+func find(version uint) (Migration, bool) {
+	for _, m := range migrations {
+		if m.Version == version {
+			return m, true
+		}
 	}
-	return nil
+	return Migration{}, false
 }
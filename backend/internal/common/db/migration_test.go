@@ -0,0 +1,245 @@
+package migration
+
+import (
+	"testing"
+	"time"
+
+	"simple-ledger/internal/common/config"
+	"simple-ledger/internal/common/db/dbtest"
+	"simple-ledger/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// currentModels: マイグレーションを適用したスキーマと一致する必要があるモデル
+var currentModels = []interface{}{
+	&models.User{}, &models.AuthSession{}, &models.RefreshToken{}, &models.LoginHistory{},
+	&models.UserTOTP{}, &models.MFARecoveryCode{}, &models.RoleMFAPolicy{}, &models.AccountToken{}, &models.PersonalAccessToken{}, &models.UserIdentity{},
+	&models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}, &models.FiscalPeriod{},
+	&models.Attachment{}, &models.AuditLog{}, &models.AuditChainHead{}, &models.IdempotencyKey{},
+}
+
+// assertSchemaMatchesModels: すべてのモデルのテーブル・列が存在することを確認（モデルを変更した場合はマイグレーションの追加が必要）
+func assertSchemaMatchesModels(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	for _, model := range currentModels {
+		stmt := &gorm.Statement{DB: db}
+		require.NoError(t, stmt.Parse(model))
+		if !assert.True(t, db.Migrator().HasTable(model), "table %s", stmt.Schema.Table) {
+			continue
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			assert.True(t, db.Migrator().HasColumn(model, field.DBName), "column %s.%s", stmt.Schema.Table, field.DBName)
+		}
+	}
+}
+
+func TestUp_CreatesSchemaMatchingModels(t *testing.T) {
+	db := dbtest.Open(t)
+
+	applied, err := Up(db, 0)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(All()))
+	assertSchemaMatchesModels(t, db)
+
+	var head models.AuditChainHead
+	assert.NoError(t, db.First(&head, 1).Error)
+
+	// 適用済みのマイグレーションは再度適用しない
+	applied, err = Up(db, 0)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+}
+
+func TestUp_AdoptsSchemaCreatedByAutoMigrate(t *testing.T) {
+	// バージョン管理を導入する前の起動時の AutoMigrate で作成したスキーマ（0001 と同じ）
+	db := dbtest.Open(t, initialSchemaTables...)
+	require.NoError(t, db.Create(&models.User{Email: "legacy@example.com", Name: "Legacy", Role: "user"}).Error)
+
+	_, err := Up(db, 0)
+	require.NoError(t, err)
+
+	var count int64
+	db.Model(&models.User{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	pending, err := Pending(db)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestDown_RevertsLatestMigration(t *testing.T) {
+	db := dbtest.Open(t)
+	_, err := Up(db, 0)
+	require.NoError(t, err)
+
+	reverted, err := Down(db, 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	assert.Equal(t, Latest(), reverted[0].Version)
+
+	states, err := Status(db)
+	require.NoError(t, err)
+	assert.Nil(t, states[len(states)-1].AppliedAt)
+}
+
+func TestDown_AllRemovesSchema(t *testing.T) {
+	db := dbtest.Open(t)
+	_, err := Up(db, 0)
+	require.NoError(t, err)
+
+	_, err = Down(db, len(All()))
+	require.NoError(t, err)
+	assert.False(t, db.Migrator().HasTable(&models.User{}))
+	assert.False(t, db.Migrator().HasTable(&models.Transaction{}))
+}
+
+func TestStatus_ReadOnlyBeforeFirstMigration(t *testing.T) {
+	db := dbtest.Open(t)
+
+	states, err := Status(db)
+	require.NoError(t, err)
+	assert.Len(t, states, len(All()))
+	for _, state := range states {
+		assert.Nil(t, state.AppliedAt)
+	}
+	// 参照だけではスキーマを変更しない
+	assert.False(t, db.Migrator().HasTable(&schemaMigration{}))
+}
+
+func TestStatus_ReportsVersionsUnknownToBuild(t *testing.T) {
+	db := dbtest.Open(t)
+	_, err := Up(db, 0)
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&schemaMigration{Version: 9999, Name: "from_newer_build", AppliedAt: time.Now().UTC()}).Error)
+
+	states, err := Status(db)
+	require.NoError(t, err)
+	last := states[len(states)-1]
+	assert.Equal(t, uint(9999), last.Version)
+	assert.True(t, last.Unknown)
+
+	_, err = Down(db, 1)
+	assert.Error(t, err)
+}
+
+// createLedgerFixture: 勘定科目2件と貸借一致した取引を作成
+func createLedgerFixture(t *testing.T, db *gorm.DB) (models.ChartOfAccounts, models.Transaction) {
+	t.Helper()
+	user := models.User{Email: "owner@example.com", Name: "Owner", Role: "user"}
+	require.NoError(t, db.Create(&user).Error)
+	cash := models.ChartOfAccounts{Code: "1000", Name: "現金", Type: models.AssetAccount, NormalBalance: models.DebitBalance}
+	sales := models.ChartOfAccounts{Code: "4000", Name: "売上", Type: models.RevenueAccount, NormalBalance: models.CreditBalance}
+	require.NoError(t, db.Create(&cash).Error)
+	require.NoError(t, db.Create(&sales).Error)
+
+	transaction := models.Transaction{
+		UserID: user.ID,
+		Date:   time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+		JournalEntries: []models.JournalEntry{
+			{ChartOfAccountsID: cash.ID, Type: models.DebitEntry, Amount: 1000},
+			{ChartOfAccountsID: sales.ID, Type: models.CreditEntry, Amount: 1000},
+		},
+	}
+	require.NoError(t, db.Create(&transaction).Error)
+	return cash, transaction
+}
+
+func TestLedgerConstraints_RejectInvalidEntries(t *testing.T) {
+	db := dbtest.Open(t)
+	_, err := Up(db, 0)
+	require.NoError(t, err)
+	cash, transaction := createLedgerFixture(t, db)
+
+	// 金額・種類・参照先は、Go のコードを経由しない書き込みでも検証される
+	invalid := []models.JournalEntry{
+		{TransactionID: transaction.ID, ChartOfAccountsID: cash.ID, Type: models.DebitEntry, Amount: 0},
+		{TransactionID: transaction.ID, ChartOfAccountsID: cash.ID, Type: "other", Amount: 100},
+		{TransactionID: 9999, ChartOfAccountsID: cash.ID, Type: models.DebitEntry, Amount: 100},
+		{TransactionID: transaction.ID, ChartOfAccountsID: 9999, Type: models.DebitEntry, Amount: 100},
+	}
+	for _, entry := range invalid {
+		assert.Error(t, db.Create(&entry).Error, "entry %+v", entry)
+	}
+
+	// 仕訳エントリーから参照されている勘定科目は削除できない
+	assert.Error(t, db.Delete(&cash).Error)
+
+	// 取引を削除すると仕訳エントリーも削除される
+	require.NoError(t, db.Delete(&models.Transaction{}, transaction.ID).Error)
+	var count int64
+	require.NoError(t, db.Model(&models.JournalEntry{}).Where("transaction_id = ?", transaction.ID).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestLedgerConstraints_RefusesExistingViolations(t *testing.T) {
+	db := dbtest.Open(t)
+	_, err := Up(db, 2)
+	require.NoError(t, err)
+	// 0003 より前のスキーマのため、現在のモデルを使わずに作成する
+	rows := []struct {
+		table  string
+		values map[string]interface{}
+	}{
+		{"users", map[string]interface{}{"id": 1, "email": "owner@example.com", "name": "Owner", "role": "user"}},
+		{"chart_of_accounts", map[string]interface{}{"id": 1, "code": "1000", "name": "現金", "type": "asset", "normal_balance": "debit"}},
+		{"transactions", map[string]interface{}{"id": 1, "user_id": 1, "date": time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)}},
+		{"journal_entries", map[string]interface{}{"id": 1, "transaction_id": 1, "chart_of_accounts_id": 1, "type": "debit", "amount": -1}},
+		{"journal_entries", map[string]interface{}{"id": 2, "transaction_id": 1, "chart_of_accounts_id": 1, "type": "credit", "amount": -1}},
+	}
+	for _, row := range rows {
+		require.NoError(t, db.Table(row.table).Create(row.values).Error)
+	}
+
+	_, err = Up(db, 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "2 journal entries have an amount that is not positive")
+
+	pending, err := Pending(db)
+	require.NoError(t, err)
+	require.NotEmpty(t, pending)
+	assert.Equal(t, uint(3), pending[0].Version)
+}
+
+func TestLedgerConstraints_RejectUnbalancedCommit(t *testing.T) {
+	if dbtest.Driver() != config.DriverPostgres {
+		t.Skip("deferred balance enforcement is only available on PostgreSQL")
+	}
+	db := dbtest.Open(t)
+	_, err := Up(db, 0)
+	require.NoError(t, err)
+	cash, transaction := createLedgerFixture(t, db)
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&models.JournalEntry{TransactionID: transaction.ID, ChartOfAccountsID: cash.ID, Type: models.DebitEntry, Amount: 500}).Error
+	})
+	assert.Error(t, err)
+
+	// 同じDBトランザクション内で貸借が一致すればコミットできる
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return tx.Model(&models.JournalEntry{}).Where("transaction_id = ?", transaction.ID).Update("amount", 2000).Error
+	})
+	assert.NoError(t, err)
+}
+
+func TestSessionTokenVersion_KeepsExistingSessions(t *testing.T) {
+	db := dbtest.Open(t)
+	_, err := Up(db, 5)
+	require.NoError(t, err)
+
+	// 列の追加前に開始したセッション
+	require.NoError(t, db.Exec("INSERT INTO users (email, name, password, role, is_active, token_version) VALUES ('a@example.com', 'A', '', 'user', true, 3)").Error)
+	require.NoError(t, db.Exec("INSERT INTO auth_sessions (user_id, family_id, last_used_at, created_at) SELECT id, 'family-1', ?, ? FROM users", time.Now(), time.Now()).Error)
+
+	_, err = Up(db, 6)
+	require.NoError(t, err)
+
+	var session models.AuthSession
+	require.NoError(t, db.Where("family_id = ?", "family-1").First(&session).Error)
+	assert.Equal(t, uint(3), session.TokenVersion)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
+	"simple-ledger/internal/common/db/dialect"
 	"simple-ledger/internal/models"
 	"time"
 
@@ -70,7 +71,7 @@ func SeedTransactions(db *gorm.DB) {
 			// トランザクション作成
 			transaction := models.Transaction{
 				UserID:      user.ID,
-				Date:        currentDate,
+				Date:        dialect.Date(currentDate),
 				Description: description,
 			}
 
@@ -0,0 +1,24 @@
+package uow
+
+import "gorm.io/gorm"
+
+// UnitOfWork は複数のリポジトリで1つのDBトランザクションを共有するための抽象
+// fn 内では引数の tx から WithTx で生成したリポジトリのみを使うこと
+type UnitOfWork interface {
+	// Do は fn をDBトランザクション内で実行する
+	// fn がエラーを返した場合（または panic した場合）はロールバックし、それ以外はコミットする
+	Do(fn func(tx *gorm.DB) error) error
+}
+
+type gormUnitOfWork struct {
+	db *gorm.DB
+}
+
+// New は GORM をバックエンドとする UnitOfWork を生成
+func New(db *gorm.DB) UnitOfWork {
+	return &gormUnitOfWork{db: db}
+}
+
+func (u *gormUnitOfWork) Do(fn func(tx *gorm.DB) error) error {
+	return u.db.Transaction(fn)
+}
@@ -0,0 +1,50 @@
+package uow
+
+import (
+	"errors"
+	"testing"
+
+	"simple-ledger/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupUnitOfWorkTestDB() *gorm.DB {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err := db.AutoMigrate(&models.User{}); err != nil {
+		panic(err)
+	}
+	return db
+}
+
+func TestDo_CommitsOnSuccess(t *testing.T) {
+	db := setupUnitOfWorkTestDB()
+
+	err := New(db).Do(func(tx *gorm.DB) error {
+		return tx.Create(&models.User{Email: "a@example.com", Name: "A", Role: "user"}).Error
+	})
+	assert.NoError(t, err)
+
+	var count int64
+	db.Model(&models.User{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestDo_RollsBackOnError(t *testing.T) {
+	db := setupUnitOfWorkTestDB()
+	failure := errors.New("failure")
+
+	err := New(db).Do(func(tx *gorm.DB) error {
+		if err := tx.Create(&models.User{Email: "a@example.com", Name: "A", Role: "user"}).Error; err != nil {
+			return err
+		}
+		return failure
+	})
+	assert.ErrorIs(t, err, failure)
+
+	var count int64
+	db.Model(&models.User{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
@@ -0,0 +1,210 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"simple-ledger/internal/common/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderName: クライアントがリクエストごとに一意な値を指定する HTTP ヘッダー
+const HeaderName = "Idempotency-Key"
+
+// HeaderReplayed: 記録した応答を返した場合に付与する HTTP ヘッダー
+const HeaderReplayed = "Idempotent-Replayed"
+
+// maxKeyLength: キーの最大長
+const maxKeyLength = 255
+
+// replayedHeaders: 応答とともに記録し、再送時に返すヘッダー
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// Config: Idempotency-Key の設定
+type Config struct {
+	// TTL: 応答を記録しておく期間（この期間内の再送には同じ応答を返す）
+	TTL time.Duration
+
+	// LockTimeout: 処理中の記録を他のリクエストに引き継がせない期間
+	// 処理中にプロセスが停止した場合、この期間を過ぎれば同じリクエストの再送で処理をやり直せる
+	LockTimeout time.Duration
+
+	// MaxBodyBytes: キー付きリクエストの本文の最大サイズ（バイト、指紋の計算のため本文全体を読み込む）
+	MaxBodyBytes int64
+}
+
+// DefaultConfig: Idempotency-Key のデフォルト設定（記録は24時間、処理中のロックは1分、本文は1MiBまで）
+func DefaultConfig() Config {
+	return Config{
+		TTL:          24 * time.Hour,
+		LockTimeout:  time.Minute,
+		MaxBodyBytes: 1 << 20,
+	}
+}
+
+// ConfigFromEnv: 環境変数から Idempotency-Key の設定を読み込む
+func ConfigFromEnv() Config {
+	defaults := DefaultConfig()
+	return Config{
+		TTL:          time.Duration(config.GetEnvAsInt("IDEMPOTENCY_KEY_TTL_HOURS", int(defaults.TTL/time.Hour))) * time.Hour,
+		LockTimeout:  time.Duration(config.GetEnvAsInt("IDEMPOTENCY_LOCK_TIMEOUT_SECONDS", int(defaults.LockTimeout/time.Second))) * time.Second,
+		MaxBodyBytes: int64(config.GetEnvAsInt("IDEMPOTENCY_MAX_BODY_BYTES", int(defaults.MaxBodyBytes))),
+	}
+}
+
+// Middleware は Idempotency-Key ヘッダー付きのリクエストを一度だけ処理するミドルウェア
+// 同じキーで同じリクエストが再送された場合は処理せずに記録した応答を返し、異なるリクエストの場合は 409 を返す
+// キーはユーザーごとに管理するため、AuthMiddleware の後に適用すること（ヘッダーが無い場合は何もしない）
+func Middleware(store *Store, cfg Config) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(HeaderName)
+		if key == "" {
+			ctx.Next()
+			return
+		}
+		if len(key) > maxKeyLength {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+
+		userID, exists := ctx.Get("userID")
+		if !exists {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, cfg.MaxBodyBytes))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body is too large"})
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := time.Now()
+		fingerprint := requestFingerprint(ctx.Request, body)
+		record, created, err := store.Begin(userID.(uint), key, fingerprint, now, now.Add(cfg.LockTimeout), now.Add(cfg.TTL))
+		if err != nil {
+			log.Printf("idempotency: failed to look up key: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to process Idempotency-Key"})
+			return
+		}
+
+		if !created {
+			switch {
+			case record.Fingerprint != fingerprint:
+				ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Idempotency-Key has already been used for a different request"})
+			case record.StatusCode == 0:
+				ctx.Header("Retry-After", strconv.Itoa(retryAfterSeconds(record.LockedUntil, now)))
+				ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with the same Idempotency-Key is still being processed"})
+			default:
+				replay(ctx, record.StatusCode, record.ResponseHeaders, record.ResponseBody)
+			}
+			return
+		}
+
+		// 処理中に panic した場合も記録を削除し、同じキーで再試行できるようにする（panic は Recovery に任せる）
+		defer func() {
+			if r := recover(); r != nil {
+				if err := store.Discard(record.ID); err != nil {
+					log.Printf("idempotency: failed to discard key: %v", err)
+				}
+				panic(r)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: ctx.Writer}
+		ctx.Writer = recorder
+		ctx.Next()
+
+		// サーバー側の失敗は記録せず、同じキーで再試行できるようにする
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			if err := store.Discard(record.ID); err != nil {
+				log.Printf("idempotency: failed to discard key: %v", err)
+			}
+			return
+		}
+		if err := store.Complete(record.ID, status, encodeHeaders(recorder.Header()), recorder.body.Bytes()); err != nil {
+			log.Printf("idempotency: failed to store response: %v", err)
+		}
+	}
+}
+
+// retryAfterSeconds: 処理中の記録のロックが外れるまでの秒数（切り上げ、最小1秒）
+func retryAfterSeconds(lockedUntil *time.Time, now time.Time) int {
+	if lockedUntil == nil {
+		return 1
+	}
+	return max(1, int(math.Ceil(lockedUntil.Sub(now).Seconds())))
+}
+
+// requestFingerprint: メソッド・パス・本文から同じリクエストかどうかを判定する値を求める
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.RequestURI()))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// encodeHeaders: 再送時に返すヘッダーを JSON にする
+func encodeHeaders(header http.Header) string {
+	values := make(map[string]string)
+	for _, name := range replayedHeaders {
+		if value := header.Get(name); value != "" {
+			values[name] = value
+		}
+	}
+	encoded, _ := json.Marshal(values)
+	return string(encoded)
+}
+
+// replay: 記録した応答を返す
+func replay(ctx *gin.Context, status int, headers string, body []byte) {
+	var values map[string]string
+	if headers != "" {
+		if err := json.Unmarshal([]byte(headers), &values); err != nil {
+			log.Printf("idempotency: failed to decode stored headers: %v", err)
+		}
+	}
+	for name, value := range values {
+		ctx.Header(name, value)
+	}
+	ctx.Header(HeaderReplayed, "true")
+	ctx.Status(status)
+	_, _ = ctx.Writer.Write(body)
+	ctx.Abort()
+}
+
+// responseRecorder: 応答の本文を記録しながらクライアントに書き込む
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,208 @@
+package idempotency
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"simple-ledger/internal/common/db/dbtest"
+	"simple-ledger/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setupRouter: 呼び出し回数を数えるハンドラーに Middleware を適用したルーター
+func setupRouter(t *testing.T, status int) (*gin.Engine, *gorm.DB, *int) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db := dbtest.Open(t, &models.IdempotencyKey{})
+	calls := 0
+
+	router := gin.New()
+	router.POST("/items",
+		func(c *gin.Context) {
+			c.Set("userID", uint(1))
+			c.Next()
+		},
+		Middleware(NewStore(db), Config{TTL: time.Hour, LockTimeout: time.Minute, MaxBodyBytes: 1024}),
+		func(c *gin.Context) {
+			calls++
+			c.Header("ETag", `"1"`)
+			c.JSON(status, gin.H{"call": calls})
+		},
+	)
+	return router, db, &calls
+}
+
+func post(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(HeaderName, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// testFingerprint: post で送る /items へのリクエストの指紋
+func testFingerprint(body string) string {
+	return requestFingerprint(httptest.NewRequest(http.MethodPost, "/items", nil), []byte(body))
+}
+
+func TestMiddleware_WithoutKeyProcessesEveryRequest(t *testing.T) {
+	router, _, calls := setupRouter(t, http.StatusCreated)
+
+	post(router, "", `{"a":1}`)
+	post(router, "", `{"a":1}`)
+
+	assert.Equal(t, 2, *calls)
+}
+
+func TestMiddleware_ReplaysResponseForSameRequest(t *testing.T) {
+	router, _, calls := setupRouter(t, http.StatusCreated)
+
+	first := post(router, "key-1", `{"a":1}`)
+	second := post(router, "key-1", `{"a":1}`)
+
+	assert.Equal(t, 1, *calls)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.JSONEq(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, `"1"`, second.Header().Get("ETag"))
+	assert.Equal(t, "true", second.Header().Get(HeaderReplayed))
+	assert.Empty(t, first.Header().Get(HeaderReplayed))
+}
+
+func TestMiddleware_RejectsKeyReusedForDifferentRequest(t *testing.T) {
+	router, _, calls := setupRouter(t, http.StatusCreated)
+
+	post(router, "key-1", `{"a":1}`)
+	w := post(router, "key-1", `{"a":2}`)
+
+	assert.Equal(t, 1, *calls)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestMiddleware_RejectsRequestStillInProgress(t *testing.T) {
+	router, db, calls := setupRouter(t, http.StatusCreated)
+
+	// 同じキーの先行リクエストが処理中（応答が未記録）の状態
+	now := time.Now()
+	_, created, err := NewStore(db).Begin(1, "key-1", testFingerprint(`{"a":1}`), now, now.Add(30*time.Second), now.Add(time.Hour))
+	require.NoError(t, err)
+	require.True(t, created)
+
+	w := post(router, "key-1", `{"a":1}`)
+
+	assert.Equal(t, 0, *calls)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+}
+
+func TestMiddleware_ReclaimsRequestWhoseLockExpired(t *testing.T) {
+	router, db, calls := setupRouter(t, http.StatusCreated)
+
+	// 先行リクエストの処理中にプロセスが停止し、ロックの期限が切れた状態
+	now := time.Now()
+	_, created, err := NewStore(db).Begin(1, "key-1", testFingerprint(`{"a":1}`), now.Add(-2*time.Minute), now.Add(-time.Minute), now.Add(time.Hour))
+	require.NoError(t, err)
+	require.True(t, created)
+
+	// 異なるリクエストは引き継げない
+	w := post(router, "key-1", `{"a":2}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = post(router, "key-1", `{"a":1}`)
+	assert.Equal(t, 1, *calls)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// 引き継いだリクエストの応答が記録される
+	w = post(router, "key-1", `{"a":1}`)
+	assert.Equal(t, 1, *calls)
+	assert.Equal(t, "true", w.Header().Get(HeaderReplayed))
+}
+
+func TestMiddleware_DiscardsKeyWhenHandlerPanics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := dbtest.Open(t, &models.IdempotencyKey{})
+	calls := 0
+
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.POST("/items",
+		func(c *gin.Context) {
+			c.Set("userID", uint(1))
+			c.Next()
+		},
+		Middleware(NewStore(db), Config{TTL: time.Hour, LockTimeout: time.Minute, MaxBodyBytes: 1024}),
+		func(c *gin.Context) {
+			calls++
+			if calls == 1 {
+				panic("boom")
+			}
+			c.JSON(http.StatusCreated, gin.H{"call": calls})
+		},
+	)
+
+	w := post(router, "key-1", `{"a":1}`)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	// 処理中の記録が残っていないため、同じキーで再試行できる
+	w = post(router, "key-1", `{"a":1}`)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestMiddleware_RejectsTooLargeBody(t *testing.T) {
+	router, db, calls := setupRouter(t, http.StatusCreated)
+
+	w := post(router, "key-1", `{"a":"`+strings.Repeat("x", 1024)+`"}`)
+
+	assert.Equal(t, 0, *calls)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	var count int64
+	db.Model(&models.IdempotencyKey{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestMiddleware_DoesNotStoreServerErrors(t *testing.T) {
+	router, _, calls := setupRouter(t, http.StatusInternalServerError)
+
+	post(router, "key-1", `{"a":1}`)
+	post(router, "key-1", `{"a":1}`)
+
+	assert.Equal(t, 2, *calls)
+}
+
+func TestMiddleware_ProcessesExpiredKeyAgain(t *testing.T) {
+	router, db, calls := setupRouter(t, http.StatusCreated)
+
+	post(router, "key-1", `{"a":1}`)
+	require.NoError(t, db.Model(&models.IdempotencyKey{}).Where("1 = 1").Update("expires_at", time.Now().Add(-time.Minute)).Error)
+	w := post(router, "key-1", `{"a":2}`)
+
+	assert.Equal(t, 2, *calls)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get(HeaderReplayed))
+}
+
+func TestMiddleware_KeysAreScopedToUser(t *testing.T) {
+	_, db, _ := setupRouter(t, http.StatusCreated)
+	store := NewStore(db)
+	now := time.Now()
+
+	_, created, err := store.Begin(1, "key-1", "a", now, now.Add(time.Minute), now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	_, created, err = store.Begin(2, "key-1", "b", now, now.Add(time.Minute), now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, created)
+}
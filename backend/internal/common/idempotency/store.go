@@ -0,0 +1,76 @@
+package idempotency
+
+import (
+	"time"
+
+	"simple-ledger/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Store: Idempotency-Key ごとのリクエストと応答の記録（複数インスタンスで共有できるようデータベースに保存する）
+type Store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+// Begin はキーの処理を開始し、lockedUntil まで他のリクエストが同じキーを処理しないようにする
+// 有効な記録が既にある場合は作成せず、その記録と false を返す（期限切れの記録は削除して新しいリクエストとして扱う）
+// 処理中のまま lockedUntil を過ぎた同じリクエストの記録は引き継ぎ、true を返す
+func (s *Store) Begin(userID uint, key, fingerprint string, now, lockedUntil, expiresAt time.Time) (*models.IdempotencyKey, bool, error) {
+	if err := s.db.Where("expires_at <= ?", now).Delete(&models.IdempotencyKey{}).Error; err != nil {
+		return nil, false, err
+	}
+
+	record := &models.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		Fingerprint: fingerprint,
+		LockedUntil: &lockedUntil,
+		ExpiresAt:   expiresAt,
+	}
+	// 同じキーの同時リクエストは一意制約で1件だけが作成に成功する
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return record, true, nil
+	}
+
+	// 処理中のままロックの期限を過ぎた記録（処理中にプロセスが停止した場合など）は、1件のリクエストだけが引き継ぐ
+	reclaimed := s.db.Model(&models.IdempotencyKey{}).
+		Where("user_id = ? AND idempotency_key = ? AND fingerprint = ? AND status_code = 0", userID, key, fingerprint).
+		Where("locked_until IS NULL OR locked_until <= ?", now).
+		Update("locked_until", lockedUntil)
+	if reclaimed.Error != nil {
+		return nil, false, reclaimed.Error
+	}
+
+	var existing models.IdempotencyKey
+	if err := s.db.Where("user_id = ? AND idempotency_key = ?", userID, key).First(&existing).Error; err != nil {
+		return nil, false, err
+	}
+	return &existing, reclaimed.RowsAffected == 1, nil
+}
+
+// Complete は処理中の記録に応答を保存する
+func (s *Store) Complete(id uint, statusCode int, headers string, body []byte) error {
+	return s.db.Model(&models.IdempotencyKey{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status_code":      statusCode,
+			"response_headers": headers,
+			"response_body":    body,
+			"locked_until":     nil,
+		}).Error
+}
+
+// Discard は記録を削除し、同じキーで再試行できるようにする
+func (s *Store) Discard(id uint) error {
+	return s.db.Delete(&models.IdempotencyKey{}, id).Error
+}
@@ -0,0 +1,112 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net/mail"
+	"strings"
+	"time"
+
+	"simple-ledger/internal/common/config"
+)
+
+// Message: 送信するメール（本文はプレーンテキスト）
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer: メールの送信先
+type Mailer interface {
+	// Send: メールを送信（送信先に受け付けられた時点で返る）
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewFromEnv: 環境変数 MAIL_DRIVER に応じた Mailer を生成
+// smtp: SMTP サーバー経由で送信、file: ローカル開発用に .eml ファイルとして保存
+func NewFromEnv() (Mailer, error) {
+	from := config.GetEnv("MAIL_FROM", "Simple Ledger <no-reply@localhost>")
+
+	driver := config.GetEnv("MAIL_DRIVER", "file")
+	switch driver {
+	case "smtp":
+		return NewSMTPMailer(SMTPConfig{
+			Host:     config.GetEnv("SMTP_HOST", "localhost"),
+			Port:     config.GetEnvAsInt("SMTP_PORT", 587),
+			Username: config.GetEnv("SMTP_USERNAME", ""),
+			Password: config.GetEnv("SMTP_PASSWORD", ""),
+			From:     from,
+		})
+	case "file":
+		return NewFileOutbox(config.GetEnv("MAIL_OUTBOX_DIR", "./mail-outbox"), from)
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER: %s", driver)
+	}
+}
+
+// buildMessage: RFC 5322 形式のメッセージを組み立てる
+// 件名は MIME エンコードし、本文は UTF-8 の Base64 で送る（日本語を含むため）
+func buildMessage(from string, msg Message, now time.Time) ([]byte, error) {
+	if err := validateHeader(msg.To, msg.Subject); err != nil {
+		return nil, err
+	}
+	if _, err := mail.ParseAddress(msg.To); err != nil {
+		return nil, fmt.Errorf("invalid recipient address: %w", err)
+	}
+
+	id, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if at := strings.LastIndex(addr.Address, "@"); at >= 0 {
+			domain = addr.Address[at+1:]
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", id, domain)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n")
+	buf.WriteString("\r\n")
+
+	// 1行76文字で折り返す（RFC 2045）
+	encoded := base64.StdEncoding.EncodeToString([]byte(msg.Body))
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+
+	return buf.Bytes(), nil
+}
+
+// validateHeader: ヘッダーインジェクションを防ぐため、改行を含む値を拒否
+func validateHeader(values ...string) error {
+	for _, v := range values {
+		if strings.ContainsAny(v, "\r\n") {
+			return fmt.Errorf("mail header must not contain line breaks")
+		}
+	}
+	return nil
+}
+
+func randomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
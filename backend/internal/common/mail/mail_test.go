@@ -0,0 +1,139 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildMessage(t *testing.T) {
+	msg := Message{To: "user@example.com", Subject: "パスワードの再設定", Body: "本文です"}
+	data, err := buildMessage("Simple Ledger <no-reply@example.com>", msg, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	header, body, found := strings.Cut(string(data), "\r\n\r\n")
+	require.True(t, found)
+	assert.Contains(t, header, "To: user@example.com\r\n")
+	assert.Contains(t, header, "Subject: =?UTF-8?b?")
+	assert.Contains(t, header, "@example.com>\r\n")
+
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(body, "\r\n", ""))
+	require.NoError(t, err)
+	assert.Equal(t, "本文です", string(decoded))
+}
+
+func TestBuildMessage_RejectsHeaderInjection(t *testing.T) {
+	_, err := buildMessage("no-reply@example.com", Message{To: "user@example.com\r\nBcc: other@example.com", Subject: "x"}, time.Now())
+	assert.Error(t, err)
+
+	_, err = buildMessage("no-reply@example.com", Message{To: "user@example.com", Subject: "x\nBcc: other@example.com"}, time.Now())
+	assert.Error(t, err)
+}
+
+func TestFileOutbox(t *testing.T) {
+	dir := t.TempDir()
+	outbox, err := NewFileOutbox(dir, "no-reply@example.com")
+	require.NoError(t, err)
+
+	require.NoError(t, outbox.Send(context.Background(), Message{To: "user@example.com", Subject: "Hello", Body: "body"}))
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.Len(t, files, 1)
+	data, _ := os.ReadFile(files[0])
+	assert.Contains(t, string(data), "To: user@example.com")
+}
+
+// startFakeSMTP: 受信したメッセージを返すだけの SMTP サーバーを起動
+func startFakeSMTP(t *testing.T) (string, chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		write := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+		write("220 localhost ESMTP")
+		var envelope []string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch cmd {
+			case "EHLO":
+				write("250-localhost")
+				write("250 AUTH PLAIN")
+			case "AUTH":
+				write("235 Authentication successful")
+			case "MAIL", "RCPT":
+				envelope = append(envelope, line)
+				write("250 OK")
+			case "DATA":
+				write("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					l, err := reader.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				received <- strings.Join(envelope, "\n") + "\n" + data.String()
+				write("250 OK")
+			case "QUIT":
+				write("221 Bye")
+				return
+			default:
+				write("502 Command not implemented")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), received
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	addr, received := startFakeSMTP(t)
+	host, port, _ := net.SplitHostPort(addr)
+	portNum, _ := net.LookupPort("tcp", port)
+
+	mailer, err := NewSMTPMailer(SMTPConfig{
+		Host:     host,
+		Port:     portNum,
+		Username: "user",
+		Password: "secret",
+		From:     "Simple Ledger <no-reply@example.com>",
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, mailer.Send(ctx, Message{To: "user@example.com", Subject: "Hello", Body: "body"}))
+
+	select {
+	case data := <-received:
+		assert.Contains(t, data, "MAIL FROM:<no-reply@example.com>")
+		assert.Contains(t, data, "RCPT TO:<user@example.com>")
+		assert.Contains(t, data, "Subject: Hello")
+	case <-time.After(5 * time.Second):
+		t.Fatal("message was not received")
+	}
+}
@@ -0,0 +1,70 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// MemoryOutbox: 送信したメールをメモリ上に保持する Mailer（テスト用）
+type MemoryOutbox struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemoryOutbox() *MemoryOutbox {
+	return &MemoryOutbox{}
+}
+
+// Send: メールを保持する
+func (o *MemoryOutbox) Send(ctx context.Context, msg Message) error {
+	if err := validateHeader(msg.To, msg.Subject); err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+// Messages: 送信されたメールを送信順に返す
+func (o *MemoryOutbox) Messages() []Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]Message(nil), o.messages...)
+}
+
+// FileOutbox: 送信したメールを .eml ファイルとして保存する Mailer（ローカル開発用）
+// 保存したファイルはメールクライアントで開いて内容を確認できる
+type FileOutbox struct {
+	dir  string
+	from string
+	now  func() time.Time
+}
+
+func NewFileOutbox(dir string, from string) (*FileOutbox, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail outbox directory: %w", err)
+	}
+	return &FileOutbox{dir: dir, from: from, now: time.Now}, nil
+}
+
+// Send: メールをファイルに保存
+func (o *FileOutbox) Send(ctx context.Context, msg Message) error {
+	now := o.now()
+	data, err := buildMessage(o.from, msg, now)
+	if err != nil {
+		return err
+	}
+
+	id, err := randomID()
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), id[:8])
+	return os.WriteFile(filepath.Join(o.dir, name), data, 0o600)
+}
@@ -0,0 +1,303 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"html/template"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockCodeExpiration: モック IdP が発行する認可コードの有効期限
+const mockCodeExpiration = time.Minute
+
+// MockIssuer: ローカル開発・テスト用の最小限の OpenID Connect プロバイダー
+// 認可エンドポイントでは任意のメールアドレスでログインでき（login_hint を指定した場合は入力画面を省略）、
+// PKCE（S256）とクライアント認証を検証したうえで RS256 で署名した ID トークンを発行する
+// 本番環境では使用しないこと
+type MockIssuer struct {
+	clientID     string
+	clientSecret string
+	key          *rsa.PrivateKey
+	keyID        string
+	now          func() time.Time
+
+	mu        sync.Mutex
+	issuerURL string
+	codes     map[string]mockAuthorization
+}
+
+// mockAuthorization: 認可コードに紐づく認可リクエストとログインしたユーザー
+type mockAuthorization struct {
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+	claims        jwt.MapClaims
+	expiresAt     time.Time
+}
+
+func NewMockIssuer(clientID string, clientSecret string) (*MockIssuer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	keyID, err := RandomString()
+	if err != nil {
+		return nil, err
+	}
+	return &MockIssuer{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		key:          key,
+		keyID:        keyID[:16],
+		now:          time.Now,
+		codes:        make(map[string]mockAuthorization),
+	}, nil
+}
+
+// SetIssuerURL: 発行者のURL（モック IdP を公開するURL）を設定
+func (m *MockIssuer) SetIssuerURL(issuerURL string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.issuerURL = strings.TrimSuffix(issuerURL, "/")
+}
+
+// IssuerURL: 発行者のURL
+func (m *MockIssuer) IssuerURL() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.issuerURL
+}
+
+func (m *MockIssuer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		m.handleDiscovery(w)
+	case "/authorize":
+		m.handleAuthorize(w, r)
+	case "/token":
+		m.handleToken(w, r)
+	case "/jwks":
+		m.handleJWKS(w)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (m *MockIssuer) handleDiscovery(w http.ResponseWriter) {
+	issuer := m.IssuerURL()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                issuer,
+		"authorization_endpoint":                issuer + "/authorize",
+		"token_endpoint":                        issuer + "/token",
+		"jwks_uri":                              issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (m *MockIssuer) handleJWKS(w http.ResponseWriter) {
+	pub := m.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": m.keyID,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+// mockLoginPage: ログインするユーザーを入力する画面（元の認可リクエストのパラメーターを引き継ぐ）
+var mockLoginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Mock OIDC Login</title></head>
+<body>
+<h1>Mock OIDC Login</h1>
+<form method="get" action="authorize">
+{{range $name, $values := .}}{{range $values}}<input type="hidden" name="{{$name}}" value="{{.}}">
+{{end}}{{end}}<p><label>Email <input type="email" name="login_hint" required></label></p>
+<p><label>Name <input type="text" name="name"></label></p>
+<p><label>Groups (comma separated) <input type="text" name="groups"></label></p>
+<p><label>Email verified <select name="email_verified"><option value="true">true</option><option value="false">false</option></select></label></p>
+<p><button type="submit">Sign in</button></p>
+</form>
+</body></html>`))
+
+// handleAuthorize: 認可リクエストを検証し、ユーザーを決めて認可コードを発行する
+// login_hint（メールアドレス）、name、groups（カンマ区切り）、email_verified でログインするユーザーを指定できる
+func (m *MockIssuer) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURI := query.Get("redirect_uri")
+	if query.Get("client_id") != m.clientID || redirectURI == "" {
+		http.Error(w, "invalid client_id or redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		http.Error(w, "authorization code flow with PKCE (S256) is required", http.StatusBadRequest)
+		return
+	}
+
+	email := strings.TrimSpace(query.Get("login_hint"))
+	if email == "" {
+		hidden := url.Values{}
+		for key, values := range query {
+			switch key {
+			case "login_hint", "name", "groups", "email_verified":
+			default:
+				hidden[key] = values
+			}
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = mockLoginPage.Execute(w, hidden)
+		return
+	}
+
+	name := query.Get("name")
+	if name == "" {
+		name, _, _ = strings.Cut(email, "@")
+	}
+	var groups []string
+	for _, group := range strings.Split(query.Get("groups"), ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			groups = append(groups, group)
+		}
+	}
+	subject := sha256.Sum256([]byte(strings.ToLower(email)))
+
+	code, err := RandomString()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	m.mu.Lock()
+	m.codes[code] = mockAuthorization{
+		redirectURI:   redirectURI,
+		codeChallenge: query.Get("code_challenge"),
+		nonce:         query.Get("nonce"),
+		claims: jwt.MapClaims{
+			"sub":            hex.EncodeToString(subject[:16]),
+			"email":          email,
+			"email_verified": query.Get("email_verified") != "false",
+			"name":           name,
+			"groups":         groups,
+		},
+		expiresAt: m.now().Add(mockCodeExpiration),
+	}
+	m.mu.Unlock()
+
+	callback, err := url.Parse(redirectURI)
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	params := callback.Query()
+	params.Set("code", code)
+	if state := query.Get("state"); state != "" {
+		params.Set("state", state)
+	}
+	callback.RawQuery = params.Encode()
+	http.Redirect(w, r, callback.String(), http.StatusFound)
+}
+
+// handleToken: 認可コードを交換して ID トークンを発行する（認可コードは一度だけ使用できる）
+func (m *MockIssuer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeTokenError(w, http.StatusMethodNotAllowed, "invalid_request")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeTokenError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != m.clientID || subtle.ConstantTimeCompare([]byte(clientSecret), []byte(m.clientSecret)) != 1 {
+		writeTokenError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		writeTokenError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	m.mu.Lock()
+	auth, found := m.codes[code]
+	delete(m.codes, code)
+	m.mu.Unlock()
+
+	if !found || m.now().After(auth.expiresAt) || r.PostForm.Get("redirect_uri") != auth.redirectURI {
+		writeTokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+	if CodeChallenge(r.PostForm.Get("code_verifier")) != auth.codeChallenge {
+		writeTokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+
+	now := m.now()
+	claims := jwt.MapClaims{
+		"iss": m.IssuerURL(),
+		"aud": m.clientID,
+		"iat": now.Unix(),
+		"exp": now.Add(5 * time.Minute).Unix(),
+	}
+	if auth.nonce != "" {
+		claims["nonce"] = auth.nonce
+	}
+	for k, v := range auth.claims {
+		claims[k] = v
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = m.keyID
+	idToken, err := token.SignedString(m.key)
+	if err != nil {
+		writeTokenError(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+
+	accessToken, err := RandomString()
+	if err != nil {
+		writeTokenError(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func writeTokenError(w http.ResponseWriter, status int, code string) {
+	writeJSON(w, status, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,106 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupMockProvider: モック IdP とそれに接続する Provider を作成
+func setupMockProvider(t *testing.T) *Provider {
+	mock, err := NewMockIssuer("client", "secret")
+	require.NoError(t, err)
+	server := httptest.NewServer(mock)
+	t.Cleanup(server.Close)
+	mock.SetIssuerURL(server.URL)
+
+	provider, err := NewProvider(Config{
+		IssuerURL:    server.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:8080/api/auth/oidc/callback",
+		Scopes:       []string{"email", "profile"},
+	})
+	require.NoError(t, err)
+	return provider
+}
+
+// authorize: 認可エンドポイントにアクセスし、リダイレクト先から認可コードを取り出す
+func authorize(t *testing.T, authURL string, extra url.Values) string {
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	query := u.Query()
+	for k, v := range extra {
+		query[k] = v
+	}
+	u.RawQuery = query.Encode()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(u.String())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, "state-1", location.Query().Get("state"))
+	return location.Query().Get("code")
+}
+
+func TestProvider_AuthorizationCodeFlowWithPKCE(t *testing.T) {
+	provider := setupMockProvider(t)
+	ctx := context.Background()
+	verifier, _ := RandomString()
+
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", CodeChallenge(verifier))
+	require.NoError(t, err)
+	assert.Contains(t, authURL, "scope=openid+email+profile")
+
+	code := authorize(t, authURL, url.Values{"login_hint": {"alice@example.com"}, "groups": {"ledger-admins"}})
+	claims, err := provider.Exchange(ctx, code, verifier, "nonce-1")
+	require.NoError(t, err)
+	assert.NotEmpty(t, claims.Subject)
+	assert.Equal(t, "alice@example.com", claims.Email)
+	assert.True(t, claims.EmailVerified)
+	assert.Equal(t, "alice", claims.Name)
+	assert.Equal(t, []any{"ledger-admins"}, claims.Raw["groups"])
+
+	// 認可コードは一度だけ使用できる
+	_, err = provider.Exchange(ctx, code, verifier, "nonce-1")
+	assert.Error(t, err)
+}
+
+func TestProvider_RejectsWrongVerifierAndNonce(t *testing.T) {
+	provider := setupMockProvider(t)
+	ctx := context.Background()
+	verifier, _ := RandomString()
+
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", CodeChallenge(verifier))
+	require.NoError(t, err)
+
+	code := authorize(t, authURL, url.Values{"login_hint": {"alice@example.com"}})
+	_, err = provider.Exchange(ctx, code, verifier+"x", "nonce-1")
+	assert.Error(t, err)
+
+	code = authorize(t, authURL, url.Values{"login_hint": {"alice@example.com"}})
+	_, err = provider.Exchange(ctx, code, verifier, "other-nonce")
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+}
+
+func TestProvider_RejectsIssuerMismatch(t *testing.T) {
+	mock, err := NewMockIssuer("client", "secret")
+	require.NoError(t, err)
+	server := httptest.NewServer(mock)
+	defer server.Close()
+	mock.SetIssuerURL("https://idp.example.com")
+
+	provider, err := NewProvider(Config{IssuerURL: server.URL, ClientID: "client", RedirectURL: "http://localhost/callback"})
+	require.NoError(t, err)
+	_, err = provider.AuthCodeURL(context.Background(), "s", "n", "c")
+	assert.Error(t, err)
+}
@@ -0,0 +1,396 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"simple-ledger/internal/common/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// keysRefreshInterval: 未知の kid を受け取った場合に公開鍵を再取得する最小間隔
+const keysRefreshInterval = time.Minute
+
+// maxResponseBytes: IdP からのレスポンスとして読み込む最大サイズ
+const maxResponseBytes = 1 << 20
+
+// ErrInvalidIDToken: ID トークンの署名・発行者・対象者・有効期限・nonce のいずれかが不正
+var ErrInvalidIDToken = errors.New("invalid ID token")
+
+// Config: OpenID Connect プロバイダー（IdP）の接続設定
+type Config struct {
+	// IssuerURL: 発行者（/.well-known/openid-configuration の取得元）
+	IssuerURL string
+
+	// ClientID, ClientSecret: IdP に登録したクライアントの認証情報
+	ClientID     string
+	ClientSecret string
+
+	// RedirectURL: 認可コードを受け取るコールバックURL（IdP に登録したもの）
+	RedirectURL string
+
+	// Scopes: 要求するスコープ（openid は常に含める）
+	Scopes []string
+}
+
+// Claims: 検証済みの ID トークンのクレーム
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+
+	// Raw: すべてのクレーム（ロールの判定などに使う）
+	Raw map[string]any
+}
+
+// providerMetadata: ディスカバリーで取得する IdP の情報
+type providerMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider: 認可コードフロー（PKCE）で IdP と通信するクライアント
+// IdP の情報と公開鍵は最初に必要になった時点で取得してキャッシュする
+type Provider struct {
+	config Config
+	client *http.Client
+	now    func() time.Time
+
+	mu            sync.Mutex
+	metadata      *providerMetadata
+	keys          map[string]*rsa.PublicKey
+	keysFetchedAt time.Time
+}
+
+func NewProvider(cfg Config) (*Provider, error) {
+	if cfg.IssuerURL == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, fmt.Errorf("OIDC issuer URL, client ID and redirect URL are required")
+	}
+	cfg.IssuerURL = strings.TrimSuffix(cfg.IssuerURL, "/")
+	if !slices.Contains(cfg.Scopes, "openid") {
+		cfg.Scopes = append([]string{"openid"}, cfg.Scopes...)
+	}
+	return &Provider{
+		config: cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+		now:    time.Now,
+	}, nil
+}
+
+// NewProviderFromEnv: 環境変数から Provider を生成（OIDC_ISSUER_URL が未設定の場合は nil）
+// OIDC_MOCK_ISSUER=true の場合はモック IdP を OIDC_MOCK_ADDR で起動して接続する（本番環境では起動しない）
+func NewProviderFromEnv() (*Provider, error) {
+	cfg := Config{
+		IssuerURL:    config.GetEnv("OIDC_ISSUER_URL", ""),
+		ClientID:     config.GetEnv("OIDC_CLIENT_ID", ""),
+		ClientSecret: config.GetEnv("OIDC_CLIENT_SECRET", ""),
+		RedirectURL:  config.GetEnv("OIDC_REDIRECT_URL", "http://localhost:8080/api/auth/oidc/callback"),
+		Scopes:       strings.Fields(config.GetEnv("OIDC_SCOPES", "openid email profile")),
+	}
+
+	if config.GetEnv("OIDC_MOCK_ISSUER", "false") == "true" {
+		if config.GetEnv("APP_ENV", "development") == "production" {
+			return nil, fmt.Errorf("OIDC_MOCK_ISSUER must not be enabled in production")
+		}
+		if cfg.ClientID == "" {
+			cfg.ClientID = "simple-ledger"
+		}
+		if cfg.ClientSecret == "" {
+			cfg.ClientSecret = "mock-secret"
+		}
+		mock, err := NewMockIssuer(cfg.ClientID, cfg.ClientSecret)
+		if err != nil {
+			return nil, err
+		}
+		addr := config.GetEnv("OIDC_MOCK_ADDR", "localhost:9400")
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to start mock OIDC issuer: %w", err)
+		}
+		publicURL := config.GetEnv("OIDC_MOCK_PUBLIC_URL", "")
+		if publicURL == "" {
+			publicURL = "http://" + addr
+		}
+		mock.SetIssuerURL(publicURL)
+		go func() {
+			if err := http.Serve(listener, mock); err != nil {
+				log.Printf("mock OIDC issuer stopped: %v", err)
+			}
+		}()
+		cfg.IssuerURL = mock.IssuerURL()
+	}
+
+	if cfg.IssuerURL == "" {
+		return nil, nil
+	}
+	return NewProvider(cfg)
+}
+
+// Issuer: 発行者（ユーザーとの紐づけに使う）
+func (p *Provider) Issuer() string {
+	return p.config.IssuerURL
+}
+
+// AuthCodeURL: IdP の認可エンドポイントへのURLを返す
+// state は CSRF 対策、nonce は ID トークンの再利用対策、codeChallenge は PKCE のチャレンジ（S256）
+func (p *Provider) AuthCodeURL(ctx context.Context, state string, nonce string, codeChallenge string) (string, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(p.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return metadata.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange: 認可コードをトークンエンドポイントで交換し、ID トークンを検証してクレームを返す
+func (p *Provider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*Claims, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"code_verifier": {codeVerifier},
+		"client_id":     {p.config.ClientID},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// client_secret_basic: ID とシークレットは URL エンコードしてから Basic 認証に使う（RFC 6749 2.3.1）
+	req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.doJSON(req, &token)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("token request failed (%d): %s %s", status, token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("token response does not contain an ID token")
+	}
+
+	return p.verifyIDToken(ctx, metadata, token.IDToken, nonce)
+}
+
+// verifyIDToken: ID トークンの署名（RS256）と標準クレームを検証
+func (p *Provider) verifyIDToken(ctx context.Context, metadata *providerMetadata, rawToken string, nonce string) (*Claims, error) {
+	raw := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawToken, raw, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.publicKey(ctx, metadata, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(metadata.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithTimeFunc(p.now),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if tokenNonce, _ := raw["nonce"].(string); nonce == "" || tokenNonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	// 複数の対象者を含む場合は azp が自分であること（OpenID Connect Core 3.1.3.7）
+	if aud, _ := raw.GetAudience(); len(aud) > 1 {
+		if azp, _ := raw["azp"].(string); azp != p.config.ClientID {
+			return nil, fmt.Errorf("%w: unexpected authorized party", ErrInvalidIDToken)
+		}
+	}
+
+	claims := &Claims{Raw: map[string]any(raw)}
+	claims.Subject, _ = raw["sub"].(string)
+	claims.Email, _ = raw["email"].(string)
+	claims.Name, _ = raw["name"].(string)
+	// email_verified を文字列で返す IdP もある
+	switch verified := raw["email_verified"].(type) {
+	case bool:
+		claims.EmailVerified = verified
+	case string:
+		claims.EmailVerified = verified == "true"
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+
+	return claims, nil
+}
+
+// discover: IdP の情報を取得（取得済みの場合はキャッシュを返す）
+func (p *Provider) discover(ctx context.Context) (*providerMetadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.config.IssuerURL+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var metadata providerMetadata
+	status, err := p.doJSON(req, &metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC discovery document: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch OIDC discovery document: status %d", status)
+	}
+	// 発行者が設定と一致しない場合は使用しない（OpenID Connect Discovery 4.3）
+	if strings.TrimSuffix(metadata.Issuer, "/") != p.config.IssuerURL {
+		return nil, fmt.Errorf("OIDC issuer mismatch: expected %s, got %s", p.config.IssuerURL, metadata.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("OIDC discovery document is missing required endpoints")
+	}
+
+	p.metadata = &metadata
+	return p.metadata, nil
+}
+
+// publicKey: kid に対応する公開鍵を返す
+// 未知の kid の場合は IdP が鍵を更新した可能性があるため、間隔を空けて再取得する
+func (p *Provider) publicKey(ctx context.Context, metadata *providerMetadata, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	if p.keys != nil && p.now().Sub(p.keysFetchedAt) < keysRefreshInterval {
+		return nil, fmt.Errorf("unknown key ID: %s", kid)
+	}
+
+	keys, err := p.fetchKeys(ctx, metadata.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+	p.keysFetchedAt = p.now()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key ID: %s", kid)
+}
+
+// lookupKey: キャッシュから公開鍵を探す（kid が無い場合は鍵が1つだけのときに限りそれを使う）
+func (p *Provider) lookupKey(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// fetchKeys: JWKS から RSA 公開鍵を取得
+func (p *Provider) fetchKeys(ctx context.Context, jwksURI string) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	status, err := p.doJSON(req, &set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC signing keys: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch OIDC signing keys: status %d", status)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range set.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil || len(e) > 4 {
+			continue
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+// doJSON: リクエストを送信して JSON レスポンスを読み込み、ステータスコードを返す
+func (p *Provider) doJSON(req *http.Request, v any) (int, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return 0, err
+	}
+	if err := json.Unmarshal(body, v); err != nil && resp.StatusCode == http.StatusOK {
+		return 0, fmt.Errorf("invalid JSON response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Policy: 失敗回数に応じた待機時間（指数バックオフ）の規則
+type Policy struct {
+	// Window: 失敗回数を数える期間（最初の失敗から）
+	Window time.Duration
+
+	// FreeFailures: 待機なしで許容する失敗回数
+	FreeFailures int64
+
+	// BaseDelay: FreeFailures を超えた最初の失敗後の待機時間（以降は失敗ごとに2倍）
+	BaseDelay time.Duration
+
+	// MaxDelay: 待機時間の上限
+	MaxDelay time.Duration
+}
+
+// Delay: 失敗回数に対する待機時間を返す
+func (p Policy) Delay(failures int64) time.Duration {
+	if failures <= p.FreeFailures {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.FreeFailures + 1; i < failures; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	if delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// Limiter: 対象（IPアドレスやアカウントなど）ごとの失敗回数と待機状態を Store で管理する
+// Store を共有すれば複数インスタンス間でも同じ制限がかかる
+type Limiter struct {
+	store  Store
+	prefix string
+	policy Policy
+}
+
+// NewLimiter: Limiter を生成（prefix はストア上のキーの名前空間）
+func NewLimiter(store Store, prefix string, policy Policy) *Limiter {
+	return &Limiter{store: store, prefix: prefix, policy: policy}
+}
+
+// Check: 対象が待機中であれば残りの待機時間を返す（0 の場合は試行可能）
+func (l *Limiter) Check(ctx context.Context, subject string) (time.Duration, error) {
+	return l.store.TTL(ctx, l.key("block", subject))
+}
+
+// Fail: 失敗を記録し、期間内の失敗回数と次の試行までの待機時間を返す
+func (l *Limiter) Fail(ctx context.Context, subject string) (int64, time.Duration, error) {
+	failures, err := l.store.Incr(ctx, l.key("fail", subject), l.policy.Window)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	delay := l.policy.Delay(failures)
+	if delay > 0 {
+		if err := l.store.Set(ctx, l.key("block", subject), "1", delay); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return failures, delay, nil
+}
+
+// Reset: 対象の失敗回数と待機状態を消去
+func (l *Limiter) Reset(ctx context.Context, subject string) error {
+	return l.store.Del(ctx, l.key("fail", subject), l.key("block", subject))
+}
+
+// key: ストア上のキー（メールアドレス等をそのまま保存しないようハッシュ化する）
+func (l *Limiter) key(kind string, subject string) string {
+	sum := sha256.Sum256([]byte(subject))
+	return l.prefix + ":" + kind + ":" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// sweepInterval: 期限切れのキーをまとめて削除する書き込み回数の間隔
+const sweepInterval = 1024
+
+// MemoryStore: プロセス内メモリに保持する Store（単一ノード・テスト用）
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	writes  int
+	now     func() time.Time
+}
+
+type memoryEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+// NewMemoryStore: メモリ上の Store を生成
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry), now: time.Now}
+}
+
+func (s *MemoryStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	entry, ok := s.get(key, now)
+	if !ok {
+		entry = memoryEntry{value: "0", expiresAt: now.Add(ttl)}
+	}
+
+	n, err := strconv.ParseInt(entry.value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	n++
+	entry.value = strconv.FormatInt(n, 10)
+	s.put(key, entry, now)
+
+	return n, nil
+}
+
+func (s *MemoryStore) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.put(key, memoryEntry{value: value, expiresAt: now.Add(ttl)}, now)
+	return nil
+}
+
+func (s *MemoryStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	entry, ok := s.get(key, now)
+	if !ok {
+		return 0, nil
+	}
+	return entry.expiresAt.Sub(now), nil
+}
+
+func (s *MemoryStore) Del(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// get: 有効期限内のエントリーを取得（呼び出し側でロックを取得すること）
+func (s *MemoryStore) get(key string, now time.Time) (memoryEntry, bool) {
+	entry, ok := s.entries[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		return memoryEntry{}, false
+	}
+	return entry, true
+}
+
+// put: エントリーを保存し、一定回数ごとに期限切れのエントリーを削除（呼び出し側でロックを取得すること）
+func (s *MemoryStore) put(key string, entry memoryEntry, now time.Time) {
+	s.entries[key] = entry
+
+	s.writes++
+	if s.writes%sweepInterval != 0 {
+		return
+	}
+	for k, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyDelay(t *testing.T) {
+	policy := Policy{FreeFailures: 3, BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	assert.Equal(t, time.Duration(0), policy.Delay(3))
+	assert.Equal(t, 1*time.Second, policy.Delay(4))
+	assert.Equal(t, 2*time.Second, policy.Delay(5))
+	assert.Equal(t, 8*time.Second, policy.Delay(7))
+	assert.Equal(t, 10*time.Second, policy.Delay(8))
+	assert.Equal(t, 10*time.Second, policy.Delay(1000))
+}
+
+func TestMemoryStore_Expiration(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	n, _ := store.Incr(ctx, "count", time.Minute)
+	assert.Equal(t, int64(1), n)
+
+	// INCR は作成時の期限を延長しない
+	now = now.Add(30 * time.Second)
+	n, _ = store.Incr(ctx, "count", time.Minute)
+	assert.Equal(t, int64(2), n)
+	ttl, _ := store.TTL(ctx, "count")
+	assert.Equal(t, 30*time.Second, ttl)
+
+	now = now.Add(30 * time.Second)
+	ttl, _ = store.TTL(ctx, "count")
+	assert.Equal(t, time.Duration(0), ttl)
+	n, _ = store.Incr(ctx, "count", time.Minute)
+	assert.Equal(t, int64(1), n)
+}
+
+func TestLimiter_FailCheckReset(t *testing.T) {
+	store := NewMemoryStore()
+	limiter := NewLimiter(store, "test", Policy{Window: time.Minute, FreeFailures: 1, BaseDelay: time.Second, MaxDelay: time.Minute})
+	ctx := context.Background()
+
+	failures, delay, err := limiter.Fail(ctx, "user@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), failures)
+	assert.Equal(t, time.Duration(0), delay)
+
+	wait, _ := limiter.Check(ctx, "user@example.com")
+	assert.Equal(t, time.Duration(0), wait)
+
+	_, delay, _ = limiter.Fail(ctx, "user@example.com")
+	assert.Equal(t, time.Second, delay)
+	wait, _ = limiter.Check(ctx, "user@example.com")
+	assert.Greater(t, wait, time.Duration(0))
+
+	// 他の対象には影響しない
+	wait, _ = limiter.Check(ctx, "other@example.com")
+	assert.Equal(t, time.Duration(0), wait)
+
+	require.NoError(t, limiter.Reset(ctx, "user@example.com"))
+	wait, _ = limiter.Check(ctx, "user@example.com")
+	assert.Equal(t, time.Duration(0), wait)
+
+	// キーに対象の値をそのまま含めない
+	for key := range store.entries {
+		assert.NotContains(t, key, "other@example.com")
+		assert.True(t, strings.HasPrefix(key, "test:"))
+	}
+}
+
+// startFakeRedis: MemoryStore を使って必要なコマンドのみ応答する RESP サーバーを起動
+func startFakeRedis(t *testing.T, password string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	store := NewMemoryStore()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveFakeRedis(conn, store, password)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func serveFakeRedis(conn net.Conn, store *MemoryStore, password string) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	ctx := context.Background()
+	authenticated := password == ""
+
+	for {
+		reply, err := readReply(reader)
+		if err != nil {
+			return
+		}
+		items := reply.([]interface{})
+		args := make([]string, len(items))
+		for i, item := range items {
+			args[i] = item.(string)
+		}
+
+		var response string
+		switch cmd := strings.ToUpper(args[0]); {
+		case cmd == "AUTH":
+			authenticated = args[1] == password
+			response = "+OK\r\n"
+			if !authenticated {
+				response = "-WRONGPASS invalid password\r\n"
+			}
+		case !authenticated:
+			response = "-NOAUTH Authentication required.\r\n"
+		case cmd == "SET":
+			ms, _ := strconv.Atoi(args[4])
+			nx := len(args) > 5 && strings.ToUpper(args[5]) == "NX"
+			if ttl, _ := store.TTL(ctx, args[1]); nx && ttl > 0 {
+				response = "$-1\r\n"
+				break
+			}
+			_ = store.Set(ctx, args[1], args[2], time.Duration(ms)*time.Millisecond)
+			response = "+OK\r\n"
+		case cmd == "INCR":
+			ttl, _ := store.TTL(ctx, args[1])
+			n, _ := store.Incr(ctx, args[1], ttl)
+			response = fmt.Sprintf(":%d\r\n", n)
+		case cmd == "PTTL":
+			ttl, _ := store.TTL(ctx, args[1])
+			if ttl <= 0 {
+				response = ":-2\r\n"
+			} else {
+				response = fmt.Sprintf(":%d\r\n", ttl.Milliseconds())
+			}
+		case cmd == "DEL":
+			_ = store.Del(ctx, args[1:]...)
+			response = fmt.Sprintf(":%d\r\n", len(args)-1)
+		default:
+			response = "-ERR unknown command\r\n"
+		}
+
+		if _, err := conn.Write([]byte(response)); err != nil {
+			return
+		}
+	}
+}
+
+func TestRedisStore_Commands(t *testing.T) {
+	addr := startFakeRedis(t, "secret")
+	store, err := NewRedisStore(RedisConfig{Addr: addr, Password: "secret"})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	n, err := store.Incr(ctx, "count", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = store.Incr(ctx, "count", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	ttl, err := store.TTL(ctx, "count")
+	require.NoError(t, err)
+	assert.Greater(t, ttl, 50*time.Second)
+
+	require.NoError(t, store.Set(ctx, "block", "1", time.Second))
+	require.NoError(t, store.Del(ctx, "count", "block"))
+
+	ttl, err = store.TTL(ctx, "count")
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), ttl)
+}
+
+func TestRedisStore_AuthFailure(t *testing.T) {
+	addr := startFakeRedis(t, "secret")
+	store, _ := NewRedisStore(RedisConfig{Addr: addr, Password: "wrong"})
+
+	_, err := store.TTL(context.Background(), "count")
+	assert.ErrorContains(t, err, "WRONGPASS")
+}
@@ -0,0 +1,262 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// defaultRedisTimeout: context に期限がない場合の1コマンドあたりのタイムアウト
+const defaultRedisTimeout = 3 * time.Second
+
+// RedisConfig: Redis 互換サーバーの接続設定
+type RedisConfig struct {
+	// Addr: host:port
+	Addr string
+
+	// Password: AUTH に使うパスワード（空の場合は認証しない）
+	Password string
+
+	// DB: SELECT するデータベース番号
+	DB int
+
+	// PoolSize: 保持する接続数の上限（0 の場合は 10）
+	PoolSize int
+}
+
+// RedisStore: Redis 互換サーバー（Redis, Valkey, KeyDB など）に保持する Store
+// 外部ライブラリを使わず、RESP プロトコルで必要なコマンドのみを送信する
+type RedisStore struct {
+	config RedisConfig
+	dialer net.Dialer
+	pool   chan *redisConn
+}
+
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// redisError: サーバーが返したエラー応答（接続自体は再利用できる）
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// NewRedisStore: Redis 互換サーバーの Store を生成（接続は最初のコマンド実行時に確立）
+func NewRedisStore(cfg RedisConfig) (*RedisStore, error) {
+	if cfg.Addr == "" {
+		return nil, errors.New("redis address is required")
+	}
+	if cfg.PoolSize <= 0 {
+		cfg.PoolSize = 10
+	}
+	return &RedisStore{
+		config: cfg,
+		dialer: net.Dialer{Timeout: defaultRedisTimeout},
+		pool:   make(chan *redisConn, cfg.PoolSize),
+	}, nil
+}
+
+func (s *RedisStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	// キーが存在しない場合のみ期限付きで作成し、INCR は既存の期限を維持する
+	if _, err := s.do(ctx, "SET", key, "0", "PX", strconv.FormatInt(ttl.Milliseconds(), 10), "NX"); err != nil {
+		return 0, err
+	}
+
+	reply, err := s.do(ctx, "INCR", key)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected INCR reply %T", reply)
+	}
+	return n, nil
+}
+
+func (s *RedisStore) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	_, err := s.do(ctx, "SET", key, value, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+func (s *RedisStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	reply, err := s.do(ctx, "PTTL", key)
+	if err != nil {
+		return 0, err
+	}
+	ms, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected PTTL reply %T", reply)
+	}
+	// -2: キーなし、-1: 期限なし（このストアでは作成しない）
+	if ms < 0 {
+		return 0, nil
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+func (s *RedisStore) Del(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := s.do(ctx, append([]string{"DEL"}, keys...)...)
+	return err
+}
+
+// do: コマンドを送信して応答を返す
+// 通信エラーの場合は接続を破棄し、サーバーのエラー応答の場合は接続をプールに戻す
+func (s *RedisStore) do(ctx context.Context, args ...string) (interface{}, error) {
+	rc, err := s.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := rc.roundTrip(ctx, args)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		_ = rc.conn.Close()
+		return nil, err
+	}
+
+	s.release(rc)
+	return reply, err
+}
+
+// acquire: プールから接続を取り出す（空の場合は新しく接続する）
+func (s *RedisStore) acquire(ctx context.Context) (*redisConn, error) {
+	select {
+	case rc := <-s.pool:
+		return rc, nil
+	default:
+	}
+
+	conn, err := s.dialer.DialContext(ctx, "tcp", s.config.Addr)
+	if err != nil {
+		return nil, err
+	}
+	rc := &redisConn{conn: conn, reader: bufio.NewReader(conn)}
+
+	if s.config.Password != "" {
+		if _, err := rc.roundTrip(ctx, []string{"AUTH", s.config.Password}); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	if s.config.DB != 0 {
+		if _, err := rc.roundTrip(ctx, []string{"SELECT", strconv.Itoa(s.config.DB)}); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+
+	return rc, nil
+}
+
+// release: 接続をプールに戻す（満杯の場合は閉じる）
+func (s *RedisStore) release(rc *redisConn) {
+	select {
+	case s.pool <- rc:
+	default:
+		_ = rc.conn.Close()
+	}
+}
+
+// roundTrip: コマンドを RESP 配列として送信し、応答を1つ読み取る
+func (rc *redisConn) roundTrip(ctx context.Context, args []string) (interface{}, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultRedisTimeout)
+	}
+	if err := rc.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	if _, err := rc.conn.Write(buf); err != nil {
+		return nil, err
+	}
+
+	return readReply(rc.reader)
+}
+
+// readReply: RESP の応答を1つ読み取る
+// 単純文字列・バルク文字列は string、整数は int64、配列は []interface{}、null は nil を返す
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, 0, n)
+		for i := 0; i < n; i++ {
+			item, err := readReply(r)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply type %q", line[0])
+	}
+}
+
+// readLine: CRLF で終わる1行を読み取り、CRLF を除いて返す
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", errors.New("redis: malformed reply")
+	}
+	return line[:len(line)-2], nil
+}
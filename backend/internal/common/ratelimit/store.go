@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"simple-ledger/internal/common/config"
+)
+
+// Store: 試行回数などを期限付きで保持するキーバリューストア
+// Redis のコマンド（INCR / SET PX / PTTL / DEL）で実装できる操作のみを定義し、
+// 単一ノードではメモリ上の実装、複数インスタンスでは Redis 互換サーバーを共有する
+type Store interface {
+	// Incr: 整数値を1増やして増加後の値を返す（キーが存在しない場合は ttl 付きで 0 から作成）
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+
+	// Set: 値を ttl 付きで保存（既存の値と期限は上書き）
+	Set(ctx context.Context, key string, value string, ttl time.Duration) error
+
+	// TTL: キーの残り有効期間を返す（キーが存在しない場合は 0）
+	TTL(ctx context.Context, key string) (time.Duration, error)
+
+	// Del: キーを削除（存在しない場合もエラーにしない）
+	Del(ctx context.Context, keys ...string) error
+}
+
+// NewStoreFromEnv: 環境変数 RATE_LIMIT_STORE に応じたストアを生成
+// memory: プロセス内メモリ（単一ノード向け）、redis: Redis 互換サーバー（複数インスタンス向け）
+func NewStoreFromEnv() (Store, error) {
+	driver := config.GetEnv("RATE_LIMIT_STORE", "memory")
+	switch driver {
+	case "memory":
+		return NewMemoryStore(), nil
+	case "redis":
+		return NewRedisStore(RedisConfig{
+			Addr:     config.GetEnv("REDIS_ADDR", "localhost:6379"),
+			Password: config.GetEnv("REDIS_PASSWORD", ""),
+			DB:       config.GetEnvAsInt("REDIS_DB", 0),
+		})
+	default:
+		return nil, fmt.Errorf("unknown RATE_LIMIT_STORE: %s", driver)
+	}
+}
@@ -0,0 +1,101 @@
+package requestmeta
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"strings"
+
+	"simple-ledger/internal/common/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderRequestID: リクエストIDを受け渡すHTTPヘッダー
+const HeaderRequestID = "X-Request-ID"
+
+// contextKeyRequestID: gin.Context にリクエストIDを保存するキー
+const contextKeyRequestID = "requestID"
+
+// validRequestID: クライアントから受け取るリクエストIDとして許容する形式
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Meta: 操作者とリクエストの情報（監査ログなどで使用）
+type Meta struct {
+	// ActorID: 操作したユーザーID（未認証の場合は nil）
+	ActorID *uint
+
+	// RequestID: リクエストID
+	RequestID string
+
+	// IPAddress: 送信元IPアドレス
+	IPAddress string
+
+	// UserAgent: User-Agent ヘッダー
+	UserAgent string
+}
+
+// Middleware はリクエストIDを採番するミドルウェア
+// クライアントが妥当な X-Request-ID を送った場合はそれを引き継ぎ、レスポンスヘッダーにも返す
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestID := ctx.GetHeader(HeaderRequestID)
+		if !validRequestID.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+
+		ctx.Set(contextKeyRequestID, requestID)
+		ctx.Header(HeaderRequestID, requestID)
+
+		ctx.Next()
+	}
+}
+
+// FromContext: gin.Context から操作者とリクエストの情報を取得
+// AuthMiddleware を通過していない場合、ActorID は nil になる
+func FromContext(ctx *gin.Context) Meta {
+	meta := Meta{
+		RequestID: ctx.GetString(contextKeyRequestID),
+		UserAgent: ctx.Request.UserAgent(),
+		IPAddress: ctx.ClientIP(),
+	}
+
+	if userID, exists := ctx.Get("userID"); exists {
+		if id, ok := userID.(uint); ok {
+			meta.ActorID = &id
+		}
+	}
+
+	return meta
+}
+
+// TrustedProxiesFromEnv: X-Forwarded-For を信頼するプロキシ（IPアドレスまたはCIDR）を環境変数から読み込む
+// 未設定の場合は nil を返し、転送ヘッダーを無視して接続元のアドレスを送信元IPアドレスとする
+// （クライアントが任意の X-Forwarded-For を送ってログイン試行制限や監査ログを偽装できないようにするため）
+func TrustedProxiesFromEnv() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(config.GetEnv("TRUSTED_PROXIES", ""), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// ForCommand: HTTP を経由しない操作（管理コマンドなど）の情報を生成
+// ActorID は nil とし、User-Agent にコマンド名を記録する
+func ForCommand(name string) Meta {
+	return Meta{
+		RequestID: newRequestID(),
+		UserAgent: name,
+	}
+}
+
+// newRequestID: ランダムなリクエストIDを生成
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
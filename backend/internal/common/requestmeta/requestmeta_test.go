@@ -0,0 +1,69 @@
+package requestmeta
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware_GeneratesRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/", nil)
+
+	Middleware()(c)
+
+	meta := FromContext(c)
+	assert.Len(t, meta.RequestID, 32)
+	assert.Equal(t, meta.RequestID, w.Header().Get(HeaderRequestID))
+	assert.Nil(t, meta.ActorID)
+}
+
+func TestMiddleware_KeepsValidClientRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	c.Request.Header.Set(HeaderRequestID, "req-123")
+
+	Middleware()(c)
+
+	assert.Equal(t, "req-123", FromContext(c).RequestID)
+}
+
+func TestMiddleware_ReplacesInvalidClientRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	c.Request.Header.Set(HeaderRequestID, "bad id\nwith newline")
+
+	Middleware()(c)
+
+	assert.NotEqual(t, "bad id\nwith newline", FromContext(c).RequestID)
+	assert.Len(t, FromContext(c).RequestID, 32)
+}
+
+func TestFromContext_ReadsActorAndClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	c.Request.RemoteAddr = "192.0.2.10:12345"
+	c.Request.Header.Set("User-Agent", "test-agent")
+	c.Set("userID", uint(7))
+
+	meta := FromContext(c)
+	if assert.NotNil(t, meta.ActorID) {
+		assert.Equal(t, uint(7), *meta.ActorID)
+	}
+	assert.Equal(t, "192.0.2.10", meta.IPAddress)
+	assert.Equal(t, "test-agent", meta.UserAgent)
+}
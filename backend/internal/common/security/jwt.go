@@ -1,7 +1,10 @@
 package security
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -14,9 +17,12 @@ var (
 )
 
 // InitJWT は JWT シークレットキーと有効期限を初期化
+// 非対称鍵（InitJWTKeys）を設定した場合、シークレットキーは使用しない
+// シークレットキーが空の場合はプロセスごとにランダムな鍵を生成する（再起動すると発行済みのトークンは無効になる）
 func InitJWT(secret string, tokenExpirationHours float64, refreshTokenExpirationHours float64) {
 	if secret == "" {
-		secret = "development-secret-key-change-in-production"
+		log.Print("WARNING: JWT_SECRET is not set; using a random secret key for this process (tokens are invalidated on restart)")
+		secret = rand.Text()
 	}
 	jwtSecret = []byte(secret)
 
@@ -38,59 +44,151 @@ func GetRefreshTokenExpirationSeconds() int {
 	return int(refreshTokenExpiration.Seconds())
 }
 
+// トークン種別（typ クレーム）
+// アクセストークンをリフレッシュトークンとして使う、またはその逆を防ぐ
+const (
+	TokenTypeAccess       = "access"
+	TokenTypeRefresh      = "refresh"
+	TokenTypeMFAChallenge = "mfa_challenge"
+
+	TokenTypePasswordReset     = "password_reset"
+	TokenTypeEmailVerification = "email_verification"
+
+	TokenTypeOIDCState = "oidc_state"
+)
+
+// mfaChallengeExpiration は二段階認証のチャレンジトークンの有効期限
+const mfaChallengeExpiration = 5 * time.Minute
+
+// oidcStateExpiration はシングルサインオンのログイン開始から完了までの有効期限
+const oidcStateExpiration = 10 * time.Minute
+
 // CustomClaims は JWT カスタムクレーム
 type CustomClaims struct {
-	UserID   uint   `json:"userId"`
-	Email    string `json:"email"`
-	Role     string `json:"role"`
-	IsActive bool   `json:"isActive"`
+	UserID    uint   `json:"userId"`
+	Email     string `json:"email,omitempty"`
+	Role      string `json:"role,omitempty"`
+	IsActive  bool   `json:"isActive,omitempty"`
+	TokenType string `json:"typ"`
+	Version   uint   `json:"ver,omitempty"`     // アクセストークンのみ: 発行時のユーザーのトークンバージョン
+	SessionID string `json:"sid,omitempty"`     // リフレッシュトークンのみ: セッション（トークンファミリー）ID
+	Purpose   string `json:"purpose,omitempty"` // チャレンジトークンのみ: verify（コード入力）または enroll（登録が必要）
+	jwt.RegisteredClaims
+}
+
+// OIDCStateClaims はシングルサインオンのログイン開始時にブラウザーの Cookie に保存する状態
+// IdP から戻ってきたリクエストが同じブラウザーで開始したものか（state）を確認し、
+// ID トークンの nonce と PKCE の code_verifier を受け渡す
+type OIDCStateClaims struct {
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"cv"`
+	RedirectPath string `json:"redirect,omitempty"` // ログイン後に表示するフロントエンドのパス
+	TokenType    string `json:"typ"`
 	jwt.RegisteredClaims
 }
 
 // GenerateToken はアクセストークンを生成
-func GenerateToken(userID uint, email string, role string, isActive bool) (string, error) {
+// tokenVersion はユーザーのトークンバージョン（変更後は CheckTokenVersion で拒否される）
+func GenerateToken(userID uint, email string, role string, isActive bool, tokenVersion uint) (string, error) {
 	claims := CustomClaims{
-		UserID:   userID,
-		Email:    email,
-		Role:     role,
-		IsActive: isActive,
+		UserID:    userID,
+		Email:     email,
+		Role:      role,
+		IsActive:  isActive,
+		TokenType: TokenTypeAccess,
+		Version:   tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(tokenExpiration)),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return signToken(claims)
 }
 
 // GenerateRefreshToken はリフレッシュトークンを生成
-func GenerateRefreshToken(userID uint, email string, role string, isActive bool) (string, error) {
+// sessionID はトークンファミリー、tokenID は jti としてサーバー側に記録され、交換・失効の判定に使う
+func GenerateRefreshToken(userID uint, sessionID string, tokenID string) (string, error) {
 	claims := CustomClaims{
-		UserID:   userID,
-		Email:    email,
-		Role:     role,
-		IsActive: isActive,
+		UserID:    userID,
+		TokenType: TokenTypeRefresh,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(refreshTokenExpiration)),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return signToken(claims)
+}
+
+// GenerateMFAChallengeToken は二段階認証のチャレンジトークンを生成
+// パスワード認証に成功したことを示し、ワンタイムコードの入力（2段階目）でのみ使用できる
+func GenerateMFAChallengeToken(userID uint, purpose string) (string, error) {
+	claims := CustomClaims{
+		UserID:    userID,
+		TokenType: TokenTypeMFAChallenge,
+		Purpose:   purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(mfaChallengeExpiration)),
+		},
+	}
+
+	return signToken(claims)
+}
+
+// GetMFAChallengeExpirationSeconds はチャレンジトークンの有効期限を秒で返す
+func GetMFAChallengeExpirationSeconds() int {
+	return int(mfaChallengeExpiration.Seconds())
+}
+
+// GenerateAccountToken はメールで送信するアカウント操作用トークン（パスワード再設定・メールアドレスの確認）を生成
+// tokenID は jti としてサーバー側に記録され、一度だけ使用できる
+func GenerateAccountToken(tokenType string, userID uint, email string, tokenID string, expiresIn time.Duration) (string, error) {
+	claims := CustomClaims{
+		UserID:    userID,
+		Email:     email,
+		TokenType: tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+		},
+	}
+
+	return signToken(claims)
+}
+
+// GenerateOIDCStateToken はシングルサインオンの状態トークンを生成
+func GenerateOIDCStateToken(state string, nonce string, codeVerifier string, redirectPath string) (string, error) {
+	claims := OIDCStateClaims{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		RedirectPath: redirectPath,
+		TokenType:    TokenTypeOIDCState,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(oidcStateExpiration)),
+		},
+	}
+
+	return signToken(claims)
+}
+
+// GetOIDCStateExpirationSeconds は状態トークンの有効期限を秒で返す
+func GetOIDCStateExpirationSeconds() int {
+	return int(oidcStateExpiration.Seconds())
 }
 
 // VerifyToken はトークンを検証してクレームを返す
 func VerifyToken(tokenString string) (*CustomClaims, error) {
 	claims := &CustomClaims{}
 
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return jwtSecret, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, claims, verificationKey)
 
 	if err != nil {
 		return nil, err
@@ -102,3 +200,84 @@ func VerifyToken(tokenString string) (*CustomClaims, error) {
 
 	return claims, nil
 }
+
+// VerifyAccessToken はアクセストークンを検証してクレームを返す
+func VerifyAccessToken(tokenString string) (*CustomClaims, error) {
+	claims, err := VerifyToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.TokenType != TokenTypeAccess {
+		return nil, fmt.Errorf("not an access token")
+	}
+
+	return claims, nil
+}
+
+// VerifyRefreshToken はリフレッシュトークンを検証してクレームを返す
+func VerifyRefreshToken(tokenString string) (*CustomClaims, error) {
+	claims, err := VerifyToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.TokenType != TokenTypeRefresh || claims.SessionID == "" || claims.ID == "" {
+		return nil, fmt.Errorf("not a refresh token")
+	}
+
+	return claims, nil
+}
+
+// NewTokenID はセッションIDやトークンIDに使うランダムな識別子を生成
+func NewTokenID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// VerifyMFAChallengeToken はチャレンジトークンを検証してクレームを返す
+func VerifyMFAChallengeToken(tokenString string) (*CustomClaims, error) {
+	claims, err := VerifyToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.TokenType != TokenTypeMFAChallenge {
+		return nil, fmt.Errorf("not an MFA challenge token")
+	}
+
+	return claims, nil
+}
+
+// VerifyAccountToken はアカウント操作用トークンを検証してクレームを返す
+func VerifyAccountToken(tokenString string, tokenType string) (*CustomClaims, error) {
+	claims, err := VerifyToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.TokenType != tokenType || claims.ID == "" {
+		return nil, fmt.Errorf("not a %s token", tokenType)
+	}
+
+	return claims, nil
+}
+
+// VerifyOIDCStateToken はシングルサインオンの状態トークンを検証してクレームを返す
+func VerifyOIDCStateToken(tokenString string) (*OIDCStateClaims, error) {
+	claims := &OIDCStateClaims{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, verificationKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid || claims.TokenType != TokenTypeOIDCState || claims.State == "" || claims.CodeVerifier == "" {
+		return nil, fmt.Errorf("not an OIDC state token")
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,247 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSAKeyBits は受け付ける RSA 鍵の最小ビット数
+const minRSAKeyBits = 2048
+
+// SigningKey は JWT の署名・検証に使う非対称鍵（Ed25519 または RSA）
+type SigningKey struct {
+	ID      string            // kid ヘッダー（公開鍵の JWK Thumbprint）
+	Method  jwt.SigningMethod // EdDSA または RS256
+	private crypto.Signer     // 検証専用の鍵の場合は nil
+	public  crypto.PublicKey
+}
+
+// JWK は公開鍵の JSON Web Key 表現
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	Curve     string `json:"crv,omitempty"` // Ed25519 のみ
+	X         string `json:"x,omitempty"`   // Ed25519 のみ
+	N         string `json:"n,omitempty"`   // RSA のみ
+	E         string `json:"e,omitempty"`   // RSA のみ
+}
+
+// JWKS は /.well-known/jwks.json で公開する鍵の一覧
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// ParseSigningKeyPEM は PEM 形式の鍵を読み込む
+// 秘密鍵（PKCS#8 または PKCS#1）の場合は署名にも使え、公開鍵（PKIX）の場合は検証専用になる
+func ParseSigningKeyPEM(data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{}
+	if signer, ok := parsed.(crypto.Signer); ok {
+		key.private = signer
+		parsed = signer.Public()
+	}
+	switch pub := parsed.(type) {
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.public = pub
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key must be at least %d bits", minRSAKeyBits)
+		}
+		key.Method = jwt.SigningMethodRS256
+		key.public = pub
+	default:
+		return nil, fmt.Errorf("unsupported key type: %T (Ed25519 or RSA is required)", parsed)
+	}
+	key.ID = key.thumbprint()
+	return key, nil
+}
+
+// LoadSigningKeyFile は PEM ファイルから鍵を読み込む
+func LoadSigningKeyFile(path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParseSigningKeyPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// JWK は公開鍵を JSON Web Key に変換する
+func (k *SigningKey) JWK() JWK {
+	jwk := JWK{Use: "sig", Algorithm: k.Method.Alg(), KeyID: k.ID}
+	switch pub := k.public.(type) {
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	}
+	return jwk
+}
+
+// thumbprint は公開鍵の JWK Thumbprint（RFC 7638）を返す
+// 鍵の内容から決まるため、複数インスタンスで同じ鍵を使えば kid も一致する
+func (k *SigningKey) thumbprint() string {
+	jwk := k.JWK()
+	var members string
+	if jwk.KeyType == "OKP" {
+		members = fmt.Sprintf(`{"crv":%q,"kty":%q,"x":%q}`, jwk.Curve, jwk.KeyType, jwk.X)
+	} else {
+		members = fmt.Sprintf(`{"e":%q,"kty":%q,"n":%q}`, jwk.E, jwk.KeyType, jwk.N)
+	}
+	sum := sha256.Sum256([]byte(members))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// KeySet は署名に使う鍵と、検証に使える鍵（ローテーション前の鍵を含む）の集合
+type KeySet struct {
+	signing *SigningKey
+	keys    map[string]*SigningKey
+	ordered []*SigningKey
+}
+
+// NewKeySet は鍵の集合を作成する
+// verification にはローテーション前の鍵など、署名には使わないが検証は続ける鍵を指定する
+func NewKeySet(signing *SigningKey, verification ...*SigningKey) (*KeySet, error) {
+	if signing == nil || signing.private == nil {
+		return nil, errors.New("signing key must be a private key")
+	}
+
+	set := &KeySet{signing: signing, keys: make(map[string]*SigningKey)}
+	for _, key := range append([]*SigningKey{signing}, verification...) {
+		if _, exists := set.keys[key.ID]; exists {
+			continue
+		}
+		set.keys[key.ID] = key
+		set.ordered = append(set.ordered, key)
+	}
+	return set, nil
+}
+
+// LoadKeySet は署名鍵と検証用の鍵をファイルから読み込む（signingKeyFile が空の場合は nil）
+func LoadKeySet(signingKeyFile string, verificationKeyFiles []string) (*KeySet, error) {
+	if signingKeyFile == "" {
+		return nil, nil
+	}
+
+	signing, err := LoadSigningKeyFile(signingKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	var verification []*SigningKey
+	for _, path := range verificationKeyFiles {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		key, err := LoadSigningKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		verification = append(verification, key)
+	}
+	return NewKeySet(signing, verification...)
+}
+
+// SigningKeyID は署名に使う鍵の kid を返す
+func (s *KeySet) SigningKeyID() string {
+	return s.signing.ID
+}
+
+// JWKS は検証に使えるすべての公開鍵を返す
+func (s *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: make([]JWK, 0, len(s.ordered))}
+	for _, key := range s.ordered {
+		jwks.Keys = append(jwks.Keys, key.JWK())
+	}
+	return jwks
+}
+
+// jwtKeys は JWT の署名・検証に使う鍵（未設定の場合は JWT_SECRET による HS256）
+var jwtKeys *KeySet
+
+// InitJWTKeys は JWT の署名・検証を非対称鍵に切り替える（nil の場合は HS256 に戻す）
+// 切り替え後は HS256 で署名されたトークンを受け付けない
+func InitJWTKeys(keys *KeySet) {
+	jwtKeys = keys
+}
+
+// PublicJWKS は JWT の検証に使える公開鍵の一覧を返す（HS256 の場合は空）
+func PublicJWKS() JWKS {
+	if jwtKeys == nil {
+		return JWKS{Keys: []JWK{}}
+	}
+	return jwtKeys.JWKS()
+}
+
+// signToken はクレームに署名する（非対称鍵の場合は kid ヘッダーを付ける）
+func signToken(claims jwt.Claims) (string, error) {
+	if jwtKeys == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtSecret)
+	}
+
+	token := jwt.NewWithClaims(jwtKeys.signing.Method, claims)
+	token.Header["kid"] = jwtKeys.signing.ID
+	return token.SignedString(jwtKeys.signing.private)
+}
+
+// verificationKey はトークンのヘッダー（alg・kid）に対応する検証用の鍵を返す
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	if jwtKeys == nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return jwtSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := jwtKeys.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id: %q", kid)
+	}
+	// 鍵と異なるアルゴリズムを指定したトークン（アルゴリズムの取り違え）を拒否する
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.public, nil
+}
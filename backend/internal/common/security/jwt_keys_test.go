@@ -0,0 +1,121 @@
+package security
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKeyFile: 秘密鍵を PKCS#8 の PEM ファイルに書き出す
+func writeKeyFile(t *testing.T, key any) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+	return path
+}
+
+func TestJWTKeys_SignAndVerify(t *testing.T) {
+	InitJWT("test-secret", 1.0, 1.0)
+	t.Cleanup(func() { InitJWTKeys(nil) })
+
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	for _, tt := range []struct {
+		key any
+		alg string
+	}{
+		{edKey, "EdDSA"},
+		{rsaKey, "RS256"},
+	} {
+		keys, err := LoadKeySet(writeKeyFile(t, tt.key), nil)
+		require.NoError(t, err)
+		InitJWTKeys(keys)
+
+		token, err := GenerateToken(1, "test@example.com", "user", true, 0)
+		require.NoError(t, err)
+
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, &CustomClaims{})
+		require.NoError(t, err)
+		assert.Equal(t, tt.alg, parsed.Header["alg"])
+		assert.Equal(t, keys.SigningKeyID(), parsed.Header["kid"])
+
+		claims, err := VerifyAccessToken(token)
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), claims.UserID)
+
+		jwks := PublicJWKS()
+		require.Len(t, jwks.Keys, 1)
+		assert.Equal(t, tt.alg, jwks.Keys[0].Algorithm)
+		assert.Equal(t, keys.SigningKeyID(), jwks.Keys[0].KeyID)
+	}
+}
+
+func TestJWTKeys_Rotation(t *testing.T) {
+	InitJWT("test-secret", 1.0, 1.0)
+	t.Cleanup(func() { InitJWTKeys(nil) })
+
+	// HS256 で発行したトークンは鍵の設定後は受け付けない
+	hmacToken, _ := GenerateToken(1, "test@example.com", "user", true, 0)
+
+	_, oldKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, newKey, _ := ed25519.GenerateKey(rand.Reader)
+	oldFile, newFile := writeKeyFile(t, oldKey), writeKeyFile(t, newKey)
+
+	oldKeys, err := LoadKeySet(oldFile, nil)
+	require.NoError(t, err)
+	InitJWTKeys(oldKeys)
+	oldToken, _ := GenerateToken(1, "test@example.com", "user", true, 0)
+
+	_, err = VerifyAccessToken(hmacToken)
+	assert.Error(t, err)
+
+	// 新しい鍵で署名し、古い鍵は検証のみに使う
+	rotated, err := LoadKeySet(newFile, []string{oldFile})
+	require.NoError(t, err)
+	InitJWTKeys(rotated)
+	assert.NotEqual(t, oldKeys.SigningKeyID(), rotated.SigningKeyID())
+	assert.Len(t, PublicJWKS().Keys, 2)
+
+	_, err = VerifyAccessToken(oldToken)
+	assert.NoError(t, err)
+	newToken, _ := GenerateToken(1, "test@example.com", "user", true, 0)
+	_, err = VerifyAccessToken(newToken)
+	assert.NoError(t, err)
+
+	// 古い鍵を外すと、古い鍵で署名したトークンは拒否される
+	withoutOld, err := LoadKeySet(newFile, nil)
+	require.NoError(t, err)
+	InitJWTKeys(withoutOld)
+	_, err = VerifyAccessToken(oldToken)
+	assert.Error(t, err)
+}
+
+func TestParseSigningKeyPEM_Rejects(t *testing.T) {
+	// 検証専用の公開鍵は署名鍵に使えない
+	edPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(edPub)
+	publicKey, err := ParseSigningKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	require.NoError(t, err)
+	_, err = NewKeySet(publicKey)
+	assert.Error(t, err)
+
+	// 短い RSA 鍵
+	weak, _ := rsa.GenerateKey(rand.Reader, 1024)
+	_, err = ParseSigningKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(weak)}))
+	assert.Error(t, err)
+
+	_, err = ParseSigningKeyPEM([]byte("not a key"))
+	assert.Error(t, err)
+}
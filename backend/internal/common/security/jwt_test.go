@@ -10,7 +10,7 @@ import (
 func TestGenerateToken(t *testing.T) {
 	InitJWT("test-secret", 1.0, 1.0)
 
-	token, err := GenerateToken(1, "test@example.com", "user", true)
+	token, err := GenerateToken(1, "test@example.com", "user", true, 0)
 
 	assert.NoError(t, err)
 	assert.NotEmpty(t, token)
@@ -20,7 +20,7 @@ func TestVerifyToken_Success(t *testing.T) {
 	InitJWT("test-secret", 1.0, 1.0)
 
 	// トークンを生成
-	token, _ := GenerateToken(1, "test@example.com", "user", true)
+	token, _ := GenerateToken(1, "test@example.com", "user", true, 0)
 
 	// トークンを検証
 	claims, err := VerifyToken(token)
@@ -45,7 +45,7 @@ func TestVerifyToken_TamperedToken(t *testing.T) {
 	InitJWT("test-secret", 1.0, 1.0)
 
 	// トークンを生成
-	token, _ := GenerateToken(1, "test@example.com", "user", true)
+	token, _ := GenerateToken(1, "test@example.com", "user", true, 0)
 
 	// トークンを改ざん
 	tamperedToken := token + "tampered"
@@ -59,7 +59,7 @@ func TestVerifyToken_TamperedToken(t *testing.T) {
 func TestGenerateRefreshToken(t *testing.T) {
 	InitJWT("test-secret", 1.0, 1.0)
 
-	token, err := GenerateRefreshToken(1, "test@example.com", "user", true)
+	token, err := GenerateRefreshToken(1, "session-1", "token-1")
 
 	assert.NoError(t, err)
 	assert.NotEmpty(t, token)
@@ -69,7 +69,7 @@ func TestRefreshTokenExpiration(t *testing.T) {
 	InitJWT("test-secret", 1.0, 1.0)
 
 	// リフレッシュトークンを生成
-	token, _ := GenerateRefreshToken(1, "test@example.com", "user", true)
+	token, _ := GenerateRefreshToken(1, "session-1", "token-1")
 
 	// トークンを検証
 	claims, err := VerifyToken(token)
@@ -86,7 +86,7 @@ func TestRefreshTokenExpiration(t *testing.T) {
 func TestTokenWithDifferentSecrets(t *testing.T) {
 	// Secret1 でトークン生成
 	InitJWT("secret1", 1.0, 1.0)
-	token, _ := GenerateToken(1, "test@example.com", "user", true)
+	token, _ := GenerateToken(1, "test@example.com", "user", true, 0)
 
 	// Secret2 で検証(失敗するはず)
 	InitJWT("secret2", 1.0, 1.0)
@@ -95,3 +95,33 @@ func TestTokenWithDifferentSecrets(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, claims)
 }
+
+func TestInitJWT_EmptySecretIsRandomPerProcess(t *testing.T) {
+	InitJWT("", 1.0, 1.0)
+	token, _ := GenerateToken(1, "test@example.com", "user", true, 0)
+	_, err := VerifyToken(token)
+	assert.NoError(t, err)
+
+	// 固定の鍵ではないため、再初期化すると発行済みのトークンは検証できない
+	InitJWT("", 1.0, 1.0)
+	_, err = VerifyToken(token)
+	assert.Error(t, err)
+}
+
+func TestVerifyAccessToken_RejectsRefreshToken(t *testing.T) {
+	InitJWT("test-secret", 1.0, 1.0)
+
+	refreshToken, _ := GenerateRefreshToken(1, "session-1", "token-1")
+	accessToken, _ := GenerateToken(1, "test@example.com", "user", true, 0)
+
+	_, err := VerifyAccessToken(refreshToken)
+	assert.Error(t, err)
+
+	_, err = VerifyRefreshToken(accessToken)
+	assert.Error(t, err)
+
+	claims, err := VerifyRefreshToken(refreshToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "session-1", claims.SessionID)
+	assert.Equal(t, "token-1", claims.ID)
+}
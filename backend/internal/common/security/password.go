@@ -1,20 +1,134 @@
 package security
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"simple-ledger/internal/common/config"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// HashPassword はパスワードをハッシュ化する
+// argon2idPrefix は argon2id のハッシュの先頭（それ以外は bcrypt として扱う）
+const argon2idPrefix = "$argon2id$"
+
+// Argon2Params は argon2id のパラメーター
+// パラメーターはハッシュに埋め込まれるため、変更しても既存のハッシュは検証でき、次回のログイン時に更新される
+type Argon2Params struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params は既定のパラメーター（OWASP 推奨の m=19MiB, t=2, p=1）
+func DefaultArgon2Params() Argon2Params {
+	return Argon2Params{
+		Memory:      19 * 1024,
+		Iterations:  2,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// Argon2ParamsFromEnv は環境変数からパラメーターを読み込む（未設定の項目は既定値）
+func Argon2ParamsFromEnv() Argon2Params {
+	params := DefaultArgon2Params()
+	if v := config.GetEnvAsInt("PASSWORD_HASH_MEMORY_KIB", 0); v > 0 {
+		params.Memory = uint32(v)
+	}
+	if v := config.GetEnvAsInt("PASSWORD_HASH_ITERATIONS", 0); v > 0 {
+		params.Iterations = uint32(v)
+	}
+	if v := config.GetEnvAsInt("PASSWORD_HASH_PARALLELISM", 0); v > 0 && v <= 255 {
+		params.Parallelism = uint8(v)
+	}
+	return params
+}
+
+// argon2Params は新しいハッシュに使うパラメーター
+var argon2Params = DefaultArgon2Params()
+
+// InitPasswordHashing はパスワードのハッシュに使うパラメーターを設定する
+func InitPasswordHashing(params Argon2Params) {
+	argon2Params = params
+}
+
+// HashPassword はパスワードを argon2id でハッシュ化する
+// 形式: $argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>（salt・hash はパディングなしの Base64）
 func HashPassword(password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
+	params := argon2Params
+	salt := make([]byte, params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
 		return "", err
 	}
-	return string(hash), nil
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version, params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
 }
 
-// VerifyPassword はパスワードとハッシュを検証する
+// VerifyPassword はパスワードとハッシュを検証する（argon2id と以前の bcrypt のハッシュに対応）
 func VerifyPassword(hashedPassword string, password *string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(*password))
-	return err == nil
+	if !strings.HasPrefix(hashedPassword, argon2idPrefix) {
+		err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(*password))
+		return err == nil
+	}
+
+	params, salt, key, err := decodeArgon2Hash(hashedPassword)
+	if err != nil {
+		return false
+	}
+	other := argon2.IDKey([]byte(*password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	return subtle.ConstantTimeCompare(key, other) == 1
+}
+
+// PasswordNeedsRehash はハッシュが現在の方式・パラメーターと異なるか（bcrypt や古いパラメーターの argon2id）を返す
+// 検証に成功したパスワードで HashPassword し直して保存する
+func PasswordNeedsRehash(hashedPassword string) bool {
+	params, _, _, err := decodeArgon2Hash(hashedPassword)
+	if err != nil {
+		return true
+	}
+	return params != argon2Params
+}
+
+// decodeArgon2Hash は argon2id のハッシュからパラメーター・ソルト・ハッシュ値を取り出す
+func decodeArgon2Hash(hashedPassword string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, errors.New("not an argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, err
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2 version: %d", version)
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, err
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, err
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
 }
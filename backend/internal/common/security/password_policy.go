@@ -0,0 +1,99 @@
+package security
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"simple-ledger/internal/common/config"
+)
+
+// ErrWeakPassword はパスワードがパスワードポリシーを満たさないことを示す
+var ErrWeakPassword = errors.New("password does not meet the password policy")
+
+// minPersonalInfoLength: パスワードに含まれているかを確認するメールアドレス・名前の最小文字数
+// 短い名前（例: "Al"）で無関係なパスワードを拒否しないようにする
+const minPersonalInfoLength = 3
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+// commonPasswords はよく使われる・漏洩したパスワード（小文字）
+var commonPasswords = parsePasswordList(commonPasswordList)
+
+// parsePasswordList は1行1件の一覧を読み込む（空行と # で始まる行は無視する）
+func parsePasswordList(list string) map[string]struct{} {
+	passwords := make(map[string]struct{})
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords[strings.ToLower(line)] = struct{}{}
+	}
+	return passwords
+}
+
+// PasswordPolicy はユーザーが設定するパスワードの条件
+type PasswordPolicy struct {
+	// MinLength・MaxLength: 文字数（バイト数ではない）の下限・上限
+	MinLength int
+	MaxLength int
+
+	// RejectPersonalInfo: メールアドレス（@ より前）や名前（姓・名のそれぞれを含む）を含むパスワードを拒否する
+	RejectPersonalInfo bool
+
+	// RejectCommon: よく使われる・漏洩したパスワード（同梱の一覧）を拒否する
+	RejectCommon bool
+}
+
+// DefaultPasswordPolicy は既定のパスワードポリシー
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:          8,
+		MaxLength:          128,
+		RejectPersonalInfo: true,
+		RejectCommon:       true,
+	}
+}
+
+// PasswordPolicyFromEnv は環境変数からパスワードポリシーを読み込む（未設定の項目は既定値）
+func PasswordPolicyFromEnv() PasswordPolicy {
+	policy := DefaultPasswordPolicy()
+	policy.MinLength = config.GetEnvAsInt("PASSWORD_MIN_LENGTH", policy.MinLength)
+	policy.MaxLength = config.GetEnvAsInt("PASSWORD_MAX_LENGTH", policy.MaxLength)
+	policy.RejectPersonalInfo = config.GetEnvAsBool("PASSWORD_REJECT_PERSONAL_INFO", policy.RejectPersonalInfo)
+	policy.RejectCommon = config.GetEnvAsBool("PASSWORD_REJECT_COMMON", policy.RejectCommon)
+	return policy
+}
+
+// Validate はパスワードがポリシーを満たすか確認する（満たさない場合は理由を付けた ErrWeakPassword）
+// email・name はパスワードを設定するユーザーのもの（変更する場合は変更後の値）
+func (p PasswordPolicy) Validate(password string, email string, name string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		return fmt.Errorf("%w: must be at least %d characters", ErrWeakPassword, p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return fmt.Errorf("%w: must be at most %d characters", ErrWeakPassword, p.MaxLength)
+	}
+
+	lower := strings.ToLower(password)
+	if p.RejectPersonalInfo {
+		localPart, _, _ := strings.Cut(strings.ToLower(email), "@")
+		nameParts := strings.Fields(strings.ToLower(name))
+		for _, info := range append([]string{localPart, strings.Join(nameParts, "")}, nameParts...) {
+			if utf8.RuneCountInString(info) >= minPersonalInfoLength && strings.Contains(lower, info) {
+				return fmt.Errorf("%w: must not contain your email address or name", ErrWeakPassword)
+			}
+		}
+	}
+	if p.RejectCommon {
+		if _, found := commonPasswords[lower]; found {
+			return fmt.Errorf("%w: this password is too common", ErrWeakPassword)
+		}
+	}
+	return nil
+}
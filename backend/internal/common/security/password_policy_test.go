@@ -0,0 +1,50 @@
+package security
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	policy := DefaultPasswordPolicy()
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  string
+	}{
+		{"valid", "violet-harbor-tram", ""},
+		{"too short", "a1b2c3", "at least 8 characters"},
+		{"too long", strings.Repeat("x", 129), "at most 128 characters"},
+		{"multibyte length", "帳簿の合言葉です", ""},
+		{"email local part", "xTaro.Yamada99", "email address or name"},
+		{"name part", "yamada-ledger", "email address or name"},
+		{"full name", "TaroYamada!", "email address or name"},
+		{"common", "Password123", "too common"},
+		{"common breached", "qwerty123", "too common"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password, "taro.yamada@example.com", "Taro Yamada")
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrWeakPassword)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestPasswordPolicy_Configurable(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 12}
+
+	assert.ErrorIs(t, policy.Validate("violet-tram", "a@example.com", "A"), ErrWeakPassword)
+	// 個人情報・一覧の確認を無効にした場合
+	assert.NoError(t, policy.Validate("password1234", "password@example.com", "Password"))
+
+	// 短い名前は確認しない
+	assert.NoError(t, DefaultPasswordPolicy().Validate("violet-harbor-al", "al@example.com", "Al"))
+}
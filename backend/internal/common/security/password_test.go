@@ -0,0 +1,52 @@
+package security
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHashPassword_Argon2id(t *testing.T) {
+	hash, err := HashPassword("correct horse battery staple")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=19456,t=2,p=1$"))
+
+	password := "correct horse battery staple"
+	assert.True(t, VerifyPassword(hash, &password))
+	wrong := "correct horse battery stapler"
+	assert.False(t, VerifyPassword(hash, &wrong))
+
+	// 同じパスワードでもソルトが異なる
+	other, _ := HashPassword(password)
+	assert.NotEqual(t, hash, other)
+	assert.False(t, PasswordNeedsRehash(hash))
+
+	broken := "$argon2id$v=19$m=19456,t=2,p=1$!!$!!"
+	assert.False(t, VerifyPassword(broken, &password))
+}
+
+func TestPasswordNeedsRehash(t *testing.T) {
+	t.Cleanup(func() { InitPasswordHashing(DefaultArgon2Params()) })
+	password := "correct horse battery staple"
+
+	// 以前の bcrypt のハッシュは検証でき、作り直しが必要
+	legacy, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+	assert.True(t, VerifyPassword(string(legacy), &password))
+	assert.True(t, PasswordNeedsRehash(string(legacy)))
+
+	// パラメーターを変更すると、既存のハッシュは検証できるが作り直しが必要
+	hash, _ := HashPassword(password)
+	params := DefaultArgon2Params()
+	params.Iterations = 3
+	InitPasswordHashing(params)
+	assert.True(t, VerifyPassword(hash, &password))
+	assert.True(t, PasswordNeedsRehash(hash))
+
+	rehashed, _ := HashPassword(password)
+	assert.Contains(t, rehashed, "m=19456,t=3,p=1")
+	assert.False(t, PasswordNeedsRehash(rehashed))
+}
@@ -0,0 +1,33 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// PersonalAccessTokenPrefix は個人用アクセストークンの接頭辞
+// JWT と区別できるようにし、漏洩時にシークレットスキャナーで検出しやすくする
+const PersonalAccessTokenPrefix = "slpat_"
+
+// NewPersonalAccessToken はランダムな個人用アクセストークン（256bit）を生成
+func NewPersonalAccessToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return PersonalAccessTokenPrefix + hex.EncodeToString(b), nil
+}
+
+// IsPersonalAccessToken は文字列が個人用アクセストークンの形式かどうかを返す
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+// HashPersonalAccessToken は保存・照合用のハッシュを返す
+// トークンは十分な長さのランダム値のため、パスワードのような低速ハッシュは不要
+func HashPersonalAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
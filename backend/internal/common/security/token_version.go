@@ -0,0 +1,119 @@
+package security
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrStaleToken はトークン発行後にロール・パスワード・有効/無効が変更されたことを示す
+var ErrStaleToken = errors.New("token has been revoked")
+
+// TokenVersionLoader はユーザーの現在のトークンバージョンを取得する（ユーザーが存在しない場合はエラー）
+type TokenVersionLoader func(userID uint) (uint, error)
+
+// tokenVersionEntry はキャッシュしたトークンバージョン
+type tokenVersionEntry struct {
+	version   uint
+	expiresAt time.Time
+}
+
+// TokenVersionCache はユーザーのトークンバージョンを一定時間キャッシュする
+// 他のインスタンスでの変更はキャッシュの有効期間が過ぎるまで反映されない
+type TokenVersionCache struct {
+	load TokenVersionLoader
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[uint]tokenVersionEntry
+
+	// generation: Invalidate のたびに進める値
+	// 取得中に Invalidate された場合、取得した（古い可能性のある）バージョンをキャッシュしないために使う
+	generation uint64
+}
+
+func NewTokenVersionCache(load TokenVersionLoader, ttl time.Duration) *TokenVersionCache {
+	return &TokenVersionCache{
+		load:    load,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[uint]tokenVersionEntry),
+	}
+}
+
+// Get はユーザーの現在のトークンバージョンを返す
+func (c *TokenVersionCache) Get(userID uint) (uint, error) {
+	now := c.now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[userID]
+	generation := c.generation
+	c.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.version, nil
+	}
+
+	version, err := c.load(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != generation {
+		return version, nil
+	}
+	// 期限切れのエントリーが溜まらないよう、追加のたびに掃除する
+	for id, e := range c.entries {
+		if !now.Before(e.expiresAt) {
+			delete(c.entries, id)
+		}
+	}
+	c.entries[userID] = tokenVersionEntry{version: version, expiresAt: now.Add(c.ttl)}
+	return version, nil
+}
+
+// Invalidate はユーザーのキャッシュを削除する（このインスタンスでの変更をすぐに反映する）
+// 削除の前に取得を開始した Get の結果もキャッシュされない
+func (c *TokenVersionCache) Invalidate(userID uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, userID)
+	c.generation++
+}
+
+// tokenVersions はアクセストークンの検証に使うキャッシュ（未設定の場合はバージョンを検証しない）
+var tokenVersions *TokenVersionCache
+
+// InitTokenVersions はアクセストークンのバージョンの検証を有効にする（load が nil の場合は無効にする）
+func InitTokenVersions(load TokenVersionLoader, ttl time.Duration) {
+	if load == nil {
+		tokenVersions = nil
+		return
+	}
+	tokenVersions = NewTokenVersionCache(load, ttl)
+}
+
+// CheckTokenVersion はアクセストークンのバージョンがユーザーの現在のバージョンと一致するか確認する
+func CheckTokenVersion(claims *CustomClaims) error {
+	if tokenVersions == nil {
+		return nil
+	}
+
+	version, err := tokenVersions.Get(claims.UserID)
+	if err != nil {
+		return err
+	}
+	if claims.Version != version {
+		return ErrStaleToken
+	}
+	return nil
+}
+
+// InvalidateTokenVersion はトークンバージョンを変更したユーザーのキャッシュを削除する
+func InvalidateTokenVersion(userID uint) {
+	if tokenVersions != nil {
+		tokenVersions.Invalidate(userID)
+	}
+}
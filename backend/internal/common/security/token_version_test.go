@@ -0,0 +1,86 @@
+package security
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenVersionCache(t *testing.T) {
+	versions := map[uint]uint{1: 3}
+	loads := 0
+	cache := NewTokenVersionCache(func(userID uint) (uint, error) {
+		loads++
+		version, ok := versions[userID]
+		if !ok {
+			return 0, errors.New("not found")
+		}
+		return version, nil
+	}, 10*time.Second)
+	now := time.Unix(1000, 0)
+	cache.now = func() time.Time { return now }
+
+	version, err := cache.Get(1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), version)
+
+	// 有効期間内はキャッシュを返す
+	versions[1] = 4
+	version, _ = cache.Get(1)
+	assert.Equal(t, uint(3), version)
+	assert.Equal(t, 1, loads)
+
+	// 削除すると次回は再取得する
+	cache.Invalidate(1)
+	version, _ = cache.Get(1)
+	assert.Equal(t, uint(4), version)
+
+	// 有効期間が過ぎると再取得する
+	versions[1] = 5
+	now = now.Add(10 * time.Second)
+	version, _ = cache.Get(1)
+	assert.Equal(t, uint(5), version)
+	assert.Equal(t, 3, loads)
+
+	_, err = cache.Get(2)
+	assert.Error(t, err)
+}
+
+func TestTokenVersionCache_DoesNotCacheLoadRacingInvalidate(t *testing.T) {
+	version := uint(1)
+	loads := 0
+	var cache *TokenVersionCache
+	cache = NewTokenVersionCache(func(userID uint) (uint, error) {
+		loads++
+		loaded := version
+		if loads == 1 {
+			// 取得中に他のリクエストがバージョンを変更してキャッシュを削除した
+			version = 2
+			cache.Invalidate(userID)
+		}
+		return loaded, nil
+	}, time.Minute)
+
+	got, err := cache.Get(1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), got)
+
+	// 古いバージョンはキャッシュされず、次回は再取得する
+	got, _ = cache.Get(1)
+	assert.Equal(t, uint(2), got)
+	assert.Equal(t, 2, loads)
+}
+
+func TestCheckTokenVersion(t *testing.T) {
+	InitTokenVersions(func(userID uint) (uint, error) { return 2, nil }, time.Minute)
+	t.Cleanup(func() { InitTokenVersions(nil, 0) })
+
+	assert.NoError(t, CheckTokenVersion(&CustomClaims{UserID: 1, Version: 2}))
+	assert.ErrorIs(t, CheckTokenVersion(&CustomClaims{UserID: 1, Version: 1}), ErrStaleToken)
+
+	// 未設定の場合は検証しない
+	InitTokenVersions(nil, 0)
+	assert.NoError(t, CheckTokenVersion(&CustomClaims{UserID: 1, Version: 1}))
+}
@@ -0,0 +1,67 @@
+package security
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RFC 6238 付録B のテストベクター（SHA-1、8桁）
+func TestHOTP_RFC6238Vectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, hotp(key, uint64(tt.unix/30), 8))
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(59, 0)
+
+	// 6桁の場合は8桁の値の下位6桁
+	step, ok := VerifyTOTP(secret, "287082", now, 0)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), step)
+
+	// 前後1ステップのずれは許容する
+	_, ok = VerifyTOTP(secret, "287082", now.Add(30*time.Second), 0)
+	assert.True(t, ok)
+	_, ok = VerifyTOTP(secret, "287082", now.Add(90*time.Second), 0)
+	assert.False(t, ok)
+
+	// 使用済みのステップは拒否する
+	_, ok = VerifyTOTP(secret, "287082", now, 1)
+	assert.False(t, ok)
+
+	_, ok = VerifyTOTP(secret, "000000", now, 0)
+	assert.False(t, ok)
+}
+
+func TestGenerateTOTPSecretAndURI(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	assert.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	code, err := TOTPCode(secret, TOTPStep(time.Now()))
+	assert.NoError(t, err)
+	assert.Len(t, code, 6)
+
+	uri := TOTPAuthURI("Simple Ledger", "user@example.com", secret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Simple%20Ledger:user@example.com?"))
+	assert.Contains(t, uri, "secret="+secret)
+	assert.Contains(t, uri, "issuer=Simple+Ledger")
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"simple-ledger/internal/common/config"
+)
+
+// ErrNotFound: 指定したキーのオブジェクトが存在しない
+var ErrNotFound = errors.New("storage object not found")
+
+// Storage: 添付ファイルなどのバイナリを保存するストレージ
+// キーは "/" 区切りの相対パス（先頭の "/" や ".." は使用不可）
+type Storage interface {
+	// Put: オブジェクトを保存（同じキーが存在する場合は上書き）
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+
+	// Get: オブジェクトを取得（呼び出し側で Close すること）
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete: オブジェクトを削除（存在しない場合もエラーにしない）
+	Delete(ctx context.Context, key string) error
+}
+
+// NewFromEnv: 環境変数 STORAGE_DRIVER に応じたストレージを生成
+// local: ローカルファイルシステム、s3: S3互換ストレージ（MinIO を含む）
+func NewFromEnv() (Storage, error) {
+	driver := config.GetEnv("STORAGE_DRIVER", "local")
+	switch driver {
+	case "local":
+		return NewLocalStorage(config.GetEnv("STORAGE_LOCAL_DIR", "./storage"))
+	case "s3":
+		return NewS3Storage(S3Config{
+			Endpoint:        config.GetEnv("S3_ENDPOINT", ""),
+			Region:          config.GetEnv("S3_REGION", "us-east-1"),
+			Bucket:          config.GetEnv("S3_BUCKET", ""),
+			AccessKeyID:     config.GetEnv("S3_ACCESS_KEY_ID", ""),
+			SecretAccessKey: config.GetEnv("S3_SECRET_ACCESS_KEY", ""),
+		})
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER: %s", driver)
+	}
+}
+
+// validateKey: キーがストレージのルート外を指していないか確認
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("invalid storage key: %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("invalid storage key: %q", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"errors"
+	"simple-ledger/internal/common/db/dialect"
+	"simple-ledger/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FiscalPeriodRepository: 会計期間リポジトリ
+type FiscalPeriodRepository struct {
+	db *gorm.DB
+}
+
+// NewFiscalPeriodRepository: 会計期間リポジトリの生成
+func NewFiscalPeriodRepository(db *gorm.DB) *FiscalPeriodRepository {
+	return &FiscalPeriodRepository{db: db}
+}
+
+// WithTx: 指定したDBトランザクション上で動作するリポジトリを返す
+func (r *FiscalPeriodRepository) WithTx(tx *gorm.DB) *FiscalPeriodRepository {
+	return &FiscalPeriodRepository{db: tx}
+}
+
+// FindClosedContaining: 指定日を含む締め済みの会計期間を取得（該当なしの場合は nil）
+func (r *FiscalPeriodRepository) FindClosedContaining(userID uint, date time.Time) (*models.FiscalPeriod, error) {
+	var period models.FiscalPeriod
+	err := r.db.
+		Where("user_id = ? AND start_date <= ? AND end_date >= ? AND closed_at IS NOT NULL", userID, dialect.Date(date), dialect.Date(date)).
+		Order("start_date ASC").
+		First(&period).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &period, nil
+}
+
+// FindOverlapping: 指定した期間と重なる会計期間を取得（開始日の昇順）
+func (r *FiscalPeriodRepository) FindOverlapping(userID uint, startDate time.Time, endDate time.Time) ([]models.FiscalPeriod, error) {
+	var periods []models.FiscalPeriod
+	if err := r.db.
+		Where("user_id = ? AND start_date <= ? AND end_date >= ?", userID, dialect.Date(endDate), dialect.Date(startDate)).
+		Order("start_date ASC").
+		Find(&periods).Error; err != nil {
+		return nil, err
+	}
+	return periods, nil
+}
+
+// Create: 会計期間を作成
+func (r *FiscalPeriodRepository) Create(period *models.FiscalPeriod) error {
+	period.StartDate = dialect.Date(period.StartDate)
+	period.EndDate = dialect.Date(period.EndDate)
+	return r.db.Create(period).Error
+}
+
+// MarkClosed: 会計期間を締め済みにする
+func (r *FiscalPeriodRepository) MarkClosed(period *models.FiscalPeriod, closedAt time.Time) error {
+	if err := r.db.Model(period).Update("closed_at", closedAt).Error; err != nil {
+		return err
+	}
+	period.ClosedAt = &closedAt
+	return nil
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	auditService "simple-ledger/internal/audit/service"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/requestmeta"
+	"simple-ledger/internal/fiscal_period/repository"
+	"simple-ledger/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidPeriod: 日付の形式が不正、または終了日が開始日より前
+	ErrInvalidPeriod = errors.New("invalid fiscal period: dates must be YYYY-MM-DD and the end date must not be before the start date")
+
+	// ErrPeriodOverlaps: 既存の会計期間と一部だけ重なる
+	ErrPeriodOverlaps = errors.New("fiscal period overlaps an existing period")
+
+	// ErrPeriodAlreadyClosed: 同じ期間がすでに締め済み
+	ErrPeriodAlreadyClosed = errors.New("fiscal period is already closed")
+)
+
+// FiscalPeriodService: 会計期間サービス
+type FiscalPeriodService struct {
+	repo  *repository.FiscalPeriodRepository
+	audit *auditService.AuditService
+	uow   uow.UnitOfWork
+}
+
+// NewFiscalPeriodService: 会計期間サービスの生成
+func NewFiscalPeriodService(repo *repository.FiscalPeriodRepository, audit *auditService.AuditService, unitOfWork uow.UnitOfWork) *FiscalPeriodService {
+	return &FiscalPeriodService{repo: repo, audit: audit, uow: unitOfWork}
+}
+
+// Close: 会計期間を締める（締め済みの期間の取引は追加・変更・削除できなくなる）
+// 同じ期間が未締めで登録済みの場合はそれを締め、無い場合は締め済みの期間として作成する
+func (s *FiscalPeriodService) Close(userID uint, startDate string, endDate string, meta requestmeta.Meta) (*models.FiscalPeriod, error) {
+	start, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		return nil, ErrInvalidPeriod
+	}
+	end, err := time.Parse("2006-01-02", endDate)
+	if err != nil || end.Before(start) {
+		return nil, ErrInvalidPeriod
+	}
+
+	var period *models.FiscalPeriod
+	err = s.uow.Do(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		overlapping, err := repo.FindOverlapping(userID, start, end)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		var before *models.FiscalPeriod
+		switch {
+		case len(overlapping) == 0:
+			period = &models.FiscalPeriod{UserID: userID, StartDate: start, EndDate: end, ClosedAt: &now}
+			if err := repo.Create(period); err != nil {
+				return err
+			}
+		case len(overlapping) == 1 && overlapping[0].StartDate.Equal(start) && overlapping[0].EndDate.Equal(end):
+			if overlapping[0].ClosedAt != nil {
+				return ErrPeriodAlreadyClosed
+			}
+			snapshot := overlapping[0]
+			before = &snapshot
+			period = &overlapping[0]
+			if err := repo.MarkClosed(period, now); err != nil {
+				return err
+			}
+		default:
+			return ErrPeriodOverlaps
+		}
+
+		entry := auditService.Entry{
+			Action:     models.AuditActionClose,
+			EntityType: models.AuditEntityFiscalPeriod,
+			EntityID:   period.ID,
+			After:      period,
+		}
+		if before != nil {
+			entry.Before = before
+		}
+		return s.audit.WithTx(tx).Record(meta, entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return period, nil
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	auditRepository "simple-ledger/internal/audit/repository"
+	auditService "simple-ledger/internal/audit/service"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/requestmeta"
+	"simple-ledger/internal/fiscal_period/repository"
+	"simple-ledger/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupFiscalPeriodServiceTest(t *testing.T) (*FiscalPeriodService, *gorm.DB) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err := db.AutoMigrate(&models.FiscalPeriod{}, &models.AuditLog{}, &models.AuditChainHead{}); err != nil {
+		panic(err)
+	}
+
+	svc := NewFiscalPeriodService(
+		repository.NewFiscalPeriodRepository(db),
+		auditService.NewAuditService(auditRepository.NewAuditLogRepository(db)),
+		uow.New(db),
+	)
+	return svc, db
+}
+
+func TestClose_CreatesClosedPeriod(t *testing.T) {
+	svc, db := setupFiscalPeriodServiceTest(t)
+
+	period, err := svc.Close(1, "2024-01-01", "2024-12-31", requestmeta.Meta{})
+	require.NoError(t, err)
+	assert.NotNil(t, period.ClosedAt)
+
+	// 締め済みの期間に含まれる日付として検索できる
+	found, err := repository.NewFiscalPeriodRepository(db).FindClosedContaining(1, period.EndDate)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, period.ID, found.ID)
+
+	var log models.AuditLog
+	require.NoError(t, db.First(&log).Error)
+	assert.Equal(t, models.AuditActionClose, log.Action)
+	assert.Equal(t, models.AuditEntityFiscalPeriod, log.EntityType)
+}
+
+func TestClose_ClosesExistingOpenPeriod(t *testing.T) {
+	svc, db := setupFiscalPeriodServiceTest(t)
+	open := models.FiscalPeriod{UserID: 1}
+	open.StartDate, open.EndDate = mustDate(t, "2024-01-01"), mustDate(t, "2024-03-31")
+	require.NoError(t, repository.NewFiscalPeriodRepository(db).Create(&open))
+
+	period, err := svc.Close(1, "2024-01-01", "2024-03-31", requestmeta.Meta{})
+	require.NoError(t, err)
+	assert.Equal(t, open.ID, period.ID)
+	assert.NotNil(t, period.ClosedAt)
+
+	_, err = svc.Close(1, "2024-01-01", "2024-03-31", requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrPeriodAlreadyClosed)
+}
+
+func TestClose_RejectsOverlapAndInvalidDates(t *testing.T) {
+	svc, _ := setupFiscalPeriodServiceTest(t)
+	_, err := svc.Close(1, "2024-01-01", "2024-06-30", requestmeta.Meta{})
+	require.NoError(t, err)
+
+	_, err = svc.Close(1, "2024-06-01", "2024-12-31", requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrPeriodOverlaps)
+
+	// 他のユーザーの期間とは重ならない
+	_, err = svc.Close(2, "2024-06-01", "2024-12-31", requestmeta.Meta{})
+	assert.NoError(t, err)
+
+	_, err = svc.Close(1, "2025-12-31", "2025-01-01", requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrInvalidPeriod)
+	_, err = svc.Close(1, "2025/01/01", "2025-12-31", requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrInvalidPeriod)
+}
+
+func mustDate(t *testing.T, value string) time.Time {
+	t.Helper()
+	date, err := time.Parse("2006-01-02", value)
+	require.NoError(t, err)
+	return date
+}
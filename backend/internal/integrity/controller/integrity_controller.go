@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"simple-ledger/internal/common/requestmeta"
+	"simple-ledger/internal/integrity/dto"
+	"simple-ledger/internal/integrity/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IntegrityController: 整合性チェックコントローラー
+type IntegrityController struct {
+	service *service.IntegrityService
+}
+
+// NewIntegrityController: 整合性チェックコントローラーの生成
+func NewIntegrityController(service *service.IntegrityService) *IntegrityController {
+	return &IntegrityController{service: service}
+}
+
+// GetReport: 整合性チェックを実行して結果を取得（データは変更しない）
+// GET /api/integrity/check
+func (ctrl *IntegrityController) GetReport(c *gin.Context) {
+	report, err := ctrl.service.Check(false, requestmeta.FromContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check ledger integrity"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// RunCheck: 整合性チェックを実行し、指定された場合は問題のある取引を隔離する
+// POST /api/integrity/check {"quarantine": true}
+func (ctrl *IntegrityController) RunCheck(c *gin.Context) {
+	var req dto.CheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	report, err := ctrl.service.Check(req.Quarantine, requestmeta.FromContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check ledger integrity"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
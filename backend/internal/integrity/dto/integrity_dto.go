@@ -0,0 +1,67 @@
+package dto
+
+import "time"
+
+// IssueKind: 整合性チェックで検出する問題の種類
+type IssueKind string
+
+const (
+	IssueUnbalanced      IssueKind = "unbalanced"       // 借方合計と貸方合計が一致しない
+	IssueTooFewLines     IssueKind = "too_few_lines"    // 仕訳エントリーが2行未満
+	IssueMissingSide     IssueKind = "missing_side"     // 借方・貸方のどちらかが無い
+	IssueOrphanedEntry   IssueKind = "orphaned_entry"   // 取引が存在しない仕訳エントリー
+	IssueMissingAccount  IssueKind = "missing_account"  // 削除された勘定科目を参照する仕訳エントリー
+	IssueInactiveAccount IssueKind = "inactive_account" // 無効な勘定科目を参照する仕訳エントリー
+	IssueCorrectionCycle IssueKind = "correction_cycle" // 修正元をたどると自身に戻る取引
+)
+
+// CheckRequest: 整合性チェックの実行リクエスト
+type CheckRequest struct {
+	// Quarantine: 問題のある取引を隔離し、問題が解消された取引の隔離を解除するかどうか
+	Quarantine bool `json:"quarantine"`
+}
+
+// Issue: 検出された問題
+type Issue struct {
+	// Kind: 問題の種類
+	Kind IssueKind `json:"kind"`
+
+	// UserID: 取引の所有者（取引が存在しない場合は省略）
+	UserID uint `json:"userId,omitempty"`
+
+	// TransactionID: 対象の取引ID（孤立した仕訳エントリーの場合は存在しない取引のID）
+	TransactionID uint `json:"transactionId"`
+
+	// JournalEntryID: 対象の仕訳エントリーID（仕訳エントリー単位の問題の場合のみ）
+	JournalEntryID uint `json:"journalEntryId,omitempty"`
+
+	// ChartOfAccountsID: 対象の勘定科目ID（勘定科目の問題の場合のみ）
+	ChartOfAccountsID uint `json:"chartOfAccountsId,omitempty"`
+
+	// Detail: 問題の詳細
+	Detail string `json:"detail"`
+}
+
+// Report: 整合性チェックの結果
+type Report struct {
+	// CheckedAt: チェックを開始した日時
+	CheckedAt time.Time `json:"checkedAt"`
+
+	// Valid: 問題が見つからなかったかどうか
+	Valid bool `json:"valid"`
+
+	// CheckedTransactions: チェックした取引の数
+	CheckedTransactions int `json:"checkedTransactions"`
+
+	// Counts: 問題の種類ごとの件数
+	Counts map[IssueKind]int `json:"counts"`
+
+	// Issues: 検出された問題の一覧
+	Issues []Issue `json:"issues"`
+
+	// Quarantined: 今回隔離した取引ID（隔離を指定した場合のみ）
+	Quarantined []uint `json:"quarantined"`
+
+	// Released: 問題が解消されたため隔離を解除した取引ID（隔離を指定した場合のみ）
+	Released []uint `json:"released"`
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"time"
+
+	"simple-ledger/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// TransactionSummary: 取引ごとの仕訳エントリーの集計
+type TransactionSummary struct {
+	ID            uint
+	UserID        uint
+	QuarantinedAt *time.Time
+	LineCount     int
+	DebitLines    int
+	CreditLines   int
+	DebitTotal    int64
+	CreditTotal   int64
+}
+
+// AccountReference: 削除または無効化された勘定科目を参照する仕訳エントリー
+type AccountReference struct {
+	JournalEntryID    uint
+	TransactionID     uint
+	UserID            uint
+	ChartOfAccountsID uint
+	// AccountFound: 勘定科目が存在するかどうか（存在しない場合は削除済み）
+	AccountFound bool
+}
+
+// CorrectionLink: 修正取引と修正元の取引の関係
+type CorrectionLink struct {
+	ID              uint
+	UserID          uint
+	CorrectedFromID uint
+}
+
+// IntegrityRepository: 整合性チェック用のリポジトリ（全ユーザーの取引を対象にする）
+type IntegrityRepository struct {
+	db *gorm.DB
+}
+
+// NewIntegrityRepository: 整合性チェック用リポジトリの生成
+func NewIntegrityRepository(db *gorm.DB) *IntegrityRepository {
+	return &IntegrityRepository{db: db}
+}
+
+// WithTx: 指定したDBトランザクション上で動作するリポジトリを返す
+func (r *IntegrityRepository) WithTx(tx *gorm.DB) *IntegrityRepository {
+	return &IntegrityRepository{db: tx}
+}
+
+// GetTransactionSummaries: afterID より後の取引を ID の昇順に limit 件取得し、仕訳エントリーを集計する
+func (r *IntegrityRepository) GetTransactionSummaries(afterID uint, limit int) ([]TransactionSummary, error) {
+	var summaries []TransactionSummary
+	err := r.db.Table("transactions AS t").
+		Select(`t.id, t.user_id, t.quarantined_at,
+			COUNT(je.id) AS line_count,
+			COALESCE(SUM(CASE WHEN je.type = ? THEN 1 ELSE 0 END), 0) AS debit_lines,
+			COALESCE(SUM(CASE WHEN je.type = ? THEN 1 ELSE 0 END), 0) AS credit_lines,
+			COALESCE(SUM(CASE WHEN je.type = ? THEN je.amount ELSE 0 END), 0) AS debit_total,
+			COALESCE(SUM(CASE WHEN je.type = ? THEN je.amount ELSE 0 END), 0) AS credit_total`,
+			models.DebitEntry, models.CreditEntry, models.DebitEntry, models.CreditEntry).
+		Joins("LEFT JOIN journal_entries AS je ON je.transaction_id = t.id").
+		Where("t.id > ?", afterID).
+		Group("t.id, t.user_id, t.quarantined_at").
+		Order("t.id ASC").
+		Limit(limit).
+		Scan(&summaries).Error
+	if err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// GetOrphanedEntries: 取引が存在しない仕訳エントリーを取得
+func (r *IntegrityRepository) GetOrphanedEntries() ([]models.JournalEntry, error) {
+	var entries []models.JournalEntry
+	err := r.db.
+		Select("journal_entries.*").
+		Joins("LEFT JOIN transactions ON transactions.id = journal_entries.transaction_id").
+		Where("transactions.id IS NULL").
+		Order("journal_entries.id ASC").
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetEntriesWithUnusableAccounts: 削除または無効化された勘定科目を参照する仕訳エントリーを取得（孤立した仕訳エントリーは除く）
+func (r *IntegrityRepository) GetEntriesWithUnusableAccounts() ([]AccountReference, error) {
+	var references []AccountReference
+	err := r.db.Table("journal_entries AS je").
+		Select(`je.id AS journal_entry_id, je.transaction_id, t.user_id, je.chart_of_accounts_id,
+			CASE WHEN c.id IS NULL THEN 0 ELSE 1 END AS account_found`).
+		Joins("JOIN transactions AS t ON t.id = je.transaction_id").
+		Joins("LEFT JOIN chart_of_accounts AS c ON c.id = je.chart_of_accounts_id").
+		Where("c.id IS NULL OR c.is_active = ?", false).
+		Order("je.id ASC").
+		Scan(&references).Error
+	if err != nil {
+		return nil, err
+	}
+	return references, nil
+}
+
+// GetCorrectionLinks: 修正元が設定されている取引を取得
+func (r *IntegrityRepository) GetCorrectionLinks() ([]CorrectionLink, error) {
+	var links []CorrectionLink
+	err := r.db.Model(&models.Transaction{}).
+		Select("id, user_id, corrected_from_id").
+		Where("corrected_from_id IS NOT NULL").
+		Order("id ASC").
+		Scan(&links).Error
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// GetQuarantinedIDs: 隔離中の取引IDを取得
+func (r *IntegrityRepository) GetQuarantinedIDs() ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.Transaction{}).
+		Where("quarantined_at IS NOT NULL").
+		Order("id ASC").
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GetTransaction: 取引を取得（監査ログの変更前・変更後の記録に使う）
+func (r *IntegrityRepository) GetTransaction(id uint) (*models.Transaction, error) {
+	var transaction models.Transaction
+	if err := r.db.First(&transaction, id).Error; err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// SetQuarantine: 取引の隔離状態を更新（at が nil の場合は隔離を解除）
+// 利用者による変更ではないため更新日時は変更しない
+func (r *IntegrityRepository) SetQuarantine(transaction *models.Transaction, at *time.Time, reason string) error {
+	if err := r.db.Model(transaction).UpdateColumns(map[string]interface{}{
+		"quarantined_at":    at,
+		"quarantine_reason": reason,
+	}).Error; err != nil {
+		return err
+	}
+	transaction.QuarantinedAt = at
+	transaction.QuarantineReason = reason
+	return nil
+}
@@ -0,0 +1,29 @@
+package router
+
+import (
+	auditRepository "simple-ledger/internal/audit/repository"
+	auditService "simple-ledger/internal/audit/service"
+	"simple-ledger/internal/auth/middleware"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/integrity/controller"
+	"simple-ledger/internal/integrity/repository"
+	"simple-ledger/internal/integrity/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func SetupIntegrityRoutes(apiGroup *gin.RouterGroup, db *gorm.DB) {
+	repo := repository.NewIntegrityRepository(db)
+	auditSvc := auditService.NewAuditService(auditRepository.NewAuditLogRepository(db))
+	svc := service.NewIntegrityService(repo, auditSvc, uow.New(db))
+	ctrl := controller.NewIntegrityController(svc)
+
+	// 整合性チェックは全ユーザーの取引を対象にするため管理者のみ
+	integrityRoutes := apiGroup.Group("/integrity")
+	integrityRoutes.Use(middleware.AuthMiddleware(), middleware.RequireRole("admin"))
+	{
+		integrityRoutes.GET("/check", ctrl.GetReport) // GET /api/integrity/check
+		integrityRoutes.POST("/check", ctrl.RunCheck) // POST /api/integrity/check
+	}
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	auditService "simple-ledger/internal/audit/service"
+	"simple-ledger/internal/common/config"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/requestmeta"
+	"simple-ledger/internal/integrity/dto"
+	"simple-ledger/internal/integrity/repository"
+	"simple-ledger/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// checkBatchSize: 取引を一度に読み込む件数
+const checkBatchSize = 500
+
+// Config: 定期的な整合性チェックの設定
+type Config struct {
+	// Interval: チェックの間隔（0 の場合は定期的なチェックを行わない）
+	Interval time.Duration
+
+	// Quarantine: 定期的なチェックで問題のある取引を隔離するかどうか
+	Quarantine bool
+}
+
+// DefaultConfig: 定期的な整合性チェックのデフォルト設定（無効）
+func DefaultConfig() Config {
+	return Config{
+		Interval:   0,
+		Quarantine: false,
+	}
+}
+
+// ConfigFromEnv: 環境変数から定期的な整合性チェックの設定を読み込む
+func ConfigFromEnv() Config {
+	defaults := DefaultConfig()
+	return Config{
+		Interval:   time.Duration(config.GetEnvAsInt("INTEGRITY_CHECK_INTERVAL_MINUTES", int(defaults.Interval/time.Minute))) * time.Minute,
+		Quarantine: config.GetEnvAsBool("INTEGRITY_CHECK_QUARANTINE", defaults.Quarantine),
+	}
+}
+
+// IntegrityService: 帳簿の整合性チェックサービス
+// 取引の補償削除の失敗や仕訳エントリー単体の API によって残りうる不整合を検出する
+type IntegrityService struct {
+	repo  *repository.IntegrityRepository
+	audit *auditService.AuditService
+	uow   uow.UnitOfWork
+}
+
+// NewIntegrityService: 整合性チェックサービスの生成
+func NewIntegrityService(repo *repository.IntegrityRepository, audit *auditService.AuditService, unitOfWork uow.UnitOfWork) *IntegrityService {
+	return &IntegrityService{repo: repo, audit: audit, uow: unitOfWork}
+}
+
+// Check: すべての取引を検査して結果を返す
+// quarantine が true の場合は問題のある取引を隔離し、隔離中で問題が解消された取引の隔離を解除する
+// 孤立した仕訳エントリーは隔離する取引が無いため、報告のみ行う
+func (s *IntegrityService) Check(quarantine bool, meta requestmeta.Meta) (*dto.Report, error) {
+	report := &dto.Report{
+		CheckedAt:   time.Now().UTC(),
+		Counts:      map[dto.IssueKind]int{},
+		Issues:      []dto.Issue{},
+		Quarantined: []uint{},
+		Released:    []uint{},
+	}
+	quarantined := map[uint]bool{}
+
+	// 取引ごとの貸借・行数
+	var afterID uint
+	for {
+		summaries, err := s.repo.GetTransactionSummaries(afterID, checkBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, summary := range summaries {
+			report.CheckedTransactions++
+			if summary.QuarantinedAt != nil {
+				quarantined[summary.ID] = true
+			}
+			for _, issue := range checkSummary(summary) {
+				addIssue(report, issue)
+			}
+		}
+		if len(summaries) < checkBatchSize {
+			break
+		}
+		afterID = summaries[len(summaries)-1].ID
+	}
+
+	// 孤立した仕訳エントリー
+	orphans, err := s.repo.GetOrphanedEntries()
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range orphans {
+		addIssue(report, dto.Issue{
+			Kind:              dto.IssueOrphanedEntry,
+			TransactionID:     entry.TransactionID,
+			JournalEntryID:    entry.ID,
+			ChartOfAccountsID: entry.ChartOfAccountsID,
+			Detail:            fmt.Sprintf("journal entry %d references missing transaction %d", entry.ID, entry.TransactionID),
+		})
+	}
+
+	// 削除・無効化された勘定科目
+	references, err := s.repo.GetEntriesWithUnusableAccounts()
+	if err != nil {
+		return nil, err
+	}
+	for _, reference := range references {
+		issue := dto.Issue{
+			Kind:              dto.IssueInactiveAccount,
+			UserID:            reference.UserID,
+			TransactionID:     reference.TransactionID,
+			JournalEntryID:    reference.JournalEntryID,
+			ChartOfAccountsID: reference.ChartOfAccountsID,
+			Detail:            fmt.Sprintf("journal entry %d references inactive account %d", reference.JournalEntryID, reference.ChartOfAccountsID),
+		}
+		if !reference.AccountFound {
+			issue.Kind = dto.IssueMissingAccount
+			issue.Detail = fmt.Sprintf("journal entry %d references missing account %d", reference.JournalEntryID, reference.ChartOfAccountsID)
+		}
+		addIssue(report, issue)
+	}
+
+	// 修正の循環
+	links, err := s.repo.GetCorrectionLinks()
+	if err != nil {
+		return nil, err
+	}
+	for _, issue := range findCorrectionCycles(links) {
+		addIssue(report, issue)
+	}
+
+	report.Valid = len(report.Issues) == 0
+	if quarantine {
+		if err := s.applyQuarantine(report, quarantined, meta); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// applyQuarantine: 問題のある取引を隔離し、問題が解消された取引の隔離を解除する（変更は監査ログに記録する）
+func (s *IntegrityService) applyQuarantine(report *dto.Report, quarantined map[uint]bool, meta requestmeta.Meta) error {
+	reasons := quarantineReasons(report.Issues)
+	var quarantineIDs, releaseIDs []uint
+	for id := range reasons {
+		if !quarantined[id] {
+			quarantineIDs = append(quarantineIDs, id)
+		}
+	}
+	for id := range quarantined {
+		if _, ok := reasons[id]; !ok {
+			releaseIDs = append(releaseIDs, id)
+		}
+	}
+	sort.Slice(quarantineIDs, func(i, j int) bool { return quarantineIDs[i] < quarantineIDs[j] })
+	sort.Slice(releaseIDs, func(i, j int) bool { return releaseIDs[i] < releaseIDs[j] })
+	if len(quarantineIDs) == 0 && len(releaseIDs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	err := s.uow.Do(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		audit := s.audit.WithTx(tx)
+		update := func(id uint, action models.AuditAction, at *time.Time, reason string) error {
+			transaction, err := repo.GetTransaction(id)
+			if err != nil {
+				return err
+			}
+			before := *transaction
+			if err := repo.SetQuarantine(transaction, at, reason); err != nil {
+				return err
+			}
+			return audit.Record(meta, auditService.Entry{
+				Action:     action,
+				EntityType: models.AuditEntityTransaction,
+				EntityID:   id,
+				Before:     &before,
+				After:      transaction,
+			})
+		}
+
+		for _, id := range quarantineIDs {
+			if err := update(id, models.AuditActionQuarantine, &now, reasons[id]); err != nil {
+				return err
+			}
+		}
+		for _, id := range releaseIDs {
+			if err := update(id, models.AuditActionRelease, nil, ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	report.Quarantined = append(report.Quarantined, quarantineIDs...)
+	report.Released = append(report.Released, releaseIDs...)
+	return nil
+}
+
+// RunPeriodically: ctx が終了するまで interval ごとにチェックを実行し、結果の概要をログに出力する
+func (s *IntegrityService) RunPeriodically(ctx context.Context, cfg Config) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := s.Check(cfg.Quarantine, requestmeta.ForCommand("integrity-check"))
+			if err != nil {
+				log.Printf("Integrity check failed: %v", err)
+				continue
+			}
+			if report.Valid && len(report.Released) == 0 {
+				log.Printf("Integrity check passed (%d transactions)", report.CheckedTransactions)
+				continue
+			}
+			log.Printf("Integrity check found %d issues in %d transactions (by kind: %v, quarantined: %v, released: %v)",
+				len(report.Issues), report.CheckedTransactions, report.Counts, report.Quarantined, report.Released)
+		}
+	}
+}
+
+// checkSummary: 取引の行数と貸借を検査
+func checkSummary(summary repository.TransactionSummary) []dto.Issue {
+	var issues []dto.Issue
+	issue := func(kind dto.IssueKind, detail string) {
+		issues = append(issues, dto.Issue{Kind: kind, UserID: summary.UserID, TransactionID: summary.ID, Detail: detail})
+	}
+
+	if summary.LineCount < 2 {
+		issue(dto.IssueTooFewLines, fmt.Sprintf("transaction has %d journal entries", summary.LineCount))
+	}
+	if summary.DebitLines == 0 || summary.CreditLines == 0 {
+		issue(dto.IssueMissingSide, fmt.Sprintf("transaction has %d debit lines and %d credit lines", summary.DebitLines, summary.CreditLines))
+	}
+	if summary.DebitTotal != summary.CreditTotal {
+		issue(dto.IssueUnbalanced, fmt.Sprintf("debit total %d does not equal credit total %d", summary.DebitTotal, summary.CreditTotal))
+	}
+	return issues
+}
+
+// findCorrectionCycles: 修正元をたどると同じ取引に戻る循環を検出（循環に含まれる取引ごとに1件）
+func findCorrectionCycles(links []repository.CorrectionLink) []dto.Issue {
+	next := make(map[uint]uint, len(links))
+	owners := make(map[uint]uint, len(links))
+	for _, link := range links {
+		next[link.ID] = link.CorrectedFromID
+		owners[link.ID] = link.UserID
+	}
+
+	// 0: 未訪問、1: 現在たどっている経路上、2: 検査済み
+	state := make(map[uint]int, len(links))
+	var issues []dto.Issue
+	for _, link := range links {
+		var path []uint
+		id, linked := link.ID, true
+		for linked && state[id] == 0 {
+			state[id] = 1
+			path = append(path, id)
+			id, linked = next[id]
+		}
+		if linked && state[id] == 1 {
+			// 経路上の id から先が循環
+			start := 0
+			for path[start] != id {
+				start++
+			}
+			cycle := path[start:]
+			labels := make([]string, 0, len(cycle)+1)
+			for _, member := range cycle {
+				labels = append(labels, fmt.Sprint(member))
+			}
+			labels = append(labels, fmt.Sprint(id))
+			detail := "correction chain cycle: " + strings.Join(labels, " -> ")
+			for _, member := range cycle {
+				issues = append(issues, dto.Issue{Kind: dto.IssueCorrectionCycle, UserID: owners[member], TransactionID: member, Detail: detail})
+			}
+		}
+		for _, member := range path {
+			state[member] = 2
+		}
+	}
+	return issues
+}
+
+// addIssue: 問題を結果に追加
+func addIssue(report *dto.Report, issue dto.Issue) {
+	report.Issues = append(report.Issues, issue)
+	report.Counts[issue.Kind]++
+}
+
+// quarantineReasons: 隔離する取引IDと理由（問題の種類の一覧）
+func quarantineReasons(issues []dto.Issue) map[uint]string {
+	kinds := map[uint][]string{}
+	for _, issue := range issues {
+		if issue.Kind == dto.IssueOrphanedEntry {
+			continue
+		}
+		found := false
+		for _, kind := range kinds[issue.TransactionID] {
+			if kind == string(issue.Kind) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			kinds[issue.TransactionID] = append(kinds[issue.TransactionID], string(issue.Kind))
+		}
+	}
+
+	reasons := make(map[uint]string, len(kinds))
+	for id, list := range kinds {
+		reasons[id] = strings.Join(list, ", ")
+	}
+	return reasons
+}
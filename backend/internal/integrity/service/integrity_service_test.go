@@ -0,0 +1,165 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	auditRepository "simple-ledger/internal/audit/repository"
+	auditService "simple-ledger/internal/audit/service"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/requestmeta"
+	"simple-ledger/internal/integrity/dto"
+	"simple-ledger/internal/integrity/repository"
+	"simple-ledger/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupIntegrityServiceTest(t *testing.T) (*IntegrityService, *gorm.DB) {
+	// 外部キー制約を有効にしない（孤立した仕訳エントリーなどの不整合を作るため）
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err := db.AutoMigrate(&models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}, &models.AuditLog{}, &models.AuditChainHead{}); err != nil {
+		panic(err)
+	}
+
+	svc := NewIntegrityService(
+		repository.NewIntegrityRepository(db),
+		auditService.NewAuditService(auditRepository.NewAuditLogRepository(db)),
+		uow.New(db),
+	)
+	return svc, db
+}
+
+// createAccount: 勘定科目を作成（isActive が false の場合は無効にする）
+func createAccount(t *testing.T, db *gorm.DB, code string, isActive bool) *models.ChartOfAccounts {
+	account := &models.ChartOfAccounts{Code: code, Name: code, Type: models.AssetAccount, NormalBalance: models.DebitBalance, IsActive: true}
+	require.NoError(t, db.Create(account).Error)
+	if !isActive {
+		require.NoError(t, db.Model(account).Update("is_active", false).Error)
+	}
+	return account
+}
+
+// createTransaction: 仕訳エントリー付きの取引を作成
+func createTransaction(t *testing.T, db *gorm.DB, userID uint, entries ...models.JournalEntry) *models.Transaction {
+	transaction := &models.Transaction{UserID: userID, Date: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), JournalEntries: entries}
+	require.NoError(t, db.Create(transaction).Error)
+	return transaction
+}
+
+func line(accountID uint, entryType models.EntryType, amount int) models.JournalEntry {
+	return models.JournalEntry{ChartOfAccountsID: accountID, Type: entryType, Amount: amount}
+}
+
+// issueKinds: 取引IDごとの問題の種類
+func issueKinds(report *dto.Report) map[uint][]dto.IssueKind {
+	kinds := map[uint][]dto.IssueKind{}
+	for _, issue := range report.Issues {
+		kinds[issue.TransactionID] = append(kinds[issue.TransactionID], issue.Kind)
+	}
+	return kinds
+}
+
+func TestCheck_HealthyLedger(t *testing.T) {
+	svc, db := setupIntegrityServiceTest(t)
+	cash := createAccount(t, db, "1000", true)
+	sales := createAccount(t, db, "4000", true)
+	createTransaction(t, db, 1, line(cash.ID, models.DebitEntry, 1000), line(sales.ID, models.CreditEntry, 1000))
+	createTransaction(t, db, 2, line(cash.ID, models.DebitEntry, 500), line(sales.ID, models.CreditEntry, 300), line(sales.ID, models.CreditEntry, 200))
+
+	report, err := svc.Check(false, requestmeta.Meta{})
+	require.NoError(t, err)
+	assert.True(t, report.Valid)
+	assert.Equal(t, 2, report.CheckedTransactions)
+	assert.Empty(t, report.Issues)
+	assert.Empty(t, report.Quarantined)
+}
+
+func TestCheck_DetectsProblems(t *testing.T) {
+	svc, db := setupIntegrityServiceTest(t)
+	cash := createAccount(t, db, "1000", true)
+	sales := createAccount(t, db, "4000", true)
+	retired := createAccount(t, db, "4900", false)
+
+	unbalanced := createTransaction(t, db, 1, line(cash.ID, models.DebitEntry, 1000), line(sales.ID, models.CreditEntry, 900))
+	single := createTransaction(t, db, 1, line(cash.ID, models.DebitEntry, 1000))
+	empty := createTransaction(t, db, 2)
+	inactive := createTransaction(t, db, 2, line(cash.ID, models.DebitEntry, 100), line(retired.ID, models.CreditEntry, 100))
+	missing := createTransaction(t, db, 2, line(cash.ID, models.DebitEntry, 100), line(9999, models.CreditEntry, 100))
+	require.NoError(t, db.Create(&models.JournalEntry{TransactionID: 8888, ChartOfAccountsID: cash.ID, Type: models.DebitEntry, Amount: 100}).Error)
+
+	// 修正元をたどると自身に戻る取引
+	first := createTransaction(t, db, 3, line(cash.ID, models.DebitEntry, 100), line(sales.ID, models.CreditEntry, 100))
+	second := createTransaction(t, db, 3, line(cash.ID, models.DebitEntry, 100), line(sales.ID, models.CreditEntry, 100))
+	require.NoError(t, db.Model(first).Update("corrected_from_id", second.ID).Error)
+	require.NoError(t, db.Model(second).Update("corrected_from_id", first.ID).Error)
+	// 循環しない修正の連鎖は問題にしない
+	correction := createTransaction(t, db, 3, line(cash.ID, models.DebitEntry, 100), line(sales.ID, models.CreditEntry, 100))
+	require.NoError(t, db.Model(correction).Update("corrected_from_id", first.ID).Error)
+
+	report, err := svc.Check(false, requestmeta.Meta{})
+	require.NoError(t, err)
+	assert.False(t, report.Valid)
+	assert.Equal(t, 8, report.CheckedTransactions)
+
+	kinds := issueKinds(report)
+	assert.Equal(t, []dto.IssueKind{dto.IssueUnbalanced}, kinds[unbalanced.ID])
+	assert.ElementsMatch(t, []dto.IssueKind{dto.IssueTooFewLines, dto.IssueMissingSide, dto.IssueUnbalanced}, kinds[single.ID])
+	assert.ElementsMatch(t, []dto.IssueKind{dto.IssueTooFewLines, dto.IssueMissingSide}, kinds[empty.ID])
+	assert.Equal(t, []dto.IssueKind{dto.IssueInactiveAccount}, kinds[inactive.ID])
+	assert.Equal(t, []dto.IssueKind{dto.IssueMissingAccount}, kinds[missing.ID])
+	assert.Equal(t, []dto.IssueKind{dto.IssueOrphanedEntry}, kinds[8888])
+	assert.Equal(t, []dto.IssueKind{dto.IssueCorrectionCycle}, kinds[first.ID])
+	assert.Equal(t, []dto.IssueKind{dto.IssueCorrectionCycle}, kinds[second.ID])
+	assert.Empty(t, kinds[correction.ID])
+	assert.Equal(t, 2, report.Counts[dto.IssueCorrectionCycle])
+
+	// 点検のみではデータを変更しない
+	var quarantined int64
+	require.NoError(t, db.Model(&models.Transaction{}).Where("quarantined_at IS NOT NULL").Count(&quarantined).Error)
+	assert.Zero(t, quarantined)
+}
+
+func TestCheck_QuarantinesAndReleases(t *testing.T) {
+	svc, db := setupIntegrityServiceTest(t)
+	cash := createAccount(t, db, "1000", true)
+	sales := createAccount(t, db, "4000", true)
+	healthy := createTransaction(t, db, 1, line(cash.ID, models.DebitEntry, 1000), line(sales.ID, models.CreditEntry, 1000))
+	broken := createTransaction(t, db, 1, line(cash.ID, models.DebitEntry, 1000), line(sales.ID, models.CreditEntry, 900))
+
+	report, err := svc.Check(true, requestmeta.Meta{})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{broken.ID}, report.Quarantined)
+	assert.Empty(t, report.Released)
+
+	var stored models.Transaction
+	require.NoError(t, db.First(&stored, broken.ID).Error)
+	require.NotNil(t, stored.QuarantinedAt)
+	assert.Equal(t, string(dto.IssueUnbalanced), stored.QuarantineReason)
+	var untouched models.Transaction
+	require.NoError(t, db.First(&untouched, healthy.ID).Error)
+	assert.Nil(t, untouched.QuarantinedAt)
+
+	var log models.AuditLog
+	require.NoError(t, db.Where("action = ?", models.AuditActionQuarantine).First(&log).Error)
+	assert.Equal(t, broken.ID, log.EntityID)
+
+	// 隔離済みの取引は再度隔離しない
+	report, err = svc.Check(true, requestmeta.Meta{})
+	require.NoError(t, err)
+	assert.Empty(t, report.Quarantined)
+
+	// 修復後のチェックで隔離を解除する
+	require.NoError(t, db.Model(&models.JournalEntry{}).Where("transaction_id = ? AND type = ?", broken.ID, models.CreditEntry).Update("amount", 1000).Error)
+	report, err = svc.Check(true, requestmeta.Meta{})
+	require.NoError(t, err)
+	assert.True(t, report.Valid)
+	assert.Equal(t, []uint{broken.ID}, report.Released)
+	var released models.Transaction
+	require.NoError(t, db.First(&released, broken.ID).Error)
+	assert.Nil(t, released.QuarantinedAt)
+	assert.Empty(t, released.QuarantineReason)
+}
@@ -1,11 +1,14 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
+	"simple-ledger/internal/common/requestmeta"
 	"simple-ledger/internal/journal_entry/dto"
 	"simple-ledger/internal/journal_entry/service"
+	postingService "simple-ledger/internal/posting/service"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,8 +23,8 @@ func NewJournalEntryController(service *service.JournalEntryService) *JournalEnt
 	return &JournalEntryController{service: service}
 }
 
-// CreateJournalEntry: 仕訳エントリーを作成
-func (ctrl *JournalEntryController) CreateJournalEntry(c *gin.Context) {
+// CreateJournalEntries: 取引に仕訳エントリーを追加
+func (ctrl *JournalEntryController) CreateJournalEntries(c *gin.Context) {
 	transactionIDStr := c.Param("transactionId")
 	transactionID, err := strconv.ParseUint(transactionIDStr, 10, 32)
 	if err != nil {
@@ -29,19 +32,25 @@ func (ctrl *JournalEntryController) CreateJournalEntry(c *gin.Context) {
 		return
 	}
 
-	var req dto.CreateJournalEntryRequest
+	var req dto.CreateJournalEntriesRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	entry, err := ctrl.service.CreateJournalEntry(uint(transactionID), &req)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	entries, err := ctrl.service.CreateJournalEntries(userID.(uint), uint(transactionID), &req, requestmeta.FromContext(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, dto.ToJournalEntryResponse(entry))
+	c.JSON(http.StatusCreated, dto.ToJournalEntryResponseSlice(entries))
 }
 
 // GetJournalEntryByID: IDで仕訳エントリーを取得
@@ -53,9 +62,15 @@ func (ctrl *JournalEntryController) GetJournalEntryByID(c *gin.Context) {
 		return
 	}
 
-	entry, err := ctrl.service.GetJournalEntryByID(uint(id))
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	entry, err := ctrl.service.GetJournalEntryByID(userID.(uint), uint(id))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Journal entry not found"})
+		respondError(c, err)
 		return
 	}
 
@@ -71,9 +86,15 @@ func (ctrl *JournalEntryController) GetJournalEntriesByTransactionID(c *gin.Cont
 		return
 	}
 
-	entries, err := ctrl.service.GetJournalEntriesByTransactionID(uint(transactionID))
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	entries, err := ctrl.service.GetJournalEntriesByTransactionID(userID.(uint), uint(transactionID))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -89,8 +110,18 @@ func (ctrl *JournalEntryController) ValidateTransaction(c *gin.Context) {
 		return
 	}
 
-	isValid, err := ctrl.service.ValidateTransaction(uint(transactionID))
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	isValid, err := ctrl.service.ValidateTransactionForUser(userID.(uint), uint(transactionID))
 	if err != nil {
+		if errors.Is(err, service.ErrTransactionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -116,9 +147,15 @@ func (ctrl *JournalEntryController) UpdateJournalEntry(c *gin.Context) {
 		return
 	}
 
-	entry, err := ctrl.service.UpdateJournalEntry(uint(id), &req)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	entry, err := ctrl.service.UpdateJournalEntry(userID.(uint), uint(id), &req, requestmeta.FromContext(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -134,10 +171,31 @@ func (ctrl *JournalEntryController) DeleteJournalEntry(c *gin.Context) {
 		return
 	}
 
-	if err := ctrl.service.DeleteJournalEntry(uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	if err := ctrl.service.DeleteJournalEntry(userID.(uint), uint(id), requestmeta.FromContext(c)); err != nil {
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Journal entry deleted successfully"})
 }
+
+// respondError: サービス層のエラーを HTTP ステータスに変換して返す
+func respondError(c *gin.Context, err error) {
+	var validationErr *postingService.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "details": validationErr.Errors})
+	case errors.Is(err, service.ErrTransactionNotFound), errors.Is(err, service.ErrJournalEntryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTransactionUnbalanced):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -17,6 +17,13 @@ type CreateJournalEntryRequest struct {
 	Description string `json:"description"`
 }
 
+// CreateJournalEntriesRequest: 取引への仕訳エントリー追加リクエスト
+// 追加後も取引が貸借一致している必要があるため、貸借一致した複数の行をまとめて追加する
+type CreateJournalEntriesRequest struct {
+	// JournalEntries: 追加する仕訳エントリー（借方合計と貸方合計が一致すること）
+	JournalEntries []CreateJournalEntryRequest `json:"journalEntries" binding:"required,min=2"`
+}
+
 // JournalEntryResponse: 仕訳エントリーレスポンス
 type JournalEntryResponse struct {
 	// ID: 仕訳エントリーID
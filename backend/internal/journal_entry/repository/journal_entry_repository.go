@@ -4,6 +4,7 @@ import (
 	"simple-ledger/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // JournalEntryRepository: 仕訳エントリーリポジトリ
@@ -16,6 +17,37 @@ func NewJournalEntryRepository(db *gorm.DB) *JournalEntryRepository {
 	return &JournalEntryRepository{db: db}
 }
 
+// WithTx: 指定したDBトランザクション上で動作するリポジトリを返す
+func (r *JournalEntryRepository) WithTx(tx *gorm.DB) *JournalEntryRepository {
+	return &JournalEntryRepository{db: tx}
+}
+
+// GetTransactionByID: 仕訳エントリーの親となる取引を取得（所有者確認用）
+func (r *JournalEntryRepository) GetTransactionByID(transactionID uint) (*models.Transaction, error) {
+	var transaction models.Transaction
+	if err := r.db.Where("id = ?", transactionID).First(&transaction).Error; err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// LockTransactionByID: 仕訳エントリーの親となる取引を行ロック付きで取得（DBトランザクション内で使用する）
+func (r *JournalEntryRepository) LockTransactionByID(transactionID uint) (*models.Transaction, error) {
+	var transaction models.Transaction
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", transactionID).First(&transaction).Error; err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// IncrementTransactionVersion: 親となる取引のバージョンを1つ進める
+// 仕訳エントリーの変更後、取引の ETag（If-Match）で同時に行われた更新を検出できるようにする
+func (r *JournalEntryRepository) IncrementTransactionVersion(transactionID uint) error {
+	return r.db.Model(&models.Transaction{}).
+		Where("id = ?", transactionID).
+		UpdateColumn("version", gorm.Expr("version + 1")).Error
+}
+
 // Create: 仕訳エントリーを作成
 func (r *JournalEntryRepository) Create(entry *models.JournalEntry) error {
 	return r.db.Create(entry).Error
@@ -34,6 +66,19 @@ func (r *JournalEntryRepository) GetByID(id uint) (*models.JournalEntry, error)
 	return &entry, nil
 }
 
+// LockByIDInTransaction: 取引に属する仕訳エントリーを行ロック付きで取得（DBトランザクション内で、取引をロックした後に使用する）
+func (r *JournalEntryRepository) LockByIDInTransaction(id uint, transactionID uint) (*models.JournalEntry, error) {
+	var entry models.JournalEntry
+	if err := r.db.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("ChartOfAccounts").
+		Where("id = ? AND transaction_id = ?", id, transactionID).
+		First(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
 // GetByTransactionID: 取引IDで仕訳エントリーの一覧を取得
 func (r *JournalEntryRepository) GetByTransactionID(transactionID uint) ([]models.JournalEntry, error) {
 	var entries []models.JournalEntry
@@ -75,6 +120,22 @@ func (r *JournalEntryRepository) CreateBatch(entries []models.JournalEntry) erro
 	return r.db.CreateInBatches(entries, 100).Error
 }
 
+// GetUnbalancedTransactionIDs: 指定した取引のうち、借方合計と貸方合計が一致しないものの ID を取得
+func (r *JournalEntryRepository) GetUnbalancedTransactionIDs(transactionIDs []uint) ([]uint, error) {
+	var ids []uint
+	if len(transactionIDs) == 0 {
+		return ids, nil
+	}
+	if err := r.db.Model(&models.JournalEntry{}).
+		Where("transaction_id IN ?", transactionIDs).
+		Group("transaction_id").
+		Having("SUM(CASE WHEN type = ? THEN amount ELSE 0 END) <> SUM(CASE WHEN type = ? THEN amount ELSE 0 END)", models.DebitEntry, models.CreditEntry).
+		Pluck("transaction_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
 // Update: 仕訳エントリーを更新
 func (r *JournalEntryRepository) Update(entry *models.JournalEntry) error {
 	return r.db.Save(entry).Error
@@ -1,10 +1,17 @@
 package router
 
 import (
+	auditRepository "simple-ledger/internal/audit/repository"
+	auditService "simple-ledger/internal/audit/service"
 	"simple-ledger/internal/auth/middleware"
+	chartOfAccountsRepository "simple-ledger/internal/chart_of_accounts/repository"
+	"simple-ledger/internal/common/db/uow"
+	fiscalPeriodRepository "simple-ledger/internal/fiscal_period/repository"
 	"simple-ledger/internal/journal_entry/controller"
 	"simple-ledger/internal/journal_entry/repository"
 	"simple-ledger/internal/journal_entry/service"
+	"simple-ledger/internal/models"
+	postingService "simple-ledger/internal/posting/service"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -14,15 +21,21 @@ import (
 func SetupJournalEntryRoutes(api *gin.RouterGroup, db *gorm.DB) {
 	// リポジトリ、サービス、コントローラーのインスタンス化
 	repo := repository.NewJournalEntryRepository(db)
-	svc := service.NewJournalEntryService(repo)
+	validator := postingService.NewPostingValidator(
+		chartOfAccountsRepository.NewChartOfAccountsRepository(db),
+		fiscalPeriodRepository.NewFiscalPeriodRepository(db),
+		postingService.ConfigFromEnv(),
+	)
+	auditSvc := auditService.NewAuditService(auditRepository.NewAuditLogRepository(db))
+	svc := service.NewJournalEntryService(repo, validator, auditSvc, uow.New(db))
 	ctrl := controller.NewJournalEntryController(svc)
 
 	// 仕訳エントリーグループ
 	journalEntryGroup := api.Group("/journal-entries")
-	journalEntryGroup.Use(middleware.AuthMiddleware())
+	journalEntryGroup.Use(middleware.AuthMiddleware(models.TokenScopeTransactionsWrite))
 	{
-		// POST: 取引に仕訳エントリーを作成
-		journalEntryGroup.POST("/transactions/:transactionId", ctrl.CreateJournalEntry)
+		// POST: 取引に貸借一致した仕訳エントリーを追加
+		journalEntryGroup.POST("/transactions/:transactionId", ctrl.CreateJournalEntries)
 
 		// GET: 取引の全ての仕訳エントリーを取得
 		journalEntryGroup.GET("/transactions/:transactionId", ctrl.GetJournalEntriesByTransactionID)
@@ -2,66 +2,141 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	auditService "simple-ledger/internal/audit/service"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/requestmeta"
 	"simple-ledger/internal/journal_entry/dto"
 	"simple-ledger/internal/journal_entry/repository"
 	"simple-ledger/internal/models"
+	postingService "simple-ledger/internal/posting/service"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrTransactionNotFound: 取引が存在しない、または他ユーザーの取引
+	ErrTransactionNotFound = errors.New("transaction not found")
+
+	// ErrJournalEntryNotFound: 仕訳エントリーが存在しない、または他ユーザーの仕訳エントリー
+	ErrJournalEntryNotFound = errors.New("journal entry not found")
+
+	// ErrTransactionUnbalanced: 変更後の取引が貸借一致しない
+	ErrTransactionUnbalanced = errors.New("journal entry change would leave the transaction unbalanced")
 )
 
 // JournalEntryService: 仕訳エントリーサービス
 type JournalEntryService struct {
-	repo *repository.JournalEntryRepository
+	repo      *repository.JournalEntryRepository
+	validator postingService.PostingValidator
+	audit     *auditService.AuditService
+	uow       uow.UnitOfWork
 }
 
 // NewJournalEntryService: 仕訳エントリーサービスの生成
-func NewJournalEntryService(repo *repository.JournalEntryRepository) *JournalEntryService {
-	return &JournalEntryService{repo: repo}
+func NewJournalEntryService(
+	repo *repository.JournalEntryRepository,
+	validator postingService.PostingValidator,
+	audit *auditService.AuditService,
+	unitOfWork uow.UnitOfWork,
+) *JournalEntryService {
+	return &JournalEntryService{repo: repo, validator: validator, audit: audit, uow: unitOfWork}
+}
+
+// WithTx: 指定したDBトランザクション上で動作するサービスを返す
+func (s *JournalEntryService) WithTx(tx *gorm.DB) *JournalEntryService {
+	return &JournalEntryService{
+		repo:      s.repo.WithTx(tx),
+		validator: s.validator.WithTx(tx),
+		audit:     s.audit.WithTx(tx),
+		uow:       uow.New(tx),
+	}
 }
 
-// CreateJournalEntry: 仕訳エントリーを作成
-func (s *JournalEntryService) CreateJournalEntry(transactionID uint, req *dto.CreateJournalEntryRequest) (*models.JournalEntry, error) {
+// CreateJournalEntries: 取引に仕訳エントリーを追加
+// 取引の所有者のみ追加でき、追加後も取引が貸借一致している必要がある（追加する行だけで貸借一致させる）
+// 仕訳エントリーの作成・更新・削除では取引のバージョンも進める（取引の If-Match による排他制御のため）
+func (s *JournalEntryService) CreateJournalEntries(userID uint, transactionID uint, req *dto.CreateJournalEntriesRequest, meta requestmeta.Meta) ([]models.JournalEntry, error) {
 	if transactionID == 0 {
 		return nil, errors.New("transaction ID is required")
 	}
 
-	if req.Amount <= 0 {
-		return nil, errors.New("amount must be greater than 0")
+	entries := make([]models.JournalEntry, len(req.JournalEntries))
+	for i, line := range req.JournalEntries {
+		entries[i] = models.JournalEntry{
+			TransactionID:     transactionID,
+			ChartOfAccountsID: line.ChartOfAccountsID,
+			Type:              line.Type,
+			Amount:            line.Amount,
+			Description:       line.Description,
+		}
 	}
 
-	entry := &models.JournalEntry{
-		TransactionID:     transactionID,
-		ChartOfAccountsID: req.ChartOfAccountsID,
-		Type:              req.Type,
-		Amount:            req.Amount,
-		Description:       req.Description,
-	}
+	err := s.uow.Do(func(tx *gorm.DB) error {
+		txRepo := s.repo.WithTx(tx)
 
-	if err := s.repo.Create(entry); err != nil {
+		// 所有者の確認と取引日の取得は、書き込みと同じDBトランザクションで取引をロックして行う
+		transaction, err := s.lockOwnedTransaction(txRepo, userID, transactionID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.validateLines(s.validator.WithTx(tx), userID, transaction.Date, entries); err != nil {
+			return err
+		}
+
+		if err := txRepo.CreateBatch(entries); err != nil {
+			return err
+		}
+
+		if err := s.ensureBalanced(txRepo, transactionID); err != nil {
+			return err
+		}
+
+		if err := txRepo.IncrementTransactionVersion(transactionID); err != nil {
+			return err
+		}
+
+		audit := s.audit.WithTx(tx)
+		for i := range entries {
+			if err := audit.Record(meta, auditService.Entry{
+				Action:     models.AuditActionCreate,
+				EntityType: models.AuditEntityJournalEntry,
+				EntityID:   entries[i].ID,
+				After:      dto.ToJournalEntryResponse(&entries[i]),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return entry, nil
+	return entries, nil
 }
 
 // GetJournalEntryByID: IDで仕訳エントリーを取得
-func (s *JournalEntryService) GetJournalEntryByID(id uint) (*models.JournalEntry, error) {
+func (s *JournalEntryService) GetJournalEntryByID(userID uint, id uint) (*models.JournalEntry, error) {
 	if id == 0 {
 		return nil, errors.New("journal entry ID is required")
 	}
 
-	entry, err := s.repo.GetByID(id)
-	if err != nil {
-		return nil, err
-	}
-
-	return entry, nil
+	return s.getOwnedEntry(s.repo, userID, id)
 }
 
 // GetJournalEntriesByTransactionID: 取引IDで仕訳エントリーの一覧を取得
-func (s *JournalEntryService) GetJournalEntriesByTransactionID(transactionID uint) ([]models.JournalEntry, error) {
+func (s *JournalEntryService) GetJournalEntriesByTransactionID(userID uint, transactionID uint) ([]models.JournalEntry, error) {
 	if transactionID == 0 {
 		return nil, errors.New("transaction ID is required")
 	}
 
+	if _, err := s.ensureTransactionOwner(s.repo, userID, transactionID); err != nil {
+		return nil, err
+	}
+
 	entries, err := s.repo.GetByTransactionID(transactionID)
 	if err != nil {
 		return nil, err
@@ -72,49 +147,21 @@ func (s *JournalEntryService) GetJournalEntriesByTransactionID(transactionID uin
 
 // ValidateTransaction: 取引がバランスしているか確認（複式簿記の基本原則）
 func (s *JournalEntryService) ValidateTransaction(transactionID uint) (bool, error) {
-	entries, err := s.repo.GetByTransactionID(transactionID)
-	if err != nil {
-		return false, err
-	}
-
-	// 最低2つのエントリーが必要（借方1 + 貸方1）
-	if len(entries) < 2 {
-		return false, errors.New("transaction must have at least 2 entries (debit and credit)")
-	}
-
-	// 借方と貸方の両方が存在するか確認
-	hasDebit := false
-	hasCredit := false
-
-	for _, entry := range entries {
-		if entry.Type == models.DebitEntry {
-			hasDebit = true
-		} else if entry.Type == models.CreditEntry {
-			hasCredit = true
-		}
-	}
-
-	if !hasDebit || !hasCredit {
-		return false, errors.New("transaction must have both debit and credit entries")
-	}
+	return s.validateTransaction(s.repo, transactionID)
+}
 
-	// 借方合計 = 貸方合計を確認
-	isBalanced, err := s.repo.IsBalanced(transactionID)
-	if err != nil {
+// ValidateTransactionForUser: 所有者確認を行ったうえで取引のバランスを確認
+func (s *JournalEntryService) ValidateTransactionForUser(userID uint, transactionID uint) (bool, error) {
+	if _, err := s.ensureTransactionOwner(s.repo, userID, transactionID); err != nil {
 		return false, err
 	}
 
-	if !isBalanced {
-		debitTotal, _ := s.repo.CalculateDebitTotal(transactionID)
-		creditTotal, _ := s.repo.CalculateCreditTotal(transactionID)
-		return false, errors.New("debit total must equal credit total (debit: " + string(rune(debitTotal)) + ", credit: " + string(rune(creditTotal)) + ")")
-	}
-
-	return true, nil
+	return s.validateTransaction(s.repo, transactionID)
 }
 
 // UpdateJournalEntry: 仕訳エントリーを更新
-func (s *JournalEntryService) UpdateJournalEntry(id uint, req *dto.CreateJournalEntryRequest) (*models.JournalEntry, error) {
+// 更新と貸借一致の再検証は同一のDBトランザクションで行い、一致しない場合はロールバックする
+func (s *JournalEntryService) UpdateJournalEntry(userID uint, id uint, req *dto.CreateJournalEntryRequest, meta requestmeta.Meta) (*models.JournalEntry, error) {
 	if id == 0 {
 		return nil, errors.New("journal entry ID is required")
 	}
@@ -123,17 +170,50 @@ func (s *JournalEntryService) UpdateJournalEntry(id uint, req *dto.CreateJournal
 		return nil, errors.New("amount must be greater than 0")
 	}
 
-	entry, err := s.repo.GetByID(id)
-	if err != nil {
-		return nil, err
-	}
+	var entry *models.JournalEntry
+	err := s.uow.Do(func(tx *gorm.DB) error {
+		txRepo := s.repo.WithTx(tx)
+
+		var err error
+		entry, err = s.lockOwnedEntry(txRepo, userID, id)
+		if err != nil {
+			return err
+		}
 
-	entry.ChartOfAccountsID = req.ChartOfAccountsID
-	entry.Type = req.Type
-	entry.Amount = req.Amount
-	entry.Description = req.Description
+		before := dto.ToJournalEntryResponse(entry)
 
-	if err := s.repo.Update(entry); err != nil {
+		entry.ChartOfAccountsID = req.ChartOfAccountsID
+		entry.Type = req.Type
+		entry.Amount = req.Amount
+		entry.Description = req.Description
+		// 勘定科目が変わる可能性があるため、古いリレーションは保存対象から外す
+		entry.ChartOfAccounts = nil
+
+		if err := s.validateLines(s.validator.WithTx(tx), userID, entry.Transaction.Date, []models.JournalEntry{*entry}); err != nil {
+			return err
+		}
+
+		if err := txRepo.Update(entry); err != nil {
+			return err
+		}
+
+		if err := s.ensureBalanced(txRepo, entry.TransactionID); err != nil {
+			return err
+		}
+
+		if err := txRepo.IncrementTransactionVersion(entry.TransactionID); err != nil {
+			return err
+		}
+
+		return s.audit.WithTx(tx).Record(meta, auditService.Entry{
+			Action:     models.AuditActionUpdate,
+			EntityType: models.AuditEntityJournalEntry,
+			EntityID:   entry.ID,
+			Before:     before,
+			After:      dto.ToJournalEntryResponse(entry),
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -141,12 +221,43 @@ func (s *JournalEntryService) UpdateJournalEntry(id uint, req *dto.CreateJournal
 }
 
 // DeleteJournalEntry: 仕訳エントリーを削除
-func (s *JournalEntryService) DeleteJournalEntry(id uint) error {
+// 削除後に取引が貸借一致しなくなる場合はロールバックする
+func (s *JournalEntryService) DeleteJournalEntry(userID uint, id uint, meta requestmeta.Meta) error {
 	if id == 0 {
 		return errors.New("journal entry ID is required")
 	}
 
-	return s.repo.Delete(id)
+	return s.uow.Do(func(tx *gorm.DB) error {
+		txRepo := s.repo.WithTx(tx)
+
+		entry, err := s.lockOwnedEntry(txRepo, userID, id)
+		if err != nil {
+			return err
+		}
+
+		if err := s.validator.WithTx(tx).ValidatePeriodOpen(userID, entry.Transaction.Date); err != nil {
+			return err
+		}
+
+		if err := txRepo.Delete(id); err != nil {
+			return err
+		}
+
+		if err := s.ensureBalanced(txRepo, entry.TransactionID); err != nil {
+			return err
+		}
+
+		if err := txRepo.IncrementTransactionVersion(entry.TransactionID); err != nil {
+			return err
+		}
+
+		return s.audit.WithTx(tx).Record(meta, auditService.Entry{
+			Action:     models.AuditActionDelete,
+			EntityType: models.AuditEntityJournalEntry,
+			EntityID:   id,
+			Before:     dto.ToJournalEntryResponse(entry),
+		})
+	})
 }
 
 // GetJournalEntriesByTransactionIDWithValidation: 取引IDで仕訳エントリーを取得（バリデーション付き）
@@ -165,6 +276,7 @@ func (s *JournalEntryService) GetJournalEntriesByTransactionIDWithValidation(tra
 }
 
 // CalculateAccountBalance: 勘定科目の残高を計算
+// 整合性チェックで隔離された取引と、取引が存在しない仕訳エントリーは含めない
 func (s *JournalEntryService) CalculateAccountBalance(chartOfAccountsID uint, normalBalance models.NormalBalance) (int, error) {
 	entries, err := s.repo.GetEntriesByChartOfAccountsID(chartOfAccountsID)
 	if err != nil {
@@ -174,6 +286,10 @@ func (s *JournalEntryService) CalculateAccountBalance(chartOfAccountsID uint, no
 	var balance int
 
 	for _, entry := range entries {
+		if entry.Transaction == nil || entry.Transaction.QuarantinedAt != nil {
+			continue
+		}
+
 		// 通常残高に基づいて借方/貸方の符号を決定
 		if normalBalance == models.DebitBalance {
 			// 借方が正常残高の場合、借方は+、貸方は-
@@ -194,3 +310,160 @@ func (s *JournalEntryService) CalculateAccountBalance(chartOfAccountsID uint, no
 
 	return balance, nil
 }
+
+// validateTransaction: 指定したリポジトリ（DBトランザクション）上で取引のバランスを確認
+func (s *JournalEntryService) validateTransaction(repo *repository.JournalEntryRepository, transactionID uint) (bool, error) {
+	entries, err := repo.GetByTransactionID(transactionID)
+	if err != nil {
+		return false, err
+	}
+
+	// 最低2つのエントリーが必要（借方1 + 貸方1）
+	if len(entries) < 2 {
+		return false, errors.New("transaction must have at least 2 entries (debit and credit)")
+	}
+
+	// 借方と貸方の両方が存在するか確認
+	hasDebit := false
+	hasCredit := false
+
+	for _, entry := range entries {
+		if entry.Type == models.DebitEntry {
+			hasDebit = true
+		} else if entry.Type == models.CreditEntry {
+			hasCredit = true
+		}
+	}
+
+	if !hasDebit || !hasCredit {
+		return false, errors.New("transaction must have both debit and credit entries")
+	}
+
+	// 借方合計 = 貸方合計を確認
+	debitTotal, err := repo.CalculateDebitTotal(transactionID)
+	if err != nil {
+		return false, err
+	}
+
+	creditTotal, err := repo.CalculateCreditTotal(transactionID)
+	if err != nil {
+		return false, err
+	}
+
+	if debitTotal != creditTotal {
+		return false, fmt.Errorf("debit total must equal credit total (debit: %d, credit: %d)", debitTotal, creditTotal)
+	}
+
+	return true, nil
+}
+
+// ensureBalanced: 取引が貸借一致していなければ ErrTransactionUnbalanced を返す
+func (s *JournalEntryService) ensureBalanced(repo *repository.JournalEntryRepository, transactionID uint) error {
+	isValid, err := s.validateTransaction(repo, transactionID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrTransactionUnbalanced, err)
+	}
+	if !isValid {
+		return ErrTransactionUnbalanced
+	}
+	return nil
+}
+
+// ensureTransactionOwner: 取引がユーザーの所有であることを確認
+// 他ユーザーの取引は存在しないものとして扱い、IDの推測による情報漏えいを防ぐ
+func (s *JournalEntryService) ensureTransactionOwner(repo *repository.JournalEntryRepository, userID uint, transactionID uint) (*models.Transaction, error) {
+	transaction, err := repo.GetTransactionByID(transactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	if transaction.UserID != userID {
+		return nil, ErrTransactionNotFound
+	}
+
+	return transaction, nil
+}
+
+// lockOwnedTransaction: DBトランザクション内で取引をロックし、ユーザーの所有であることを確認
+// 確認から書き込みまでの間に取引が削除・変更されないようにする
+func (s *JournalEntryService) lockOwnedTransaction(repo *repository.JournalEntryRepository, userID uint, transactionID uint) (*models.Transaction, error) {
+	transaction, err := repo.LockTransactionByID(transactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	if transaction.UserID != userID {
+		return nil, ErrTransactionNotFound
+	}
+
+	return transaction, nil
+}
+
+// lockOwnedEntry: DBトランザクション内で仕訳エントリーの親となる取引をロックし、所有者を確認したうえで仕訳エントリーを取得
+// ロックの前に読んだ仕訳エントリーは削除・付け替えされている可能性があるため、取引のロック後に読み直す
+func (s *JournalEntryService) lockOwnedEntry(repo *repository.JournalEntryRepository, userID uint, id uint) (*models.JournalEntry, error) {
+	current, err := s.getOwnedEntry(repo, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction, err := s.lockOwnedTransaction(repo, userID, current.TransactionID)
+	if err != nil {
+		if errors.Is(err, ErrTransactionNotFound) {
+			return nil, ErrJournalEntryNotFound
+		}
+		return nil, err
+	}
+
+	entry, err := repo.LockByIDInTransaction(id, transaction.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJournalEntryNotFound
+		}
+		return nil, err
+	}
+	entry.Transaction = transaction
+
+	return entry, nil
+}
+
+// validateLines: 追加・変更する仕訳行を記帳検証にかける（貸借一致は書き込み後に ensureBalanced で確認する）
+func (s *JournalEntryService) validateLines(validator postingService.PostingValidator, userID uint, date time.Time, entries []models.JournalEntry) error {
+	lines := make([]postingService.Line, len(entries))
+	for i, entry := range entries {
+		lines[i] = postingService.Line{
+			ChartOfAccountsID: entry.ChartOfAccountsID,
+			Type:              entry.Type,
+			Amount:            entry.Amount,
+			Description:       entry.Description,
+		}
+	}
+	return validator.Validate(&postingService.Posting{
+		UserID: userID,
+		Date:   date,
+		Lines:  lines,
+	})
+}
+
+// getOwnedEntry: ユーザーが所有する取引に属する仕訳エントリーを取得
+func (s *JournalEntryService) getOwnedEntry(repo *repository.JournalEntryRepository, userID uint, id uint) (*models.JournalEntry, error) {
+	entry, err := repo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJournalEntryNotFound
+		}
+		return nil, err
+	}
+
+	if entry.Transaction == nil || entry.Transaction.UserID != userID {
+		return nil, ErrJournalEntryNotFound
+	}
+
+	return entry, nil
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	auditRepository "simple-ledger/internal/audit/repository"
+	auditService "simple-ledger/internal/audit/service"
+	chartOfAccountsRepository "simple-ledger/internal/chart_of_accounts/repository"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/requestmeta"
+	fiscalPeriodRepository "simple-ledger/internal/fiscal_period/repository"
+	"simple-ledger/internal/journal_entry/dto"
+	"simple-ledger/internal/journal_entry/repository"
+	"simple-ledger/internal/models"
+	postingService "simple-ledger/internal/posting/service"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupJournalEntryServiceTest: ユーザー2名と、ユーザー1が所有する貸借一致した取引を作成
+func setupJournalEntryServiceTest() (*JournalEntryService, *gorm.DB, models.Transaction) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}, &models.FiscalPeriod{}, &models.AuditLog{}, &models.AuditChainHead{}); err != nil {
+		panic(err)
+	}
+
+	owner := models.User{Email: "owner@example.com", Name: "Owner", Password: "hashed", Role: "user", IsActive: true}
+	db.Create(&owner)
+	other := models.User{Email: "other@example.com", Name: "Other", Password: "hashed", Role: "user", IsActive: true}
+	db.Create(&other)
+
+	cash := models.ChartOfAccounts{Code: "1000", Name: "現金", Type: models.AssetAccount, NormalBalance: models.DebitBalance, IsActive: true}
+	db.Create(&cash)
+	sales := models.ChartOfAccounts{Code: "4000", Name: "売上", Type: models.RevenueAccount, NormalBalance: models.CreditBalance, IsActive: true}
+	db.Create(&sales)
+
+	transaction := models.Transaction{
+		UserID:      owner.ID,
+		Date:        time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+		Description: "商品販売",
+		JournalEntries: []models.JournalEntry{
+			{ChartOfAccountsID: cash.ID, Type: models.DebitEntry, Amount: 1000},
+			{ChartOfAccountsID: sales.ID, Type: models.CreditEntry, Amount: 1000},
+		},
+	}
+	db.Create(&transaction)
+
+	validator := postingService.NewPostingValidator(
+		chartOfAccountsRepository.NewChartOfAccountsRepository(db),
+		fiscalPeriodRepository.NewFiscalPeriodRepository(db),
+		postingService.DefaultConfig(),
+	)
+
+	auditSvc := auditService.NewAuditService(auditRepository.NewAuditLogRepository(db))
+
+	return NewJournalEntryService(repository.NewJournalEntryRepository(db), validator, auditSvc, uow.New(db)), db, transaction
+}
+
+func TestGetJournalEntryByID_OtherUsersEntryIsNotFound(t *testing.T) {
+	svc, _, transaction := setupJournalEntryServiceTest()
+	entryID := transaction.JournalEntries[0].ID
+
+	entry, err := svc.GetJournalEntryByID(1, entryID)
+	assert.NoError(t, err)
+	assert.Equal(t, entryID, entry.ID)
+
+	entry, err = svc.GetJournalEntryByID(2, entryID)
+	assert.ErrorIs(t, err, ErrJournalEntryNotFound)
+	assert.Nil(t, entry)
+}
+
+func TestGetJournalEntriesByTransactionID_OtherUsersTransactionIsNotFound(t *testing.T) {
+	svc, _, transaction := setupJournalEntryServiceTest()
+
+	entries, err := svc.GetJournalEntriesByTransactionID(1, transaction.ID)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	entries, err = svc.GetJournalEntriesByTransactionID(2, transaction.ID)
+	assert.ErrorIs(t, err, ErrTransactionNotFound)
+	assert.Nil(t, entries)
+}
+
+// balancedLines: 現金（借方）と売上（貸方）の貸借一致した追加リクエスト
+func balancedLines(amount int) *dto.CreateJournalEntriesRequest {
+	return &dto.CreateJournalEntriesRequest{JournalEntries: []dto.CreateJournalEntryRequest{
+		{ChartOfAccountsID: 1, Type: models.DebitEntry, Amount: amount},
+		{ChartOfAccountsID: 2, Type: models.CreditEntry, Amount: amount},
+	}}
+}
+
+func TestCreateJournalEntries_BalancedLinesSucceed(t *testing.T) {
+	svc, db, transaction := setupJournalEntryServiceTest()
+
+	entries, err := svc.CreateJournalEntries(1, transaction.ID, balancedLines(500), requestmeta.Meta{})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	for _, entry := range entries {
+		assert.NotZero(t, entry.ID)
+		assert.Equal(t, transaction.ID, entry.TransactionID)
+	}
+
+	var count int64
+	db.Model(&models.JournalEntry{}).Where("transaction_id = ?", transaction.ID).Count(&count)
+	assert.Equal(t, int64(4), count)
+
+	isValid, err := svc.ValidateTransaction(transaction.ID)
+	assert.NoError(t, err)
+	assert.True(t, isValid)
+
+	var stored models.Transaction
+	db.First(&stored, transaction.ID)
+	assert.Equal(t, transaction.Version+1, stored.Version)
+}
+
+func TestCreateJournalEntries_OtherUsersTransactionIsRejected(t *testing.T) {
+	svc, db, transaction := setupJournalEntryServiceTest()
+
+	entries, err := svc.CreateJournalEntries(2, transaction.ID, balancedLines(500), requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrTransactionNotFound)
+	assert.Nil(t, entries)
+
+	var count int64
+	db.Model(&models.JournalEntry{}).Where("transaction_id = ?", transaction.ID).Count(&count)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestCreateJournalEntries_UnbalancedIsRolledBack(t *testing.T) {
+	svc, db, transaction := setupJournalEntryServiceTest()
+
+	req := balancedLines(500)
+	req.JournalEntries[1].Amount = 300
+	entries, err := svc.CreateJournalEntries(1, transaction.ID, req, requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrTransactionUnbalanced)
+	assert.Nil(t, entries)
+
+	var count int64
+	db.Model(&models.JournalEntry{}).Where("transaction_id = ?", transaction.ID).Count(&count)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestUpdateJournalEntry_BalancedChangeSucceeds(t *testing.T) {
+	svc, _, transaction := setupJournalEntryServiceTest()
+	debit := transaction.JournalEntries[0]
+
+	// 金額を変えずに摘要だけ変更する場合は貸借一致を維持できる
+	req := &dto.CreateJournalEntryRequest{
+		ChartOfAccountsID: debit.ChartOfAccountsID,
+		Type:              models.DebitEntry,
+		Amount:            1000,
+		Description:       "摘要を修正",
+	}
+	entry, err := svc.UpdateJournalEntry(1, debit.ID, req, requestmeta.Meta{})
+	assert.NoError(t, err)
+	assert.Equal(t, "摘要を修正", entry.Description)
+}
+
+func TestUpdateJournalEntry_IncrementsTransactionVersion(t *testing.T) {
+	svc, db, transaction := setupJournalEntryServiceTest()
+	debit := transaction.JournalEntries[0]
+	version := func() uint {
+		var stored models.Transaction
+		db.First(&stored, transaction.ID)
+		return stored.Version
+	}
+	initial := version()
+
+	// 貸借一致しない変更はロールバックされ、バージョンも変わらない
+	_, err := svc.UpdateJournalEntry(1, debit.ID, &dto.CreateJournalEntryRequest{ChartOfAccountsID: debit.ChartOfAccountsID, Type: models.DebitEntry, Amount: 500}, requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrTransactionUnbalanced)
+	assert.Equal(t, initial, version())
+
+	// 取引の ETag で同時に行われた変更を検出できるよう、仕訳エントリーの変更でもバージョンを進める
+	_, err = svc.UpdateJournalEntry(1, debit.ID, &dto.CreateJournalEntryRequest{ChartOfAccountsID: debit.ChartOfAccountsID, Type: models.DebitEntry, Amount: 1000, Description: "摘要を修正"}, requestmeta.Meta{})
+	assert.NoError(t, err)
+	assert.Equal(t, initial+1, version())
+}
+
+func TestUpdateJournalEntry_UnbalancedIsRolledBack(t *testing.T) {
+	svc, db, transaction := setupJournalEntryServiceTest()
+	debit := transaction.JournalEntries[0]
+
+	req := &dto.CreateJournalEntryRequest{ChartOfAccountsID: debit.ChartOfAccountsID, Type: models.DebitEntry, Amount: 999}
+	entry, err := svc.UpdateJournalEntry(1, debit.ID, req, requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrTransactionUnbalanced)
+	assert.Nil(t, entry)
+
+	var stored models.JournalEntry
+	db.First(&stored, debit.ID)
+	assert.Equal(t, 1000, stored.Amount)
+}
+
+func TestUpdateJournalEntry_OtherUsersEntryIsRejected(t *testing.T) {
+	svc, _, transaction := setupJournalEntryServiceTest()
+	debit := transaction.JournalEntries[0]
+
+	req := &dto.CreateJournalEntryRequest{ChartOfAccountsID: debit.ChartOfAccountsID, Type: models.DebitEntry, Amount: 1000}
+	entry, err := svc.UpdateJournalEntry(2, debit.ID, req, requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrJournalEntryNotFound)
+	assert.Nil(t, entry)
+}
+
+func TestDeleteJournalEntry_UnbalancedIsRolledBack(t *testing.T) {
+	svc, db, transaction := setupJournalEntryServiceTest()
+	credit := transaction.JournalEntries[1]
+
+	err := svc.DeleteJournalEntry(1, credit.ID, requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrTransactionUnbalanced)
+
+	var count int64
+	db.Model(&models.JournalEntry{}).Where("id = ?", credit.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestDeleteJournalEntry_OtherUsersEntryIsRejected(t *testing.T) {
+	svc, _, transaction := setupJournalEntryServiceTest()
+
+	err := svc.DeleteJournalEntry(2, transaction.JournalEntries[1].ID, requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrJournalEntryNotFound)
+}
+
+func TestCreateJournalEntries_InactiveAccountIsRejected(t *testing.T) {
+	svc, db, transaction := setupJournalEntryServiceTest()
+
+	inactive := models.ChartOfAccounts{Code: "9000", Name: "旧科目", Type: models.ExpenseAccount, NormalBalance: models.DebitBalance, IsActive: true}
+	db.Create(&inactive)
+	db.Model(&inactive).Update("is_active", false)
+
+	req := balancedLines(500)
+	req.JournalEntries[0].ChartOfAccountsID = inactive.ID
+	entries, err := svc.CreateJournalEntries(1, transaction.ID, req, requestmeta.Meta{})
+	var validationErr *postingService.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Nil(t, entries)
+}
+
+func TestDeleteJournalEntry_ClosedPeriodIsRejected(t *testing.T) {
+	svc, db, transaction := setupJournalEntryServiceTest()
+
+	closedAt := time.Now()
+	db.Create(&models.FiscalPeriod{
+		UserID:    1,
+		StartDate: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+		ClosedAt:  &closedAt,
+	})
+
+	err := svc.DeleteJournalEntry(1, transaction.JournalEntries[1].ID, requestmeta.Meta{})
+	var validationErr *postingService.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+}
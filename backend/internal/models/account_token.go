@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// アカウント操作トークンの用途
+const (
+	AccountTokenPasswordReset     = "password_reset"     // パスワード再設定
+	AccountTokenEmailVerification = "email_verification" // メールアドレスの確認
+)
+
+// AccountToken: メールで送信するアカウント操作用トークン（パスワード再設定・メールアドレスの確認）
+// トークン自体は署名付きで、ここには jti のみを記録して一度だけ使えるようにする
+type AccountToken struct {
+	// ID: トークンの一意識別子（主キー）
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// UserID: ユーザーID（外部キー）
+	UserID uint `gorm:"not null;index:idx_account_token_user_purpose" json:"userId"`
+
+	// Purpose: 用途（password_reset / email_verification）
+	Purpose string `gorm:"type:varchar(50);not null;index:idx_account_token_user_purpose" json:"purpose"`
+
+	// TokenID: トークンの jti クレーム
+	TokenID string `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+
+	// Email: 発行時の送信先メールアドレス
+	Email string `gorm:"type:varchar(255);not null" json:"email"`
+
+	// ExpiresAt: 有効期限
+	ExpiresAt time.Time `gorm:"not null" json:"expiresAt"`
+
+	// UsedAt: 使用日時（使用済み・無効化済みの場合に設定）
+	UsedAt *time.Time `json:"usedAt"`
+
+	// CreatedAt: 発行日時
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// AccountToken 構造体は account_tokens テーブルにマッピングされることを明示する
+func (AccountToken) TableName() string {
+	return "account_tokens"
+}
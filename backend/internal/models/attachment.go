@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Attachment: 取引に添付された証憑（領収書・請求書・PDFなど）
+// 電子帳簿保存法の検索要件（取引年月日・取引金額・取引先）を満たすため、検索項目を添付ファイル側に保持する
+type Attachment struct {
+	// ID: 添付ファイルの一意識別子（主キー）
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// UserID: 所有ユーザーID
+	UserID uint `gorm:"not null;index:idx_attachment_user_date" json:"userId"`
+
+	// TransactionID: 添付先の取引ID
+	TransactionID uint `gorm:"not null;index" json:"transactionId"`
+
+	// TransactionDate: 取引年月日（検索項目）
+	TransactionDate time.Time `gorm:"type:date;not null;index:idx_attachment_user_date" json:"transactionDate"`
+
+	// Amount: 取引金額（検索項目）
+	Amount int `gorm:"not null;index" json:"amount"`
+
+	// AmountSpecified: 取引金額をアップロード時に指定したか（false の場合は取引の変更時に借方合計に合わせて更新する）
+	AmountSpecified bool `gorm:"not null;default:false" json:"amountSpecified"`
+
+	// Counterparty: 取引先（検索項目）
+	Counterparty string `gorm:"type:varchar(255);index" json:"counterparty"`
+
+	// FileName: アップロード時のファイル名
+	FileName string `gorm:"type:varchar(255);not null" json:"fileName"`
+
+	// ContentType: ファイルの種類（MIMEタイプ）
+	ContentType string `gorm:"type:varchar(100);not null" json:"contentType"`
+
+	// Size: ファイルサイズ（バイト）
+	Size int64 `gorm:"not null" json:"size"`
+
+	// StorageKey: ストレージ上のキー
+	StorageKey string `gorm:"type:varchar(255);not null;uniqueIndex" json:"-"`
+
+	// SHA256: ファイル内容のハッシュ（改ざん検出用、16進数）
+	SHA256 string `gorm:"column:sha256;type:varchar(64);not null" json:"sha256"`
+
+	// CreatedAt: アップロード日時
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Attachment 構造体は attachments テーブルにマッピングされることを明示する
+func (Attachment) TableName() string {
+	return "attachments"
+}
@@ -0,0 +1,102 @@
+package models
+
+import "time"
+
+type AuditAction string
+
+const (
+	AuditActionCreate     AuditAction = "create"      // 作成
+	AuditActionUpdate     AuditAction = "update"      // 更新
+	AuditActionDelete     AuditAction = "delete"      // 削除
+	AuditActionCorrect    AuditAction = "correct"     // 修正取引による訂正
+	AuditActionLock       AuditAction = "lock"        // ログイン失敗によるアカウントロック
+	AuditActionUnlock     AuditAction = "unlock"      // アカウントロックの解除
+	AuditActionMFAEnable  AuditAction = "mfa_enable"  // 二段階認証の有効化
+	AuditActionMFADisable AuditAction = "mfa_disable" // 二段階認証の無効化・管理者による解除
+	AuditActionClose      AuditAction = "close"       // 会計期間の締め
+	AuditActionQuarantine AuditAction = "quarantine"  // 整合性チェックによる取引の隔離
+	AuditActionRelease    AuditAction = "release"     // 問題が解消された取引の隔離の解除
+)
+
+type AuditEntityType string
+
+const (
+	AuditEntityTransaction         AuditEntityType = "transaction"           // 取引
+	AuditEntityJournalEntry        AuditEntityType = "journal_entry"         // 仕訳エントリー
+	AuditEntityChartOfAccounts     AuditEntityType = "chart_of_accounts"     // 勘定科目
+	AuditEntityUser                AuditEntityType = "user"                  // ユーザー
+	AuditEntityAttachment          AuditEntityType = "attachment"            // 添付ファイル
+	AuditEntityMFAPolicy           AuditEntityType = "mfa_policy"            // ロールごとの二段階認証の必須設定
+	AuditEntityPersonalAccessToken AuditEntityType = "personal_access_token" // 個人用アクセストークン
+	AuditEntityUserIdentity        AuditEntityType = "user_identity"         // シングルサインオンのアカウントの紐づけ
+	AuditEntityFiscalPeriod        AuditEntityType = "fiscal_period"         // 会計期間
+)
+
+// AuditLog: 監査ログ（電子帳簿保存法に基づく訂正・削除履歴）
+// 各レコードは直前のレコードのハッシュを含めてハッシュ化され、改ざん・削除を検出できる
+type AuditLog struct {
+	// ID: 監査ログの一意識別子（主キー）
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// Sequence: チェーン上の連番（1始まり、欠番は削除の痕跡）
+	Sequence uint64 `gorm:"not null;uniqueIndex" json:"sequence"`
+
+	// ActorID: 操作したユーザーID（未認証の操作の場合は NULL）
+	ActorID *uint `gorm:"index" json:"actorId"`
+
+	// Action: 操作の種類
+	Action AuditAction `gorm:"type:varchar(50);not null" json:"action"`
+
+	// EntityType: 対象エンティティの種類
+	EntityType AuditEntityType `gorm:"type:varchar(50);not null;index:idx_audit_entity" json:"entityType"`
+
+	// EntityID: 対象エンティティのID
+	EntityID uint `gorm:"not null;index:idx_audit_entity" json:"entityId"`
+
+	// RequestID: 操作を行ったリクエストのID
+	RequestID string `gorm:"type:varchar(64)" json:"requestId"`
+
+	// IPAddress: 操作元のIPアドレス
+	IPAddress string `gorm:"type:varchar(45)" json:"ipAddress"`
+
+	// Before: 変更前のJSON（作成の場合は空）
+	Before string `gorm:"type:text" json:"before"`
+
+	// After: 変更後のJSON（削除の場合は空）
+	After string `gorm:"type:text" json:"after"`
+
+	// PrevHash: 直前のレコードのハッシュ（先頭レコードの場合は空）
+	PrevHash string `gorm:"type:varchar(64)" json:"prevHash"`
+
+	// Hash: このレコードのハッシュ（SHA-256、16進数）
+	Hash string `gorm:"type:varchar(64);not null" json:"hash"`
+
+	// CreatedAt: 記録日時
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// AuditLog 構造体は audit_logs テーブルにマッピングされることを明示する
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// AuditChainHead: 監査ログチェーンの末尾（1行のみ）
+// 追記時にこの行をロックして連番を直列化し、末尾レコードの削除も検出できるようにする
+type AuditChainHead struct {
+	// ID: 常に 1
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// Sequence: 最後に記録したレコードの連番
+	Sequence uint64 `gorm:"not null" json:"sequence"`
+
+	// Hash: 最後に記録したレコードのハッシュ
+	Hash string `gorm:"type:varchar(64)" json:"hash"`
+
+	// UpdatedAt: 最終更新日時
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// AuditChainHead 構造体は audit_chain_heads テーブルにマッピングされることを明示する
+func (AuditChainHead) TableName() string {
+	return "audit_chain_heads"
+}
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// AuthSession: ログインセッション（リフレッシュトークンのファミリー）
+// ログインごとに1行作成され、リフレッシュのたびに同じファミリー内で新しいリフレッシュトークンが発行される
+type AuthSession struct {
+	// ID: セッションの一意識別子（主キー）
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// UserID: ユーザーID（外部キー）
+	UserID uint `gorm:"not null;index" json:"userId"`
+
+	// FamilyID: トークンファミリーID（リフレッシュトークンの sid クレームに含まれるランダム値）
+	FamilyID string `gorm:"type:varchar(64);not null;uniqueIndex" json:"familyId"`
+
+	// UserAgent: ログイン時の User-Agent
+	UserAgent string `gorm:"type:varchar(512)" json:"userAgent"`
+
+	// IPAddress: ログイン時のIPアドレス
+	IPAddress string `gorm:"type:varchar(45)" json:"ipAddress"`
+
+	// TokenVersion: セッションが有効なユーザーのトークンバージョン（ロール・パスワード・有効/無効の変更後はリフレッシュできない）
+	TokenVersion uint `gorm:"not null;default:0" json:"-"`
+
+	// LastUsedAt: 最後にリフレッシュした日時
+	LastUsedAt time.Time `json:"lastUsedAt"`
+
+	// RevokedAt: 失効日時（NULL の場合は有効）
+	RevokedAt *time.Time `json:"revokedAt"`
+
+	// RevokedReason: 失効理由（logout, logout_all, reuse_detected, password_reset, password_change, admin_update など）
+	RevokedReason string `gorm:"type:varchar(50)" json:"revokedReason"`
+
+	// CreatedAt: ログイン日時
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// AuthSession 構造体は auth_sessions テーブルにマッピングされることを明示する
+func (AuthSession) TableName() string {
+	return "auth_sessions"
+}
+
+// RefreshToken: 発行済みリフレッシュトークン
+// トークン本体は保存せず、JWT の jti（ランダム値）のみを保持する
+type RefreshToken struct {
+	// ID: リフレッシュトークンの一意識別子（主キー）
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// SessionID: 所属するセッションID（外部キー）
+	SessionID uint `gorm:"not null;index" json:"sessionId"`
+
+	// TokenID: JWT の jti クレーム
+	TokenID string `gorm:"type:varchar(64);not null;uniqueIndex" json:"tokenId"`
+
+	// ExpiresAt: 有効期限
+	ExpiresAt time.Time `gorm:"not null" json:"expiresAt"`
+
+	// RotatedAt: 新しいトークンと交換された日時（NULL の場合は未使用）
+	// 交換済みのトークンが再提示された場合は漏洩とみなし、セッション全体を失効させる
+	RotatedAt *time.Time `json:"rotatedAt"`
+
+	// CreatedAt: 発行日時
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// RefreshToken 構造体は refresh_tokens テーブルにマッピングされることを明示する
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// FiscalPeriod: 会計期間（締め処理の単位）
+type FiscalPeriod struct {
+	// ID: 会計期間の一意識別子（主キー）
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// UserID: ユーザーID（外部キー）
+	UserID uint `gorm:"not null;index:idx_fiscal_period_user_start" json:"userId"`
+
+	// StartDate: 期間の開始日（この日を含む）
+	StartDate time.Time `gorm:"type:date;not null;index:idx_fiscal_period_user_start" json:"startDate"`
+
+	// EndDate: 期間の終了日（この日を含む）
+	EndDate time.Time `gorm:"type:date;not null" json:"endDate"`
+
+	// ClosedAt: 締め日時（NULL の場合は未締め）- 締め済み期間の取引は追加・変更・削除できない
+	ClosedAt *time.Time `json:"closedAt"`
+
+	// CreatedAt: 作成日時
+	CreatedAt time.Time `json:"createdAt"`
+
+	// UpdatedAt: 最終更新日時
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// FiscalPeriod 構造体は fiscal_periods テーブルにマッピングされることを明示する
+func (FiscalPeriod) TableName() string {
+	return "fiscal_periods"
+}
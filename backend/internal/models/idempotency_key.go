@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// IdempotencyKey: Idempotency-Key ヘッダー付きで受け付けた更新系リクエストと、その応答の記録
+// 同じキーで再送されたリクエストには処理を繰り返さず、記録した応答を返す
+type IdempotencyKey struct {
+	// ID: 主キー
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// UserID: リクエストしたユーザーのID（キーはユーザーごとに一意）
+	UserID uint `gorm:"not null;uniqueIndex:idx_idempotency_user_key" json:"userId"`
+
+	// Key: クライアントが指定した Idempotency-Key
+	Key string `gorm:"column:idempotency_key;type:varchar(255);not null;uniqueIndex:idx_idempotency_user_key" json:"key"`
+
+	// Fingerprint: リクエスト（メソッド・パス・本文）の SHA-256（同じキーで異なるリクエストが送られたことの検出に使う）
+	Fingerprint string `gorm:"type:varchar(64);not null" json:"-"`
+
+	// StatusCode: 記録した応答のステータスコード（0 の場合は処理中）
+	StatusCode int `gorm:"not null;default:0" json:"statusCode"`
+
+	// ResponseHeaders: 再送時に返す応答ヘッダー（JSON）
+	ResponseHeaders string `gorm:"type:text" json:"-"`
+
+	// ResponseBody: 記録した応答の本文
+	ResponseBody []byte `json:"-"`
+
+	// LockedUntil: 処理中の記録を他のリクエストに引き継がせない期限（応答の記録後は NULL）
+	// 処理中にプロセスが停止した場合、この期限を過ぎれば同じリクエストの再送で処理をやり直せる
+	LockedUntil *time.Time `json:"lockedUntil"`
+
+	// ExpiresAt: 記録の有効期限（過ぎた後は同じキーを新しいリクエストとして扱う）
+	ExpiresAt time.Time `gorm:"not null;index" json:"expiresAt"`
+
+	// CreatedAt: 受付日時
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// IdempotencyKey 構造体は idempotency_keys テーブルにマッピングされることを明示する
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
@@ -23,14 +23,14 @@ type JournalEntry struct {
 	// ChartOfAccountsID: 勘定科目ID（外部キー）
 	ChartOfAccountsID uint `gorm:"not null;index" json:"chartOfAccountsId"`
 
-	// ChartOfAccounts: リレーション（勘定科目）
-	ChartOfAccounts *ChartOfAccounts `gorm:"foreignKey:ChartOfAccountsID" json:"chartOfAccounts,omitempty"`
+	// ChartOfAccounts: リレーション（勘定科目）- 仕訳エントリーから参照されている勘定科目は削除できない
+	ChartOfAccounts *ChartOfAccounts `gorm:"foreignKey:ChartOfAccountsID;constraint:OnDelete:RESTRICT" json:"chartOfAccounts,omitempty"`
 
 	// Type: 仕訳のタイプ（debit/credit）
-	Type EntryType `gorm:"type:varchar(50);not null" json:"type"`
+	Type EntryType `gorm:"type:varchar(50);not null;check:chk_journal_entries_type,type IN ('debit','credit')" json:"type"`
 
-	// Amount: 金額
-	Amount int `gorm:"not null" json:"amount"`
+	// Amount: 金額（正の値のみ）
+	Amount int `gorm:"not null;check:chk_journal_entries_amount,amount > 0" json:"amount"`
 
 	// Description: 仕訳の説明（摘要）
 	Description string `gorm:"type:text" json:"description"`
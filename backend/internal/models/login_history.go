@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// ログイン失敗理由
+const (
+	LoginFailureInvalidCredentials    = "invalid_credentials"     // メールアドレスまたはパスワードの誤り
+	LoginFailureUserInactive          = "user_inactive"           // 無効化されたユーザー
+	LoginFailureLockedOut             = "locked_out"              // 失敗回数が上限に達しアカウントをロックした
+	LoginFailureAccountLocked         = "account_locked"          // ロック中のアカウントへの試行
+	LoginFailureInvalidMFACode        = "invalid_mfa_code"        // 二段階認証のコードの誤り
+	LoginFailurePasswordLoginDisabled = "password_login_disabled" // パスワードによるログインが禁止されたユーザー
+)
+
+// LoginHistory: ログイン履歴（成功・失敗の両方を記録）
+type LoginHistory struct {
+	// ID: ログイン履歴の一意識別子（主キー）
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// UserID: ユーザーID（存在しないメールアドレスでの試行の場合は NULL）
+	UserID *uint `gorm:"index" json:"userId"`
+
+	// Email: ログインに使用されたメールアドレス
+	Email string `gorm:"type:varchar(255);not null;index" json:"email"`
+
+	// Success: ログインに成功したかどうか
+	Success bool `gorm:"not null" json:"success"`
+
+	// FailureReason: 失敗理由（成功の場合は空）
+	FailureReason string `gorm:"type:varchar(50)" json:"failureReason"`
+
+	// SessionID: 成功時に作成されたセッションID
+	SessionID *uint `json:"sessionId"`
+
+	// IPAddress: ログイン元のIPアドレス
+	IPAddress string `gorm:"type:varchar(45)" json:"ipAddress"`
+
+	// UserAgent: ログイン時の User-Agent
+	UserAgent string `gorm:"type:varchar(512)" json:"userAgent"`
+
+	// CreatedAt: ログイン日時
+	CreatedAt time.Time `gorm:"index" json:"createdAt"`
+}
+
+// LoginHistory 構造体は login_histories テーブルにマッピングされることを明示する
+func (LoginHistory) TableName() string {
+	return "login_histories"
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// 個人用アクセストークンのスコープ
+const (
+	TokenScopeRead              = "read"               // 参照（GET）
+	TokenScopeTransactionsWrite = "transactions:write" // 取引・仕訳・添付ファイルの登録・更新・削除
+	TokenScopeAdmin             = "admin"              // 管理者用エンドポイントを含むすべての操作（管理者のみ発行可）
+)
+
+// PersonalAccessToken: スクリプトや CLI から API を利用するための個人用アクセストークン
+// トークン自体は発行時に一度だけ返し、ここにはハッシュのみを保存する
+type PersonalAccessToken struct {
+	// ID: トークンの一意識別子（主キー）
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// UserID: 発行したユーザーID（外部キー）
+	UserID uint `gorm:"not null;index" json:"userId"`
+
+	// Name: 用途を識別するための名前
+	Name string `gorm:"type:varchar(100);not null" json:"name"`
+
+	// TokenPrefix: 一覧での識別用にトークンの先頭部分のみを保持
+	TokenPrefix string `gorm:"type:varchar(16);not null" json:"tokenPrefix"`
+
+	// TokenHash: トークンの SHA-256 ハッシュ
+	TokenHash string `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+
+	// Scopes: スコープ（カンマ区切り）
+	Scopes string `gorm:"type:varchar(255);not null" json:"scopes"`
+
+	// ExpiresAt: 有効期限
+	ExpiresAt time.Time `gorm:"not null" json:"expiresAt"`
+
+	// LastUsedAt: 最終利用日時（未使用の場合は NULL）
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+
+	// RevokedAt: 失効日時（有効な場合は NULL）
+	RevokedAt *time.Time `json:"revokedAt"`
+
+	// CreatedAt: 発行日時
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// PersonalAccessToken 構造体は personal_access_tokens テーブルにマッピングされることを明示する
+func (PersonalAccessToken) TableName() string {
+	return "personal_access_tokens"
+}
+
+// ScopeList はスコープを配列で返す
+func (t *PersonalAccessToken) ScopeList() []string {
+	if t.Scopes == "" {
+		return nil
+	}
+	return strings.Split(t.Scopes, ",")
+}
+
+// HasScope はスコープを持つかどうかを返す
+func (t *PersonalAccessToken) HasScope(scope string) bool {
+	return slices.Contains(t.ScopeList(), scope)
+}
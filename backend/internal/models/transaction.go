@@ -19,8 +19,8 @@ type Transaction struct {
 	// Description: 取引の説明・摘要
 	Description string `gorm:"type:text" json:"description"`
 
-	// JournalEntries: リレーション（仕訳エントリー）- 1つの取引は複数の仕訳エントリーを持つ
-	JournalEntries []JournalEntry `gorm:"foreignKey:TransactionID" json:"journalEntries,omitempty"`
+	// JournalEntries: リレーション（仕訳エントリー）- 1つの取引は複数の仕訳エントリーを持つ（取引の削除時に一緒に削除される）
+	JournalEntries []JournalEntry `gorm:"foreignKey:TransactionID;constraint:OnDelete:CASCADE" json:"journalEntries,omitempty"`
 
 	// CorrectedFromID: 修正元の取引ID（修正の場合のみ）
 	CorrectedFromID *uint `gorm:"index" json:"correctedFromId,omitempty"`
@@ -34,6 +34,15 @@ type Transaction struct {
 	// CorrectionNote: 修正の理由・説明
 	CorrectionNote string `gorm:"type:text" json:"correctionNote"`
 
+	// QuarantinedAt: 整合性チェックで問題が見つかり隔離された日時（隔離されていない場合は NULL）
+	QuarantinedAt *time.Time `json:"quarantinedAt,omitempty"`
+
+	// QuarantineReason: 隔離の理由（検出された問題の種類）
+	QuarantineReason string `gorm:"type:text" json:"quarantineReason,omitempty"`
+
+	// Version: 楽観的排他制御のバージョン（変更のたびに1増える、ETag として返す）
+	Version uint `gorm:"not null;default:1" json:"version"`
+
 	// CreatedAt: 取引の作成日時
 	CreatedAt time.Time `gorm:"index:idx_user_date_created,sort:desc" json:"createdAt"`
 
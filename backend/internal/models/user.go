@@ -3,15 +3,19 @@ package models
 import "time"
 
 type User struct {
-	ID          uint       `gorm:"primaryKey" json:"id"`
-	Email       string     `gorm:"type:varchar(255);uniqueIndex" json:"email"`
-	Name        string     `gorm:"type:varchar(255);not null" json:"name"`
-	Password    string     `gorm:"type:text" json:"-"`
-	Role        string     `gorm:"type:varchar(50);not null" json:"role"` // "admin", "user"
-	IsActive    bool       `gorm:"default:true" json:"isActive"`          // 無効ユーザー管理用
-	LastLoginAt *time.Time `json:"lastLoginAt"`                           // 最終ログイン日時(NULLを許容)
-	CreatedAt   time.Time  `json:"createdAt"`
-	UpdatedAt   time.Time  `json:"updatedAt"`
+	ID                    uint       `gorm:"primaryKey" json:"id"`
+	Email                 string     `gorm:"type:varchar(255);uniqueIndex" json:"email"`
+	Name                  string     `gorm:"type:varchar(255);not null" json:"name"`
+	Password              string     `gorm:"type:text" json:"-"`
+	Role                  string     `gorm:"type:varchar(50);not null" json:"role"`               // "admin", "user"
+	IsActive              bool       `gorm:"default:true" json:"isActive"`                        // 無効ユーザー管理用
+	LastLoginAt           *time.Time `json:"lastLoginAt"`                                         // 最終ログイン日時(NULLを許容)
+	LockedUntil           *time.Time `json:"lockedUntil"`                                         // ログイン失敗によるロックの解除日時(NULLはロックなし)
+	EmailVerifiedAt       *time.Time `json:"emailVerifiedAt"`                                     // メールアドレスの確認日時(NULLは未確認)
+	PasswordLoginDisabled bool       `gorm:"not null;default:false" json:"passwordLoginDisabled"` // パスワードによるログインの禁止(シングルサインオンのみ)
+	TokenVersion          uint       `gorm:"not null;default:0" json:"-"`                         // ロール・パスワード・有効/無効の変更ごとに加算し、古いアクセストークンを拒否する
+	CreatedAt             time.Time  `json:"createdAt"`
+	UpdatedAt             time.Time  `json:"updatedAt"`
 }
 
 // User 構造体は users テーブルにマッピングされることを明示する
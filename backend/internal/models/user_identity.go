@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// UserIdentity: シングルサインオン（OpenID Connect）のアカウントとユーザーの紐づけ
+// IdP の発行者と sub の組でユーザーを識別する（メールアドレスは IdP 側で変更されうるため使わない）
+type UserIdentity struct {
+	// ID: 紐づけの一意識別子（主キー）
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// UserID: ユーザーID（外部キー）
+	UserID uint `gorm:"not null;index" json:"userId"`
+
+	// Issuer: IdP の発行者（iss クレーム）
+	Issuer string `gorm:"type:varchar(255);not null;uniqueIndex:idx_user_identity_subject" json:"issuer"`
+
+	// Subject: IdP 上のユーザー識別子（sub クレーム）
+	Subject string `gorm:"type:varchar(255);not null;uniqueIndex:idx_user_identity_subject" json:"subject"`
+
+	// Email: 最後のログイン時に IdP から受け取ったメールアドレス
+	Email string `gorm:"type:varchar(255)" json:"email"`
+
+	// LastLoginAt: 最後にこのアカウントでログインした日時
+	LastLoginAt *time.Time `json:"lastLoginAt"`
+
+	// CreatedAt: 紐づけた日時
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// UserIdentity 構造体は user_identities テーブルにマッピングされることを明示する
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// UserTOTP: ユーザーの TOTP（RFC 6238）二段階認証の設定
+type UserTOTP struct {
+	// ID: 一意識別子（主キー）
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// UserID: ユーザーID（外部キー、1ユーザーにつき1件）
+	UserID uint `gorm:"not null;uniqueIndex" json:"userId"`
+
+	// Secret: TOTP シークレット（Base32）
+	Secret string `gorm:"type:varchar(64);not null" json:"-"`
+
+	// EnabledAt: 有効化日時（NULL の場合は登録途中で、ログインには使われない）
+	EnabledAt *time.Time `json:"enabledAt"`
+
+	// LastUsedStep: 最後に使用したタイムステップ（同じコードの再利用を防ぐ）
+	LastUsedStep int64 `gorm:"not null;default:0" json:"-"`
+
+	// CreatedAt: 作成日時
+	CreatedAt time.Time `json:"createdAt"`
+
+	// UpdatedAt: 最終更新日時
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// UserTOTP 構造体は user_totps テーブルにマッピングされることを明示する
+func (UserTOTP) TableName() string {
+	return "user_totps"
+}
+
+// MFARecoveryCode: 認証アプリを利用できない場合の使い捨てリカバリーコード
+type MFARecoveryCode struct {
+	// ID: 一意識別子（主キー）
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// UserID: ユーザーID（外部キー）
+	UserID uint `gorm:"not null;index" json:"userId"`
+
+	// CodeHash: コードの SHA-256（16進数）- コード自体は発行時に一度だけ表示する
+	CodeHash string `gorm:"type:varchar(64);not null" json:"-"`
+
+	// UsedAt: 使用日時（NULL の場合は未使用）
+	UsedAt *time.Time `json:"usedAt"`
+
+	// CreatedAt: 発行日時
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// MFARecoveryCode 構造体は mfa_recovery_codes テーブルにマッピングされることを明示する
+func (MFARecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
+
+// RoleMFAPolicy: ロールごとの二段階認証の必須設定
+type RoleMFAPolicy struct {
+	// Role: ロール名（"admin", "user"）
+	Role string `gorm:"type:varchar(50);primaryKey" json:"role"`
+
+	// Required: 二段階認証を必須にするかどうか
+	Required bool `gorm:"not null;default:false" json:"required"`
+
+	// UpdatedAt: 最終更新日時
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// RoleMFAPolicy 構造体は role_mfa_policies テーブルにマッピングされることを明示する
+func (RoleMFAPolicy) TableName() string {
+	return "role_mfa_policies"
+}
@@ -0,0 +1,324 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	chartOfAccountsRepository "simple-ledger/internal/chart_of_accounts/repository"
+	"simple-ledger/internal/common/config"
+	fiscalPeriodRepository "simple-ledger/internal/fiscal_period/repository"
+	"simple-ledger/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Line: 検証対象の仕訳行
+type Line struct {
+	ChartOfAccountsID uint
+	Type              models.EntryType
+	Amount            int
+	Description       string
+}
+
+// Posting: 検証対象の記帳（取引日と仕訳行の組）
+type Posting struct {
+	// UserID: 記帳するユーザー（締め済み期間の判定に使用）
+	UserID uint
+
+	// Date: 取引日
+	Date time.Time
+
+	// Lines: 仕訳行（エラーのインデックスはこのスライスの位置を指す）
+	Lines []Line
+}
+
+// FieldError: フィールド単位の検証エラー
+type FieldError struct {
+	// Index: 問題のある仕訳行のインデックス（取引全体に対するエラーの場合は nil）
+	Index *int `json:"index,omitempty"`
+
+	// Field: 問題のあるフィールド名（JSON のキー名）
+	Field string `json:"field"`
+
+	// Message: エラー内容
+	Message string `json:"message"`
+}
+
+// ValidationError: 記帳の検証エラー（複数のフィールドエラーをまとめて返す）
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fieldErr := range e.Errors {
+		if fieldErr.Index != nil {
+			messages = append(messages, fmt.Sprintf("journalEntries[%d].%s: %s", *fieldErr.Index, fieldErr.Field, fieldErr.Message))
+		} else {
+			messages = append(messages, fmt.Sprintf("%s: %s", fieldErr.Field, fieldErr.Message))
+		}
+	}
+	return "posting validation failed: " + strings.Join(messages, "; ")
+}
+
+// Config: 記帳検証の設定
+type Config struct {
+	// MaxPastYears: 取引日として許容する過去の年数
+	MaxPastYears int
+
+	// MaxFutureDays: 取引日として許容する未来の日数
+	MaxFutureDays int
+
+	// MaxLineAmount: 1行あたりの金額の上限
+	MaxLineAmount int
+}
+
+// DefaultConfig: 記帳検証のデフォルト設定
+func DefaultConfig() Config {
+	return Config{
+		MaxPastYears:  10,
+		MaxFutureDays: 365,
+		MaxLineAmount: 1_000_000_000,
+	}
+}
+
+// ConfigFromEnv: 環境変数から記帳検証の設定を読み込む
+func ConfigFromEnv() Config {
+	defaults := DefaultConfig()
+	return Config{
+		MaxPastYears:  config.GetEnvAsInt("POSTING_MAX_PAST_YEARS", defaults.MaxPastYears),
+		MaxFutureDays: config.GetEnvAsInt("POSTING_MAX_FUTURE_DAYS", defaults.MaxFutureDays),
+		MaxLineAmount: config.GetEnvAsInt("POSTING_MAX_LINE_AMOUNT", defaults.MaxLineAmount),
+	}
+}
+
+// PostingValidator: すべての書き込み経路（取引・仕訳エントリー・インポート等）で共通の記帳検証
+// 貸借一致の検証は各サービスで行い、ここでは勘定科目・日付・金額・重複行を検証する
+// 勘定科目は全ユーザー共通のマスタのため、所属の検証は存在と有効性の確認のみ
+type PostingValidator interface {
+	// Validate: 記帳内容を検証し、問題があれば *ValidationError を返す
+	Validate(posting *Posting) error
+
+	// ValidateAll: 複数の記帳をまとめて検証し、記帳ごとの結果（問題が無い場合は nil、ある場合は *ValidationError）を返す
+	// 勘定科目と締め済み期間はまとめて取得するため、記帳の件数に比例して問い合わせが増えることはない
+	ValidateAll(postings []*Posting) ([]error, error)
+
+	// ValidatePeriodOpen: 取引日が締め済み期間に含まれていないか検証（削除時などに使用）
+	ValidatePeriodOpen(userID uint, date time.Time) error
+
+	// WithTx: 指定したDBトランザクション上で検証する PostingValidator を返す（書き込みと同じトランザクションで検証する場合に使用）
+	WithTx(tx *gorm.DB) PostingValidator
+}
+
+type postingValidator struct {
+	accountRepo chartOfAccountsRepository.ChartOfAccountsRepository
+	periodRepo  *fiscalPeriodRepository.FiscalPeriodRepository
+	config      Config
+	now         func() time.Time
+}
+
+// NewPostingValidator: 記帳検証の生成
+func NewPostingValidator(
+	accountRepo chartOfAccountsRepository.ChartOfAccountsRepository,
+	periodRepo *fiscalPeriodRepository.FiscalPeriodRepository,
+	cfg Config,
+) PostingValidator {
+	return &postingValidator{
+		accountRepo: accountRepo,
+		periodRepo:  periodRepo,
+		config:      cfg,
+		now:         time.Now,
+	}
+}
+
+func (v *postingValidator) WithTx(tx *gorm.DB) PostingValidator {
+	return &postingValidator{
+		accountRepo: v.accountRepo.WithTx(tx),
+		periodRepo:  v.periodRepo.WithTx(tx),
+		config:      v.config,
+		now:         v.now,
+	}
+}
+
+func (v *postingValidator) Validate(posting *Posting) error {
+	results, err := v.ValidateAll([]*Posting{posting})
+	if err != nil {
+		return err
+	}
+	return results[0]
+}
+
+func (v *postingValidator) ValidateAll(postings []*Posting) ([]error, error) {
+	accountsByID, err := v.loadAccounts(postings)
+	if err != nil {
+		return nil, err
+	}
+	closedPeriods, err := v.loadClosedPeriods(postings)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]error, len(postings))
+	for i, posting := range postings {
+		var fieldErrors []FieldError
+		fieldErrors = append(fieldErrors, v.validateDate(posting.Date, closedPeriods[posting.UserID])...)
+		fieldErrors = append(fieldErrors, v.validateLines(posting, accountsByID)...)
+		if len(fieldErrors) > 0 {
+			results[i] = &ValidationError{Errors: fieldErrors}
+		}
+	}
+	return results, nil
+}
+
+func (v *postingValidator) ValidatePeriodOpen(userID uint, date time.Time) error {
+	period, err := v.periodRepo.FindClosedContaining(userID, date)
+	if err != nil {
+		return err
+	}
+	if period != nil {
+		return &ValidationError{Errors: []FieldError{closedPeriodError(period)}}
+	}
+	return nil
+}
+
+// loadAccounts: 記帳が参照する勘定科目をまとめて取得
+func (v *postingValidator) loadAccounts(postings []*Posting) (map[uint]models.ChartOfAccounts, error) {
+	var ids []uint
+	for _, posting := range postings {
+		for _, line := range posting.Lines {
+			ids = append(ids, line.ChartOfAccountsID)
+		}
+	}
+	accounts, err := v.accountRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	accountsByID := make(map[uint]models.ChartOfAccounts, len(accounts))
+	for _, account := range accounts {
+		accountsByID[account.ID] = account
+	}
+	return accountsByID, nil
+}
+
+// loadClosedPeriods: 記帳の取引日の範囲と重なる締め済みの会計期間をユーザーごとに取得（開始日の昇順）
+func (v *postingValidator) loadClosedPeriods(postings []*Posting) (map[uint][]models.FiscalPeriod, error) {
+	type dateRange struct{ start, end time.Time }
+	ranges := make(map[uint]dateRange)
+	var userIDs []uint
+	for _, posting := range postings {
+		day := truncateToDate(posting.Date)
+		r, exists := ranges[posting.UserID]
+		if !exists {
+			userIDs = append(userIDs, posting.UserID)
+			r = dateRange{start: day, end: day}
+		}
+		if day.Before(r.start) {
+			r.start = day
+		}
+		if day.After(r.end) {
+			r.end = day
+		}
+		ranges[posting.UserID] = r
+	}
+
+	closedPeriods := make(map[uint][]models.FiscalPeriod, len(userIDs))
+	for _, userID := range userIDs {
+		periods, err := v.periodRepo.FindOverlapping(userID, ranges[userID].start, ranges[userID].end)
+		if err != nil {
+			return nil, err
+		}
+		for _, period := range periods {
+			if period.ClosedAt != nil {
+				closedPeriods[userID] = append(closedPeriods[userID], period)
+			}
+		}
+	}
+	return closedPeriods, nil
+}
+
+// validateDate: 取引日が許容範囲内かつ締め済み期間外であることを確認
+func (v *postingValidator) validateDate(date time.Time, closedPeriods []models.FiscalPeriod) []FieldError {
+	var fieldErrors []FieldError
+
+	today := truncateToDate(v.now())
+	earliest := today.AddDate(-v.config.MaxPastYears, 0, 0)
+	latest := today.AddDate(0, 0, v.config.MaxFutureDays)
+	day := truncateToDate(date)
+
+	if day.Before(earliest) {
+		fieldErrors = append(fieldErrors, FieldError{
+			Field:   "date",
+			Message: fmt.Sprintf("date must not be earlier than %s", earliest.Format("2006-01-02")),
+		})
+	}
+	if day.After(latest) {
+		fieldErrors = append(fieldErrors, FieldError{
+			Field:   "date",
+			Message: fmt.Sprintf("date must not be later than %s", latest.Format("2006-01-02")),
+		})
+	}
+
+	for i := range closedPeriods {
+		period := &closedPeriods[i]
+		if !day.Before(truncateToDate(period.StartDate)) && !day.After(truncateToDate(period.EndDate)) {
+			fieldErrors = append(fieldErrors, closedPeriodError(period))
+			break
+		}
+	}
+
+	return fieldErrors
+}
+
+// validateLines: 各仕訳行の勘定科目・区分・金額と重複を確認
+func (v *postingValidator) validateLines(posting *Posting, accountsByID map[uint]models.ChartOfAccounts) []FieldError {
+	var fieldErrors []FieldError
+
+	seen := make(map[Line]int, len(posting.Lines))
+	for i, line := range posting.Lines {
+		index := i
+		lineError := func(field string, message string) FieldError {
+			return FieldError{Index: &index, Field: field, Message: message}
+		}
+
+		account, exists := accountsByID[line.ChartOfAccountsID]
+		switch {
+		case !exists:
+			fieldErrors = append(fieldErrors, lineError("chartOfAccountsId", fmt.Sprintf("account %d does not exist", line.ChartOfAccountsID)))
+		case !account.IsActive:
+			fieldErrors = append(fieldErrors, lineError("chartOfAccountsId", fmt.Sprintf("account %s (%s) is inactive", account.Code, account.Name)))
+		}
+
+		if line.Type != models.DebitEntry && line.Type != models.CreditEntry {
+			fieldErrors = append(fieldErrors, lineError("type", "type must be debit or credit"))
+		}
+
+		if line.Amount <= 0 {
+			fieldErrors = append(fieldErrors, lineError("amount", "amount must be greater than 0"))
+		} else if line.Amount > v.config.MaxLineAmount {
+			fieldErrors = append(fieldErrors, lineError("amount", fmt.Sprintf("amount must not exceed %d", v.config.MaxLineAmount)))
+		}
+
+		if first, duplicated := seen[line]; duplicated {
+			fieldErrors = append(fieldErrors, lineError("journalEntries", fmt.Sprintf("duplicate of line %d", first)))
+		} else {
+			seen[line] = i
+		}
+	}
+
+	return fieldErrors
+}
+
+// closedPeriodError: 締め済み期間に対するエラーを生成
+func closedPeriodError(period *models.FiscalPeriod) FieldError {
+	return FieldError{
+		Field: "date",
+		Message: fmt.Sprintf("date falls within closed period %s to %s",
+			period.StartDate.Format("2006-01-02"), period.EndDate.Format("2006-01-02")),
+	}
+}
+
+// truncateToDate: 時刻を切り捨てて日付のみにする
+func truncateToDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
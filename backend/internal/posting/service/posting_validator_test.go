@@ -0,0 +1,181 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	chartOfAccountsRepository "simple-ledger/internal/chart_of_accounts/repository"
+	fiscalPeriodRepository "simple-ledger/internal/fiscal_period/repository"
+	"simple-ledger/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupPostingValidatorTest: 有効・無効の勘定科目を持つ検証を作成（基準日は 2025-06-15）
+func setupPostingValidatorTest() (*postingValidator, *gorm.DB, models.ChartOfAccounts, models.ChartOfAccounts, models.ChartOfAccounts) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err := db.AutoMigrate(&models.ChartOfAccounts{}, &models.FiscalPeriod{}); err != nil {
+		panic(err)
+	}
+
+	cash := models.ChartOfAccounts{Code: "1000", Name: "現金", Type: models.AssetAccount, NormalBalance: models.DebitBalance, IsActive: true}
+	db.Create(&cash)
+	sales := models.ChartOfAccounts{Code: "4000", Name: "売上", Type: models.RevenueAccount, NormalBalance: models.CreditBalance, IsActive: true}
+	db.Create(&sales)
+	inactive := models.ChartOfAccounts{Code: "9000", Name: "旧科目", Type: models.ExpenseAccount, NormalBalance: models.DebitBalance, IsActive: true}
+	db.Create(&inactive)
+	db.Model(&inactive).Update("is_active", false)
+
+	validator := NewPostingValidator(
+		chartOfAccountsRepository.NewChartOfAccountsRepository(db),
+		fiscalPeriodRepository.NewFiscalPeriodRepository(db),
+		DefaultConfig(),
+	).(*postingValidator)
+	validator.now = func() time.Time { return time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC) }
+
+	return validator, db, cash, sales, inactive
+}
+
+func balancedPosting(debitAccountID uint, creditAccountID uint, date time.Time) *Posting {
+	return &Posting{
+		UserID: 1,
+		Date:   date,
+		Lines: []Line{
+			{ChartOfAccountsID: debitAccountID, Type: models.DebitEntry, Amount: 1000},
+			{ChartOfAccountsID: creditAccountID, Type: models.CreditEntry, Amount: 1000},
+		},
+	}
+}
+
+func requireValidationError(t *testing.T, err error) *ValidationError {
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	return validationErr
+}
+
+func TestValidate_ValidPosting(t *testing.T) {
+	validator, _, cash, sales, _ := setupPostingValidatorTest()
+
+	err := validator.Validate(balancedPosting(cash.ID, sales.ID, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)))
+	assert.NoError(t, err)
+}
+
+func TestValidate_InactiveAndMissingAccounts(t *testing.T) {
+	validator, _, _, sales, inactive := setupPostingValidatorTest()
+
+	posting := balancedPosting(inactive.ID, sales.ID, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))
+	posting.Lines[1].ChartOfAccountsID = 999
+
+	validationErr := requireValidationError(t, validator.Validate(posting))
+	require.Len(t, validationErr.Errors, 2)
+	assert.Equal(t, 0, *validationErr.Errors[0].Index)
+	assert.Equal(t, "chartOfAccountsId", validationErr.Errors[0].Field)
+	assert.Contains(t, validationErr.Errors[0].Message, "inactive")
+	assert.Equal(t, 1, *validationErr.Errors[1].Index)
+	assert.Contains(t, validationErr.Errors[1].Message, "does not exist")
+}
+
+func TestValidate_DateOutsideWindow(t *testing.T) {
+	validator, _, cash, sales, _ := setupPostingValidatorTest()
+
+	for _, date := range []time.Time{
+		time.Date(2015, 6, 14, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 6, 16, 0, 0, 0, 0, time.UTC),
+	} {
+		validationErr := requireValidationError(t, validator.Validate(balancedPosting(cash.ID, sales.ID, date)))
+		require.Len(t, validationErr.Errors, 1)
+		assert.Equal(t, "date", validationErr.Errors[0].Field)
+		assert.Nil(t, validationErr.Errors[0].Index)
+	}
+
+	// 境界値は許容する
+	assert.NoError(t, validator.Validate(balancedPosting(cash.ID, sales.ID, time.Date(2015, 6, 15, 0, 0, 0, 0, time.UTC))))
+	assert.NoError(t, validator.Validate(balancedPosting(cash.ID, sales.ID, time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC))))
+}
+
+func TestValidate_ClosedPeriod(t *testing.T) {
+	validator, db, cash, sales, _ := setupPostingValidatorTest()
+
+	closedAt := time.Date(2025, 4, 10, 0, 0, 0, 0, time.UTC)
+	db.Create(&models.FiscalPeriod{
+		UserID:    1,
+		StartDate: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC),
+		ClosedAt:  &closedAt,
+	})
+	// 未締めの期間は記帳できる
+	db.Create(&models.FiscalPeriod{
+		UserID:    1,
+		StartDate: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC),
+	})
+
+	validationErr := requireValidationError(t, validator.Validate(balancedPosting(cash.ID, sales.ID, time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC))))
+	assert.Contains(t, validationErr.Errors[0].Message, "closed period")
+
+	assert.NoError(t, validator.Validate(balancedPosting(cash.ID, sales.ID, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC))))
+
+	// 他ユーザーの締め済み期間は影響しない
+	posting := balancedPosting(cash.ID, sales.ID, time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC))
+	posting.UserID = 2
+	assert.NoError(t, validator.Validate(posting))
+
+	requireValidationError(t, validator.ValidatePeriodOpen(1, time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)))
+	assert.NoError(t, validator.ValidatePeriodOpen(1, time.Date(2025, 4, 15, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestValidate_AmountLimits(t *testing.T) {
+	validator, _, cash, sales, _ := setupPostingValidatorTest()
+
+	posting := balancedPosting(cash.ID, sales.ID, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))
+	posting.Lines[0].Amount = 0
+	posting.Lines[1].Amount = DefaultConfig().MaxLineAmount + 1
+
+	validationErr := requireValidationError(t, validator.Validate(posting))
+	require.Len(t, validationErr.Errors, 2)
+	assert.Equal(t, "amount", validationErr.Errors[0].Field)
+	assert.Equal(t, "amount", validationErr.Errors[1].Field)
+	assert.Contains(t, validationErr.Errors[1].Message, "must not exceed")
+}
+
+func TestValidate_DuplicateLines(t *testing.T) {
+	validator, _, cash, sales, _ := setupPostingValidatorTest()
+
+	posting := balancedPosting(cash.ID, sales.ID, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))
+	posting.Lines = append(posting.Lines, posting.Lines[0])
+
+	validationErr := requireValidationError(t, validator.Validate(posting))
+	require.Len(t, validationErr.Errors, 1)
+	assert.Equal(t, 2, *validationErr.Errors[0].Index)
+	assert.Equal(t, "duplicate of line 0", validationErr.Errors[0].Message)
+	assert.Contains(t, validationErr.Error(), "journalEntries[2].journalEntries")
+}
+
+func TestValidateAll_ReturnsResultPerPosting(t *testing.T) {
+	validator, db, cash, sales, inactive := setupPostingValidatorTest()
+
+	closedAt := time.Date(2025, 4, 10, 0, 0, 0, 0, time.UTC)
+	db.Create(&models.FiscalPeriod{
+		UserID:    1,
+		StartDate: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC),
+		ClosedAt:  &closedAt,
+	})
+
+	results, err := validator.ValidateAll([]*Posting{
+		balancedPosting(cash.ID, sales.ID, time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC)),
+		balancedPosting(cash.ID, sales.ID, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)),
+		balancedPosting(inactive.ID, sales.ID, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)),
+		balancedPosting(cash.ID, sales.ID, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)),
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	assert.NoError(t, results[0])
+	assert.Contains(t, requireValidationError(t, results[1]).Errors[0].Message, "closed period")
+	assert.Equal(t, "chartOfAccountsId", requireValidationError(t, results[2]).Errors[0].Field)
+	assert.NoError(t, results[3])
+}
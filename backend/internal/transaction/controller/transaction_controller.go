@@ -1,9 +1,14 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"simple-ledger/internal/common/requestmeta"
+	postingService "simple-ledger/internal/posting/service"
 	"simple-ledger/internal/transaction/dto"
 	"simple-ledger/internal/transaction/service"
 
@@ -12,9 +17,11 @@ import (
 
 type TransactionController interface {
 	Create() gin.HandlerFunc
+	CreateBatch() gin.HandlerFunc
 	GetByID() gin.HandlerFunc
 	GetByUserID() gin.HandlerFunc
 	GetByUserIDWithPagination() gin.HandlerFunc
+	GetByUserIDWithCursor() gin.HandlerFunc
 	Update() gin.HandlerFunc
 	Delete() gin.HandlerFunc
 }
@@ -46,15 +53,52 @@ func (ctrl *transactionController) Create() gin.HandlerFunc {
 			return
 		}
 
-		result, err := ctrl.service.Create(userID.(uint), &req)
+		result, err := ctrl.service.Create(userID.(uint), &req, requestmeta.FromContext(c))
 		if err != nil {
+			respondBadRequest(c, err)
+			return
+		}
+
+		c.Header("ETag", etag(result.Version))
+		c.JSON(http.StatusCreated, result)
+	}
+}
+
+// CreateBatch: 取引を一括登録
+// すべて登録された場合は 201、all_or_nothing で登録されなかった場合は 400、best_effort で一部のみ登録された場合は 200 を返す
+func (ctrl *transactionController) CreateBatch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.BatchCreateTransactionsRequest
+
+		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
+				"error": "Invalid request body",
 			})
 			return
 		}
 
-		c.JSON(http.StatusCreated, result)
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "User ID not found",
+			})
+			return
+		}
+
+		result, err := ctrl.service.CreateBatch(userID.(uint), &req, requestmeta.FromContext(c))
+		if err != nil {
+			respondBadRequest(c, err)
+			return
+		}
+
+		switch {
+		case result.Failed == 0:
+			c.JSON(http.StatusCreated, result)
+		case result.Created == 0 && result.Mode == dto.BatchModeAllOrNothing:
+			c.JSON(http.StatusBadRequest, result)
+		default:
+			c.JSON(http.StatusOK, result)
+		}
 	}
 }
 
@@ -92,6 +136,7 @@ func (ctrl *transactionController) GetByID() gin.HandlerFunc {
 			return
 		}
 
+		c.Header("ETag", etag(result.Version))
 		c.JSON(http.StatusOK, result)
 	}
 }
@@ -157,6 +202,43 @@ func (ctrl *transactionController) GetByUserIDWithPagination() gin.HandlerFunc {
 	}
 }
 
+// GetByUserIDWithCursor: 取引一覧をカーソルによるページネーションで取得
+func (ctrl *transactionController) GetByUserIDWithCursor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "User ID not found",
+			})
+			return
+		}
+
+		var req dto.GetTransactionsWithCursorRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid query parameters: " + err.Error(),
+			})
+			return
+		}
+
+		result, err := ctrl.service.GetByUserIDWithCursor(userID.(uint), &req)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidCursor) {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch transactions",
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
 func (ctrl *transactionController) Update() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		idStr := c.Param("id")
@@ -185,11 +267,18 @@ func (ctrl *transactionController) Update() gin.HandlerFunc {
 			return
 		}
 
-		result, err := ctrl.service.Update(uint(id), userID.(uint), &req)
+		version, ok := versionFromIfMatch(c)
+		if !ok {
+			return
+		}
+
+		result, err := ctrl.service.Update(uint(id), userID.(uint), version, &req, requestmeta.FromContext(c))
+		if errors.Is(err, service.ErrVersionMismatch) {
+			ctrl.respondVersionMismatch(c, uint(id), userID.(uint))
+			return
+		}
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
+			respondBadRequest(c, err)
 			return
 		}
 
@@ -200,6 +289,7 @@ func (ctrl *transactionController) Update() gin.HandlerFunc {
 			return
 		}
 
+		c.Header("ETag", etag(result.Version))
 		c.JSON(http.StatusOK, result)
 	}
 }
@@ -223,7 +313,23 @@ func (ctrl *transactionController) Delete() gin.HandlerFunc {
 			return
 		}
 
-		if err := ctrl.service.Delete(uint(id), userID.(uint)); err != nil {
+		version, ok := versionFromIfMatch(c)
+		if !ok {
+			return
+		}
+
+		err = ctrl.service.Delete(uint(id), userID.(uint), version, requestmeta.FromContext(c))
+		if errors.Is(err, service.ErrVersionMismatch) {
+			ctrl.respondVersionMismatch(c, uint(id), userID.(uint))
+			return
+		}
+		if errors.Is(err, service.ErrTransactionHasAttachments) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": err.Error(),
 			})
@@ -235,3 +341,65 @@ func (ctrl *transactionController) Delete() gin.HandlerFunc {
 		})
 	}
 }
+
+// respondBadRequest: 記帳検証エラーの場合は行ごとの詳細を含めて 400 を返す
+func respondBadRequest(c *gin.Context, err error) {
+	var validationErr *postingService.ValidationError
+	if errors.As(err, &validationErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   err.Error(),
+			"details": validationErr.Errors,
+		})
+		return
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error": err.Error(),
+	})
+}
+
+// etag: 取引のバージョンを ETag の値にする
+func etag(version uint) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// versionFromIfMatch: If-Match ヘッダーから更新・削除の対象とするバージョンを取得
+// ヘッダーが無い場合は 428 を返して false を返す
+// 形式が不正な値はどのバージョンとも一致しないものとして 0 を返す（412 になる）
+// 中継サーバーが弱い ETag（W/）に変換する場合があるため W/ は無視する
+func versionFromIfMatch(c *gin.Context) (uint, bool) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{
+			"error": "If-Match header with the transaction ETag is required",
+		})
+		return 0, false
+	}
+
+	value = strings.TrimPrefix(value, "W/")
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return 0, true
+	}
+	version, err := strconv.ParseUint(value[1:len(value)-1], 10, 32)
+	if err != nil {
+		return 0, true
+	}
+	return uint(version), true
+}
+
+// respondVersionMismatch: 現在の取引と ETag を含めて 412 を返す
+func (ctrl *transactionController) respondVersionMismatch(c *gin.Context, id uint, userID uint) {
+	current, err := ctrl.service.GetByID(id, userID)
+	if err != nil || current == nil {
+		c.JSON(http.StatusPreconditionFailed, gin.H{
+			"error": service.ErrVersionMismatch.Error(),
+		})
+		return
+	}
+
+	c.Header("ETag", etag(current.Version))
+	c.JSON(http.StatusPreconditionFailed, gin.H{
+		"error":   service.ErrVersionMismatch.Error(),
+		"current": current,
+	})
+}
@@ -8,10 +8,17 @@ import (
 	"testing"
 	"time"
 
+	attachmentrepository "simple-ledger/internal/attachment/repository"
+	auditrepository "simple-ledger/internal/audit/repository"
+	auditservice "simple-ledger/internal/audit/service"
+	chartOfAccountsRepository "simple-ledger/internal/chart_of_accounts/repository"
+	"simple-ledger/internal/common/db/uow"
+	fiscalPeriodRepository "simple-ledger/internal/fiscal_period/repository"
 	jeDto "simple-ledger/internal/journal_entry/dto"
 	jerepository "simple-ledger/internal/journal_entry/repository"
 	jeservice "simple-ledger/internal/journal_entry/service"
 	"simple-ledger/internal/models"
+	postingService "simple-ledger/internal/posting/service"
 	"simple-ledger/internal/transaction/dto"
 	txrepository "simple-ledger/internal/transaction/repository"
 	txservice "simple-ledger/internal/transaction/service"
@@ -33,7 +40,13 @@ func setupControllerTestDB() *gorm.DB {
 	if err := db.AutoMigrate(&models.Transaction{}); err != nil {
 		panic(err)
 	}
-	if err := db.AutoMigrate(&models.JournalEntry{}); err != nil {
+	if err := db.AutoMigrate(&models.JournalEntry{}, &models.Attachment{}); err != nil {
+		panic(err)
+	}
+	if err := db.AutoMigrate(&models.AuditLog{}, &models.AuditChainHead{}); err != nil {
+		panic(err)
+	}
+	if err := db.AutoMigrate(&models.FiscalPeriod{}); err != nil {
 		panic(err)
 	}
 
@@ -64,8 +77,10 @@ func TestGetByUserIDWithPaginationController(t *testing.T) {
 	db := setupControllerTestDB()
 	txRepo := txrepository.NewTransactionRepository(db)
 	jeRepo := jerepository.NewJournalEntryRepository(db)
-	jeSvc := jeservice.NewJournalEntryService(jeRepo)
-	svc := txservice.NewTransactionService(txRepo, jeRepo, jeSvc)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := txservice.NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), txservice.DefaultConfig())
 	ctrl := NewTransactionController(svc)
 
 	// テストデータの作成（30件）
@@ -121,8 +136,10 @@ func TestGetByUserIDWithPaginationMissingParams(t *testing.T) {
 	db := setupControllerTestDB()
 	txRepo := txrepository.NewTransactionRepository(db)
 	jeRepo := jerepository.NewJournalEntryRepository(db)
-	jeSvc := jeservice.NewJournalEntryService(jeRepo)
-	svc := txservice.NewTransactionService(txRepo, jeRepo, jeSvc)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := txservice.NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), txservice.DefaultConfig())
 	ctrl := NewTransactionController(svc)
 
 	// ページパラメータなし
@@ -141,8 +158,10 @@ func TestGetByUserIDWithPaginationMissingUserID(t *testing.T) {
 	db := setupControllerTestDB()
 	txRepo := txrepository.NewTransactionRepository(db)
 	jeRepo := jerepository.NewJournalEntryRepository(db)
-	jeSvc := jeservice.NewJournalEntryService(jeRepo)
-	svc := txservice.NewTransactionService(txRepo, jeRepo, jeSvc)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := txservice.NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), txservice.DefaultConfig())
 	ctrl := NewTransactionController(svc)
 
 	// userID context なし
@@ -161,16 +180,16 @@ func TestCreateTransactionWithDoubleEntry(t *testing.T) {
 	db := setupControllerTestDB()
 	txRepo := txrepository.NewTransactionRepository(db)
 	jeRepo := jerepository.NewJournalEntryRepository(db)
-	jeSvc := jeservice.NewJournalEntryService(jeRepo)
-	svc := txservice.NewTransactionService(txRepo, jeRepo, jeSvc)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := txservice.NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), txservice.DefaultConfig())
 	ctrl := NewTransactionController(svc)
 
 	// 必要な勘定科目を作成
-	accountDebit := models.ChartOfAccounts{
-		Code: "1000", Name: "現金", Type: models.AssetAccount,
-		NormalBalance: models.DebitBalance, IsActive: true,
-	}
-	db.Create(&accountDebit)
+	// 現金はsetupControllerTestDBで作成済み
+	var accountDebit models.ChartOfAccounts
+	db.Where("code = ?", "1000").First(&accountDebit)
 
 	accountCredit := models.ChartOfAccounts{
 		Code: "4000", Name: "売上", Type: models.RevenueAccount,
@@ -212,15 +231,15 @@ func TestCreateTransactionWithUnbalancedEntries(t *testing.T) {
 	db := setupControllerTestDB()
 	txRepo := txrepository.NewTransactionRepository(db)
 	jeRepo := jerepository.NewJournalEntryRepository(db)
-	jeSvc := jeservice.NewJournalEntryService(jeRepo)
-	svc := txservice.NewTransactionService(txRepo, jeRepo, jeSvc)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := txservice.NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), txservice.DefaultConfig())
 	ctrl := NewTransactionController(svc)
 
-	accountDebit := models.ChartOfAccounts{
-		Code: "1000", Name: "現金", Type: models.AssetAccount,
-		NormalBalance: models.DebitBalance, IsActive: true,
-	}
-	db.Create(&accountDebit)
+	// 現金はsetupControllerTestDBで作成済み
+	var accountDebit models.ChartOfAccounts
+	db.Where("code = ?", "1000").First(&accountDebit)
 
 	accountCredit := models.ChartOfAccounts{
 		Code: "4000", Name: "売上", Type: models.RevenueAccount,
@@ -256,8 +275,10 @@ func TestCreateTransactionInvalidJSON(t *testing.T) {
 	db := setupControllerTestDB()
 	txRepo := txrepository.NewTransactionRepository(db)
 	jeRepo := jerepository.NewJournalEntryRepository(db)
-	jeSvc := jeservice.NewJournalEntryService(jeRepo)
-	svc := txservice.NewTransactionService(txRepo, jeRepo, jeSvc)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := txservice.NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), txservice.DefaultConfig())
 	ctrl := NewTransactionController(svc)
 
 	w := httptest.NewRecorder()
@@ -272,3 +293,247 @@ func TestCreateTransactionInvalidJSON(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	assert.Contains(t, w.Body.String(), "Invalid request body")
 }
+
+// TestCreateTransactionWithInactiveAccount: コントローラー - 記帳検証エラーは行ごとの詳細を返す
+func TestCreateTransactionWithInactiveAccount(t *testing.T) {
+	db := setupControllerTestDB()
+	txRepo := txrepository.NewTransactionRepository(db)
+	jeRepo := jerepository.NewJournalEntryRepository(db)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := txservice.NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), txservice.DefaultConfig())
+	ctrl := NewTransactionController(svc)
+
+	var accountDebit models.ChartOfAccounts
+	db.Where("code = ?", "1000").First(&accountDebit)
+
+	accountCredit := models.ChartOfAccounts{
+		Code: "4000", Name: "売上", Type: models.RevenueAccount,
+		NormalBalance: models.CreditBalance, IsActive: true,
+	}
+	db.Create(&accountCredit)
+	db.Model(&accountCredit).Update("is_active", false)
+
+	req := dto.CreateTransactionRequest{
+		Date:        "2024-12-01",
+		Description: "無効な科目",
+		JournalEntries: []jeDto.CreateJournalEntryRequest{
+			{ChartOfAccountsID: accountDebit.ID, Type: models.DebitEntry, Amount: 100000},
+			{ChartOfAccountsID: accountCredit.ID, Type: models.CreditEntry, Amount: 100000},
+		},
+	}
+
+	w := httptest.NewRecorder()
+	jsonReq, _ := json.Marshal(req)
+	httpReq, _ := http.NewRequest("POST", "/api/transactions", bytes.NewBuffer(jsonReq))
+	httpReq.Header.Set("Content-Type", "application/json")
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httpReq
+	c.Set("userID", uint(1))
+
+	ctrl.Create()(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response struct {
+		Error   string                      `json:"error"`
+		Details []postingService.FieldError `json:"details"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	if assert.Len(t, response.Details, 1) {
+		assert.Equal(t, 1, *response.Details[0].Index)
+		assert.Equal(t, "chartOfAccountsId", response.Details[0].Field)
+	}
+
+	var count int64
+	db.Model(&models.Transaction{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+// newTestPostingValidator: テスト用の記帳検証を生成
+func newTestPostingValidator(db *gorm.DB) postingService.PostingValidator {
+	return postingService.NewPostingValidator(
+		chartOfAccountsRepository.NewChartOfAccountsRepository(db),
+		fiscalPeriodRepository.NewFiscalPeriodRepository(db),
+		postingService.DefaultConfig(),
+	)
+}
+
+// TestUpdateTransactionRequiresIfMatch: コントローラー - ETag による楽観的排他制御
+func TestUpdateTransactionRequiresIfMatch(t *testing.T) {
+	db := setupControllerTestDB()
+	txRepo := txrepository.NewTransactionRepository(db)
+	jeRepo := jerepository.NewJournalEntryRepository(db)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := txservice.NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), txservice.DefaultConfig())
+	ctrl := NewTransactionController(svc)
+
+	var accountDebit models.ChartOfAccounts
+	db.Where("code = ?", "1000").First(&accountDebit)
+	accountCredit := models.ChartOfAccounts{
+		Code: "4000", Name: "売上", Type: models.RevenueAccount,
+		NormalBalance: models.CreditBalance, IsActive: true,
+	}
+	db.Create(&accountCredit)
+
+	req := dto.CreateTransactionRequest{
+		Date:        "2024-12-01",
+		Description: "商品販売",
+		JournalEntries: []jeDto.CreateJournalEntryRequest{
+			{ChartOfAccountsID: accountDebit.ID, Type: models.DebitEntry, Amount: 100000, Description: "販売"},
+			{ChartOfAccountsID: accountCredit.ID, Type: models.CreditEntry, Amount: 100000, Description: "販売"},
+		},
+	}
+	jsonReq, _ := json.Marshal(req)
+
+	send := func(handler gin.HandlerFunc, method string, ifMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		httpReq, _ := http.NewRequest(method, "/api/transactions/1", bytes.NewBuffer(jsonReq))
+		httpReq.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			httpReq.Header.Set("If-Match", ifMatch)
+		}
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httpReq
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+		c.Set("userID", uint(1))
+		handler(c)
+		return w
+	}
+
+	w := send(ctrl.Create(), "POST", "")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+
+	// If-Match が無い更新・削除は受け付けない
+	assert.Equal(t, http.StatusPreconditionRequired, send(ctrl.Update(), "PUT", "").Code)
+	assert.Equal(t, http.StatusPreconditionRequired, send(ctrl.Delete(), "DELETE", "").Code)
+
+	w = send(ctrl.Update(), "PUT", `"1"`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+	// 古い ETag での更新・削除は 412 と現在の取引を返す
+	for _, handler := range []gin.HandlerFunc{ctrl.Update(), ctrl.Delete()} {
+		w = send(handler, "PUT", `"1"`)
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+		var body struct {
+			Current dto.TransactionResponse `json:"current"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, uint(2), body.Current.Version)
+	}
+
+	w = send(ctrl.Delete(), "DELETE", `W/"2"`)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestCreateBatchTransactionsStatus: 一括登録の結果に応じたステータスコード
+func TestCreateBatchTransactionsStatus(t *testing.T) {
+	db := setupControllerTestDB()
+	txRepo := txrepository.NewTransactionRepository(db)
+	jeRepo := jerepository.NewJournalEntryRepository(db)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := txservice.NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), txservice.DefaultConfig())
+	ctrl := NewTransactionController(svc)
+
+	var accountDebit models.ChartOfAccounts
+	db.Where("code = ?", "1000").First(&accountDebit)
+	accountCredit := models.ChartOfAccounts{
+		Code: "4000", Name: "売上", Type: models.RevenueAccount,
+		NormalBalance: models.CreditBalance, IsActive: true,
+	}
+	db.Create(&accountCredit)
+
+	item := func(amount int) dto.CreateTransactionRequest {
+		return dto.CreateTransactionRequest{
+			Date: "2024-12-01",
+			JournalEntries: []jeDto.CreateJournalEntryRequest{
+				{ChartOfAccountsID: accountDebit.ID, Type: models.DebitEntry, Amount: amount},
+				{ChartOfAccountsID: accountCredit.ID, Type: models.CreditEntry, Amount: amount},
+			},
+		}
+	}
+	invalid := item(1000)
+	invalid.JournalEntries[0].ChartOfAccountsID = 999
+
+	for _, tc := range []struct {
+		name   string
+		req    dto.BatchCreateTransactionsRequest
+		status int
+	}{
+		{"all created", dto.BatchCreateTransactionsRequest{Transactions: []dto.CreateTransactionRequest{item(1000), item(2000)}}, http.StatusCreated},
+		{"all or nothing with error", dto.BatchCreateTransactionsRequest{Transactions: []dto.CreateTransactionRequest{item(1000), invalid}}, http.StatusBadRequest},
+		{"best effort with error", dto.BatchCreateTransactionsRequest{Mode: dto.BatchModeBestEffort, Transactions: []dto.CreateTransactionRequest{item(1000), invalid}}, http.StatusOK},
+		{"invalid mode", dto.BatchCreateTransactionsRequest{Mode: "partial", Transactions: []dto.CreateTransactionRequest{item(1000)}}, http.StatusBadRequest},
+		{"empty", dto.BatchCreateTransactionsRequest{Transactions: []dto.CreateTransactionRequest{}}, http.StatusBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			jsonReq, _ := json.Marshal(tc.req)
+			httpReq, _ := http.NewRequest("POST", "/api/transactions/batch", bytes.NewBuffer(jsonReq))
+			httpReq.Header.Set("Content-Type", "application/json")
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httpReq
+			c.Set("userID", uint(1))
+
+			ctrl.CreateBatch()(c)
+
+			assert.Equal(t, tc.status, w.Code)
+		})
+	}
+
+	var count int64
+	db.Model(&models.Transaction{}).Count(&count)
+	assert.Equal(t, int64(3), count)
+}
+
+// TestGetByUserIDWithCursorController: カーソルによるページネーションのクエリパラメーターの検証
+func TestGetByUserIDWithCursorController(t *testing.T) {
+	db := setupControllerTestDB()
+	txRepo := txrepository.NewTransactionRepository(db)
+	jeRepo := jerepository.NewJournalEntryRepository(db)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := txservice.NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), txservice.DefaultConfig())
+	ctrl := NewTransactionController(svc)
+
+	for i := 1; i <= 3; i++ {
+		db.Create(&models.Transaction{UserID: 1, Date: time.Date(2024, 12, i, 0, 0, 0, 0, time.UTC)})
+	}
+
+	for _, tc := range []struct {
+		query  string
+		status int
+	}{
+		{"?limit=2", http.StatusOK},
+		{"?limit=101", http.StatusBadRequest},
+		{"?cursor=invalid", http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/transactions/cursor"+tc.query, nil)
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Set("userID", uint(1))
+
+		ctrl.GetByUserIDWithCursor()(c)
+
+		assert.Equal(t, tc.status, w.Code, tc.query)
+		if tc.status == http.StatusOK {
+			var response dto.GetTransactionsWithCursorResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Len(t, response.Transactions, 2)
+			assert.NotNil(t, response.NextCursor)
+			assert.Nil(t, response.Total)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package dto
 
 import (
 	journalEntryDto "simple-ledger/internal/journal_entry/dto"
+	postingService "simple-ledger/internal/posting/service"
 	"time"
 )
 
@@ -20,6 +21,51 @@ type CreateTransactionRequest struct {
 	CorrectionNote string `json:"correctionNote" binding:"max=255"`
 }
 
+// 一括登録のモード
+const (
+	BatchModeAllOrNothing = "all_or_nothing" // 1件でも登録できない取引がある場合はすべて登録しない（既定）
+	BatchModeBestEffort   = "best_effort"    // 登録できる取引のみ登録する
+)
+
+// BatchCreateTransactionsRequest: 取引一括登録リクエスト
+type BatchCreateTransactionsRequest struct {
+	// Mode: 登録のモード（all_or_nothing / best_effort、省略時は all_or_nothing）
+	Mode string `json:"mode" binding:"omitempty,oneof=all_or_nothing best_effort"`
+
+	// Transactions: 登録する取引（各取引の検証エラーは結果に取引ごとに返す）
+	Transactions []CreateTransactionRequest `json:"transactions" binding:"required,min=1"`
+}
+
+// BatchItemResult: 一括登録の取引ごとの結果
+type BatchItemResult struct {
+	// Index: リクエストの transactions における位置
+	Index int `json:"index"`
+
+	// Transaction: 登録された取引（登録されなかった場合は省略）
+	Transaction *TransactionResponse `json:"transaction,omitempty"`
+
+	// Error: 登録できなかった理由
+	Error string `json:"error,omitempty"`
+
+	// Details: 記帳検証のフィールドエラー
+	Details []postingService.FieldError `json:"details,omitempty"`
+}
+
+// BatchCreateTransactionsResponse: 取引一括登録レスポンス
+type BatchCreateTransactionsResponse struct {
+	// Mode: 登録のモード
+	Mode string `json:"mode"`
+
+	// Created: 登録された取引の件数
+	Created int `json:"created"`
+
+	// Failed: 検証エラーの取引の件数（all_or_nothing の場合、エラーの無い取引も登録されない）
+	Failed int `json:"failed"`
+
+	// Results: 取引ごとの結果（リクエストの順）
+	Results []BatchItemResult `json:"results"`
+}
+
 // TransactionResponse: 取引レスポンス
 type TransactionResponse struct {
 	// ID: 取引ID
@@ -46,6 +92,15 @@ type TransactionResponse struct {
 	// CorrectionNote: 修正の理由・説明
 	CorrectionNote string `json:"correctionNote"`
 
+	// QuarantinedAt: 整合性チェックで隔離された日時（隔離されていない場合は省略）
+	QuarantinedAt *time.Time `json:"quarantinedAt,omitempty"`
+
+	// QuarantineReason: 隔離の理由
+	QuarantineReason string `json:"quarantineReason,omitempty"`
+
+	// Version: 取引のバージョン（更新・削除時に If-Match で指定する）
+	Version uint `json:"version"`
+
 	// CreatedAt: 作成日時
 	CreatedAt time.Time `json:"createdAt"`
 
@@ -91,3 +146,33 @@ type GetTransactionsWithPaginationResponse struct {
 	// HasNextPage: 次ページが存在するか
 	HasNextPage bool `json:"hasNextPage"`
 }
+
+// GetTransactionsWithCursorRequest: カーソルによるページネーション付き取引一覧取得リクエスト
+type GetTransactionsWithCursorRequest struct {
+	// Cursor: 前回のレスポンスの nextCursor または prevCursor（省略時は先頭から）
+	Cursor string `form:"cursor"`
+
+	// Limit: 1ページあたりの件数（省略時は 20）
+	Limit int `form:"limit" binding:"omitempty,min=1,max=100"`
+
+	// Keyword: キーワード（摘要で検索）
+	Keyword string `form:"keyword"`
+
+	// IncludeTotal: 取引総数を含めるかどうか（件数の集計が必要なため、必要な場合のみ指定する）
+	IncludeTotal bool `form:"includeTotal"`
+}
+
+// GetTransactionsWithCursorResponse: カーソルによるページネーション付き取引一覧レスポンス
+type GetTransactionsWithCursorResponse struct {
+	// Transactions: 取引一覧
+	Transactions []TransactionResponse `json:"transactions"`
+
+	// NextCursor: 次ページ（古い側）を取得するカーソル（次ページが無い場合は null）
+	NextCursor *string `json:"nextCursor"`
+
+	// PrevCursor: 前ページ（新しい側）を取得するカーソル（前ページが無い場合は null）
+	PrevCursor *string `json:"prevCursor"`
+
+	// Total: 取引総数（includeTotal を指定した場合のみ）
+	Total *int `json:"total,omitempty"`
+}
@@ -1,10 +1,12 @@
 package repository
 
 import (
+	"simple-ledger/internal/common/db/dialect"
 	"simple-ledger/internal/models"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // TransactionRepository: 取引リポジトリ
@@ -17,11 +19,47 @@ func NewTransactionRepository(db *gorm.DB) *TransactionRepository {
 	return &TransactionRepository{db: db}
 }
 
+// WithTx: 指定したDBトランザクション上で動作するリポジトリを返す
+func (r *TransactionRepository) WithTx(tx *gorm.DB) *TransactionRepository {
+	return &TransactionRepository{db: tx}
+}
+
 // Create: 取引を作成
 func (r *TransactionRepository) Create(transaction *models.Transaction) error {
+	transaction.Date = dialect.Date(transaction.Date)
+	if transaction.Version == 0 {
+		transaction.Version = 1
+	}
 	return r.db.Create(transaction).Error
 }
 
+// CreateBatch: 取引をまとめて作成（作成後、各取引に ID が設定される）
+func (r *TransactionRepository) CreateBatch(transactions []models.Transaction) error {
+	for i := range transactions {
+		if transactions[i].Version == 0 {
+			transactions[i].Version = 1
+		}
+	}
+	return r.db.CreateInBatches(transactions, 100).Error
+}
+
+// GetByIDs: 複数のIDで取引を取得（仕訳エントリーを含む、IDの昇順）
+func (r *TransactionRepository) GetByIDs(ids []uint) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	if len(ids) == 0 {
+		return transactions, nil
+	}
+	if err := r.db.
+		Preload("JournalEntries").
+		Preload("JournalEntries.ChartOfAccounts").
+		Where("id IN ?", ids).
+		Order("id ASC").
+		Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
 // GetByID: IDで取引を取得
 func (r *TransactionRepository) GetByID(id uint) (*models.Transaction, error) {
 	var transaction models.Transaction
@@ -36,6 +74,19 @@ func (r *TransactionRepository) GetByID(id uint) (*models.Transaction, error) {
 	return &transaction, nil
 }
 
+// LockByID: IDで取引を行ロック付きで取得（DBトランザクション内で使用する）
+func (r *TransactionRepository) LockByID(id uint) (*models.Transaction, error) {
+	var transaction models.Transaction
+	if err := r.db.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("JournalEntries").
+		Where("id = ?", id).
+		First(&transaction).Error; err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
 // GetByUserID: ユーザーIDで取引一覧を取得
 func (r *TransactionRepository) GetByUserID(userID uint) ([]models.Transaction, error) {
 	var transactions []models.Transaction
@@ -60,7 +111,7 @@ func (r *TransactionRepository) GetByUserIDAndDateRange(
 	if err := r.db.
 		Preload("JournalEntries").
 		Preload("JournalEntries.ChartOfAccounts").
-		Where("user_id = ? AND date BETWEEN ? AND ?", userID, startDate, endDate).
+		Where("user_id = ? AND date BETWEEN ? AND ?", userID, dialect.Date(startDate), dialect.Date(endDate)).
 		Order("date DESC, created_at DESC").
 		Find(&transactions).Error; err != nil {
 		return nil, err
@@ -104,9 +155,9 @@ func (r *TransactionRepository) GetByUserIDWithPaginationAndKeyword(userID uint,
 
 	query := r.db.Where("user_id = ?", userID)
 
-	// キーワード検索（descriptionで部分一致）
+	// キーワード検索（descriptionで部分一致、英字の大文字・小文字は区別しない）
 	if keyword != "" {
-		query = query.Where("description LIKE ?", "%"+keyword+"%")
+		query = query.Where(dialect.Contains(r.db, "description", keyword))
 	}
 
 	// 全件数を取得
@@ -131,6 +182,68 @@ func (r *TransactionRepository) GetByUserIDWithPaginationAndKeyword(userID uint,
 	return transactions, total, nil
 }
 
+// Cursor: キーセットページネーションの位置（一覧の並び順 date DESC, created_at DESC, id DESC における取引のキー）
+type Cursor struct {
+	Date      time.Time
+	CreatedAt time.Time
+	ID        uint
+}
+
+// GetByUserIDWithCursor: ユーザーIDで取引一覧をキーセットページネーションで取得（date DESC, created_at DESC, id DESC）
+// cursor が nil の場合は先頭から、backward の場合は cursor より前（新しい側）の取引を取得する（どちらも一覧の並び順で返す）
+// idx_user_date_created の範囲を走査するため、OFFSET と異なり位置が深くなっても遅くならない
+func (r *TransactionRepository) GetByUserIDWithCursor(userID uint, keyword string, cursor *Cursor, backward bool, limit int) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+
+	query := r.db.Where("user_id = ?", userID)
+	if keyword != "" {
+		query = query.Where(dialect.Contains(r.db, "description", keyword))
+	}
+
+	order := "date DESC, created_at DESC, id DESC"
+	if cursor != nil {
+		date := dialect.Date(cursor.Date)
+		createdAt := dialect.Time(cursor.CreatedAt)
+		if backward {
+			order = "date ASC, created_at ASC, id ASC"
+			query = query.Where("date >= ? AND (date > ? OR (date = ? AND (created_at > ? OR (created_at = ? AND id > ?))))",
+				date, date, date, createdAt, createdAt, cursor.ID)
+		} else {
+			query = query.Where("date <= ? AND (date < ? OR (date = ? AND (created_at < ? OR (created_at = ? AND id < ?))))",
+				date, date, date, createdAt, createdAt, cursor.ID)
+		}
+	}
+
+	if err := query.
+		Preload("JournalEntries").
+		Preload("JournalEntries.ChartOfAccounts").
+		Order(order).
+		Limit(limit).
+		Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+
+	if backward {
+		for i, j := 0, len(transactions)-1; i < j; i, j = i+1, j-1 {
+			transactions[i], transactions[j] = transactions[j], transactions[i]
+		}
+	}
+	return transactions, nil
+}
+
+// CountByUserID: ユーザーの取引件数を取得（keyword を指定した場合は摘要の部分一致）
+func (r *TransactionRepository) CountByUserID(userID uint, keyword string) (int64, error) {
+	var total int64
+	query := r.db.Model(&models.Transaction{}).Where("user_id = ?", userID)
+	if keyword != "" {
+		query = query.Where(dialect.Contains(r.db, "description", keyword))
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
 // GetByUserIDAndChartOfAccountsID: ユーザーIDと勘定科目IDで取引一覧を取得
 // Note: 複式簿記対応後、取引は複数の勘定科目を含むため、この関数は廃止予定
 func (r *TransactionRepository) GetByUserIDAndChartOfAccountsID(
@@ -152,8 +265,19 @@ func (r *TransactionRepository) GetByUserIDAndChartOfAccountsID(
 }
 
 // Update: 取引を更新
+// 仕訳エントリーは JournalEntryRepository で管理するため、リレーションは保存対象から外す
 func (r *TransactionRepository) Update(transaction *models.Transaction) error {
-	return r.db.Save(transaction).Error
+	transaction.Date = dialect.Date(transaction.Date)
+	return r.db.Omit(clause.Associations).Save(transaction).Error
+}
+
+// IncrementVersion: バージョンが version の場合のみ1つ進める（一致しない場合は false）
+// 更新・修正・削除の前に同じDBトランザクション内で呼び出し、同時に行われた変更を検出する
+func (r *TransactionRepository) IncrementVersion(id uint, version uint) (bool, error) {
+	result := r.db.Model(&models.Transaction{}).
+		Where("id = ? AND version = ?", id, version).
+		UpdateColumn("version", gorm.Expr("version + 1"))
+	return result.RowsAffected == 1, result.Error
 }
 
 // Delete: 取引を削除
@@ -4,27 +4,15 @@ import (
 	"testing"
 	"time"
 
+	"simple-ledger/internal/common/db/dbtest"
 	"simple-ledger/internal/models"
 
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
-func setupTransactionTestDB() *gorm.DB {
-	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err := db.AutoMigrate(&models.User{}); err != nil {
-		panic(err)
-	}
-	if err := db.AutoMigrate(&models.ChartOfAccounts{}); err != nil {
-		panic(err)
-	}
-	if err := db.AutoMigrate(&models.Transaction{}); err != nil {
-		panic(err)
-	}
-	if err := db.AutoMigrate(&models.JournalEntry{}); err != nil {
-		panic(err)
-	}
+func setupTransactionTestDB(t *testing.T) *gorm.DB {
+	db := dbtest.Open(t, &models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{})
 
 	// テストデータの作成
 	user := models.User{
@@ -50,7 +38,7 @@ func setupTransactionTestDB() *gorm.DB {
 }
 
 func TestCreate(t *testing.T) {
-	db := setupTransactionTestDB()
+	db := setupTransactionTestDB(t)
 	repo := NewTransactionRepository(db)
 
 	transaction := models.Transaction{
@@ -65,7 +53,7 @@ func TestCreate(t *testing.T) {
 }
 
 func TestGetByID(t *testing.T) {
-	db := setupTransactionTestDB()
+	db := setupTransactionTestDB(t)
 	repo := NewTransactionRepository(db)
 
 	transaction := models.Transaction{
@@ -83,7 +71,7 @@ func TestGetByID(t *testing.T) {
 }
 
 func TestGetByUserID(t *testing.T) {
-	db := setupTransactionTestDB()
+	db := setupTransactionTestDB(t)
 	repo := NewTransactionRepository(db)
 
 	transactions := []models.Transaction{
@@ -109,7 +97,7 @@ func TestGetByUserID(t *testing.T) {
 }
 
 func TestGetByUserIDAndDateRange(t *testing.T) {
-	db := setupTransactionTestDB()
+	db := setupTransactionTestDB(t)
 	repo := NewTransactionRepository(db)
 
 	transactions := []models.Transaction{
@@ -139,7 +127,7 @@ func TestGetByUserIDAndDateRange(t *testing.T) {
 }
 
 func TestDeleteByUserIDAndID(t *testing.T) {
-	db := setupTransactionTestDB()
+	db := setupTransactionTestDB(t)
 	repo := NewTransactionRepository(db)
 
 	transaction := models.Transaction{
@@ -158,7 +146,7 @@ func TestDeleteByUserIDAndID(t *testing.T) {
 }
 
 func TestGetByUserIDWithPagination(t *testing.T) {
-	db := setupTransactionTestDB()
+	db := setupTransactionTestDB(t)
 	repo := NewTransactionRepository(db)
 
 	// テストデータの作成（30件）
@@ -223,3 +211,113 @@ func TestGetByUserIDWithPagination(t *testing.T) {
 	assert.Len(t, transactions, 0)
 	assert.Equal(t, int64(0), total)
 }
+
+func TestGetByUserIDAndDateRange_IncludesEndDateRegardlessOfTimeZone(t *testing.T) {
+	db := setupTransactionTestDB(t)
+	repo := NewTransactionRepository(db)
+
+	// 取引日は日付として保存されるため、時刻やタイムゾーンを含む値で登録・検索しても結果は変わらない
+	jst := time.FixedZone("JST", 9*60*60)
+	transaction := models.Transaction{
+		UserID:      1,
+		Date:        time.Date(2024, 12, 20, 8, 30, 0, 0, jst),
+		Description: "期末の取引",
+	}
+	assert.NoError(t, repo.Create(&transaction))
+
+	results, err := repo.GetByUserIDAndDateRange(1,
+		time.Date(2024, 12, 20, 0, 0, 0, 0, jst),
+		time.Date(2024, 12, 20, 23, 59, 59, 0, time.UTC),
+	)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "2024-12-20", results[0].Date.Format("2006-01-02"))
+}
+
+func TestGetByUserIDWithPaginationAndKeyword_MatchesSameOnAllDatabases(t *testing.T) {
+	db := setupTransactionTestDB(t)
+	repo := NewTransactionRepository(db)
+
+	for _, description := range []string{"Office Supplies", "office chair", "100% 還元", "1000円の振替", "file_name", "filename"} {
+		db.Create(&models.Transaction{UserID: 1, Date: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), Description: description})
+	}
+
+	tests := []struct {
+		keyword  string
+		expected int64
+	}{
+		{"OFFICE", 2}, // 英字の大文字・小文字は区別しない
+		{"100%", 1},   // % はワイルドカードとして扱わない
+		{"e_n", 1},    // _ もワイルドカードとして扱わない
+		{"!", 0},
+		{"振替", 1},
+	}
+	for _, tt := range tests {
+		_, total, err := repo.GetByUserIDWithPaginationAndKeyword(1, 1, 10, tt.keyword)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.expected, total, tt.keyword)
+	}
+}
+
+func TestGetByUserIDWithCursor_WalksAllTransactionsInOrder(t *testing.T) {
+	db := setupTransactionTestDB(t)
+	repo := NewTransactionRepository(db)
+
+	// 同じ取引日・同じ作成日時（一括登録）の取引を含めて、ID で順序が決まることを確認する
+	createdAt := time.Date(2024, 12, 5, 9, 30, 0, 123000000, time.UTC)
+	for i := 0; i < 10; i++ {
+		db.Create(&models.Transaction{
+			UserID:    1,
+			Date:      time.Date(2024, 12, 1+i%3, 0, 0, 0, 0, time.UTC),
+			CreatedAt: createdAt.Add(time.Duration(i%2) * time.Second),
+		})
+	}
+	db.Create(&models.Transaction{UserID: 2, Date: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)})
+
+	var expected []uint
+	all, err := repo.GetByUserIDWithCursor(1, "", nil, false, 100)
+	assert.NoError(t, err)
+	for _, transaction := range all {
+		expected = append(expected, transaction.ID)
+	}
+	assert.Len(t, expected, 10)
+
+	cursorOf := func(transaction models.Transaction) *Cursor {
+		return &Cursor{Date: transaction.Date, CreatedAt: transaction.CreatedAt, ID: transaction.ID}
+	}
+
+	// 古い側へ3件ずつ
+	var forward []uint
+	var cursor *Cursor
+	for {
+		page, err := repo.GetByUserIDWithCursor(1, "", cursor, false, 3)
+		assert.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		for _, transaction := range page {
+			forward = append(forward, transaction.ID)
+		}
+		cursor = cursorOf(page[len(page)-1])
+	}
+	assert.Equal(t, expected, forward)
+
+	// 末尾から新しい側へ3件ずつ（各ページは一覧の並び順）
+	var backward []uint
+	cursor = cursorOf(all[len(all)-1])
+	backward = append(backward, all[len(all)-1].ID)
+	for {
+		page, err := repo.GetByUserIDWithCursor(1, "", cursor, true, 3)
+		assert.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		ids := make([]uint, 0, len(page))
+		for _, transaction := range page {
+			ids = append(ids, transaction.ID)
+		}
+		backward = append(ids, backward...)
+		cursor = cursorOf(page[0])
+	}
+	assert.Equal(t, expected, backward)
+}
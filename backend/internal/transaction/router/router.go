@@ -1,9 +1,18 @@
 package router
 
 import (
+	attachmentRepository "simple-ledger/internal/attachment/repository"
+	auditRepository "simple-ledger/internal/audit/repository"
+	auditService "simple-ledger/internal/audit/service"
 	"simple-ledger/internal/auth/middleware"
+	chartOfAccountsRepository "simple-ledger/internal/chart_of_accounts/repository"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/idempotency"
+	fiscalPeriodRepository "simple-ledger/internal/fiscal_period/repository"
 	journalEntryRepository "simple-ledger/internal/journal_entry/repository"
 	journalEntryService "simple-ledger/internal/journal_entry/service"
+	"simple-ledger/internal/models"
+	postingService "simple-ledger/internal/posting/service"
 	"simple-ledger/internal/transaction/controller"
 	"simple-ledger/internal/transaction/repository"
 	"simple-ledger/internal/transaction/service"
@@ -15,16 +24,25 @@ import (
 func SetupTransactionRoutes(apiGroup *gin.RouterGroup, db *gorm.DB) {
 	repo := repository.NewTransactionRepository(db)
 	journalEntryRepo := journalEntryRepository.NewJournalEntryRepository(db)
-	journalEntrySvc := journalEntryService.NewJournalEntryService(journalEntryRepo)
-	svc := service.NewTransactionService(repo, journalEntryRepo, journalEntrySvc)
+	validator := postingService.NewPostingValidator(
+		chartOfAccountsRepository.NewChartOfAccountsRepository(db),
+		fiscalPeriodRepository.NewFiscalPeriodRepository(db),
+		postingService.ConfigFromEnv(),
+	)
+	auditSvc := auditService.NewAuditService(auditRepository.NewAuditLogRepository(db))
+	journalEntrySvc := journalEntryService.NewJournalEntryService(journalEntryRepo, validator, auditSvc, uow.New(db))
+	svc := service.NewTransactionService(repo, journalEntryRepo, attachmentRepository.NewAttachmentRepository(db), journalEntrySvc, validator, auditSvc, uow.New(db), service.ConfigFromEnv())
 	ctrl := controller.NewTransactionController(svc)
+	idempotent := idempotency.Middleware(idempotency.NewStore(db), idempotency.ConfigFromEnv())
 
 	transactionRoutes := apiGroup.Group("/transactions")
-	transactionRoutes.Use(middleware.AuthMiddleware())
+	transactionRoutes.Use(middleware.AuthMiddleware(models.TokenScopeTransactionsWrite))
 	{
-		transactionRoutes.POST("", ctrl.Create())
+		transactionRoutes.POST("", idempotent, ctrl.Create())
+		transactionRoutes.POST("/batch", idempotent, ctrl.CreateBatch())
 		transactionRoutes.GET("", ctrl.GetByUserID())
 		transactionRoutes.GET("/paginated", ctrl.GetByUserIDWithPagination())
+		transactionRoutes.GET("/cursor", ctrl.GetByUserIDWithCursor())
 		transactionRoutes.GET("/:id", ctrl.GetByID())
 		transactionRoutes.PUT("/:id", ctrl.Update())
 		transactionRoutes.DELETE("/:id", ctrl.Delete())
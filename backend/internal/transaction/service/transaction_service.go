@@ -1,63 +1,110 @@
 package service
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
+	attachmentRepository "simple-ledger/internal/attachment/repository"
+	auditService "simple-ledger/internal/audit/service"
+	"simple-ledger/internal/common/config"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/requestmeta"
 	journalEntryDto "simple-ledger/internal/journal_entry/dto"
 	journalEntryRepository "simple-ledger/internal/journal_entry/repository"
 	journalEntryService "simple-ledger/internal/journal_entry/service"
 	"simple-ledger/internal/models"
+	postingService "simple-ledger/internal/posting/service"
 	"simple-ledger/internal/transaction/dto"
 	"simple-ledger/internal/transaction/repository"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type TransactionService interface {
-	Create(userID uint, req *dto.CreateTransactionRequest) (*dto.TransactionResponse, error)
+	Create(userID uint, req *dto.CreateTransactionRequest, meta requestmeta.Meta) (*dto.TransactionResponse, error)
+	CreateBatch(userID uint, req *dto.BatchCreateTransactionsRequest, meta requestmeta.Meta) (*dto.BatchCreateTransactionsResponse, error)
 	GetByID(transactionID uint, userID uint) (*dto.TransactionResponse, error)
 	GetByUserID(userID uint) (*dto.GetTransactionsResponse, error)
 	GetByUserIDAndDateRange(userID uint, startDate string, endDate string) (*dto.GetTransactionsResponse, error)
 	GetByUserIDWithPagination(userID uint, page, pageSize int) (*dto.GetTransactionsWithPaginationResponse, error)
 	GetByUserIDWithPaginationAndKeyword(userID uint, page, pageSize int, keyword string) (*dto.GetTransactionsWithPaginationResponse, error)
-	Update(transactionID uint, userID uint, req *dto.CreateTransactionRequest) (*dto.TransactionResponse, error)
-	Delete(transactionID uint, userID uint) error
+	GetByUserIDWithCursor(userID uint, req *dto.GetTransactionsWithCursorRequest) (*dto.GetTransactionsWithCursorResponse, error)
+	Update(transactionID uint, userID uint, version uint, req *dto.CreateTransactionRequest, meta requestmeta.Meta) (*dto.TransactionResponse, error)
+	Delete(transactionID uint, userID uint, version uint, meta requestmeta.Meta) error
+}
+
+// ErrVersionMismatch: 指定されたバージョンが現在の取引のバージョンと一致しない（他の変更が先に保存された）
+var ErrVersionMismatch = errors.New("transaction has been modified by another request")
+
+// ErrInvalidCursor: ページネーションのカーソルが不正（改変された、または別の形式の値）
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrTransactionHasAttachments: 添付ファイル（証憑）がある取引は削除できない
+var ErrTransactionHasAttachments = errors.New("transaction has attachments; delete them first")
+
+// defaultCursorLimit: カーソルによるページネーションの1ページあたりのデフォルト件数
+const defaultCursorLimit = 20
+
+// Config: 取引サービスの設定
+type Config struct {
+	// BatchMaxItems: 一括登録で1回に受け付ける取引の上限
+	BatchMaxItems int
+}
+
+// DefaultConfig: 取引サービスのデフォルト設定
+func DefaultConfig() Config {
+	return Config{
+		BatchMaxItems: 500,
+	}
+}
+
+// ConfigFromEnv: 環境変数から取引サービスの設定を読み込む
+func ConfigFromEnv() Config {
+	defaults := DefaultConfig()
+	return Config{
+		BatchMaxItems: config.GetEnvAsInt("TRANSACTION_BATCH_MAX_ITEMS", defaults.BatchMaxItems),
+	}
 }
 
 type transactionService struct {
 	repo                *repository.TransactionRepository
 	journalEntryRepo    *journalEntryRepository.JournalEntryRepository
+	attachmentRepo      *attachmentRepository.AttachmentRepository
 	journalEntryService *journalEntryService.JournalEntryService
+	validator           postingService.PostingValidator
+	audit               *auditService.AuditService
+	uow                 uow.UnitOfWork
+	config              Config
 }
 
 func NewTransactionService(
 	repo *repository.TransactionRepository,
 	journalEntryRepo *journalEntryRepository.JournalEntryRepository,
+	attachmentRepo *attachmentRepository.AttachmentRepository,
 	journalEntrySvc *journalEntryService.JournalEntryService,
+	validator postingService.PostingValidator,
+	audit *auditService.AuditService,
+	unitOfWork uow.UnitOfWork,
+	cfg Config,
 ) TransactionService {
 	return &transactionService{
 		repo:                repo,
 		journalEntryRepo:    journalEntryRepo,
+		attachmentRepo:      attachmentRepo,
 		journalEntryService: journalEntrySvc,
+		validator:           validator,
+		audit:               audit,
+		uow:                 unitOfWork,
+		config:              cfg,
 	}
 }
 
-func (s *transactionService) Create(userID uint, req *dto.CreateTransactionRequest) (*dto.TransactionResponse, error) {
-	// バリデーション
-	if len(req.JournalEntries) < 2 {
-		return nil, errors.New("transaction must have at least 2 journal entries (one debit and one credit)")
-	}
-
-	// 借方と貸方の両方が存在するか確認
-	hasDebit := false
-	hasCredit := false
-	for _, entry := range req.JournalEntries {
-		if entry.Type == models.DebitEntry {
-			hasDebit = true
-		} else if entry.Type == models.CreditEntry {
-			hasCredit = true
-		}
-	}
-	if !hasDebit || !hasCredit {
-		return nil, errors.New("transaction must have both debit and credit entries")
+func (s *transactionService) Create(userID uint, req *dto.CreateTransactionRequest, meta requestmeta.Meta) (*dto.TransactionResponse, error) {
+	// 書き込み前にリクエストの仕訳を検証
+	if err := validateJournalEntries(req.JournalEntries); err != nil {
+		return nil, err
 	}
 
 	date, err := time.Parse("2006-01-02", req.Date)
@@ -65,59 +112,174 @@ func (s *transactionService) Create(userID uint, req *dto.CreateTransactionReque
 		return nil, errors.New("invalid date format, use YYYY-MM-DD")
 	}
 
-	// トランザクション作成
+	// 勘定科目・日付・金額・重複行の検証
+	if err := s.validatePosting(userID, date, req.JournalEntries); err != nil {
+		return nil, err
+	}
+
 	transaction := models.Transaction{
 		UserID:      userID,
 		Date:        date,
 		Description: req.Description,
 	}
 
-	if err := s.repo.Create(&transaction); err != nil {
+	// 取引と仕訳エントリーは1つのDBトランザクションで作成し、失敗時はまとめてロールバック
+	var result *models.Transaction
+	err = s.uow.Do(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).Create(&transaction); err != nil {
+			return err
+		}
+
+		var err error
+		result, err = s.postJournalEntries(tx, &transaction, req.JournalEntries)
+		if err != nil {
+			return err
+		}
+
+		return s.audit.WithTx(tx).Record(meta, auditService.Entry{
+			Action:     models.AuditActionCreate,
+			EntityType: models.AuditEntityTransaction,
+			EntityID:   result.ID,
+			After:      s.transactionToResponse(result),
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	// 仕訳エントリー作成
-	var journalEntries []models.JournalEntry
-	for _, entryReq := range req.JournalEntries {
-		journalEntry := models.JournalEntry{
-			TransactionID:     transaction.ID,
-			ChartOfAccountsID: entryReq.ChartOfAccountsID,
-			Type:              entryReq.Type,
-			Amount:            entryReq.Amount,
-			Description:       entryReq.Description,
-		}
-		journalEntries = append(journalEntries, journalEntry)
+	return s.transactionToResponse(result), nil
+}
+
+// CreateBatch: 取引を一括登録
+// すべての取引を検証したうえで、登録する取引を1つのDBトランザクションでまとめて作成する
+// 検証エラーは取引ごとに結果に含め、all_or_nothing の場合は1件でもエラーがあれば何も登録しない
+func (s *transactionService) CreateBatch(userID uint, req *dto.BatchCreateTransactionsRequest, meta requestmeta.Meta) (*dto.BatchCreateTransactionsResponse, error) {
+	mode := req.Mode
+	if mode == "" {
+		mode = dto.BatchModeAllOrNothing
+	}
+	if mode != dto.BatchModeAllOrNothing && mode != dto.BatchModeBestEffort {
+		return nil, fmt.Errorf("invalid batch mode: %s", mode)
+	}
+	if len(req.Transactions) == 0 {
+		return nil, errors.New("batch must contain at least 1 transaction")
+	}
+	if len(req.Transactions) > s.config.BatchMaxItems {
+		return nil, fmt.Errorf("batch must not contain more than %d transactions", s.config.BatchMaxItems)
 	}
 
-	if err := s.journalEntryRepo.CreateBatch(journalEntries); err != nil {
-		// トランザクション作成がロールバックされるように、エラーを返す
-		if delErr := s.repo.Delete(transaction.ID); delErr != nil {
-			return nil, errors.New("failed to rollback transaction: " + delErr.Error())
+	response := &dto.BatchCreateTransactionsResponse{
+		Mode:    mode,
+		Results: make([]dto.BatchItemResult, len(req.Transactions)),
+	}
+
+	// 取引日と摘要の検証（仕訳行の検証は記帳検証に任せる）
+	postings := make([]*postingService.Posting, 0, len(req.Transactions))
+	postingIndexes := make([]int, 0, len(req.Transactions))
+	for i := range req.Transactions {
+		item := &req.Transactions[i]
+		response.Results[i].Index = i
+
+		date, err := parseBatchItem(item)
+		if err != nil {
+			setBatchItemError(&response.Results[i], err)
+			continue
 		}
+		postings = append(postings, &postingService.Posting{
+			UserID: userID,
+			Date:   date,
+			Lines:  postingLines(item.JournalEntries),
+		})
+		postingIndexes = append(postingIndexes, i)
+	}
+
+	// 勘定科目・日付・金額・種別・重複行の検証（まとめて問い合わせる）
+	postingErrs, err := s.validator.ValidateAll(postings)
+	if err != nil {
 		return nil, err
 	}
+	var accepted []int
+	var transactions []models.Transaction
+	for j, i := range postingIndexes {
+		if postingErrs[j] != nil {
+			setBatchItemError(&response.Results[i], postingErrs[j])
+			continue
+		}
+		// 貸借一致は記帳検証の対象外のため、ここで確認する
+		if err := validateBalance(req.Transactions[i].JournalEntries); err != nil {
+			setBatchItemError(&response.Results[i], err)
+			continue
+		}
+		accepted = append(accepted, i)
+		transactions = append(transactions, models.Transaction{
+			UserID:      userID,
+			Date:        postings[j].Date,
+			Description: req.Transactions[i].Description,
+		})
+	}
 
-	// 複式簿記の検証
-	isValid, err := s.journalEntryService.ValidateTransaction(transaction.ID)
-	if !isValid || err != nil {
-		if delErr := s.journalEntryRepo.DeleteByTransactionID(transaction.ID); delErr != nil {
-			return nil, errors.New("failed to delete journal entries: " + delErr.Error())
+	response.Failed = len(req.Transactions) - len(accepted)
+	if len(accepted) == 0 || (response.Failed > 0 && mode == dto.BatchModeAllOrNothing) {
+		return response, nil
+	}
+
+	var created []models.Transaction
+	err = s.uow.Do(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).CreateBatch(transactions); err != nil {
+			return err
+		}
+
+		ids := make([]uint, len(transactions))
+		var journalEntries []models.JournalEntry
+		for j, i := range accepted {
+			ids[j] = transactions[j].ID
+			journalEntries = append(journalEntries, newJournalEntries(transactions[j].ID, req.Transactions[i].JournalEntries)...)
 		}
-		if delErr := s.repo.Delete(transaction.ID); delErr != nil {
-			return nil, errors.New("failed to delete transaction: " + delErr.Error())
+		if err := s.journalEntryRepo.WithTx(tx).CreateBatch(journalEntries); err != nil {
+			return err
 		}
+
+		// 複式簿記の検証（書き込み後の状態を同一トランザクション内でまとめて確認）
+		unbalanced, err := s.journalEntryRepo.WithTx(tx).GetUnbalancedTransactionIDs(ids)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if len(unbalanced) > 0 {
+			return errors.New("transaction failed validation: debit and credit totals must be equal")
 		}
-		return nil, errors.New("transaction failed validation: debit and credit totals must be equal")
-	}
 
-	result, err := s.repo.GetByID(transaction.ID)
+		created, err = s.repo.WithTx(tx).GetByIDs(ids)
+		if err != nil {
+			return err
+		}
+		for j := range created {
+			if err := s.audit.WithTx(tx).Record(meta, auditService.Entry{
+				Action:     models.AuditActionCreate,
+				EntityType: models.AuditEntityTransaction,
+				EntityID:   created[j].ID,
+				After:      s.transactionToResponse(&created[j]),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return s.transactionToResponse(result), nil
+	// 作成した取引は ID でリクエストの位置に対応づける
+	createdByID := make(map[uint]*models.Transaction, len(created))
+	for j := range created {
+		createdByID[created[j].ID] = &created[j]
+	}
+	for j, i := range accepted {
+		if transaction, ok := createdByID[transactions[j].ID]; ok {
+			response.Results[i].Transaction = s.transactionToResponse(transaction)
+		}
+	}
+	response.Created = len(created)
+	return response, nil
 }
 
 func (s *transactionService) GetByID(transactionID uint, userID uint) (*dto.TransactionResponse, error) {
@@ -221,7 +383,78 @@ func (s *transactionService) GetByUserIDWithPaginationAndKeyword(userID uint, pa
 	}, nil
 }
 
-func (s *transactionService) Update(transactionID uint, userID uint, req *dto.CreateTransactionRequest) (*dto.TransactionResponse, error) {
+// GetByUserIDWithCursor: ユーザーの取引一覧をカーソルによるページネーションで取得
+// OFFSET と異なり、閲覧中に取引が追加・削除されてもページの境目で取引が重複・欠落しない
+func (s *transactionService) GetByUserIDWithCursor(userID uint, req *dto.GetTransactionsWithCursorRequest) (*dto.GetTransactionsWithCursorResponse, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultCursorLimit
+	}
+
+	var position *cursorPosition
+	if req.Cursor != "" {
+		var err error
+		position, err = decodeCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var cursor *repository.Cursor
+	backward := false
+	if position != nil {
+		cursor = &repository.Cursor{Date: position.Date, CreatedAt: position.CreatedAt, ID: position.ID}
+		backward = position.Backward
+	}
+
+	// 1件多く取得して、取得方向にさらに取引があるかを判定する
+	transactions, err := s.repo.GetByUserIDWithCursor(userID, req.Keyword, cursor, backward, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	hasMore := len(transactions) > limit
+	if hasMore {
+		if backward {
+			transactions = transactions[1:]
+		} else {
+			transactions = transactions[:limit]
+		}
+	}
+
+	response := &dto.GetTransactionsWithCursorResponse{
+		Transactions: make([]dto.TransactionResponse, 0, len(transactions)),
+	}
+	for i := range transactions {
+		response.Transactions = append(response.Transactions, *s.transactionToResponse(&transactions[i]))
+	}
+
+	if len(transactions) > 0 {
+		// 取得方向の先は hasMore で、逆方向はカーソルを指定した場合（元のページがある場合）に続きがある
+		if (!backward && hasMore) || (backward && cursor != nil) {
+			next := encodeCursor(&transactions[len(transactions)-1], false)
+			response.NextCursor = &next
+		}
+		if (backward && hasMore) || (!backward && cursor != nil) {
+			prev := encodeCursor(&transactions[0], true)
+			response.PrevCursor = &prev
+		}
+	}
+
+	if req.IncludeTotal {
+		total, err := s.repo.CountByUserID(userID, req.Keyword)
+		if err != nil {
+			return nil, err
+		}
+		count := int(total)
+		response.Total = &count
+	}
+
+	return response, nil
+}
+
+// Update: 取引を更新（修正ノートがある場合は修正取引を作成）
+// version は利用者が取得した時点の取引のバージョンで、現在のバージョンと一致しない場合は ErrVersionMismatch を返す
+func (s *transactionService) Update(transactionID uint, userID uint, version uint, req *dto.CreateTransactionRequest, meta requestmeta.Meta) (*dto.TransactionResponse, error) {
 	transaction, err := s.repo.GetByID(transactionID)
 	if err != nil {
 		return nil, err
@@ -231,22 +464,13 @@ func (s *transactionService) Update(transactionID uint, userID uint, req *dto.Cr
 		return nil, errors.New("unauthorized")
 	}
 
-	if len(req.JournalEntries) < 2 {
-		return nil, errors.New("transaction must have at least 2 journal entries (one debit and one credit)")
+	if transaction.Version != version {
+		return nil, ErrVersionMismatch
 	}
 
-	// 借方と貸方の両方が存在するか確認
-	hasDebit := false
-	hasCredit := false
-	for _, entry := range req.JournalEntries {
-		if entry.Type == models.DebitEntry {
-			hasDebit = true
-		} else if entry.Type == models.CreditEntry {
-			hasCredit = true
-		}
-	}
-	if !hasDebit || !hasCredit {
-		return nil, errors.New("transaction must have both debit and credit entries")
+	// 既存の仕訳を削除する前に、置き換え後の仕訳を検証
+	if err := validateJournalEntries(req.JournalEntries); err != nil {
+		return nil, err
 	}
 
 	date, err := time.Parse("2006-01-02", req.Date)
@@ -254,9 +478,16 @@ func (s *transactionService) Update(transactionID uint, userID uint, req *dto.Cr
 		return nil, errors.New("invalid date format, use YYYY-MM-DD")
 	}
 
+	// 勘定科目・日付・金額・重複行の検証
+	if err := s.validatePosting(userID, date, req.JournalEntries); err != nil {
+		return nil, err
+	}
+
+	var result *models.Transaction
+	before := s.transactionToResponse(transaction)
+
 	// 修正フロー：CorrectionNoteがある場合は新しいトランザクションを作成
 	if req.CorrectionNote != "" {
-		// 新しい修正トランザクションを作成
 		newTransaction := &models.Transaction{
 			UserID:          userID,
 			Date:            date,
@@ -266,43 +497,31 @@ func (s *transactionService) Update(transactionID uint, userID uint, req *dto.Cr
 			CorrectionNote:  req.CorrectionNote,
 		}
 
-		if err := s.repo.Create(newTransaction); err != nil {
-			return nil, err
-		}
-
-		// 新しい仕訳エントリーを作成
-		var journalEntries []models.JournalEntry
-		for _, entryReq := range req.JournalEntries {
-			journalEntry := models.JournalEntry{
-				TransactionID:     newTransaction.ID,
-				ChartOfAccountsID: entryReq.ChartOfAccountsID,
-				Type:              entryReq.Type,
-				Amount:            entryReq.Amount,
-				Description:       entryReq.Description,
+		err = s.uow.Do(func(tx *gorm.DB) error {
+			// 同じバージョンからの修正が重複しないよう、修正元のバージョンも進める
+			if err := s.incrementVersion(tx, transactionID, version); err != nil {
+				return err
 			}
-			journalEntries = append(journalEntries, journalEntry)
-		}
 
-		if err := s.journalEntryRepo.CreateBatch(journalEntries); err != nil {
-			return nil, err
-		}
-
-		// 複式簿記の検証
-		isValid, err := s.journalEntryService.ValidateTransaction(newTransaction.ID)
-		if !isValid || err != nil {
-			if delErr := s.journalEntryRepo.DeleteByTransactionID(newTransaction.ID); delErr != nil {
-				return nil, errors.New("failed to delete journal entries: " + delErr.Error())
-			}
-			if delErr := s.repo.Delete(newTransaction.ID); delErr != nil {
-				return nil, errors.New("failed to delete transaction: " + delErr.Error())
+			if err := s.repo.WithTx(tx).Create(newTransaction); err != nil {
+				return err
 			}
+
+			var err error
+			result, err = s.postJournalEntries(tx, newTransaction, req.JournalEntries)
 			if err != nil {
-				return nil, err
+				return err
 			}
-			return nil, errors.New("transaction failed validation: debit and credit totals must be equal")
-		}
 
-		result, err := s.repo.GetByID(newTransaction.ID)
+			// 修正元の取引を変更前、修正取引を変更後として記録
+			return s.audit.WithTx(tx).Record(meta, auditService.Entry{
+				Action:     models.AuditActionCorrect,
+				EntityType: models.AuditEntityTransaction,
+				EntityID:   result.ID,
+				Before:     before,
+				After:      s.transactionToResponse(result),
+			})
+		})
 		if err != nil {
 			return nil, err
 		}
@@ -311,84 +530,308 @@ func (s *transactionService) Update(transactionID uint, userID uint, req *dto.Cr
 	}
 
 	// 通常更新フロー：修正ノートなし
+	// 締め済み期間の取引は直接変更できない（修正取引で訂正する）
+	if err := s.validator.ValidatePeriodOpen(userID, transaction.Date); err != nil {
+		return nil, err
+	}
+
 	transaction.Date = date
 	transaction.Description = req.Description
+	transaction.Version = version + 1
+
+	// 仕訳の置き換えと取引の更新を1つのDBトランザクションで行う
+	err = s.uow.Do(func(tx *gorm.DB) error {
+		if err := s.incrementVersion(tx, transactionID, version); err != nil {
+			return err
+		}
+
+		if err := s.journalEntryRepo.WithTx(tx).DeleteByTransactionID(transactionID); err != nil {
+			return err
+		}
+
+		if err := s.repo.WithTx(tx).Update(transaction); err != nil {
+			return err
+		}
+
+		var err error
+		result, err = s.postJournalEntries(tx, transaction, req.JournalEntries)
+		if err != nil {
+			return err
+		}
 
-	// 既存の仕訳エントリーを削除
-	if err := s.journalEntryRepo.DeleteByTransactionID(transactionID); err != nil {
+		// 添付ファイルの検索項目（取引年月日・取引金額）を変更後の取引に合わせる
+		if err := s.attachmentRepo.WithTx(tx).SyncTransaction(transactionID, result.Date, debitTotal(result)); err != nil {
+			return err
+		}
+
+		return s.audit.WithTx(tx).Record(meta, auditService.Entry{
+			Action:     models.AuditActionUpdate,
+			EntityType: models.AuditEntityTransaction,
+			EntityID:   transactionID,
+			Before:     before,
+			After:      s.transactionToResponse(result),
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	// 新しい仕訳エントリーを作成
-	var journalEntries []models.JournalEntry
-	for _, entryReq := range req.JournalEntries {
-		journalEntry := models.JournalEntry{
-			TransactionID:     transaction.ID,
-			ChartOfAccountsID: entryReq.ChartOfAccountsID,
-			Type:              entryReq.Type,
-			Amount:            entryReq.Amount,
-			Description:       entryReq.Description,
-		}
-		journalEntries = append(journalEntries, journalEntry)
+	return s.transactionToResponse(result), nil
+}
+
+// Delete: 取引を削除
+// version は利用者が取得した時点の取引のバージョンで、現在のバージョンと一致しない場合は ErrVersionMismatch を返す
+// 添付ファイルがある取引は ErrTransactionHasAttachments を返す（証憑は取引と別に保存期間を管理するため、先に削除させる）
+func (s *transactionService) Delete(transactionID uint, userID uint, version uint, meta requestmeta.Meta) error {
+	transaction, err := s.repo.GetByID(transactionID)
+	if err != nil {
+		return err
 	}
 
-	if err := s.journalEntryRepo.CreateBatch(journalEntries); err != nil {
-		return nil, err
+	if transaction.UserID != userID {
+		return errors.New("unauthorized")
 	}
 
-	// 複式簿記の検証
-	isValid, err := s.journalEntryService.ValidateTransaction(transaction.ID)
-	if !isValid || err != nil {
-		if delErr := s.journalEntryRepo.DeleteByTransactionID(transactionID); delErr != nil {
-			return nil, errors.New("failed to delete journal entries: " + delErr.Error())
+	if transaction.Version != version {
+		return ErrVersionMismatch
+	}
+
+	if err := s.validator.ValidatePeriodOpen(userID, transaction.Date); err != nil {
+		return err
+	}
+
+	// 仕訳エントリーと取引をまとめて削除
+	return s.uow.Do(func(tx *gorm.DB) error {
+		if err := s.incrementVersion(tx, transactionID, version); err != nil {
+			return err
 		}
+
+		// バージョンの更新で取引の行をロックした後に確認し、同時に添付された場合も検出する
+		attachments, err := s.attachmentRepo.WithTx(tx).CountByTransactionID(transactionID)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		return nil, errors.New("transaction failed validation: debit and credit totals must be equal")
+		if attachments > 0 {
+			return ErrTransactionHasAttachments
+		}
+
+		if err := s.journalEntryRepo.WithTx(tx).DeleteByTransactionID(transactionID); err != nil {
+			return err
+		}
+
+		if err := s.repo.WithTx(tx).Delete(transactionID); err != nil {
+			return err
+		}
+
+		return s.audit.WithTx(tx).Record(meta, auditService.Entry{
+			Action:     models.AuditActionDelete,
+			EntityType: models.AuditEntityTransaction,
+			EntityID:   transactionID,
+			Before:     s.transactionToResponse(transaction),
+		})
+	})
+}
+
+// incrementVersion: DBトランザクション内で取引のバージョンを進める
+// 読み込み後に他のリクエストが変更を保存していた場合は ErrVersionMismatch を返し、変更をロールバックさせる
+func (s *transactionService) incrementVersion(tx *gorm.DB, transactionID uint, version uint) error {
+	ok, err := s.repo.WithTx(tx).IncrementVersion(transactionID, version)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrVersionMismatch
 	}
+	return nil
+}
 
-	if err := s.repo.Update(transaction); err != nil {
+// postJournalEntries: DBトランザクション内で取引に仕訳エントリーを作成し、貸借一致を再確認したうえで取引を取得
+func (s *transactionService) postJournalEntries(
+	tx *gorm.DB,
+	transaction *models.Transaction,
+	entryReqs []journalEntryDto.CreateJournalEntryRequest,
+) (*models.Transaction, error) {
+	journalEntries := newJournalEntries(transaction.ID, entryReqs)
+	if err := s.journalEntryRepo.WithTx(tx).CreateBatch(journalEntries); err != nil {
 		return nil, err
 	}
 
-	result, err := s.repo.GetByID(transaction.ID)
+	// 複式簿記の検証（書き込み後の状態を同一トランザクション内で確認）
+	isValid, err := s.journalEntryService.WithTx(tx).ValidateTransaction(transaction.ID)
 	if err != nil {
 		return nil, err
 	}
+	if !isValid {
+		return nil, errors.New("transaction failed validation: debit and credit totals must be equal")
+	}
 
-	return s.transactionToResponse(result), nil
+	return s.repo.WithTx(tx).GetByID(transaction.ID)
 }
 
-func (s *transactionService) Delete(transactionID uint, userID uint) error {
-	transaction, err := s.repo.GetByID(transactionID)
+// debitTotal: 取引の借方合計（取引金額）
+func debitTotal(transaction *models.Transaction) int {
+	total := 0
+	for _, entry := range transaction.JournalEntries {
+		if entry.Type == models.DebitEntry {
+			total += entry.Amount
+		}
+	}
+	return total
+}
+
+// newJournalEntries: リクエストの仕訳から取引の仕訳エントリーを生成
+func newJournalEntries(transactionID uint, entryReqs []journalEntryDto.CreateJournalEntryRequest) []models.JournalEntry {
+	journalEntries := make([]models.JournalEntry, 0, len(entryReqs))
+	for _, entryReq := range entryReqs {
+		journalEntries = append(journalEntries, models.JournalEntry{
+			TransactionID:     transactionID,
+			ChartOfAccountsID: entryReq.ChartOfAccountsID,
+			Type:              entryReq.Type,
+			Amount:            entryReq.Amount,
+			Description:       entryReq.Description,
+		})
+	}
+	return journalEntries
+}
+
+// validatePosting: 記帳検証（勘定科目・日付・金額・重複行）を実行
+func (s *transactionService) validatePosting(userID uint, date time.Time, entryReqs []journalEntryDto.CreateJournalEntryRequest) error {
+	return s.validator.Validate(&postingService.Posting{
+		UserID: userID,
+		Date:   date,
+		Lines:  postingLines(entryReqs),
+	})
+}
+
+// postingLines: リクエストの仕訳を記帳検証の仕訳行に変換
+func postingLines(entryReqs []journalEntryDto.CreateJournalEntryRequest) []postingService.Line {
+	lines := make([]postingService.Line, 0, len(entryReqs))
+	for _, entryReq := range entryReqs {
+		lines = append(lines, postingService.Line{
+			ChartOfAccountsID: entryReq.ChartOfAccountsID,
+			Type:              entryReq.Type,
+			Amount:            entryReq.Amount,
+			Description:       entryReq.Description,
+		})
+	}
+	return lines
+}
+
+// parseBatchItem: 一括登録の取引の取引日・摘要を検証し、取引日を返す
+// 一括登録では取引ごとに結果を返すため、リクエストのバインド時ではなくここで各取引の必須項目を確認する
+func parseBatchItem(req *dto.CreateTransactionRequest) (time.Time, error) {
+	if req.Date == "" {
+		return time.Time{}, errors.New("date is required")
+	}
+	if len([]rune(req.Description)) > 255 {
+		return time.Time{}, errors.New("description must not exceed 255 characters")
+	}
+	date, err := time.Parse("2006-01-02", req.Date)
 	if err != nil {
-		return err
+		return time.Time{}, errors.New("invalid date format, use YYYY-MM-DD")
 	}
+	return date, nil
+}
 
-	if transaction.UserID != userID {
-		return errors.New("unauthorized")
+// setBatchItemError: 一括登録の取引の結果にエラーを設定
+func setBatchItemError(result *dto.BatchItemResult, err error) {
+	result.Error = err.Error()
+	var validationErr *postingService.ValidationError
+	if errors.As(err, &validationErr) {
+		result.Details = validationErr.Errors
 	}
+}
 
-	// 関連する仕訳エントリーも削除
-	if err := s.journalEntryRepo.DeleteByTransactionID(transactionID); err != nil {
-		return err
+// validateJournalEntries: 書き込み前にリクエストの仕訳を検証
+// 最低2行・借方と貸方の両方・正の金額・借方合計 = 貸方合計 を確認する
+func validateJournalEntries(entries []journalEntryDto.CreateJournalEntryRequest) error {
+	for _, entry := range entries {
+		if entry.Amount <= 0 {
+			return errors.New("amount must be greater than 0")
+		}
+		if entry.Type != models.DebitEntry && entry.Type != models.CreditEntry {
+			return fmt.Errorf("invalid journal entry type: %s", entry.Type)
+		}
+	}
+
+	return validateBalance(entries)
+}
+
+// validateBalance: 最低2行・借方と貸方の両方・借方合計 = 貸方合計 を確認する
+// 金額と種別は記帳検証（PostingValidator）で確認するため、ここでは集計のみ行う
+func validateBalance(entries []journalEntryDto.CreateJournalEntryRequest) error {
+	if len(entries) < 2 {
+		return errors.New("transaction must have at least 2 journal entries (one debit and one credit)")
+	}
+
+	debitTotal := 0
+	creditTotal := 0
+	for _, entry := range entries {
+		switch entry.Type {
+		case models.DebitEntry:
+			debitTotal += entry.Amount
+		case models.CreditEntry:
+			creditTotal += entry.Amount
+		}
 	}
 
-	return s.repo.Delete(transactionID)
+	if debitTotal == 0 || creditTotal == 0 {
+		return errors.New("transaction must have both debit and credit entries")
+	}
+
+	if debitTotal != creditTotal {
+		return fmt.Errorf("debit total must equal credit total (debit: %d, credit: %d)", debitTotal, creditTotal)
+	}
+
+	return nil
+}
+
+// cursorPosition: カーソルに含める一覧上の位置と取得方向
+type cursorPosition struct {
+	Date      time.Time `json:"d"`
+	CreatedAt time.Time `json:"c"`
+	ID        uint      `json:"i"`
+	Backward  bool      `json:"b,omitempty"`
+}
+
+// encodeCursor: 取引の位置をクライアントに渡すカーソル（不透明な文字列）にする
+func encodeCursor(transaction *models.Transaction, backward bool) string {
+	encoded, _ := json.Marshal(cursorPosition{
+		Date:      transaction.Date.UTC(),
+		CreatedAt: transaction.CreatedAt.UTC(),
+		ID:        transaction.ID,
+		Backward:  backward,
+	})
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// decodeCursor: カーソルから一覧上の位置を取り出す
+func decodeCursor(cursor string) (*cursorPosition, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var position cursorPosition
+	if err := json.Unmarshal(decoded, &position); err != nil || position.ID == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &position, nil
 }
 
 func (s *transactionService) transactionToResponse(transaction *models.Transaction) *dto.TransactionResponse {
 	response := &dto.TransactionResponse{
-		ID:              transaction.ID,
-		UserID:          transaction.UserID,
-		Date:            transaction.Date.Format("2006-01-02"),
-		Description:     transaction.Description,
-		CreatedAt:       transaction.CreatedAt,
-		UpdatedAt:       transaction.UpdatedAt,
-		IsCorrection:    transaction.IsCorrection,
-		CorrectedFromID: transaction.CorrectedFromID,
-		CorrectionNote:  transaction.CorrectionNote,
+		ID:               transaction.ID,
+		UserID:           transaction.UserID,
+		Date:             transaction.Date.Format("2006-01-02"),
+		Description:      transaction.Description,
+		CreatedAt:        transaction.CreatedAt,
+		UpdatedAt:        transaction.UpdatedAt,
+		IsCorrection:     transaction.IsCorrection,
+		CorrectedFromID:  transaction.CorrectedFromID,
+		CorrectionNote:   transaction.CorrectionNote,
+		QuarantinedAt:    transaction.QuarantinedAt,
+		QuarantineReason: transaction.QuarantineReason,
+		Version:          transaction.Version,
 	}
 
 	if len(transaction.JournalEntries) > 0 {
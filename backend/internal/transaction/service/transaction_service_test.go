@@ -4,10 +4,18 @@ import (
 	"testing"
 	"time"
 
+	attachmentrepository "simple-ledger/internal/attachment/repository"
+	auditrepository "simple-ledger/internal/audit/repository"
+	auditservice "simple-ledger/internal/audit/service"
+	chartOfAccountsRepository "simple-ledger/internal/chart_of_accounts/repository"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/requestmeta"
+	fiscalPeriodRepository "simple-ledger/internal/fiscal_period/repository"
 	jeDto "simple-ledger/internal/journal_entry/dto"
 	jerepository "simple-ledger/internal/journal_entry/repository"
 	jeservice "simple-ledger/internal/journal_entry/service"
 	"simple-ledger/internal/models"
+	postingService "simple-ledger/internal/posting/service"
 	txdto "simple-ledger/internal/transaction/dto"
 	txrepository "simple-ledger/internal/transaction/repository"
 
@@ -27,7 +35,13 @@ func setupServiceTestDB() *gorm.DB {
 	if err := db.AutoMigrate(&models.Transaction{}); err != nil {
 		panic(err)
 	}
-	if err := db.AutoMigrate(&models.JournalEntry{}); err != nil {
+	if err := db.AutoMigrate(&models.JournalEntry{}, &models.Attachment{}); err != nil {
+		panic(err)
+	}
+	if err := db.AutoMigrate(&models.AuditLog{}, &models.AuditChainHead{}); err != nil {
+		panic(err)
+	}
+	if err := db.AutoMigrate(&models.FiscalPeriod{}); err != nil {
 		panic(err)
 	}
 
@@ -58,8 +72,10 @@ func TestGetByUserIDWithPagination(t *testing.T) {
 	db := setupServiceTestDB()
 	txRepo := txrepository.NewTransactionRepository(db)
 	jeRepo := jerepository.NewJournalEntryRepository(db)
-	jeSvc := jeservice.NewJournalEntryService(jeRepo)
-	svc := NewTransactionService(txRepo, jeRepo, jeSvc)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), DefaultConfig())
 
 	// テストデータの作成（30件）
 	for i := 1; i <= 30; i++ {
@@ -123,8 +139,10 @@ func TestGetByUserIDWithPaginationEdgeCase(t *testing.T) {
 	db := setupServiceTestDB()
 	txRepo := txrepository.NewTransactionRepository(db)
 	jeRepo := jerepository.NewJournalEntryRepository(db)
-	jeSvc := jeservice.NewJournalEntryService(jeRepo)
-	svc := NewTransactionService(txRepo, jeRepo, jeSvc)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), DefaultConfig())
 
 	// テストデータの作成（5件）
 	for i := 1; i <= 5; i++ {
@@ -158,7 +176,7 @@ func TestGetByUserIDWithPaginationEdgeCase(t *testing.T) {
 // TestCreateWithDoubleEntryBookkeeping: 複式簿記対応 - Create正常系（シンプルな1:1仕訳）
 func TestCreateWithDoubleEntryBookkeeping(t *testing.T) {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}, &models.Attachment{}, &models.FiscalPeriod{}, &models.AuditLog{}, &models.AuditChainHead{}); err != nil {
 		panic(err)
 	}
 
@@ -179,8 +197,10 @@ func TestCreateWithDoubleEntryBookkeeping(t *testing.T) {
 
 	txRepo := txrepository.NewTransactionRepository(db)
 	jeRepo := jerepository.NewJournalEntryRepository(db)
-	jeSvc := jeservice.NewJournalEntryService(jeRepo)
-	svc := NewTransactionService(txRepo, jeRepo, jeSvc)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), DefaultConfig())
 
 	req := &txdto.CreateTransactionRequest{
 		Date:        "2024-12-01",
@@ -191,7 +211,7 @@ func TestCreateWithDoubleEntryBookkeeping(t *testing.T) {
 		},
 	}
 
-	result, err := svc.Create(1, req)
+	result, err := svc.Create(1, req, requestmeta.Meta{})
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.Equal(t, uint(1), result.UserID)
@@ -202,7 +222,7 @@ func TestCreateWithDoubleEntryBookkeeping(t *testing.T) {
 // TestCreateWithMultipleDebitsAndCredits: 複式簿記対応 - 複数仕訳対応
 func TestCreateWithMultipleDebitsAndCredits(t *testing.T) {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}, &models.Attachment{}, &models.FiscalPeriod{}, &models.AuditLog{}, &models.AuditChainHead{}); err != nil {
 		panic(err)
 	}
 
@@ -235,8 +255,10 @@ func TestCreateWithMultipleDebitsAndCredits(t *testing.T) {
 
 	txRepo := txrepository.NewTransactionRepository(db)
 	jeRepo := jerepository.NewJournalEntryRepository(db)
-	jeSvc := jeservice.NewJournalEntryService(jeRepo)
-	svc := NewTransactionService(txRepo, jeRepo, jeSvc)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), DefaultConfig())
 
 	// (現金100,000 + 売掛金50,000) = (売上120,000 + 利息30,000)
 	req := &txdto.CreateTransactionRequest{
@@ -250,7 +272,7 @@ func TestCreateWithMultipleDebitsAndCredits(t *testing.T) {
 		},
 	}
 
-	result, err := svc.Create(1, req)
+	result, err := svc.Create(1, req, requestmeta.Meta{})
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.Len(t, result.JournalEntries, 4)
@@ -259,7 +281,7 @@ func TestCreateWithMultipleDebitsAndCredits(t *testing.T) {
 // TestCreateWithUnbalancedEntries: 複式簿記対応 - バランスが取れない場合エラー
 func TestCreateWithUnbalancedEntries(t *testing.T) {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}, &models.Attachment{}, &models.FiscalPeriod{}, &models.AuditLog{}, &models.AuditChainHead{}); err != nil {
 		panic(err)
 	}
 
@@ -280,8 +302,10 @@ func TestCreateWithUnbalancedEntries(t *testing.T) {
 
 	txRepo := txrepository.NewTransactionRepository(db)
 	jeRepo := jerepository.NewJournalEntryRepository(db)
-	jeSvc := jeservice.NewJournalEntryService(jeRepo)
-	svc := NewTransactionService(txRepo, jeRepo, jeSvc)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), DefaultConfig())
 
 	// バランスが取れない: 借方100,000 ≠ 貸方50,000
 	req := &txdto.CreateTransactionRequest{
@@ -293,7 +317,7 @@ func TestCreateWithUnbalancedEntries(t *testing.T) {
 		},
 	}
 
-	result, err := svc.Create(1, req)
+	result, err := svc.Create(1, req, requestmeta.Meta{})
 	assert.Error(t, err)
 	assert.Nil(t, result)
 	assert.Contains(t, err.Error(), "debit total must equal credit total")
@@ -302,7 +326,7 @@ func TestCreateWithUnbalancedEntries(t *testing.T) {
 // TestCreateWithoutDebit: 複式簿記対応 - 借方なしエラー
 func TestCreateWithoutDebit(t *testing.T) {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}, &models.Attachment{}, &models.FiscalPeriod{}, &models.AuditLog{}, &models.AuditChainHead{}); err != nil {
 		panic(err)
 	}
 
@@ -317,8 +341,10 @@ func TestCreateWithoutDebit(t *testing.T) {
 
 	txRepo := txrepository.NewTransactionRepository(db)
 	jeRepo := jerepository.NewJournalEntryRepository(db)
-	jeSvc := jeservice.NewJournalEntryService(jeRepo)
-	svc := NewTransactionService(txRepo, jeRepo, jeSvc)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), DefaultConfig())
 
 	req := &txdto.CreateTransactionRequest{
 		Date:        "2024-12-01",
@@ -328,7 +354,7 @@ func TestCreateWithoutDebit(t *testing.T) {
 		},
 	}
 
-	result, err := svc.Create(1, req)
+	result, err := svc.Create(1, req, requestmeta.Meta{})
 	assert.Error(t, err)
 	assert.Nil(t, result)
 	assert.Contains(t, err.Error(), "at least 2")
@@ -337,7 +363,7 @@ func TestCreateWithoutDebit(t *testing.T) {
 // TestCreateWithoutCredit: 複式簿記対応 - 貸方なしエラー
 func TestCreateWithoutCredit(t *testing.T) {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}, &models.Attachment{}, &models.FiscalPeriod{}, &models.AuditLog{}, &models.AuditChainHead{}); err != nil {
 		panic(err)
 	}
 
@@ -352,8 +378,10 @@ func TestCreateWithoutCredit(t *testing.T) {
 
 	txRepo := txrepository.NewTransactionRepository(db)
 	jeRepo := jerepository.NewJournalEntryRepository(db)
-	jeSvc := jeservice.NewJournalEntryService(jeRepo)
-	svc := NewTransactionService(txRepo, jeRepo, jeSvc)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), DefaultConfig())
 
 	req := &txdto.CreateTransactionRequest{
 		Date:        "2024-12-01",
@@ -363,7 +391,7 @@ func TestCreateWithoutCredit(t *testing.T) {
 		},
 	}
 
-	result, err := svc.Create(1, req)
+	result, err := svc.Create(1, req, requestmeta.Meta{})
 	assert.Error(t, err)
 	assert.Nil(t, result)
 	assert.Contains(t, err.Error(), "at least 2")
@@ -372,7 +400,7 @@ func TestCreateWithoutCredit(t *testing.T) {
 // TestCreateWithOnlyDebitEntries: 複式簿記対応 - 借方のみエラー
 func TestCreateWithOnlyDebitEntries(t *testing.T) {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}, &models.Attachment{}, &models.FiscalPeriod{}, &models.AuditLog{}, &models.AuditChainHead{}); err != nil {
 		panic(err)
 	}
 
@@ -387,8 +415,10 @@ func TestCreateWithOnlyDebitEntries(t *testing.T) {
 
 	txRepo := txrepository.NewTransactionRepository(db)
 	jeRepo := jerepository.NewJournalEntryRepository(db)
-	jeSvc := jeservice.NewJournalEntryService(jeRepo)
-	svc := NewTransactionService(txRepo, jeRepo, jeSvc)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), DefaultConfig())
 
 	req := &txdto.CreateTransactionRequest{
 		Date:        "2024-12-01",
@@ -399,7 +429,7 @@ func TestCreateWithOnlyDebitEntries(t *testing.T) {
 		},
 	}
 
-	result, err := svc.Create(1, req)
+	result, err := svc.Create(1, req, requestmeta.Meta{})
 	assert.Error(t, err)
 	assert.Nil(t, result)
 	assert.Contains(t, err.Error(), "must have both debit and credit")
@@ -408,7 +438,7 @@ func TestCreateWithOnlyDebitEntries(t *testing.T) {
 // TestCreateWithInvalidDateFormat: 複式簿記対応 - 日付フォーマットエラー
 func TestCreateWithInvalidDateFormat(t *testing.T) {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}, &models.Attachment{}, &models.FiscalPeriod{}, &models.AuditLog{}, &models.AuditChainHead{}); err != nil {
 		panic(err)
 	}
 
@@ -429,8 +459,10 @@ func TestCreateWithInvalidDateFormat(t *testing.T) {
 
 	txRepo := txrepository.NewTransactionRepository(db)
 	jeRepo := jerepository.NewJournalEntryRepository(db)
-	jeSvc := jeservice.NewJournalEntryService(jeRepo)
-	svc := NewTransactionService(txRepo, jeRepo, jeSvc)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), DefaultConfig())
 
 	req := &txdto.CreateTransactionRequest{
 		Date:        "2024/12/01", // 不正フォーマット
@@ -441,7 +473,7 @@ func TestCreateWithInvalidDateFormat(t *testing.T) {
 		},
 	}
 
-	result, err := svc.Create(1, req)
+	result, err := svc.Create(1, req, requestmeta.Meta{})
 	assert.Error(t, err)
 	assert.Nil(t, result)
 	assert.Contains(t, err.Error(), "invalid date format")
@@ -450,7 +482,7 @@ func TestCreateWithInvalidDateFormat(t *testing.T) {
 // TestUpdateWithDoubleEntryBookkeeping: 複式簿記対応 - Update正常系
 func TestUpdateWithDoubleEntryBookkeeping(t *testing.T) {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}, &models.Attachment{}, &models.FiscalPeriod{}, &models.AuditLog{}, &models.AuditChainHead{}); err != nil {
 		panic(err)
 	}
 
@@ -471,8 +503,10 @@ func TestUpdateWithDoubleEntryBookkeeping(t *testing.T) {
 
 	txRepo := txrepository.NewTransactionRepository(db)
 	jeRepo := jerepository.NewJournalEntryRepository(db)
-	jeSvc := jeservice.NewJournalEntryService(jeRepo)
-	svc := NewTransactionService(txRepo, jeRepo, jeSvc)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), DefaultConfig())
 
 	// 初期取引を作成
 	createReq := &txdto.CreateTransactionRequest{
@@ -484,7 +518,7 @@ func TestUpdateWithDoubleEntryBookkeeping(t *testing.T) {
 		},
 	}
 
-	created, err := svc.Create(1, createReq)
+	created, err := svc.Create(1, createReq, requestmeta.Meta{})
 	assert.NoError(t, err)
 	assert.NotNil(t, created)
 
@@ -498,7 +532,7 @@ func TestUpdateWithDoubleEntryBookkeeping(t *testing.T) {
 		},
 	}
 
-	updated, err := svc.Update(created.ID, 1, updateReq)
+	updated, err := svc.Update(created.ID, 1, created.Version, updateReq, requestmeta.Meta{})
 	assert.NoError(t, err)
 	assert.NotNil(t, updated)
 	assert.Equal(t, "2024-12-02", updated.Date)
@@ -510,7 +544,7 @@ func TestUpdateWithDoubleEntryBookkeeping(t *testing.T) {
 // TestUpdateWithCorrectionNote: 複式簿記対応 - 修正機能付きUpdate
 func TestUpdateWithCorrectionNote(t *testing.T) {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}, &models.Attachment{}, &models.FiscalPeriod{}, &models.AuditLog{}, &models.AuditChainHead{}); err != nil {
 		panic(err)
 	}
 
@@ -531,8 +565,10 @@ func TestUpdateWithCorrectionNote(t *testing.T) {
 
 	txRepo := txrepository.NewTransactionRepository(db)
 	jeRepo := jerepository.NewJournalEntryRepository(db)
-	jeSvc := jeservice.NewJournalEntryService(jeRepo)
-	svc := NewTransactionService(txRepo, jeRepo, jeSvc)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), DefaultConfig())
 
 	// 初期取引を作成
 	createReq := &txdto.CreateTransactionRequest{
@@ -544,7 +580,7 @@ func TestUpdateWithCorrectionNote(t *testing.T) {
 		},
 	}
 
-	created, err := svc.Create(1, createReq)
+	created, err := svc.Create(1, createReq, requestmeta.Meta{})
 	assert.NoError(t, err)
 	originalID := created.ID
 
@@ -559,7 +595,7 @@ func TestUpdateWithCorrectionNote(t *testing.T) {
 		},
 	}
 
-	corrected, err := svc.Update(originalID, 1, updateReq)
+	corrected, err := svc.Update(originalID, 1, created.Version, updateReq, requestmeta.Meta{})
 	assert.NoError(t, err)
 	assert.NotNil(t, corrected)
 	assert.True(t, corrected.IsCorrection)
@@ -572,7 +608,7 @@ func TestUpdateWithCorrectionNote(t *testing.T) {
 // TestUpdateUnauthorized: 複式簿記対応 - 認可チェック
 func TestUpdateUnauthorized(t *testing.T) {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}, &models.Attachment{}, &models.FiscalPeriod{}, &models.AuditLog{}, &models.AuditChainHead{}); err != nil {
 		panic(err)
 	}
 
@@ -593,8 +629,10 @@ func TestUpdateUnauthorized(t *testing.T) {
 
 	txRepo := txrepository.NewTransactionRepository(db)
 	jeRepo := jerepository.NewJournalEntryRepository(db)
-	jeSvc := jeservice.NewJournalEntryService(jeRepo)
-	svc := NewTransactionService(txRepo, jeRepo, jeSvc)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), DefaultConfig())
 
 	// ユーザーID=1で取引を作成
 	createReq := &txdto.CreateTransactionRequest{
@@ -606,7 +644,7 @@ func TestUpdateUnauthorized(t *testing.T) {
 		},
 	}
 
-	created, err := svc.Create(1, createReq)
+	created, err := svc.Create(1, createReq, requestmeta.Meta{})
 	assert.NoError(t, err)
 
 	// ユーザーID=2で更新を試みる
@@ -619,7 +657,7 @@ func TestUpdateUnauthorized(t *testing.T) {
 		},
 	}
 
-	updated, err := svc.Update(created.ID, 2, updateReq)
+	updated, err := svc.Update(created.ID, 2, created.Version, updateReq, requestmeta.Meta{})
 	assert.Error(t, err)
 	assert.Nil(t, updated)
 	assert.Contains(t, err.Error(), "unauthorized")
@@ -628,7 +666,7 @@ func TestUpdateUnauthorized(t *testing.T) {
 // TestDeleteTransaction: 複式簿記対応 - Delete機能確認
 func TestDeleteTransaction(t *testing.T) {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}, &models.Attachment{}, &models.FiscalPeriod{}, &models.AuditLog{}, &models.AuditChainHead{}); err != nil {
 		panic(err)
 	}
 
@@ -649,8 +687,10 @@ func TestDeleteTransaction(t *testing.T) {
 
 	txRepo := txrepository.NewTransactionRepository(db)
 	jeRepo := jerepository.NewJournalEntryRepository(db)
-	jeSvc := jeservice.NewJournalEntryService(jeRepo)
-	svc := NewTransactionService(txRepo, jeRepo, jeSvc)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), DefaultConfig())
 
 	// 取引を作成
 	createReq := &txdto.CreateTransactionRequest{
@@ -662,12 +702,12 @@ func TestDeleteTransaction(t *testing.T) {
 		},
 	}
 
-	created, err := svc.Create(1, createReq)
+	created, err := svc.Create(1, createReq, requestmeta.Meta{})
 	assert.NoError(t, err)
 	assert.NotNil(t, created)
 
 	// 削除
-	err = svc.Delete(created.ID, 1)
+	err = svc.Delete(created.ID, 1, created.Version, requestmeta.Meta{})
 	assert.NoError(t, err)
 
 	// 削除されたか確認
@@ -679,7 +719,7 @@ func TestDeleteTransaction(t *testing.T) {
 // TestDeleteUnauthorized: 複式簿記対応 - Delete認可チェック
 func TestDeleteUnauthorized(t *testing.T) {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}, &models.Attachment{}, &models.FiscalPeriod{}, &models.AuditLog{}, &models.AuditChainHead{}); err != nil {
 		panic(err)
 	}
 
@@ -700,8 +740,10 @@ func TestDeleteUnauthorized(t *testing.T) {
 
 	txRepo := txrepository.NewTransactionRepository(db)
 	jeRepo := jerepository.NewJournalEntryRepository(db)
-	jeSvc := jeservice.NewJournalEntryService(jeRepo)
-	svc := NewTransactionService(txRepo, jeRepo, jeSvc)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), DefaultConfig())
 
 	// ユーザーID=1で取引を作成
 	createReq := &txdto.CreateTransactionRequest{
@@ -713,11 +755,500 @@ func TestDeleteUnauthorized(t *testing.T) {
 		},
 	}
 
-	created, err := svc.Create(1, createReq)
+	created, err := svc.Create(1, createReq, requestmeta.Meta{})
 	assert.NoError(t, err)
 
 	// ユーザーID=2で削除を試みる
-	err = svc.Delete(created.ID, 2)
+	err = svc.Delete(created.ID, 2, created.Version, requestmeta.Meta{})
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "unauthorized")
 }
+
+// TestUpdateWithUnbalancedEntriesKeepsExistingEntries: 不正な更新では既存の仕訳が削除されない
+func TestUpdateWithUnbalancedEntriesKeepsExistingEntries(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}, &models.Attachment{}, &models.FiscalPeriod{}, &models.AuditLog{}, &models.AuditChainHead{}); err != nil {
+		panic(err)
+	}
+
+	user := models.User{Email: "test@example.com", Name: "Test", Password: "hashed", Role: "user", IsActive: true}
+	db.Create(&user)
+
+	accountDebit := models.ChartOfAccounts{
+		Code: "1000", Name: "現金", Type: models.AssetAccount,
+		NormalBalance: models.DebitBalance, IsActive: true,
+	}
+	db.Create(&accountDebit)
+
+	accountCredit := models.ChartOfAccounts{
+		Code: "4000", Name: "売上", Type: models.RevenueAccount,
+		NormalBalance: models.CreditBalance, IsActive: true,
+	}
+	db.Create(&accountCredit)
+
+	txRepo := txrepository.NewTransactionRepository(db)
+	jeRepo := jerepository.NewJournalEntryRepository(db)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), DefaultConfig())
+
+	created, err := svc.Create(1, &txdto.CreateTransactionRequest{
+		Date:        "2024-12-01",
+		Description: "初期取引",
+		JournalEntries: []jeDto.CreateJournalEntryRequest{
+			{ChartOfAccountsID: accountDebit.ID, Type: models.DebitEntry, Amount: 100000, Description: "販売"},
+			{ChartOfAccountsID: accountCredit.ID, Type: models.CreditEntry, Amount: 100000, Description: "販売"},
+		},
+	}, requestmeta.Meta{})
+	assert.NoError(t, err)
+
+	// 貸借が一致しない更新は拒否される
+	result, err := svc.Update(created.ID, 1, created.Version, &txdto.CreateTransactionRequest{
+		Date:        "2024-12-02",
+		Description: "不正な更新",
+		JournalEntries: []jeDto.CreateJournalEntryRequest{
+			{ChartOfAccountsID: accountDebit.ID, Type: models.DebitEntry, Amount: 200000, Description: "販売"},
+			{ChartOfAccountsID: accountCredit.ID, Type: models.CreditEntry, Amount: 100000, Description: "販売"},
+		},
+	}, requestmeta.Meta{})
+	assert.Error(t, err)
+	assert.Nil(t, result)
+
+	// 既存の取引と仕訳はそのまま残っている
+	stored, err := svc.GetByID(created.ID, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "初期取引", stored.Description)
+	assert.Len(t, stored.JournalEntries, 2)
+	assert.Equal(t, 100000, stored.JournalEntries[0].Amount)
+}
+
+// newTestPostingValidator: テスト用の記帳検証を生成
+func newTestPostingValidator(db *gorm.DB) postingService.PostingValidator {
+	return postingService.NewPostingValidator(
+		chartOfAccountsRepository.NewChartOfAccountsRepository(db),
+		fiscalPeriodRepository.NewFiscalPeriodRepository(db),
+		postingService.DefaultConfig(),
+	)
+}
+
+// TestTransactionChangesAreAudited: 作成・更新・修正・削除が監査ログに記録され、失敗した変更は記録されない
+func TestTransactionChangesAreAudited(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}, &models.Attachment{}, &models.FiscalPeriod{}, &models.AuditLog{}, &models.AuditChainHead{}); err != nil {
+		panic(err)
+	}
+
+	accountDebit := models.ChartOfAccounts{
+		Code: "1000", Name: "現金", Type: models.AssetAccount,
+		NormalBalance: models.DebitBalance, IsActive: true,
+	}
+	db.Create(&accountDebit)
+
+	accountCredit := models.ChartOfAccounts{
+		Code: "4000", Name: "売上", Type: models.RevenueAccount,
+		NormalBalance: models.CreditBalance, IsActive: true,
+	}
+	db.Create(&accountCredit)
+
+	txRepo := txrepository.NewTransactionRepository(db)
+	jeRepo := jerepository.NewJournalEntryRepository(db)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), DefaultConfig())
+
+	actorID := uint(1)
+	meta := requestmeta.Meta{ActorID: &actorID, RequestID: "req-audit", IPAddress: "192.0.2.1"}
+	entries := func(amount int) []jeDto.CreateJournalEntryRequest {
+		return []jeDto.CreateJournalEntryRequest{
+			{ChartOfAccountsID: accountDebit.ID, Type: models.DebitEntry, Amount: amount},
+			{ChartOfAccountsID: accountCredit.ID, Type: models.CreditEntry, Amount: amount},
+		}
+	}
+
+	created, err := svc.Create(1, &txdto.CreateTransactionRequest{Date: "2024-12-01", Description: "作成", JournalEntries: entries(1000)}, meta)
+	assert.NoError(t, err)
+
+	updated, err := svc.Update(created.ID, 1, created.Version, &txdto.CreateTransactionRequest{Date: "2024-12-01", Description: "更新", JournalEntries: entries(2000)}, meta)
+	assert.NoError(t, err)
+
+	// 貸借不一致で失敗した更新は監査ログにも残らない
+	_, err = svc.Update(created.ID, 1, updated.Version, &txdto.CreateTransactionRequest{
+		Date: "2024-12-01", Description: "失敗",
+		JournalEntries: []jeDto.CreateJournalEntryRequest{
+			{ChartOfAccountsID: accountDebit.ID, Type: models.DebitEntry, Amount: 3000},
+			{ChartOfAccountsID: accountCredit.ID, Type: models.CreditEntry, Amount: 1000},
+		},
+	}, meta)
+	assert.Error(t, err)
+
+	corrected, err := svc.Update(created.ID, 1, updated.Version, &txdto.CreateTransactionRequest{Date: "2024-12-01", Description: "修正", JournalEntries: entries(2500), CorrectionNote: "金額誤り"}, meta)
+	assert.NoError(t, err)
+
+	// 修正によって修正元のバージョンも進む
+	err = svc.Delete(created.ID, 1, updated.Version+1, meta)
+	assert.NoError(t, err)
+
+	var logs []models.AuditLog
+	db.Order("sequence ASC").Find(&logs)
+	if assert.Len(t, logs, 4) {
+		assert.Equal(t, models.AuditActionCreate, logs[0].Action)
+		assert.Empty(t, logs[0].Before)
+		assert.Contains(t, logs[0].After, `"description":"作成"`)
+
+		assert.Equal(t, models.AuditActionUpdate, logs[1].Action)
+		assert.Contains(t, logs[1].Before, `"amount":1000`)
+		assert.Contains(t, logs[1].After, `"amount":2000`)
+
+		assert.Equal(t, models.AuditActionCorrect, logs[2].Action)
+		assert.Equal(t, corrected.ID, logs[2].EntityID)
+		assert.Contains(t, logs[2].Before, `"description":"更新"`)
+		assert.Contains(t, logs[2].After, `"correctionNote":"金額誤り"`)
+
+		assert.Equal(t, models.AuditActionDelete, logs[3].Action)
+		assert.Equal(t, created.ID, logs[3].EntityID)
+		assert.Empty(t, logs[3].After)
+
+		for _, log := range logs {
+			assert.Equal(t, models.AuditEntityTransaction, log.EntityType)
+			assert.Equal(t, "req-audit", log.RequestID)
+			assert.Equal(t, "192.0.2.1", log.IPAddress)
+		}
+	}
+
+	result, err := auditSvc.Verify()
+	assert.NoError(t, err)
+	assert.True(t, result.Valid)
+}
+
+// TestUpdateAndDeleteWithStaleVersion: 取得後に他の更新が保存された場合は変更しない
+func TestUpdateAndDeleteWithStaleVersion(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}, &models.Attachment{}, &models.FiscalPeriod{}, &models.AuditLog{}, &models.AuditChainHead{}); err != nil {
+		panic(err)
+	}
+
+	accountDebit := models.ChartOfAccounts{Code: "1000", Name: "現金", Type: models.AssetAccount, NormalBalance: models.DebitBalance, IsActive: true}
+	db.Create(&accountDebit)
+	accountCredit := models.ChartOfAccounts{Code: "4000", Name: "売上", Type: models.RevenueAccount, NormalBalance: models.CreditBalance, IsActive: true}
+	db.Create(&accountCredit)
+
+	txRepo := txrepository.NewTransactionRepository(db)
+	jeRepo := jerepository.NewJournalEntryRepository(db)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), DefaultConfig())
+
+	request := func(description string, amount int) *txdto.CreateTransactionRequest {
+		return &txdto.CreateTransactionRequest{
+			Date:        "2024-12-01",
+			Description: description,
+			JournalEntries: []jeDto.CreateJournalEntryRequest{
+				{ChartOfAccountsID: accountDebit.ID, Type: models.DebitEntry, Amount: amount},
+				{ChartOfAccountsID: accountCredit.ID, Type: models.CreditEntry, Amount: amount},
+			},
+		}
+	}
+
+	created, err := svc.Create(1, request("作成", 1000), requestmeta.Meta{})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), created.Version)
+
+	updated, err := svc.Update(created.ID, 1, created.Version, request("先の更新", 2000), requestmeta.Meta{})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), updated.Version)
+
+	// 同じバージョンを元にした後の更新・修正・削除は拒否される
+	_, err = svc.Update(created.ID, 1, created.Version, request("後の更新", 3000), requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+	_, err = svc.Update(created.ID, 1, created.Version, &txdto.CreateTransactionRequest{
+		Date: "2024-12-01", Description: "後の修正", JournalEntries: request("", 3000).JournalEntries, CorrectionNote: "金額誤り",
+	}, requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+	assert.ErrorIs(t, svc.Delete(created.ID, 1, created.Version, requestmeta.Meta{}), ErrVersionMismatch)
+
+	stored, err := svc.GetByID(created.ID, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "先の更新", stored.Description)
+	assert.Equal(t, uint(2), stored.Version)
+	assert.Equal(t, 2000, stored.JournalEntries[0].Amount)
+
+	var count int64
+	db.Model(&models.Transaction{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestUpdateSyncsAttachmentsAndDeleteKeepsThem(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}, &models.Attachment{}, &models.FiscalPeriod{}, &models.AuditLog{}, &models.AuditChainHead{}); err != nil {
+		panic(err)
+	}
+
+	accountDebit := models.ChartOfAccounts{Code: "1000", Name: "現金", Type: models.AssetAccount, NormalBalance: models.DebitBalance, IsActive: true}
+	db.Create(&accountDebit)
+	accountCredit := models.ChartOfAccounts{Code: "4000", Name: "売上", Type: models.RevenueAccount, NormalBalance: models.CreditBalance, IsActive: true}
+	db.Create(&accountCredit)
+
+	txRepo := txrepository.NewTransactionRepository(db)
+	jeRepo := jerepository.NewJournalEntryRepository(db)
+	attachmentRepo := attachmentrepository.NewAttachmentRepository(db)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := NewTransactionService(txRepo, jeRepo, attachmentRepo, jeSvc, validator, auditSvc, uow.New(db), DefaultConfig())
+
+	request := func(date string, amount int) *txdto.CreateTransactionRequest {
+		return &txdto.CreateTransactionRequest{
+			Date:        date,
+			Description: "売上",
+			JournalEntries: []jeDto.CreateJournalEntryRequest{
+				{ChartOfAccountsID: accountDebit.ID, Type: models.DebitEntry, Amount: amount},
+				{ChartOfAccountsID: accountCredit.ID, Type: models.CreditEntry, Amount: amount},
+			},
+		}
+	}
+
+	created, err := svc.Create(1, request("2024-03-10", 1000), requestmeta.Meta{})
+	assert.NoError(t, err)
+
+	// 取引金額を取引から設定した添付ファイルと、アップロード時に指定した添付ファイル
+	derived := models.Attachment{UserID: 1, TransactionID: created.ID, TransactionDate: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), Amount: 1000, FileName: "a.pdf", ContentType: "application/pdf", StorageKey: "a", SHA256: "a"}
+	specified := models.Attachment{UserID: 1, TransactionID: created.ID, TransactionDate: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), Amount: 1100, AmountSpecified: true, FileName: "b.pdf", ContentType: "application/pdf", StorageKey: "b", SHA256: "b"}
+	assert.NoError(t, attachmentRepo.Create(&derived))
+	assert.NoError(t, attachmentRepo.Create(&specified))
+
+	updated, err := svc.Update(created.ID, 1, created.Version, request("2024-05-20", 2000), requestmeta.Meta{})
+	assert.NoError(t, err)
+
+	attachments, err := attachmentRepo.GetByTransactionID(created.ID)
+	assert.NoError(t, err)
+	assert.Len(t, attachments, 2)
+	for _, attachment := range attachments {
+		assert.Equal(t, "2024-05-20", attachment.TransactionDate.Format("2006-01-02"))
+	}
+	assert.Equal(t, 2000, attachments[0].Amount)
+	assert.Equal(t, 1100, attachments[1].Amount)
+
+	// 添付ファイルがある取引は削除できない
+	err = svc.Delete(created.ID, 1, updated.Version, requestmeta.Meta{})
+	assert.ErrorIs(t, err, ErrTransactionHasAttachments)
+
+	stored, err := svc.GetByID(created.ID, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, updated.Version, stored.Version)
+}
+
+// setupBatchTest: 一括登録のテスト用サービスと勘定科目（借方・貸方・無効）
+func setupBatchTest(cfg Config) (*gorm.DB, TransactionService, models.ChartOfAccounts, models.ChartOfAccounts, models.ChartOfAccounts) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err := db.AutoMigrate(&models.User{}, &models.ChartOfAccounts{}, &models.Transaction{}, &models.JournalEntry{}, &models.Attachment{}, &models.FiscalPeriod{}, &models.AuditLog{}, &models.AuditChainHead{}); err != nil {
+		panic(err)
+	}
+
+	accountDebit := models.ChartOfAccounts{Code: "1000", Name: "現金", Type: models.AssetAccount, NormalBalance: models.DebitBalance, IsActive: true}
+	db.Create(&accountDebit)
+	accountCredit := models.ChartOfAccounts{Code: "4000", Name: "売上", Type: models.RevenueAccount, NormalBalance: models.CreditBalance, IsActive: true}
+	db.Create(&accountCredit)
+	accountInactive := models.ChartOfAccounts{Code: "9000", Name: "廃止", Type: models.ExpenseAccount, NormalBalance: models.DebitBalance, IsActive: true}
+	db.Create(&accountInactive)
+	db.Model(&accountInactive).Update("is_active", false)
+
+	txRepo := txrepository.NewTransactionRepository(db)
+	jeRepo := jerepository.NewJournalEntryRepository(db)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), cfg)
+	return db, svc, accountDebit, accountCredit, accountInactive
+}
+
+func batchItem(date string, debitID, creditID uint, amount int) txdto.CreateTransactionRequest {
+	return txdto.CreateTransactionRequest{
+		Date:        date,
+		Description: "一括登録",
+		JournalEntries: []jeDto.CreateJournalEntryRequest{
+			{ChartOfAccountsID: debitID, Type: models.DebitEntry, Amount: amount},
+			{ChartOfAccountsID: creditID, Type: models.CreditEntry, Amount: amount},
+		},
+	}
+}
+
+// TestCreateBatch: 検証に通った取引がまとめて登録され、監査ログに記録される
+func TestCreateBatch(t *testing.T) {
+	db, svc, debit, credit, _ := setupBatchTest(DefaultConfig())
+
+	result, err := svc.CreateBatch(1, &txdto.BatchCreateTransactionsRequest{
+		Transactions: []txdto.CreateTransactionRequest{
+			batchItem("2024-12-01", debit.ID, credit.ID, 1000),
+			batchItem("2024-12-02", debit.ID, credit.ID, 2000),
+			batchItem("2024-12-03", debit.ID, credit.ID, 3000),
+		},
+	}, requestmeta.Meta{})
+	assert.NoError(t, err)
+	assert.Equal(t, txdto.BatchModeAllOrNothing, result.Mode)
+	assert.Equal(t, 3, result.Created)
+	assert.Equal(t, 0, result.Failed)
+	for i, item := range result.Results {
+		assert.Equal(t, i, item.Index)
+		if assert.NotNil(t, item.Transaction) {
+			assert.Equal(t, (i+1)*1000, item.Transaction.JournalEntries[0].Amount)
+			assert.Equal(t, uint(1), item.Transaction.Version)
+		}
+	}
+
+	var entryCount, auditCount int64
+	db.Model(&models.JournalEntry{}).Count(&entryCount)
+	db.Model(&models.AuditLog{}).Where("action = ? AND entity_type = ?", models.AuditActionCreate, models.AuditEntityTransaction).Count(&auditCount)
+	assert.Equal(t, int64(6), entryCount)
+	assert.Equal(t, int64(3), auditCount)
+}
+
+// TestCreateBatchAllOrNothing: 1件でも検証エラーがあれば何も登録せず、エラーを取引ごとに返す
+func TestCreateBatchAllOrNothing(t *testing.T) {
+	db, svc, debit, credit, inactive := setupBatchTest(DefaultConfig())
+
+	result, err := svc.CreateBatch(1, &txdto.BatchCreateTransactionsRequest{
+		Mode: txdto.BatchModeAllOrNothing,
+		Transactions: []txdto.CreateTransactionRequest{
+			batchItem("2024-12-01", debit.ID, credit.ID, 1000),
+			batchItem("2024/12/02", debit.ID, credit.ID, 1000),
+			batchItem("2024-12-03", inactive.ID, credit.ID, 1000),
+		},
+	}, requestmeta.Meta{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Created)
+	assert.Equal(t, 2, result.Failed)
+	assert.Nil(t, result.Results[0].Transaction)
+	assert.Empty(t, result.Results[0].Error)
+	assert.Contains(t, result.Results[1].Error, "invalid date format")
+	if assert.Len(t, result.Results[2].Details, 1) {
+		assert.Equal(t, "chartOfAccountsId", result.Results[2].Details[0].Field)
+	}
+
+	var count int64
+	db.Model(&models.Transaction{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+// TestCreateBatchBestEffort: 検証に通った取引のみ登録する
+func TestCreateBatchBestEffort(t *testing.T) {
+	db, svc, debit, credit, _ := setupBatchTest(DefaultConfig())
+
+	unbalanced := batchItem("2024-12-02", debit.ID, credit.ID, 1000)
+	unbalanced.JournalEntries[1].Amount = 900
+
+	result, err := svc.CreateBatch(1, &txdto.BatchCreateTransactionsRequest{
+		Mode: txdto.BatchModeBestEffort,
+		Transactions: []txdto.CreateTransactionRequest{
+			batchItem("2024-12-01", debit.ID, credit.ID, 1000),
+			unbalanced,
+			batchItem("2024-12-03", debit.ID, credit.ID, 3000),
+		},
+	}, requestmeta.Meta{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Created)
+	assert.Equal(t, 1, result.Failed)
+	assert.NotNil(t, result.Results[0].Transaction)
+	assert.Nil(t, result.Results[1].Transaction)
+	assert.Contains(t, result.Results[1].Error, "debit total must equal credit total")
+	if assert.NotNil(t, result.Results[2].Transaction) {
+		assert.Equal(t, "2024-12-03", result.Results[2].Transaction.Date)
+	}
+
+	var count int64
+	db.Model(&models.Transaction{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+}
+
+// TestCreateBatchLineErrorsComeFromPostingValidator: 仕訳行の誤りは記帳検証の結果（項目ごとの詳細）として返す
+func TestCreateBatchLineErrorsComeFromPostingValidator(t *testing.T) {
+	_, svc, debit, credit, _ := setupBatchTest(DefaultConfig())
+
+	invalid := batchItem("2024-12-01", debit.ID, credit.ID, 1000)
+	invalid.JournalEntries[0].Amount = 0
+
+	result, err := svc.CreateBatch(1, &txdto.BatchCreateTransactionsRequest{
+		Mode:         txdto.BatchModeBestEffort,
+		Transactions: []txdto.CreateTransactionRequest{invalid},
+	}, requestmeta.Meta{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Failed)
+	if assert.Len(t, result.Results[0].Details, 1) {
+		assert.Equal(t, "amount", result.Results[0].Details[0].Field)
+	}
+}
+
+// TestCreateBatchTooLarge: 上限を超える件数の一括登録は拒否する
+func TestCreateBatchTooLarge(t *testing.T) {
+	_, svc, debit, credit, _ := setupBatchTest(Config{BatchMaxItems: 2})
+
+	_, err := svc.CreateBatch(1, &txdto.BatchCreateTransactionsRequest{
+		Transactions: []txdto.CreateTransactionRequest{
+			batchItem("2024-12-01", debit.ID, credit.ID, 1000),
+			batchItem("2024-12-02", debit.ID, credit.ID, 1000),
+			batchItem("2024-12-03", debit.ID, credit.ID, 1000),
+		},
+	}, requestmeta.Meta{})
+	assert.EqualError(t, err, "batch must not contain more than 2 transactions")
+}
+
+// TestGetByUserIDWithCursor: カーソルで前後のページを取得でき、閲覧中に追加された取引でページがずれない
+func TestGetByUserIDWithCursor(t *testing.T) {
+	db := setupServiceTestDB()
+	txRepo := txrepository.NewTransactionRepository(db)
+	jeRepo := jerepository.NewJournalEntryRepository(db)
+	validator := newTestPostingValidator(db)
+	auditSvc := auditservice.NewAuditService(auditrepository.NewAuditLogRepository(db))
+	jeSvc := jeservice.NewJournalEntryService(jeRepo, validator, auditSvc, uow.New(db))
+	svc := NewTransactionService(txRepo, jeRepo, attachmentrepository.NewAttachmentRepository(db), jeSvc, validator, auditSvc, uow.New(db), DefaultConfig())
+
+	// 12/25 〜 12/01 の25件（新しい順に並ぶ）
+	for i := 1; i <= 25; i++ {
+		db.Create(&models.Transaction{UserID: 1, Date: time.Date(2024, 12, i, 0, 0, 0, 0, time.UTC), Description: "テスト取引"})
+	}
+
+	first, err := svc.GetByUserIDWithCursor(1, &txdto.GetTransactionsWithCursorRequest{Limit: 10, IncludeTotal: true})
+	assert.NoError(t, err)
+	assert.Len(t, first.Transactions, 10)
+	assert.Equal(t, "2024-12-25", first.Transactions[0].Date)
+	assert.Nil(t, first.PrevCursor)
+	if assert.NotNil(t, first.NextCursor) && assert.NotNil(t, first.Total) {
+		assert.Equal(t, 25, *first.Total)
+	}
+
+	// 閲覧中に最新の取引が追加されても、次のページは続きから始まる
+	db.Create(&models.Transaction{UserID: 1, Date: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), Description: "追加"})
+
+	second, err := svc.GetByUserIDWithCursor(1, &txdto.GetTransactionsWithCursorRequest{Cursor: *first.NextCursor, Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, second.Transactions, 10)
+	assert.Equal(t, "2024-12-15", second.Transactions[0].Date)
+	assert.Nil(t, second.Total)
+	assert.NotNil(t, second.PrevCursor)
+
+	last, err := svc.GetByUserIDWithCursor(1, &txdto.GetTransactionsWithCursorRequest{Cursor: *second.NextCursor, Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, last.Transactions, 5)
+	assert.Equal(t, "2024-12-01", last.Transactions[4].Date)
+	assert.Nil(t, last.NextCursor)
+
+	// 前のページ（新しい側）は一覧の並び順で返り、さらに前には追加された取引がある
+	back, err := svc.GetByUserIDWithCursor(1, &txdto.GetTransactionsWithCursorRequest{Cursor: *second.PrevCursor, Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, back.Transactions, 10)
+	assert.Equal(t, "2024-12-25", back.Transactions[0].Date)
+	assert.Equal(t, "2024-12-16", back.Transactions[9].Date)
+	assert.NotNil(t, back.NextCursor)
+	if assert.NotNil(t, back.PrevCursor) {
+		newest, err := svc.GetByUserIDWithCursor(1, &txdto.GetTransactionsWithCursorRequest{Cursor: *back.PrevCursor, Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, newest.Transactions, 1)
+		assert.Equal(t, "追加", newest.Transactions[0].Description)
+		assert.Nil(t, newest.PrevCursor)
+	}
+
+	_, err = svc.GetByUserIDWithCursor(1, &txdto.GetTransactionsWithCursorRequest{Cursor: "not-a-cursor"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
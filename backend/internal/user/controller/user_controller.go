@@ -1,9 +1,12 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
+	"simple-ledger/internal/common/requestmeta"
+	"simple-ledger/internal/common/security"
 	"simple-ledger/internal/user/dto"
 	"simple-ledger/internal/user/service"
 
@@ -28,8 +31,12 @@ func (c *UserController) CreateUser(ctx *gin.Context) {
 		return
 	}
 
-	user, err := c.service.CreateUser(&req)
+	user, err := c.service.CreateUser(&req, requestmeta.FromContext(ctx))
 	if err != nil {
+		if errors.Is(err, security.ErrWeakPassword) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -86,12 +93,16 @@ func (c *UserController) UpdateUser(ctx *gin.Context) {
 		return
 	}
 
-	user, err := c.service.UpdateUser(uint(id), &req)
+	user, err := c.service.UpdateUser(uint(id), &req, requestmeta.FromContext(ctx))
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			ctx.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
 			return
 		}
+		if errors.Is(err, security.ErrWeakPassword) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -108,7 +119,11 @@ func (c *UserController) DeleteUser(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.service.DeleteUser(uint(id)); err != nil {
+	if err := c.service.DeleteUser(uint(id), requestmeta.FromContext(ctx)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -7,6 +7,11 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	auditRepository "simple-ledger/internal/audit/repository"
+	auditService "simple-ledger/internal/audit/service"
+	authRepository "simple-ledger/internal/auth/repository"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/security"
 	"simple-ledger/internal/models"
 	"simple-ledger/internal/user/dto"
 	"simple-ledger/internal/user/repository"
@@ -21,9 +26,9 @@ import (
 func setupTestController() (*UserController, *gorm.DB) {
 	gin.SetMode(gin.TestMode)
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	_ = db.AutoMigrate(&models.User{})
+	_ = db.AutoMigrate(&models.User{}, &models.AuthSession{}, &models.RefreshToken{}, &models.AuditLog{}, &models.AuditChainHead{})
 	repo := repository.NewUserRepository(db)
-	svc := service.NewUserService(repo)
+	svc := service.NewUserService(repo, authRepository.NewSessionRepository(db), auditService.NewAuditService(auditRepository.NewAuditLogRepository(db)), uow.New(db), security.DefaultPasswordPolicy())
 	ctrl := NewUserController(svc)
 	return ctrl, db
 }
@@ -34,7 +39,7 @@ func TestCreateUserSuccess(t *testing.T) {
 	req := dto.CreateUserRequest{
 		Email:    "test@example.com",
 		Name:     "Test User",
-		Password: "password123",
+		Password: "s3cure-ledger-pw",
 		Role:     "user",
 	}
 
@@ -62,7 +67,7 @@ func TestCreateUserInvalidEmail(t *testing.T) {
 	req := dto.CreateUserRequest{
 		Email:    "invalid-email",
 		Name:     "Test User",
-		Password: "password123",
+		Password: "s3cure-ledger-pw",
 		Role:     "user",
 	}
 
@@ -6,7 +6,7 @@ import "time"
 type CreateUserRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Name     string `json:"name" binding:"required"`
-	Password string `json:"password" binding:"required,min=8"`
+	Password string `json:"password" binding:"required"` // 文字数などの条件はパスワードポリシーで確認する
 	Role     string `json:"role" binding:"required,oneof=admin user"`
 }
 
@@ -15,18 +15,21 @@ type UpdateUserRequest struct {
 	Name     string  `json:"name" binding:"required"`
 	Email    string  `json:"email" binding:"required,email"`
 	Role     string  `json:"role" binding:"required,oneof=admin user"`
-	Password *string `json:"password" binding:"min=8"`
+	Password *string `json:"password"`
 	IsActive *bool   `json:"isActive"`
 }
 
 // UserResponse はユーザーのレスポンスDTO
 type UserResponse struct {
-	ID          uint       `json:"id"`
-	Email       string     `json:"email"`
-	Name        string     `json:"name"`
-	Role        string     `json:"role"`
-	IsActive    bool       `json:"isActive"`
-	LastLoginAt *time.Time `json:"lastLoginAt"`
-	CreatedAt   time.Time  `json:"createdAt"`
-	UpdatedAt   time.Time  `json:"updatedAt"`
+	ID                    uint       `json:"id"`
+	Email                 string     `json:"email"`
+	Name                  string     `json:"name"`
+	Role                  string     `json:"role"`
+	IsActive              bool       `json:"isActive"`
+	LastLoginAt           *time.Time `json:"lastLoginAt"`
+	LockedUntil           *time.Time `json:"lockedUntil"`
+	EmailVerifiedAt       *time.Time `json:"emailVerifiedAt"`
+	PasswordLoginDisabled bool       `json:"passwordLoginDisabled"`
+	CreatedAt             time.Time  `json:"createdAt"`
+	UpdatedAt             time.Time  `json:"updatedAt"`
 }
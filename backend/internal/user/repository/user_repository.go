@@ -14,6 +14,11 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+// WithTx は指定したDBトランザクション上で動作するリポジトリを返す
+func (r *UserRepository) WithTx(tx *gorm.DB) *UserRepository {
+	return &UserRepository{db: tx}
+}
+
 // CreateUser はユーザーを作成
 func (r *UserRepository) CreateUser(user *models.User) error {
 	return r.db.Create(user).Error
@@ -46,6 +51,21 @@ func (r *UserRepository) GetAllUsers() ([]models.User, error) {
 	return users, nil
 }
 
+// GetTokenVersion はユーザーの現在のトークンバージョンを取得（アクセストークンの検証用）
+func (r *UserRepository) GetTokenVersion(id uint) (uint, error) {
+	user := &models.User{}
+	if err := r.db.Select("token_version").Where("id = ?", id).First(user).Error; err != nil {
+		return 0, err
+	}
+	return user.TokenVersion, nil
+}
+
+// IncrementTokenVersion は更新内容にトークンバージョンの加算を追加する
+// ロール・パスワード・有効/無効を変更する際に使い、発行済みのアクセストークンを無効にする
+func IncrementTokenVersion(updates map[string]interface{}) {
+	updates["token_version"] = gorm.Expr("token_version + 1")
+}
+
 // UpdateUser はユーザーを更新
 func (r *UserRepository) UpdateUser(id uint, updates map[string]interface{}) (*models.User, error) {
 	user := &models.User{}
@@ -3,21 +3,19 @@ package repository
 import (
 	"testing"
 
+	"simple-ledger/internal/common/db/dbtest"
 	"simple-ledger/internal/models"
 
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
-func setupTestDB() *gorm.DB {
-	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	_ = db.AutoMigrate(&models.User{})
-	return db
+func setupTestDB(t *testing.T) *gorm.DB {
+	return dbtest.Open(t, &models.User{})
 }
 
 func TestCreateUser(t *testing.T) {
-	db := setupTestDB()
+	db := setupTestDB(t)
 	repo := NewUserRepository(db)
 
 	user := &models.User{
@@ -34,7 +32,7 @@ func TestCreateUser(t *testing.T) {
 }
 
 func TestGetUserByID(t *testing.T) {
-	db := setupTestDB()
+	db := setupTestDB(t)
 	repo := NewUserRepository(db)
 
 	user := &models.User{
@@ -53,7 +51,7 @@ func TestGetUserByID(t *testing.T) {
 }
 
 func TestGetUserByEmail(t *testing.T) {
-	db := setupTestDB()
+	db := setupTestDB(t)
 	repo := NewUserRepository(db)
 
 	user := &models.User{
@@ -71,7 +69,7 @@ func TestGetUserByEmail(t *testing.T) {
 }
 
 func TestGetAllUsers(t *testing.T) {
-	db := setupTestDB()
+	db := setupTestDB(t)
 	repo := NewUserRepository(db)
 
 	users := []models.User{
@@ -89,7 +87,7 @@ func TestGetAllUsers(t *testing.T) {
 }
 
 func TestUpdateUser(t *testing.T) {
-	db := setupTestDB()
+	db := setupTestDB(t)
 	repo := NewUserRepository(db)
 
 	user := &models.User{
@@ -113,7 +111,7 @@ func TestUpdateUser(t *testing.T) {
 }
 
 func TestDeleteUser(t *testing.T) {
-	db := setupTestDB()
+	db := setupTestDB(t)
 	repo := NewUserRepository(db)
 
 	user := &models.User{
@@ -1,6 +1,12 @@
 package router
 
 import (
+	auditRepository "simple-ledger/internal/audit/repository"
+	auditService "simple-ledger/internal/audit/service"
+	"simple-ledger/internal/auth/middleware"
+	authRepository "simple-ledger/internal/auth/repository"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/security"
 	"simple-ledger/internal/user/controller"
 	"simple-ledger/internal/user/repository"
 	"simple-ledger/internal/user/service"
@@ -12,11 +18,13 @@ import (
 func SetupUserRoutes(r *gin.RouterGroup, db *gorm.DB) {
 	// リポジトリ、サービス、コントローラーの初期化
 	repo := repository.NewUserRepository(db)
-	svc := service.NewUserService(repo)
+	auditSvc := auditService.NewAuditService(auditRepository.NewAuditLogRepository(db))
+	svc := service.NewUserService(repo, authRepository.NewSessionRepository(db), auditSvc, uow.New(db), security.PasswordPolicyFromEnv())
 	ctrl := controller.NewUserController(svc)
 
-	// ユーザー関連のルート定義
+	// ユーザー関連のルート定義（ロールの変更を含むため管理者のみ）
 	userGroup := r.Group("/users")
+	userGroup.Use(middleware.AuthMiddleware(), middleware.RequireRole("admin"))
 	{
 		userGroup.POST("", ctrl.CreateUser)       // POST /api/users
 		userGroup.GET("", ctrl.GetAllUsers)       // GET /api/users
@@ -0,0 +1,57 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"simple-ledger/internal/common/db/dbtest"
+	"simple-ledger/internal/common/security"
+	"simple-ledger/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSetupUserRoutes_RequiresAdmin: 未認証・一般ユーザーはロールを変更できない
+func TestSetupUserRoutes_RequiresAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	security.InitJWT("test-secret", 1.0, 1.0)
+
+	db := dbtest.Open(t, &models.User{}, &models.AuthSession{}, &models.RefreshToken{}, &models.AuditLog{}, &models.AuditChainHead{})
+	user := models.User{Email: "user@example.com", Name: "User", Password: "hashed", Role: "user", IsActive: true}
+	require.NoError(t, db.Create(&user).Error)
+
+	router := gin.New()
+	SetupUserRoutes(router.Group("/api"), db)
+
+	userToken, err := security.GenerateToken(user.ID, user.Email, "user", true, 0)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"anonymous", "", http.StatusUnauthorized},
+		{"non-admin", userToken, http.StatusForbidden},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/api/users/1", strings.NewReader(`{"role":"admin"}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.status, w.Code)
+		})
+	}
+
+	var stored models.User
+	require.NoError(t, db.First(&stored, user.ID).Error)
+	assert.Equal(t, "user", stored.Role)
+}
@@ -2,24 +2,36 @@ package service
 
 import (
 	"fmt"
+	auditService "simple-ledger/internal/audit/service"
+	authRepository "simple-ledger/internal/auth/repository"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/requestmeta"
 	"simple-ledger/internal/common/security"
 	"simple-ledger/internal/models"
 	"simple-ledger/internal/user/dto"
 	"simple-ledger/internal/user/repository"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+// RevokedReasonAdminUpdate: 管理者がロール・パスワード・有効/無効を変更したためセッションを失効させた
+const RevokedReasonAdminUpdate = "admin_update"
+
 type UserService struct {
-	repo *repository.UserRepository
+	repo           *repository.UserRepository
+	sessionRepo    *authRepository.SessionRepository
+	audit          *auditService.AuditService
+	uow            uow.UnitOfWork
+	passwordPolicy security.PasswordPolicy
 }
 
-func NewUserService(repo *repository.UserRepository) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(repo *repository.UserRepository, sessionRepo *authRepository.SessionRepository, audit *auditService.AuditService, unitOfWork uow.UnitOfWork, passwordPolicy security.PasswordPolicy) *UserService {
+	return &UserService{repo: repo, sessionRepo: sessionRepo, audit: audit, uow: unitOfWork, passwordPolicy: passwordPolicy}
 }
 
 // CreateUser はユーザーを作成
-func (s *UserService) CreateUser(req *dto.CreateUserRequest) (*dto.UserResponse, error) {
+func (s *UserService) CreateUser(req *dto.CreateUserRequest, meta requestmeta.Meta) (*dto.UserResponse, error) {
 	// メールアドレスが既に存在するかチェック
 	_, err := s.repo.GetUserByEmail(req.Email)
 	if err == nil {
@@ -29,6 +41,10 @@ func (s *UserService) CreateUser(req *dto.CreateUserRequest) (*dto.UserResponse,
 		return nil, err
 	}
 
+	if err := s.passwordPolicy.Validate(req.Password, req.Email, req.Name); err != nil {
+		return nil, err
+	}
+
 	// パスワードをハッシュ化
 	hashedPassword, err := security.HashPassword(req.Password)
 	if err != nil {
@@ -43,7 +59,19 @@ func (s *UserService) CreateUser(req *dto.CreateUserRequest) (*dto.UserResponse,
 		IsActive: true,
 	}
 
-	if err := s.repo.CreateUser(user); err != nil {
+	err = s.uow.Do(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).CreateUser(user); err != nil {
+			return err
+		}
+
+		return s.audit.WithTx(tx).Record(meta, auditService.Entry{
+			Action:     models.AuditActionCreate,
+			EntityType: models.AuditEntityUser,
+			EntityID:   user.ID,
+			After:      s.userToResponse(user),
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -74,7 +102,12 @@ func (s *UserService) GetAllUsers() ([]dto.UserResponse, error) {
 }
 
 // UpdateUser はユーザーを更新
-func (s *UserService) UpdateUser(id uint, req *dto.UpdateUserRequest) (*dto.UserResponse, error) {
+func (s *UserService) UpdateUser(id uint, req *dto.UpdateUserRequest, meta requestmeta.Meta) (*dto.UserResponse, error) {
+	before, err := s.repo.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+
 	updates := make(map[string]interface{})
 
 	// 必須フィールド
@@ -82,8 +115,17 @@ func (s *UserService) UpdateUser(id uint, req *dto.UpdateUserRequest) (*dto.User
 	updates["email"] = req.Email
 	updates["role"] = req.Role
 
+	// メールアドレスを変更した場合は未確認に戻す
+	if req.Email != before.Email {
+		updates["email_verified_at"] = nil
+	}
+
 	// 任意フィールド（指定されている場合のみ更新）
+	// 管理者による設定用。利用者自身の変更・再設定は認証の AccountService で行う
 	if req.Password != nil {
+		if err := s.passwordPolicy.Validate(*req.Password, req.Email, req.Name); err != nil {
+			return nil, err
+		}
 		hashedPassword, err := security.HashPassword(*req.Password)
 		if err != nil {
 			return nil, err
@@ -94,28 +136,86 @@ func (s *UserService) UpdateUser(id uint, req *dto.UpdateUserRequest) (*dto.User
 		updates["is_active"] = *req.IsActive
 	}
 
-	user, err := s.repo.UpdateUser(id, updates)
+	// ロール・パスワード・有効/無効を変更した場合は発行済みのアクセストークンとセッションを無効にする
+	securityChanged := req.Role != before.Role || req.Password != nil || (req.IsActive != nil && *req.IsActive != before.IsActive)
+	if securityChanged {
+		repository.IncrementTokenVersion(updates)
+	}
+
+	var user *models.User
+	err = s.uow.Do(func(tx *gorm.DB) error {
+		var err error
+		user, err = s.repo.WithTx(tx).UpdateUser(id, updates)
+		if err != nil {
+			return err
+		}
+
+		// 盗まれたリフレッシュトークンで新しいアクセストークンを発行し続けられないようにする
+		if securityChanged {
+			if _, err := s.sessionRepo.WithTx(tx).RevokeAllSessions(id, RevokedReasonAdminUpdate, time.Now()); err != nil {
+				return err
+			}
+		}
+
+		// パスワードハッシュはレスポンスに含まれないため監査ログにも残らない
+		return s.audit.WithTx(tx).Record(meta, auditService.Entry{
+			Action:     models.AuditActionUpdate,
+			EntityType: models.AuditEntityUser,
+			EntityID:   id,
+			Before:     s.userToResponse(before),
+			After:      s.userToResponse(user),
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
+	if securityChanged {
+		security.InvalidateTokenVersion(id)
+	}
 	return s.userToResponse(user), nil
 }
 
 // DeleteUser はユーザーを削除
-func (s *UserService) DeleteUser(id uint) error {
-	return s.repo.DeleteUser(id)
+func (s *UserService) DeleteUser(id uint, meta requestmeta.Meta) error {
+	before, err := s.repo.GetUserByID(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.uow.Do(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).DeleteUser(id); err != nil {
+			return err
+		}
+
+		return s.audit.WithTx(tx).Record(meta, auditService.Entry{
+			Action:     models.AuditActionDelete,
+			EntityType: models.AuditEntityUser,
+			EntityID:   id,
+			Before:     s.userToResponse(before),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// 削除したユーザーのアクセストークンがキャッシュの有効期間中に使われないようにする
+	security.InvalidateTokenVersion(id)
+	return nil
 }
 
 // userToResponse は User モデルを UserResponse DTO に変換
 func (s *UserService) userToResponse(user *models.User) *dto.UserResponse {
 	return &dto.UserResponse{
-		ID:          user.ID,
-		Email:       user.Email,
-		Name:        user.Name,
-		Role:        user.Role,
-		IsActive:    user.IsActive,
-		LastLoginAt: user.LastLoginAt,
-		CreatedAt:   user.CreatedAt,
-		UpdatedAt:   user.UpdatedAt,
+		ID:                    user.ID,
+		Email:                 user.Email,
+		Name:                  user.Name,
+		Role:                  user.Role,
+		IsActive:              user.IsActive,
+		LastLoginAt:           user.LastLoginAt,
+		LockedUntil:           user.LockedUntil,
+		EmailVerifiedAt:       user.EmailVerifiedAt,
+		PasswordLoginDisabled: user.PasswordLoginDisabled,
+		CreatedAt:             user.CreatedAt,
+		UpdatedAt:             user.UpdatedAt,
 	}
 }
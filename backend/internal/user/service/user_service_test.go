@@ -2,7 +2,14 @@ package service
 
 import (
 	"testing"
-
+	"time"
+
+	auditRepository "simple-ledger/internal/audit/repository"
+	auditService "simple-ledger/internal/audit/service"
+	authRepository "simple-ledger/internal/auth/repository"
+	"simple-ledger/internal/common/db/uow"
+	"simple-ledger/internal/common/requestmeta"
+	"simple-ledger/internal/common/security"
 	"simple-ledger/internal/models"
 	"simple-ledger/internal/user/dto"
 	"simple-ledger/internal/user/repository"
@@ -14,9 +21,9 @@ import (
 
 func setupTestService() *UserService {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	_ = db.AutoMigrate(&models.User{})
+	_ = db.AutoMigrate(&models.User{}, &models.AuthSession{}, &models.RefreshToken{}, &models.AuditLog{}, &models.AuditChainHead{})
 	repo := repository.NewUserRepository(db)
-	return NewUserService(repo)
+	return NewUserService(repo, authRepository.NewSessionRepository(db), auditService.NewAuditService(auditRepository.NewAuditLogRepository(db)), uow.New(db), security.DefaultPasswordPolicy())
 }
 
 func TestCreateUser(t *testing.T) {
@@ -25,11 +32,11 @@ func TestCreateUser(t *testing.T) {
 	req := &dto.CreateUserRequest{
 		Email:    "test@example.com",
 		Name:     "Test User",
-		Password: "password123",
+		Password: "s3cure-ledger-pw",
 		Role:     "user",
 	}
 
-	user, err := service.CreateUser(req)
+	user, err := service.CreateUser(req, requestmeta.Meta{})
 	assert.NoError(t, err)
 	assert.Equal(t, "test@example.com", user.Email)
 	assert.Equal(t, "Test User", user.Name)
@@ -43,31 +50,46 @@ func TestCreateUserDuplicateEmail(t *testing.T) {
 	req := &dto.CreateUserRequest{
 		Email:    "test@example.com",
 		Name:     "Test User",
-		Password: "password123",
+		Password: "s3cure-ledger-pw",
 		Role:     "user",
 	}
 
 	// First user creation
-	_, err := service.CreateUser(req)
+	_, err := service.CreateUser(req, requestmeta.Meta{})
 	assert.NoError(t, err)
 
 	// Try to create user with same email
-	_, err = service.CreateUser(req)
+	_, err = service.CreateUser(req, requestmeta.Meta{})
 	assert.Error(t, err)
 	assert.Equal(t, "email already exists", err.Error())
 }
 
+func TestCreateUserWeakPassword(t *testing.T) {
+	service := setupTestService()
+
+	for _, password := range []string{"short", "password123", "test-user-2024"} {
+		req := &dto.CreateUserRequest{
+			Email:    "test@example.com",
+			Name:     "Test User",
+			Password: password,
+			Role:     "user",
+		}
+		_, err := service.CreateUser(req, requestmeta.Meta{})
+		assert.ErrorIs(t, err, security.ErrWeakPassword, password)
+	}
+}
+
 func TestGetUser(t *testing.T) {
 	service := setupTestService()
 
 	req := &dto.CreateUserRequest{
 		Email:    "test@example.com",
 		Name:     "Test User",
-		Password: "password123",
+		Password: "s3cure-ledger-pw",
 		Role:     "user",
 	}
 
-	created, _ := service.CreateUser(req)
+	created, _ := service.CreateUser(req, requestmeta.Meta{})
 
 	retrieved, err := service.GetUser(created.ID)
 	assert.NoError(t, err)
@@ -79,12 +101,12 @@ func TestGetAllUsers(t *testing.T) {
 	service := setupTestService()
 
 	requests := []dto.CreateUserRequest{
-		{Email: "user1@example.com", Name: "User 1", Password: "password123", Role: "user"},
-		{Email: "user2@example.com", Name: "User 2", Password: "password123", Role: "admin"},
+		{Email: "user1@example.com", Name: "User 1", Password: "s3cure-ledger-pw", Role: "user"},
+		{Email: "user2@example.com", Name: "User 2", Password: "s3cure-ledger-pw", Role: "admin"},
 	}
 
 	for _, req := range requests {
-		_, _ = service.CreateUser(&req)
+		_, _ = service.CreateUser(&req, requestmeta.Meta{})
 	}
 
 	users, err := service.GetAllUsers()
@@ -98,11 +120,11 @@ func TestUpdateUser(t *testing.T) {
 	req := &dto.CreateUserRequest{
 		Email:    "test@example.com",
 		Name:     "Test User",
-		Password: "password123",
+		Password: "s3cure-ledger-pw",
 		Role:     "user",
 	}
 
-	created, _ := service.CreateUser(req)
+	created, _ := service.CreateUser(req, requestmeta.Meta{})
 
 	updateReq := &dto.UpdateUserRequest{
 		Name:  "Updated Name",
@@ -110,7 +132,7 @@ func TestUpdateUser(t *testing.T) {
 		Role:  "admin",
 	}
 
-	updated, err := service.UpdateUser(created.ID, updateReq)
+	updated, err := service.UpdateUser(created.ID, updateReq, requestmeta.Meta{})
 	assert.NoError(t, err)
 	assert.Equal(t, "Updated Name", updated.Name)
 	assert.Equal(t, "updated@example.com", updated.Email)
@@ -123,11 +145,11 @@ func TestUpdateUserPassword(t *testing.T) {
 	req := &dto.CreateUserRequest{
 		Email:    "test@example.com",
 		Name:     "Test User",
-		Password: "password123",
+		Password: "s3cure-ledger-pw",
 		Role:     "user",
 	}
 
-	created, _ := service.CreateUser(req)
+	created, _ := service.CreateUser(req, requestmeta.Meta{})
 
 	newPassword := "newpassword456"
 	updateReq := &dto.UpdateUserRequest{
@@ -137,7 +159,7 @@ func TestUpdateUserPassword(t *testing.T) {
 		Password: &newPassword,
 	}
 
-	updated, err := service.UpdateUser(created.ID, updateReq)
+	updated, err := service.UpdateUser(created.ID, updateReq, requestmeta.Meta{})
 	assert.NoError(t, err)
 
 	// Verify password was updated (just check that no error occurred)
@@ -145,19 +167,81 @@ func TestUpdateUserPassword(t *testing.T) {
 	assert.NotEmpty(t, updated.UpdatedAt)
 }
 
+func TestUpdateUserIncrementsTokenVersion(t *testing.T) {
+	service := setupTestService()
+
+	req := &dto.CreateUserRequest{
+		Email:    "test@example.com",
+		Name:     "Test User",
+		Password: "s3cure-ledger-pw",
+		Role:     "admin",
+	}
+
+	created, _ := service.CreateUser(req, requestmeta.Meta{})
+	tokenVersion := func() uint {
+		version, err := service.repo.GetTokenVersion(created.ID)
+		assert.NoError(t, err)
+		return version
+	}
+
+	// 名前のみの変更ではトークンバージョンは変わらない
+	_, err := service.UpdateUser(created.ID, &dto.UpdateUserRequest{Name: "Renamed", Email: created.Email, Role: "admin"}, requestmeta.Meta{})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), tokenVersion())
+
+	// ロールの変更
+	_, err = service.UpdateUser(created.ID, &dto.UpdateUserRequest{Name: "Renamed", Email: created.Email, Role: "user"}, requestmeta.Meta{})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), tokenVersion())
+
+	// 無効化
+	inactive := false
+	_, err = service.UpdateUser(created.ID, &dto.UpdateUserRequest{Name: "Renamed", Email: created.Email, Role: "user", IsActive: &inactive}, requestmeta.Meta{})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), tokenVersion())
+}
+
+func TestUpdateUserRevokesSessions(t *testing.T) {
+	service := setupTestService()
+
+	created, _ := service.CreateUser(&dto.CreateUserRequest{
+		Email:    "test@example.com",
+		Name:     "Test User",
+		Password: "s3cure-ledger-pw",
+		Role:     "admin",
+	}, requestmeta.Meta{})
+	assert.NoError(t, service.sessionRepo.CreateSession(&models.AuthSession{UserID: created.ID, FamilyID: "family-1", LastUsedAt: time.Now()}))
+	session := func() *models.AuthSession {
+		session, err := service.sessionRepo.GetSessionByFamilyID("family-1")
+		assert.NoError(t, err)
+		return session
+	}
+
+	// 名前のみの変更ではセッションは失効しない
+	_, err := service.UpdateUser(created.ID, &dto.UpdateUserRequest{Name: "Renamed", Email: created.Email, Role: "admin"}, requestmeta.Meta{})
+	assert.NoError(t, err)
+	assert.Nil(t, session().RevokedAt)
+
+	// ロールの変更
+	_, err = service.UpdateUser(created.ID, &dto.UpdateUserRequest{Name: "Renamed", Email: created.Email, Role: "user"}, requestmeta.Meta{})
+	assert.NoError(t, err)
+	assert.NotNil(t, session().RevokedAt)
+	assert.Equal(t, RevokedReasonAdminUpdate, session().RevokedReason)
+}
+
 func TestDeleteUser(t *testing.T) {
 	service := setupTestService()
 
 	req := &dto.CreateUserRequest{
 		Email:    "test@example.com",
 		Name:     "Test User",
-		Password: "password123",
+		Password: "s3cure-ledger-pw",
 		Role:     "user",
 	}
 
-	created, _ := service.CreateUser(req)
+	created, _ := service.CreateUser(req, requestmeta.Meta{})
 
-	err := service.DeleteUser(created.ID)
+	err := service.DeleteUser(created.ID, requestmeta.Meta{})
 	assert.NoError(t, err)
 
 	_, err = service.GetUser(created.ID)
@@ -46,6 +46,7 @@ export const EditTransactionModal: React.FC<EditTransactionModalProps> = ({
     mutate(
       {
         id: transactionId,
+        version: initialData.version,
         request: {
           date: pendingFormData.date,
           description: pendingFormData.description,
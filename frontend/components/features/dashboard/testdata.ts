@@ -8,6 +8,7 @@ export const transactionData: Transaction[] = [
     description: 'クライアントA案件',
     createdAt: '2024-12-01T00:00:00Z',
     updatedAt: '2024-12-01T00:00:00Z',
+    version: 1,
   },
   {
     id: 2,
@@ -16,6 +17,7 @@ export const transactionData: Transaction[] = [
     description: 'サーバー代',
     createdAt: '2024-12-03T00:00:00Z',
     updatedAt: '2024-12-03T00:00:00Z',
+    version: 1,
   },
   {
     id: 3,
@@ -24,6 +26,7 @@ export const transactionData: Transaction[] = [
     description: 'クライアントB案件',
     createdAt: '2024-12-05T00:00:00Z',
     updatedAt: '2024-12-05T00:00:00Z',
+    version: 1,
   },
   {
     id: 4,
@@ -32,6 +35,7 @@ export const transactionData: Transaction[] = [
     description: '通信費',
     createdAt: '2024-12-07T00:00:00Z',
     updatedAt: '2024-12-07T00:00:00Z',
+    version: 1,
   },
   {
     id: 5,
@@ -40,6 +44,7 @@ export const transactionData: Transaction[] = [
     description: '文房具',
     createdAt: '2024-12-10T00:00:00Z',
     updatedAt: '2024-12-10T00:00:00Z',
+    version: 1,
   },
   {
     id: 6,
@@ -48,6 +53,7 @@ export const transactionData: Transaction[] = [
     description: 'クライアントC案件',
     createdAt: '2024-12-15T00:00:00Z',
     updatedAt: '2024-12-15T00:00:00Z',
+    version: 1,
   },
   {
     id: 7,
@@ -56,6 +62,7 @@ export const transactionData: Transaction[] = [
     description: 'ソフトウェアライセンス',
     createdAt: '2024-12-18T00:00:00Z',
     updatedAt: '2024-12-18T00:00:00Z',
+    version: 1,
   },
   {
     id: 8,
@@ -64,6 +71,7 @@ export const transactionData: Transaction[] = [
     description: 'クライアントD案件',
     createdAt: '2024-12-20T00:00:00Z',
     updatedAt: '2024-12-20T00:00:00Z',
+    version: 1,
   },
   {
     id: 9,
@@ -72,6 +80,7 @@ export const transactionData: Transaction[] = [
     description: '交通費',
     createdAt: '2024-12-22T00:00:00Z',
     updatedAt: '2024-12-22T00:00:00Z',
+    version: 1,
   },
   {
     id: 10,
@@ -80,5 +89,6 @@ export const transactionData: Transaction[] = [
     description: '書籍代',
     createdAt: '2024-12-25T00:00:00Z',
     updatedAt: '2024-12-25T00:00:00Z',
+    version: 1,
   },
 ];
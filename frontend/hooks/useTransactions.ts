@@ -12,10 +12,10 @@ import {
   updateTransaction,
   getTransaction,
   getTransactions,
-  getTransactionsWithPagination,
+  getTransactionsWithCursor,
   deleteTransaction,
   getJournalEntries,
-  createJournalEntry,
+  createJournalEntries,
   updateJournalEntry,
   deleteJournalEntry,
   validateTransaction,
@@ -23,6 +23,7 @@ import {
   UpdateTransactionRequest,
   CreateJournalEntryRequest,
   TransactionResponse,
+  TransactionsCursorPage,
 } from '@/lib/api/transactions';
 import { JournalEntry } from '@/types/journalEntry';
 import { QueryClient } from '@tanstack/react-query';
@@ -33,6 +34,10 @@ export const queryClient = new QueryClient();
 const TRANSACTIONS_QUERY_KEY = ['transactions'];
 const JOURNAL_ENTRIES_QUERY_KEY = ['journalEntries'];
 
+// 編集中に他の操作で取引が更新・削除された場合（412）のメッセージ
+const VERSION_MISMATCH_MESSAGE =
+  'この取引は他の操作で更新されています。最新の内容を確認してから再度保存してください';
+
 /**
  * 取引作成の mutation
  * @returns mutation 結果
@@ -65,18 +70,24 @@ export function useCreateTransaction(): UseMutationResult<
 export function useUpdateTransaction(): UseMutationResult<
   TransactionResponse,
   unknown,
-  { id: number; request: UpdateTransactionRequest },
+  { id: number; version: number; request: UpdateTransactionRequest },
   unknown
 > {
   return useMutation({
     mutationFn: async ({
       id,
+      version,
       request,
     }: {
       id: number;
+      version: number;
       request: UpdateTransactionRequest;
     }) => {
-      const response = await updateTransaction(id, request);
+      const response = await updateTransaction(id, version, request);
+      if (response.statusCode === 412) {
+        queryClient.invalidateQueries({ queryKey: TRANSACTIONS_QUERY_KEY });
+        throw new Error(VERSION_MISMATCH_MESSAGE);
+      }
       if (!response.data) {
         throw new Error(response.error || '取引の更新に失敗しました');
       }
@@ -97,12 +108,16 @@ export function useUpdateTransaction(): UseMutationResult<
 export function useDeleteTransaction(): UseMutationResult<
   { message: string },
   unknown,
-  number,
+  { id: number; version: number },
   unknown
 > {
   return useMutation({
-    mutationFn: async (id: number) => {
-      const response = await deleteTransaction(id);
+    mutationFn: async ({ id, version }: { id: number; version: number }) => {
+      const response = await deleteTransaction(id, version);
+      if (response.statusCode === 412) {
+        queryClient.invalidateQueries({ queryKey: TRANSACTIONS_QUERY_KEY });
+        throw new Error(VERSION_MISMATCH_MESSAGE);
+      }
       if (!response.data) {
         throw new Error(response.error || '取引の削除に失敗しました');
       }
@@ -169,24 +184,16 @@ export function useGetInfinityTransactions(
   pageSize: number = 20,
   keyword?: string,
 ) {
-  type PageData = {
-    transactions: TransactionResponse[];
-    total: number;
-    page: number;
-    pageSize: number;
-    hasNextPage: boolean;
-  };
-
   return useInfiniteQuery<
-    PageData,
+    TransactionsCursorPage,
     unknown,
-    { pages: PageData[] },
+    { pages: TransactionsCursorPage[] },
     string[],
-    number
+    string | null
   >({
     queryKey: ['transactions', 'infinite', String(pageSize), keyword ?? ''],
     queryFn: async ({ pageParam }) => {
-      const response = await getTransactionsWithPagination(
+      const response = await getTransactionsWithCursor(
         pageParam,
         pageSize,
         keyword,
@@ -196,13 +203,9 @@ export function useGetInfinityTransactions(
       }
       return response.data;
     },
-    getNextPageParam: (lastPage) => {
-      if (lastPage.hasNextPage) {
-        return lastPage.page + 1;
-      }
-      return undefined;
-    },
-    initialPageParam: 1,
+    // カーソルは一覧上の位置を指すため、閲覧中に取引が追加されても続きから取得できる
+    getNextPageParam: (lastPage) => lastPage.nextCursor ?? undefined,
+    initialPageParam: null,
     staleTime: 1000 * 60,
   });
 }
@@ -231,32 +234,36 @@ export function useGetJournalEntries(
 
 /**
  * 仕訳エントリー作成の mutation
+ * 借方・貸方の合計が等しい2行以上をまとめて追加する
  * @returns mutation 結果
  */
-export function useCreateJournalEntry(): UseMutationResult<
-  JournalEntry,
+export function useCreateJournalEntries(): UseMutationResult<
+  JournalEntry[],
   unknown,
-  { transactionId: number; request: CreateJournalEntryRequest },
+  { transactionId: number; journalEntries: CreateJournalEntryRequest[] },
   unknown
 > {
   return useMutation({
     mutationFn: async ({
       transactionId,
-      request,
+      journalEntries,
     }: {
       transactionId: number;
-      request: CreateJournalEntryRequest;
+      journalEntries: CreateJournalEntryRequest[];
     }) => {
-      const response = await createJournalEntry(transactionId, request);
+      const response = await createJournalEntries(
+        transactionId,
+        journalEntries,
+      );
       if (!response.data) {
         throw new Error(response.error || '仕訳エントリーの作成に失敗しました');
       }
       return response.data;
     },
-    onSuccess: (data) => {
+    onSuccess: (_data, { transactionId }) => {
       // 仕訳一覧をリフェッチ
       queryClient.invalidateQueries({
-        queryKey: [JOURNAL_ENTRIES_QUERY_KEY, data.transactionId],
+        queryKey: [JOURNAL_ENTRIES_QUERY_KEY, transactionId],
       });
       // 取引一覧もリフェッチ
       queryClient.invalidateQueries({ queryKey: TRANSACTIONS_QUERY_KEY });
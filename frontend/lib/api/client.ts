@@ -5,6 +5,10 @@
 
 const API_BASE_URL = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080';
 
+// CSRFトークンを受け渡すヘッダー（クッキーで認証する更新系リクエストに必須）
+const CSRF_HEADER = 'X-CSRF-Token';
+const UNSAFE_METHODS = ['POST', 'PUT', 'PATCH', 'DELETE'];
+
 export interface ApiResponse<T> {
   data?: T;
   error?: string;
@@ -17,6 +21,8 @@ export interface ApiErrorResponse {
 
 class ApiClient {
   private baseUrl: string;
+  // APIとは別オリジンのためクッキーは読めないので、レスポンスヘッダーで受け取ったトークンを保持する
+  private csrfToken: string | null = null;
 
   constructor(baseUrl: string = API_BASE_URL) {
     this.baseUrl = baseUrl;
@@ -100,6 +106,12 @@ class ApiClient {
     if (!headers.has('Content-Type')) {
       headers.set('Content-Type', 'application/json');
     }
+    if (UNSAFE_METHODS.includes(options.method ?? 'GET')) {
+      const csrfToken = await this.getCsrfToken();
+      if (csrfToken) {
+        headers.set(CSRF_HEADER, csrfToken);
+      }
+    }
 
     try {
       const response = await fetch(url, {
@@ -107,6 +119,7 @@ class ApiClient {
         headers,
         credentials: 'include', // クッキーを自動的に送信・受信
       });
+      this.storeCsrfToken(response);
 
       // ステータスコードが200-299の場合
       if (response.ok) {
@@ -135,6 +148,17 @@ class ApiClient {
 
       // その他のエラー
       const errorData = await response.json().catch(() => ({}));
+
+      // CSRFトークンが古い場合（別のタブでログインし直した場合など）は取得し直して1回だけ再試行
+      if (
+        response.status === 403 &&
+        errorData.error === 'invalid CSRF token' &&
+        !hasRetried
+      ) {
+        this.csrfToken = null;
+        return this.request<T>(path, options, true);
+      }
+
       return {
         error: errorData.error || 'エラーが発生しました',
         statusCode: response.status,
@@ -151,15 +175,44 @@ class ApiClient {
     }
   }
 
+  /**
+   * CSRFトークンを取得（未取得の場合はサーバーから取得）
+   */
+  private async getCsrfToken(): Promise<string | null> {
+    if (this.csrfToken) {
+      return this.csrfToken;
+    }
+
+    try {
+      const response = await fetch(`${this.baseUrl}/api/auth/csrf`, {
+        credentials: 'include',
+      });
+      this.storeCsrfToken(response);
+    } catch {
+      // 取得できない場合はヘッダーなしで送信する（サーバーが 403 を返す）
+    }
+    return this.csrfToken;
+  }
+
+  private storeCsrfToken(response: Response): void {
+    const token = response.headers.get(CSRF_HEADER);
+    if (token) {
+      this.csrfToken = token;
+    }
+  }
+
   private async refreshAccessToken(): Promise<boolean> {
     try {
+      const csrfToken = await this.getCsrfToken();
       const response = await fetch(`${this.baseUrl}/api/auth/refresh`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
+          ...(csrfToken ? { [CSRF_HEADER]: csrfToken } : {}),
         },
         credentials: 'include',
       });
+      this.storeCsrfToken(response);
 
       // リフレッシュ失敗時はログインページにリダイレクト
       if (!response.ok) {
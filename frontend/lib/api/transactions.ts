@@ -45,30 +45,47 @@ export interface UpdateTransactionRequest {
 
 /**
  * 取引を作成
+ * 同じ Idempotency-Key で再送されたリクエストは二重に登録されず、最初の応答が返される
  * @param request - 取引作成リクエスト
+ * @param idempotencyKey - 再送時に同じ値を指定するキー（省略時は生成）
  * @returns 作成された取引
  */
 export async function createTransaction(
   request: CreateTransactionRequest,
+  idempotencyKey: string = crypto.randomUUID(),
 ): Promise<ApiResponse<TransactionResponse>> {
-  return apiClient.post<TransactionResponse>('/api/transactions', {
-    ...request,
-  });
+  return apiClient.post<TransactionResponse>(
+    '/api/transactions',
+    { ...request },
+    { headers: { 'Idempotency-Key': idempotencyKey } },
+  );
+}
+
+/**
+ * If-Match ヘッダー（取得時点のバージョン以降に他の変更が保存されていた場合、サーバーは 412 を返す）
+ * @param version - 取引のバージョン
+ */
+function ifMatch(version: number): RequestInit {
+  return { headers: { 'If-Match': `"${version}"` } };
 }
 
 /**
  * 取引を更新
  * @param id - 取引ID
+ * @param version - 編集を始めた時点の取引のバージョン
  * @param request - 取引更新リクエスト
  * @returns 更新された取引
  */
 export async function updateTransaction(
   id: number,
+  version: number,
   request: UpdateTransactionRequest,
 ): Promise<ApiResponse<TransactionResponse>> {
-  return apiClient.put<TransactionResponse>(`/api/transactions/${id}`, {
-    ...request,
-  });
+  return apiClient.put<TransactionResponse>(
+    `/api/transactions/${id}`,
+    { ...request },
+    ifMatch(version),
+  );
 }
 
 /**
@@ -130,15 +147,59 @@ export async function getTransactionsWithPagination(
   });
 }
 
+/**
+ * カーソルによるページネーション付き取引一覧のレスポンス
+ */
+export interface TransactionsCursorPage {
+  transactions: TransactionResponse[];
+  // 次ページ（古い側）のカーソル（次ページが無い場合は null）
+  nextCursor: string | null;
+  // 前ページ（新しい側）のカーソル（前ページが無い場合は null）
+  prevCursor: string | null;
+  // includeTotal を指定した場合のみ
+  total?: number;
+}
+
+/**
+ * ユーザーの取引一覧をカーソルによるページネーションで取得
+ * 閲覧中に取引が追加・削除されてもページの境目で取引が重複・欠落しない
+ * @param cursor - 前回のレスポンスの nextCursor または prevCursor（先頭の場合は null）
+ * @param limit - 1ページあたりの件数
+ * @param keyword - 検索キーワード（オプション）
+ * @returns カーソル付き取引一覧
+ */
+export async function getTransactionsWithCursor(
+  cursor: string | null,
+  limit: number,
+  keyword?: string,
+): Promise<ApiResponse<TransactionsCursorPage>> {
+  const params: Record<string, string | number> = { limit };
+  if (cursor) {
+    params.cursor = cursor;
+  }
+  if (keyword) {
+    params.keyword = keyword;
+  }
+
+  return apiClient.get<TransactionsCursorPage>('/api/transactions/cursor', {
+    params,
+  });
+}
+
 /**
  * 取引を削除
  * @param id - 取引ID
+ * @param version - 削除を確認した時点の取引のバージョン
  * @returns 削除結果
  */
 export async function deleteTransaction(
   id: number,
+  version: number,
 ): Promise<ApiResponse<{ message: string }>> {
-  return apiClient.delete<{ message: string }>(`/api/transactions/${id}`);
+  return apiClient.delete<{ message: string }>(
+    `/api/transactions/${id}`,
+    ifMatch(version),
+  );
 }
 
 /**
@@ -156,19 +217,18 @@ export async function getJournalEntries(
 
 /**
  * 仕訳エントリーを作成
+ * 取引の貸借が一致したままになるよう、借方・貸方の合計が等しい2行以上をまとめて追加する
  * @param transactionId - 取引ID
- * @param request - 仕訳エントリー作成リクエスト
- * @returns 作成された仕訳エントリー
+ * @param journalEntries - 追加する仕訳エントリー（2行以上）
+ * @returns 作成された仕訳エントリー一覧
  */
-export async function createJournalEntry(
+export async function createJournalEntries(
   transactionId: number,
-  request: CreateJournalEntryRequest,
-): Promise<ApiResponse<JournalEntry>> {
-  return apiClient.post<JournalEntry>(
+  journalEntries: CreateJournalEntryRequest[],
+): Promise<ApiResponse<JournalEntry[]>> {
+  return apiClient.post<JournalEntry[]>(
     `/api/journal-entries/transactions/${transactionId}`,
-    {
-      ...request,
-    },
+    { journalEntries },
   );
 }
 
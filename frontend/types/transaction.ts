@@ -17,6 +17,8 @@ export interface Transaction {
   journalEntries?: JournalEntry[];
   createdAt: string;
   updatedAt: string;
+  // 楽観的排他制御のバージョン（更新・削除時に If-Match で送信する）
+  version: number;
   isCorrection?: boolean;
   correctedFromId?: number;
   correctionNote?: string;